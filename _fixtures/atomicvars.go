@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+)
+
+type astruct struct {
+	A int
+	B int
+}
+
+func main() {
+	var i32 atomic.Int32
+	var i64 atomic.Int64
+	var u64 atomic.Uint64
+	var b atomic.Bool
+	var p atomic.Pointer[astruct]
+	var pnil atomic.Pointer[astruct]
+	i32.Store(-3)
+	i64.Store(1 << 40)
+	u64.Store(42)
+	b.Store(true)
+	p.Store(&astruct{1, 2})
+	runtime.Breakpoint()
+	fmt.Println(i32.Load(), i64.Load(), u64.Load(), b.Load(), p.Load(), pnil.Load())
+}
@@ -0,0 +1,19 @@
+package main
+
+// #cgo CFLAGS: -g -Wall -O0
+/*
+struct opaque;
+extern struct opaque *newopaque(int a);
+*/
+import "C"
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func main() {
+	o := C.newopaque(3)
+	runtime.Breakpoint()
+	fmt.Println(o)
+}
@@ -0,0 +1,20 @@
+#include <stdlib.h>
+
+struct point {
+	int x, y;
+};
+
+struct opaque {
+	int a;
+	struct point pt;
+	struct opaque *next;
+};
+
+struct opaque *newopaque(int a) {
+	struct opaque *o = malloc(sizeof(struct opaque));
+	o->a = a;
+	o->pt.x = 1;
+	o->pt.y = 2;
+	o->next = NULL;
+	return o;
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type item struct {
+	n    int
+	name string
+}
+
+func main() {
+	ch := make(chan int, 4)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	<-ch
+	<-ch
+	ch <- 4
+	ch <- 5 // the buffer wraps around here
+
+	itemch := make(chan item, 2)
+	itemch <- item{1, "one"}
+
+	var nilch chan int
+	unbuffered := make(chan int)
+
+	runtime.Breakpoint()
+	fmt.Println(len(ch), len(itemch), nilch, unbuffered)
+}
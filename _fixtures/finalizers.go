@@ -0,0 +1,23 @@
+package main
+
+import "runtime"
+
+type resource struct {
+	fd   int
+	name string
+}
+
+var sink *resource
+
+func closeResource(r *resource) {
+	r.fd = -1
+}
+
+func main() {
+	withfin := &resource{fd: 3, name: "withfin"}
+	runtime.SetFinalizer(withfin, closeResource)
+	nofin := &resource{fd: 4, name: "nofin"}
+	sink = nofin
+	runtime.Breakpoint()
+	runtime.KeepAlive(withfin)
+}
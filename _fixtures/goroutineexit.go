@@ -0,0 +1,14 @@
+package main
+
+import "time"
+
+func worker(done chan<- int) {
+	done <- 42
+}
+
+func main() {
+	done := make(chan int)
+	go worker(done)
+	<-done
+	time.Sleep(time.Second)
+}
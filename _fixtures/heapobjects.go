@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type big struct {
+	p   *int
+	buf [100]int
+}
+
+type huge struct {
+	p   *int
+	buf [10000]int
+}
+
+type small struct {
+	a, b *int
+}
+
+func main() {
+	x := 1
+	b := &big{p: &x}
+	h := &huge{p: &x}
+	s := &small{a: &x}
+	bs := make([]byte, 100)
+	runtime.Breakpoint()
+	fmt.Println(b, h, s, bs)
+}
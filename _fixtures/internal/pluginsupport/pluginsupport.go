@@ -7,3 +7,10 @@ type Something interface {
 type SomethingElse interface {
 	Callback2(int, int) float64
 }
+
+func Max[T int | float64](a, b T) T {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+type leakedObj struct {
+	n   int
+	p   *int
+	buf [100]int
+}
+
+type keptObj struct {
+	p   *leakedObj
+	buf [100]int
+}
+
+var keep *leakedObj
+var kept *keptObj
+
+func main() {
+	for i := 0; i < 10; i++ {
+		keep = &leakedObj{n: i}
+	}
+	kept = &keptObj{p: keep}
+	keep = nil
+	runtime.Breakpoint()
+	fmt.Println(kept.p.n)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+var mu, other sync.Mutex
+
+func holder(locked chan<- struct{}) {
+	mu.Lock()
+	other.Lock()
+	locked <- struct{}{}
+	time.Sleep(100 * time.Millisecond)
+	other.Unlock()
+	time.Sleep(100 * time.Millisecond)
+	mu.Unlock()
+}
+
+func main() {
+	locked := make(chan struct{})
+	go holder(locked)
+	<-locked
+	other.Lock()
+	mu.Lock()
+	mu.Unlock()
+	other.Unlock()
+}
@@ -0,0 +1,18 @@
+package main
+
+func worker(ch chan int, done chan bool) {
+	<-ch
+	done <- true
+}
+
+func handoff(ch chan int, done chan bool) {
+	ch <- 1
+	<-done
+}
+
+func main() {
+	ch := make(chan int)
+	done := make(chan bool)
+	go worker(ch, done)
+	handoff(ch, done)
+}
@@ -31,3 +31,7 @@ var A interface{}
 func aIsNotNil(str string) {
 	// nothing here
 }
+
+func MaxFloat(a, b float64) float64 {
+	return pluginsupport.Max(a, b)
+}
@@ -40,5 +40,9 @@ func main() {
 	fn2 := fn2iface.(func(pluginsupport.Something) pluginsupport.SomethingElse)
 	a := fn1(3)
 	b := fn2(&asomething{2})
-	fmt.Println(a, b, ExeGlobal)
+	fmt.Println(a, b, ExeGlobal, maxInt(1, 2))
+}
+
+func maxInt(a, b int) int {
+	return pluginsupport.Max(a, b)
 }
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+)
+
+func selector(a chan int, b chan string, done chan struct{}) {
+	select {
+	case v := <-a:
+		fmt.Println(v)
+	case b <- "hello":
+	}
+	close(done)
+}
+
+func main() {
+	a := make(chan int)
+	b := make(chan string)
+	done := make(chan struct{})
+	go selector(a, b, done)
+	time.Sleep(100 * time.Millisecond)
+	runtime.Breakpoint()
+	a <- 1
+	<-done
+}
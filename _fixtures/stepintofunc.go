@@ -0,0 +1,17 @@
+package main
+
+import "fmt"
+
+func g() int { return 1 }
+
+func h() int { return 2 }
+
+func f(a, b int) int { return a + b }
+
+func main() {
+	fp := h
+	x := f(g(), fp())
+	fmt.Println(x)
+	x = f(g(), h())
+	fmt.Println(x)
+}
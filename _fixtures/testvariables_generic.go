@@ -16,7 +16,21 @@ func testfn[T any, K comparable](arg1 T, arg2 K) {
 	fmt.Println(arg1, arg2, m)
 }
 
+type Stack[T any] struct {
+	items []T
+}
+
+func (s *Stack[T]) Push(x T) {
+	s.items = append(s.items, x)
+	runtime.Breakpoint()
+}
+
+type myint int
+
 func main() {
 	testfn[int, float32](3, 2.1)
 	testfn(&astruct{0, 1}, astruct{2, 3})
+	s := &Stack[myint]{}
+	s.Push(1)
+	fmt.Println(s)
 }
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+func main() {
+	runtime.LockOSThread()
+	name := []byte("dlvworker\x00")
+	syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_SET_NAME, uintptr(unsafe.Pointer(&name[0])), 0)
+	runtime.Breakpoint()
+}
@@ -298,12 +298,12 @@ func tagFlags() string {
 	if mactags := prepareMacnative(); mactags != "" {
 		tags = append(tags, mactags)
 	}
-	if runtime.GOOS == "windows" && runtime.GOARCH == "arm64" {
-		tags = append(tags, "exp.winarm64")
-	}
 	if runtime.GOOS == "linux" && runtime.GOARCH == "ppc64le" {
 		tags = append(tags, "exp.linuxppc64le")
 	}
+	if runtime.GOOS == "linux" && runtime.GOARCH == "riscv64" {
+		tags = append(tags, "exp.linuxriscv64")
+	}
 	if Tags != nil && len(*Tags) > 0 {
 		tags = append(tags, *Tags...)
 	}
@@ -1,7 +1,21 @@
 package cmds
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 )
 
 func TestParseRedirects(t *testing.T) {
@@ -57,3 +71,170 @@ func TestParseRedirects(t *testing.T) {
 		}
 	}
 }
+
+// writeTestCert creates a certificate signed by parent (self-signed if
+// parent is nil) and writes it, and its key, to dir.
+func writeTestCert(t *testing.T, dir, name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyder, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyder}), 0600)
+	cert, _ := x509.ParseCertificate(der)
+	return cert, key
+}
+
+func TestTLSListener(t *testing.T) {
+	dir := t.TempDir()
+	ca, cakey := writeTestCert(t, dir, "ca", true, nil, nil)
+	writeTestCert(t, dir, "server", false, ca, cakey)
+	writeTestCert(t, dir, "client", false, ca, cakey)
+	defer func() {
+		tlsCert, tlsKey, tlsCA = "", "", ""
+	}()
+
+	tlsCert, tlsKey, tlsCA = filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), filepath.Join(dir, "ca.crt")
+	listener, err := netListen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	addr := listener.Addr().String()
+
+	dial := func() error {
+		conf, err := tlsClientConfig(addr)
+		if err != nil {
+			return err
+		}
+		conn, err := tls.Dial("tcp", addr, conf)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		// TLS 1.3 clients find out that their certificate was rejected only
+		// after the handshake.
+		if _, err := conn.Write([]byte("ping")); err != nil {
+			return err
+		}
+		buf := make([]byte, 4)
+		_, err = io.ReadFull(conn, buf)
+		return err
+	}
+
+	tlsCert, tlsKey = filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	if err := dial(); err != nil {
+		t.Errorf("could not connect with a client certificate: %v", err)
+	}
+
+	tlsCert, tlsKey = "", ""
+	if err := dial(); err == nil {
+		t.Errorf("connected without a client certificate")
+	}
+
+	tlsCA = ""
+	tlsCert, tlsKey = filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	if err := dial(); err == nil {
+		t.Errorf("connected to a server with a certificate signed by an unknown authority")
+	}
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix domain sockets not supported")
+	}
+	path := filepath.Join(t.TempDir(), "dlv.sock")
+
+	listener, err := listen(unixAddrPrefix + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A socket that is in use must not be removed.
+	if _, err := listen(unixAddrPrefix + path); err == nil {
+		t.Errorf("listening twice on %s succeeded", path)
+	}
+	listener.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket file not removed on close: %v", err)
+	}
+
+	// Leave a stale socket file behind, as if Delve had been killed.
+	listener, err = net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+	listener, err = listen(unixAddrPrefix + path)
+	if err != nil {
+		t.Fatalf("could not listen on a stale socket: %v", err)
+	}
+	listener.Close()
+}
+
+func TestDAPBridge(t *testing.T) {
+	client, clientBridge := net.Pipe()
+	serverBridge, server := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		dapBridge(clientBridge, serverBridge)
+		close(done)
+	}()
+
+	roundtrip := func(from, to net.Conn, msg string) {
+		go from.Write([]byte(msg))
+		buf := make([]byte, len(msg))
+		if _, err := io.ReadFull(to, buf); err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != msg {
+			t.Fatalf("got %q expected %q", buf, msg)
+		}
+	}
+	roundtrip(client, server, "Content-Length: 2\r\n\r\n{}")
+	roundtrip(server, client, "Content-Length: 3\r\n\r\n{ }")
+
+	client.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("bridge did not exit after the client disconnected")
+	}
+	if _, err := server.Read(make([]byte, 1)); err == nil {
+		t.Fatal("connection to the server was not closed")
+	}
+}
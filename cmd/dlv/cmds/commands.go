@@ -1,9 +1,12 @@
 package cmds
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
@@ -11,6 +14,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -27,6 +31,7 @@ import (
 	"github.com/go-delve/delve/pkg/version"
 	"github.com/go-delve/delve/service"
 	"github.com/go-delve/delve/service/api"
+	"github.com/go-delve/delve/service/authtoken"
 	"github.com/go-delve/delve/service/dap"
 	"github.com/go-delve/delve/service/debugger"
 	"github.com/go-delve/delve/service/rpc2"
@@ -50,6 +55,12 @@ var (
 	apiVersion int
 	// acceptMulti allows multiple clients to connect to the same server
 	acceptMulti bool
+	// acceptReadOnly allows read-only clients to connect while another
+	// client is connected
+	acceptReadOnly bool
+	// idleTimeout is the time after which a multiclient headless server
+	// stops if no client is connected
+	idleTimeout time.Duration
 	// addr is the debugging server listen address.
 	addr string
 	// initFile is the path to initialization file.
@@ -61,19 +72,49 @@ var (
 	// checkLocalConnUser is true if the debugger should check that local
 	// connections come from the same user that started the headless server
 	checkLocalConnUser bool
+	// authTokenFile is the path of a file containing the token used to
+	// authenticate connections to a headless server.
+	authTokenFile string
+	// tlsCert and tlsKey are the paths of the certificate and private key
+	// used by the headless server, or presented to the server by 'dlv
+	// connect'.
+	tlsCert, tlsKey string
+	// tlsCA is the path of the certificate of the CA used to verify client
+	// certificates (headless server) or the server certificate ('dlv
+	// connect').
+	tlsCA string
+	// connectTLS is true if 'dlv connect' should use TLS.
+	connectTLS bool
 	// tty is used to provide an alternate TTY for the program you wish to debug.
 	tty string
 	// disableASLR is used to disable ASLR
 	disableASLR bool
 
+	// recordState is used to save the state of the target every time it
+	// is resumed, for the step-back command.
+	recordState bool
+
 	// dapClientAddr is dap subcommand's flag that specifies the address of a DAP client.
 	// If it is specified, the dap server starts a debug session by dialing to the client.
 	// The dap server will serve only for the debug session.
 	dapClientAddr string
 
+	// dapServerAddr is dap subcommand's flag that specifies the address of a
+	// headless instance of Delve. If it is specified the DAP session is
+	// forwarded to it instead of being served by this process.
+	dapServerAddr string
+
 	// backend selection
 	backend string
 
+	// dsymPath is the path of a .dSYM bundle containing the debug info of
+	// the target, on macOS.
+	dsymPath string
+
+	// dwarfCache is true if the indexes built from the debug info of the
+	// target should be cached on disk.
+	dwarfCache bool
+
 	// checkGoVersion is true if the debugger should check the version of Go
 	// used to compile the executable and refuse to work on incompatible
 	// versions.
@@ -88,8 +129,20 @@ var (
 	traceStackDepth    int
 	traceUseEBPF       bool
 	traceShowTimestamp bool
+	traceShowDuration  bool
 	traceFollowCalls   int
 
+	// testRun is the regular expression selecting the tests to run, passed
+	// to the test binary as -test.run.
+	testRun string
+	// testBreakpoints is whether a breakpoint is set at the start of the
+	// tests selected by testRun.
+	testBreakpoints bool
+
+	// reuseBinary is whether the binary specified by --output is reused if
+	// its sources did not change since it was built.
+	reuseBinary bool
+
 	// redirect specifications for target process
 	redirects []string
 
@@ -150,22 +203,36 @@ func New(docCall bool) *cobra.Command {
 
 	rootCommand.PersistentFlags().BoolVarP(&headless, "headless", "", false, "Run debug server only, in headless mode. Server will accept both JSON-RPC or DAP client connections.")
 	rootCommand.PersistentFlags().BoolVarP(&acceptMulti, "accept-multiclient", "", false, "Allows a headless server to accept multiple client connections via JSON-RPC or DAP.")
+	rootCommand.PersistentFlags().DurationVar(&idleTimeout, "idle-timeout", 0, "Stops a headless server started with --accept-multiclient if no client is connected for the specified duration (for example 30m). By default the server waits for clients indefinitely.")
+	rootCommand.PersistentFlags().BoolVarP(&acceptReadOnly, "accept-readonly", "", false, "Allows a headless server started with --accept-multiclient to accept read-only JSON-RPC connections while another client is connected. Read-only clients can inspect the target but not change its state.")
 	rootCommand.PersistentFlags().IntVar(&apiVersion, "api-version", 1, "Selects JSON-RPC API version when headless. New clients should use v2. Can be reset via RPCServer.SetApiVersion. See Documentation/api/json-rpc/README.md.")
 	must(rootCommand.RegisterFlagCompletionFunc("api-version", cobra.FixedCompletions([]string{"1", "2"}, cobra.ShellCompDirectiveNoFileComp)))
 	rootCommand.PersistentFlags().StringVar(&initFile, "init", "", "Init file, executed by the terminal client.")
 	must(rootCommand.MarkPersistentFlagFilename("init"))
-	rootCommand.PersistentFlags().StringVar(&buildFlags, "build-flags", buildFlagsDefault, "Build flags, to be passed to the compiler. For example: --build-flags=\"-tags=integration -mod=vendor -cover -v\"")
+	rootCommand.PersistentFlags().StringVar(&buildFlags, "build-flags", buildFlagsDefault, "Build flags, to be passed to the compiler. For example: --build-flags=\"-tags=integration -mod=vendor -cover -v\". Values containing spaces can be surrounded by single quotes, as in --build-flags=\"-ldflags='-X main.Version=1.0 -s'\". If -gcflags is specified it must include the -N -l flags needed for debugging, for example -gcflags='all=-N -l'.")
 	must(rootCommand.RegisterFlagCompletionFunc("build-flags", cobra.NoFileCompletions))
 	rootCommand.PersistentFlags().StringVar(&workingDir, "wd", "", "Working directory for running the program.")
 	must(rootCommand.MarkPersistentFlagDirname("wd"))
 	rootCommand.PersistentFlags().BoolVarP(&checkGoVersion, "check-go-version", "", true, "Exits if the version of Go in use is not compatible (too old or too new) with the version of Delve.")
 	rootCommand.PersistentFlags().BoolVarP(&checkLocalConnUser, "only-same-user", "", true, "Only connections from the same user that started this instance of Delve are allowed to connect.")
+	rootCommand.PersistentFlags().StringVar(&authTokenFile, "auth-token-file", "", "Path of a file containing a token that clients must send to authenticate when connecting to a headless server. Also used by 'dlv connect' to authenticate with the server.")
+	must(rootCommand.MarkPersistentFlagFilename("auth-token-file"))
+	rootCommand.PersistentFlags().StringVar(&tlsCert, "tls-cert", "", "Path of the TLS certificate used by a headless server, requires --tls-key. When used with 'dlv connect' the certificate is presented to the server.")
+	must(rootCommand.MarkPersistentFlagFilename("tls-cert"))
+	rootCommand.PersistentFlags().StringVar(&tlsKey, "tls-key", "", "Path of the private key of the TLS certificate specified by --tls-cert.")
+	must(rootCommand.MarkPersistentFlagFilename("tls-key"))
+	rootCommand.PersistentFlags().StringVar(&tlsCA, "tls-ca", "", "Path of a CA certificate. A headless server will require clients to present a certificate signed by it, 'dlv connect' will use it to verify the certificate of the server.")
+	must(rootCommand.MarkPersistentFlagFilename("tls-ca"))
 	rootCommand.PersistentFlags().StringVar(&backend, "backend", "default", `Backend selection (see 'dlv help backend').`)
 	must(rootCommand.RegisterFlagCompletionFunc("backend", cobra.FixedCompletions([]string{"default", "native", "lldb", "rr"}, cobra.ShellCompDirectiveNoFileComp)))
 	rootCommand.PersistentFlags().StringArrayVarP(&redirects, "redirect", "r", []string{}, "Specifies redirect rules for target process (see 'dlv help redirect')")
 	must(rootCommand.MarkPersistentFlagFilename("redirect"))
 	rootCommand.PersistentFlags().BoolVar(&allowNonTerminalInteractive, "allow-non-terminal-interactive", false, "Allows interactive sessions of Delve that don't have a terminal as stdin, stdout and stderr")
 	rootCommand.PersistentFlags().BoolVar(&disableASLR, "disable-aslr", false, "Disables address space randomization")
+	rootCommand.PersistentFlags().StringVar(&dsymPath, "dsym", "", "Path of the .dSYM bundle containing the debug info of the program (macOS only). By default the bundle is searched next to the executable and in the debug info directories.")
+	must(rootCommand.MarkPersistentFlagFilename("dsym"))
+	rootCommand.PersistentFlags().BoolVar(&dwarfCache, "dwarf-cache", true, "Caches the indexes built from the debug info of the program in the user cache directory, so that the next sessions on the same executable start faster. The cache is keyed by a hash of the contents of the executable.")
+	rootCommand.PersistentFlags().BoolVar(&recordState, "record", false, "Experimental: saves the state of the target every time it is resumed, so that it can be restored with the step-back command (native backend on linux only)")
 
 	// 'attach' subcommand.
 	attachCommand := &cobra.Command{
@@ -214,6 +281,7 @@ option to let the process continue or kill it.
 		Run:               connectCmd,
 		ValidArgsFunction: cobra.NoFileCompletions,
 	}
+	connectCommand.Flags().BoolVar(&connectTLS, "tls", false, "Connect to the server using TLS, implied by --tls-cert and --tls-ca.")
 	rootCommand.AddCommand(connectCommand)
 
 	// 'dap' subcommand.
@@ -233,6 +301,9 @@ to be launched or a process to be attached to. The following modes can be specif
 
 Program and output binary paths will be interpreted relative to dlv's working directory.
 
+To listen on a unix domain socket instead of a TCP port, prefix the --listen address with 'unix:',
+for example --listen=unix:/tmp/dlv.sock. The socket file is removed when the server exits.
+
 This server does not accept multiple client connections (--accept-multiclient).
 Use 'dlv [command] --headless' instead and a DAP client with attach + remote config.
 While --continue is not supported, stopOnEntry launch/attach attribute can be used to control if
@@ -240,12 +311,21 @@ execution is resumed at the start of the debug session.
 
 The --client-addr flag is a special flag that makes the server initiate a debug session
 by dialing in to the host:port where a DAP client is waiting. This server process
-will exit when the debug session ends.`,
+will exit when the debug session ends.
+
+The --server-addr flag makes this process act as a bridge to a headless instance of Delve
+that is already running (for example one started with 'dlv debug --headless --accept-multiclient'):
+the DAP session is forwarded to the headless instance, which will share its debuggee with
+any other client connected to it. The DAP client should use attach + remote config.
+The --auth-token-file and TLS options are used to connect to the headless instance.`,
 		Run:               dapCmd,
 		ValidArgsFunction: cobra.NoFileCompletions,
 	}
 	dapCommand.Flags().StringVar(&dapClientAddr, "client-addr", "", "host:port where the DAP client is waiting for the DAP server to dial in")
 	must(dapCommand.RegisterFlagCompletionFunc("client-addr", cobra.NoFileCompletions))
+	dapCommand.Flags().StringVar(&dapServerAddr, "server-addr", "", "address of a running headless instance of Delve the DAP session should be forwarded to")
+	must(dapCommand.RegisterFlagCompletionFunc("server-addr", cobra.NoFileCompletions))
+	dapCommand.Flags().BoolVar(&connectTLS, "tls", false, "Connect to the headless instance specified by --server-addr using TLS, implied by --tls-cert and --tls-ca.")
 
 	// TODO(polina): support --tty when dlv dap allows to launch a program from command-line
 	rootCommand.AddCommand(dapCommand)
@@ -265,6 +345,7 @@ session.`,
 	}
 	debugCommand.Flags().String("output", "", "Output path for the binary.")
 	must(debugCommand.MarkFlagFilename("output"))
+	debugCommand.Flags().BoolVar(&reuseBinary, "reuse-binary", false, "Reuse the binary specified by --output, without removing it at exit, if its sources did not change since it was built.")
 	debugCommand.Flags().BoolVar(&continueOnStart, "continue", false, "Continue the debugged process on start.")
 	debugCommand.Flags().StringVar(&tty, "tty", "", "TTY to use for the target program")
 	must(debugCommand.MarkFlagFilename("tty"))
@@ -327,12 +408,21 @@ that package instead. Double-dashes ` + "`--`" + ` can be used to pass arguments
 
 dlv test [package] -- -test.run TestSomething -test.v -other-argument
 
+The --run flag is a shorthand for passing -test.run to the test program, and
+--break-tests sets a breakpoint at the start of the test functions it selects:
+
+dlv test [package] --run TestSomething --break-tests
+
 See also: 'go help testflag'.`,
 		Run:               testCmd,
 		ValidArgsFunction: cobra.NoFileCompletions,
 	}
 	testCommand.Flags().String("output", "", "Output path for the binary.")
 	must(testCommand.MarkFlagFilename("output"))
+	testCommand.Flags().StringVar(&testRun, "run", "", "Run only the tests matching the regular expression, passed to the test program as -test.run.")
+	must(testCommand.RegisterFlagCompletionFunc("run", cobra.NoFileCompletions))
+	testCommand.Flags().BoolVar(&testBreakpoints, "break-tests", false, "Set a breakpoint at the start of the test functions selected by --run, or of all test functions.")
+	testCommand.Flags().BoolVar(&reuseBinary, "reuse-binary", false, "Reuse the binary specified by --output, without removing it at exit, if its sources did not change since it was built.")
 	rootCommand.AddCommand(testCommand)
 
 	// 'trace' subcommand.
@@ -347,7 +437,12 @@ is useful if you do not want to begin an entire debug session, but merely want
 to know what functions your process is executing.
 
 The output of the trace sub command is printed to stderr, so if you would like to
-only see the output of the trace operations you can redirect stdout.`,
+only see the output of the trace operations you can redirect stdout.
+
+With --test the test binary of the package is built and traced. The --run flag
+selects the tests to run, for example:
+
+dlv trace --test ./pkg --run TestSomething 'pkg.hotFunc'`,
 		Run: func(cmd *cobra.Command, args []string) {
 			os.Exit(traceCmd(cmd, args, conf))
 		},
@@ -360,10 +455,14 @@ only see the output of the trace operations you can redirect stdout.`,
 	traceCommand.Flags().BoolVarP(&traceTestBinary, "test", "t", false, "Trace a test binary.")
 	traceCommand.Flags().BoolVarP(&traceUseEBPF, "ebpf", "", false, "Trace using eBPF (experimental).")
 	traceCommand.Flags().BoolVarP(&traceShowTimestamp, "timestamp", "", false, "Show timestamp in the output")
+	traceCommand.Flags().BoolVarP(&traceShowDuration, "duration", "", false, "Show the duration of function calls in the output. (Ignored with --ebpf)")
 	traceCommand.Flags().IntVarP(&traceStackDepth, "stack", "s", 0, "Show stack trace with given depth. (Ignored with --ebpf)")
 	must(traceCommand.RegisterFlagCompletionFunc("stack", cobra.NoFileCompletions))
 	traceCommand.Flags().String("output", "", "Output path for the binary.")
 	must(traceCommand.MarkFlagFilename("output"))
+	traceCommand.Flags().BoolVar(&reuseBinary, "reuse-binary", false, "Reuse the binary specified by --output, without removing it at exit, if its sources did not change since it was built.")
+	traceCommand.Flags().StringVar(&testRun, "run", "", "Run only the tests matching the regular expression, passed to the test program as -test.run. Requires --test.")
+	must(traceCommand.RegisterFlagCompletionFunc("run", cobra.NoFileCompletions))
 	traceCommand.Flags().IntVarP(&traceFollowCalls, "follow-calls", "", 0, "Trace all children of the function to the required depth")
 	rootCommand.AddCommand(traceCommand)
 
@@ -397,6 +496,38 @@ Currently supports linux/amd64 and linux/arm64 core files, windows/amd64 minidum
 	coreCommand.Flags().MarkHidden("core")
 	rootCommand.AddCommand(coreCommand)
 
+	// 'snapshot' subcommand.
+	snapshotCommand := &cobra.Command{
+		Use:   "snapshot pid [executable]",
+		Short: "Prints the goroutines of a running process without stopping it.",
+		Long: `Prints the goroutines of a running process without stopping it (only supports linux).
+
+The snapshot command reads the memory of the specified process through
+/proc/<pid>/mem instead of attaching to it with ptrace, prints a listing
+of all its goroutines with their stack traces and exits.
+
+Since the threads of the target process keep running while its memory is
+being read the state displayed may be inconsistent, in particular the
+stacks of goroutines that are running may be incorrect.
+`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("you must provide a PID")
+			}
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			os.Exit(snapshotCmd(cmd, args, conf))
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveDefault
+		},
+	}
+	rootCommand.AddCommand(snapshotCommand)
+
 	// 'version' subcommand.
 	var versionVerbose = false
 	versionCommand := &cobra.Command{
@@ -526,6 +657,7 @@ func dapCmd(cmd *cobra.Command, args []string) {
 			return 1
 		}
 		defer logflags.Close()
+		setupDwarfCache()
 
 		if loadConfErr != nil {
 			logflags.DebuggerLogger().Errorf("%v", loadConfErr)
@@ -537,6 +669,9 @@ func dapCmd(cmd *cobra.Command, args []string) {
 		if acceptMulti {
 			fmt.Fprintf(os.Stderr, "Warning: accept-multiclient mode not supported with dap\n")
 		}
+		if acceptReadOnly {
+			fmt.Fprintf(os.Stderr, "Warning: accept-readonly mode not supported with dap\n")
+		}
 		if initFile != "" {
 			fmt.Fprint(os.Stderr, "Warning: init file ignored with dap\n")
 		}
@@ -560,13 +695,17 @@ func dapCmd(cmd *cobra.Command, args []string) {
 			fmt.Fprintf(os.Stderr, "Warning: program flags ignored with dap; specify via launch/attach request instead\n")
 		}
 
+		if dapServerAddr != "" {
+			return dapBridgeCmd()
+		}
+
 		disconnectChan := make(chan struct{})
 		cfg := &service.Config{
 			DisconnectChan: disconnectChan,
 			Debugger: debugger.Config{
 				Backend:              backend,
 				Foreground:           true, // server always runs without terminal client
-				DebugInfoDirectories: conf.DebugInfoDirectories,
+				DebugInfoDirectories: debugInfoDirectories(conf),
 				CheckGoVersion:       checkGoVersion,
 				DisableASLR:          disableASLR,
 			},
@@ -574,6 +713,14 @@ func dapCmd(cmd *cobra.Command, args []string) {
 		}
 		var conn net.Conn
 		if dapClientAddr == "" {
+			if authTokenFile != "" {
+				var err error
+				cfg.AuthToken, err = authtoken.ReadFile(authTokenFile)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%v\n", err)
+					return 1
+				}
+			}
 			listener, err := netListen(addr)
 			if err != nil {
 				fmt.Printf("couldn't start listener: %s\n", err)
@@ -581,6 +728,9 @@ func dapCmd(cmd *cobra.Command, args []string) {
 			}
 			cfg.Listener = listener
 		} else { // with a predetermined client.
+			if authTokenFile != "" {
+				fmt.Fprintf(os.Stderr, "Warning: auth-token-file ignored with --client-addr\n")
+			}
 			var err error
 			conn, err = net.Dial("tcp", dapClientAddr)
 			if err != nil {
@@ -620,6 +770,20 @@ func buildBinary(cmd *cobra.Command, args []string, isTest bool) (string, bool)
 		}
 	}
 
+	var fingerprint string
+	if reuseBinary {
+		if outputFlag == "" {
+			fmt.Fprintf(os.Stderr, "--reuse-binary requires --output\n")
+			return "", false
+		}
+		fingerprint, err = gobuild.Fingerprint(args, buildFlags, isTest)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not check if %s is up to date: %v\n", outputFlag, err)
+		} else if gobuild.UpToDate(debugname, fingerprint) {
+			return debugname, true
+		}
+	}
+
 	if isTest {
 		err = gobuild.GoTestBuild(debugname, args, buildFlags)
 	} else {
@@ -632,9 +796,22 @@ func buildBinary(cmd *cobra.Command, args []string, isTest bool) (string, bool)
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		return "", false
 	}
+	if fingerprint != "" {
+		if err := gobuild.SaveFingerprint(debugname, fingerprint); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
 	return debugname, true
 }
 
+// removeBinary removes the binary built for the debugging session, unless
+// it should be reused by the next one.
+func removeBinary(debugname string) {
+	if !reuseBinary {
+		gobuild.Remove(debugname)
+	}
+}
+
 func debugCmd(cmd *cobra.Command, args []string) {
 	status := func() int {
 		dlvArgs, targetArgs := splitArgs(cmd, args)
@@ -642,7 +819,7 @@ func debugCmd(cmd *cobra.Command, args []string) {
 		if !ok {
 			return 1
 		}
-		defer gobuild.Remove(debugname)
+		defer removeBinary(debugname)
 		processArgs := append([]string{debugname}, targetArgs...)
 		return execute(0, processArgs, conf, "", debugger.ExecutingGeneratedFile, dlvArgs, buildFlags)
 	}()
@@ -657,6 +834,7 @@ func traceCmd(cmd *cobra.Command, args []string, conf *config.Config) int {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
 			return 1
 		}
+		setupDwarfCache()
 		if loadConfErr != nil {
 			logflags.DebuggerLogger().Errorf("%v", loadConfErr)
 		}
@@ -685,6 +863,14 @@ func traceCmd(cmd *cobra.Command, args []string, conf *config.Config) int {
 			dlvArgs = dlvArgs[:dlvArgsLen-1]
 		}
 
+		if testRun != "" {
+			if !traceTestBinary {
+				fmt.Fprintln(os.Stderr, "--run requires --test")
+				return 1
+			}
+			targetArgs = append([]string{"-test.run", testRun}, targetArgs...)
+		}
+
 		var debugname string
 		if traceAttachPid == 0 {
 			if dlvArgsLen >= 2 && traceExecFile != "" {
@@ -699,7 +885,7 @@ func traceCmd(cmd *cobra.Command, args []string, conf *config.Config) int {
 					return 1
 				}
 				debugname = debugexe
-				defer gobuild.Remove(debugname)
+				defer removeBinary(debugname)
 			}
 
 			processArgs = append([]string{debugname}, targetArgs...)
@@ -727,7 +913,7 @@ func traceCmd(cmd *cobra.Command, args []string, conf *config.Config) int {
 				WorkingDir:           workingDir,
 				Backend:              backend,
 				CheckGoVersion:       checkGoVersion,
-				DebugInfoDirectories: conf.DebugInfoDirectories,
+				DebugInfoDirectories: debugInfoDirectories(conf),
 			},
 		})
 		if err := server.Run(); err != nil {
@@ -812,6 +998,7 @@ func traceCmd(cmd *cobra.Command, args []string, conf *config.Config) int {
 		cmds := terminal.DebugCommands(client)
 		cfg := &config.Config{
 			TraceShowTimestamp: traceShowTimestamp,
+			TraceShowDuration:  traceShowDuration,
 		}
 		t := terminal.New(client, cfg)
 		t.SetTraceNonInteractive()
@@ -882,12 +1069,18 @@ func testCmd(cmd *cobra.Command, args []string) {
 		if !ok {
 			return 1
 		}
-		defer gobuild.Remove(debugname)
+		defer removeBinary(debugname)
+		if testRun != "" {
+			targetArgs = append([]string{"-test.run", testRun}, targetArgs...)
+		}
 		processArgs := append([]string{debugname}, targetArgs...)
 
 		if workingDir == "" {
 			workingDir = getPackageDir(dlvArgs)
 		}
+		if testBreakpoints && headless {
+			fmt.Fprint(os.Stderr, "Warning: --break-tests ignored with --headless\n")
+		}
 
 		return execute(0, processArgs, conf, "", debugger.ExecutingGeneratedTest, dlvArgs, buildFlags)
 	}()
@@ -930,6 +1123,54 @@ func coreCmd(_ *cobra.Command, args []string) {
 	os.Exit(execute(0, []string{args[0]}, conf, args[1], debugger.ExecutingOther, args, buildFlags))
 }
 
+func snapshotCmd(_ *cobra.Command, args []string, conf *config.Config) int {
+	if err := logflags.Setup(logFlag, logOutput, logDest); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	defer logflags.Close()
+	if loadConfErr != nil {
+		logflags.DebuggerLogger().Errorf("%v", loadConfErr)
+	}
+
+	pid, err := strconv.Atoi(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid pid: %s\n", args[0])
+		return 1
+	}
+
+	// Make a local in-memory connection that client and server use to communicate
+	listener, clientConn := service.ListenerPipe()
+	defer listener.Close()
+
+	server := rpccommon.NewServer(&service.Config{
+		Listener:    listener,
+		ProcessArgs: args[1:],
+		APIVersion:  2,
+		Debugger: debugger.Config{
+			AttachPid:            pid,
+			Snapshot:             true,
+			Backend:              backend,
+			CheckGoVersion:       checkGoVersion,
+			DebugInfoDirectories: debugInfoDirectories(conf),
+		},
+	})
+	if err := server.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	client := rpc2.NewClientFromConn(clientConn)
+	defer client.Detach(false)
+
+	t := terminal.New(client, conf)
+	defer t.Close()
+	if err := terminal.DebugCommands(client).Call("goroutines -t", t); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
 func connectCmd(_ *cobra.Command, args []string) {
 	if err := logflags.Setup(logFlag, logOutput, logDest); err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
@@ -983,16 +1224,105 @@ func splitArgs(cmd *cobra.Command, args []string) ([]string, []string) {
 	return args, []string{}
 }
 
+// dapBridgeCmd forwards a single DAP session between a DAP client and the
+// headless instance of Delve at dapServerAddr.
+func dapBridgeCmd() int {
+	var clientConn net.Conn
+	if dapClientAddr == "" {
+		listener, err := listen(addr)
+		if err != nil {
+			fmt.Printf("couldn't start listener: %s\n", err)
+			return 1
+		}
+		logflags.WriteDAPListeningMessage(listener.Addr())
+		clientConn, err = listener.Accept()
+		listener.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error accepting client connection: %v\n", err)
+			return 1
+		}
+	} else {
+		var err error
+		clientConn, err = net.Dial("tcp", dapClientAddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to connect to the DAP client: %v\n", err)
+			return 1
+		}
+	}
+	serverConn, err := dialServer(dapServerAddr)
+	if err != nil {
+		clientConn.Close()
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	dapBridge(clientConn, serverConn)
+	return 0
+}
+
+// dapBridge copies data between clientConn and serverConn until one of
+// them is closed, then closes both.
+func dapBridge(clientConn, serverConn net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(serverConn, clientConn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(clientConn, serverConn)
+		done <- struct{}{}
+	}()
+	<-done
+	clientConn.Close()
+	serverConn.Close()
+	<-done
+}
+
+// dialServer connects to the headless instance at addr, using TLS and
+// sending the authentication token if requested by the command line
+// options.
+func dialServer(addr string) (net.Conn, error) {
+	conn := netDial(addr)
+	if conn == nil {
+		return nil, fmt.Errorf("could not connect to %s", addr)
+	}
+	if connectTLS || tlsCert != "" || tlsCA != "" {
+		tlsConf, err := tlsClientConfig(addr)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		tlsConn := tls.Client(conn, tlsConf)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("TLS handshake failed: %v", err)
+		}
+		conn = tlsConn
+	}
+	if authTokenFile != "" {
+		token, err := authtoken.ReadFile(authTokenFile)
+		if err == nil {
+			err = authtoken.Send(conn, token)
+		}
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("could not authenticate: %v", err)
+		}
+	}
+	return conn, nil
+}
+
 func connect(addr string, clientConn net.Conn, conf *config.Config) int {
 	// Create and start a terminal - attach to running instance
 	var client *rpc2.RPCClient
 	if clientConn == nil {
-		if clientConn = netDial(addr); clientConn == nil {
-			return 1 // already logged
+		var err error
+		if clientConn, err = dialServer(addr); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
 		}
 	}
 	client = rpc2.NewClientFromConn(clientConn)
-	if client.IsMulticlient() {
+	if client.IsMulticlient() && !client.IsReadOnly() {
 		state, _ := client.GetStateNonBlocking()
 		// The error return of GetState will usually be the ErrProcessExited,
 		// which we don't care about. If there are other errors they will show up
@@ -1006,6 +1336,11 @@ func connect(addr string, clientConn net.Conn, conf *config.Config) int {
 			}
 		}
 	}
+	if testBreakpoints {
+		if err := setTestBreakpoints(client, testRun); err != nil {
+			fmt.Fprintf(os.Stderr, "could not set breakpoints on tests: %v\n", err)
+		}
+	}
 	term := terminal.New(client, conf)
 	term.InitFile = initFile
 	status, err := term.Run()
@@ -1015,6 +1350,39 @@ func connect(addr string, clientConn net.Conn, conf *config.Config) int {
 	return status
 }
 
+// setTestBreakpoints sets a breakpoint at the start of the test functions
+// whose name matches run, the regular expression passed to the test binary
+// as -test.run. Only the part of run before the first slash, which selects
+// the top-level tests, is used.
+func setTestBreakpoints(client service.Client, run string) error {
+	run, _, _ = strings.Cut(run, "/")
+	re, err := regexp.Compile(run)
+	if err != nil {
+		return err
+	}
+	fns, err := client.ListFunctions(`\.Test`, 0)
+	if err != nil {
+		return err
+	}
+	for _, fn := range fns {
+		dot := strings.LastIndex(fn, ".")
+		name := fn[dot+1:]
+		if !strings.HasPrefix(name, "Test") || name == "TestMain" || strings.ContainsAny(fn[:dot], "()") || !re.MatchString(name) {
+			continue
+		}
+		locs, _, err := client.FindLocation(api.EvalScope{GoroutineID: -1}, fn, true, nil)
+		if err != nil || len(locs) != 1 || !strings.HasSuffix(locs[0].File, "_test.go") {
+			continue
+		}
+		bp, err := client.CreateBreakpointWithExpr(&api.Breakpoint{Addr: locs[0].PC, Addrs: locs[0].PCs}, fn, nil, false)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Breakpoint %d set at %#x for %s() %s:%d\n", bp.ID, bp.Addr, bp.FunctionName, bp.File, bp.Line)
+	}
+	return nil
+}
+
 func execute(attachPid int, processArgs []string, conf *config.Config, coreFile string, kind debugger.ExecuteKind, dlvArgs []string, buildFlags string) int {
 	if err := logflags.Setup(logFlag, logOutput, logDest); err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
@@ -1024,6 +1392,7 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 	if loadConfErr != nil {
 		logflags.DebuggerLogger().Errorf("%v", loadConfErr)
 	}
+	setupDwarfCache()
 
 	if headless && (initFile != "") {
 		fmt.Fprint(os.Stderr, "Warning: init file ignored with --headless\n")
@@ -1045,6 +1414,13 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 		// call server.Stop after the terminal client exits.
 		acceptMulti = false
 	}
+	if idleTimeout != 0 && !acceptMulti {
+		fmt.Fprint(os.Stderr, "Warning: idle-timeout ignored without --headless and --accept-multiclient\n")
+	}
+	if acceptReadOnly && !acceptMulti {
+		fmt.Fprint(os.Stderr, "Error: --accept-readonly requires --headless and --accept-multiclient\n")
+		return 1
+	}
 
 	if !headless && !allowNonTerminalInteractive {
 		for _, f := range []struct {
@@ -1072,6 +1448,20 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 		return 1
 	}
 
+	if !headless && (tlsCert != "" || tlsKey != "" || tlsCA != "") {
+		fmt.Fprint(os.Stderr, "Warning: TLS options ignored without --headless\n")
+	}
+
+	var authToken string
+	if authTokenFile != "" {
+		if !headless {
+			fmt.Fprint(os.Stderr, "Warning: auth-token-file ignored without --headless\n")
+		} else if authToken, err = authtoken.ReadFile(authTokenFile); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			return 1
+		}
+	}
+
 	var listener net.Listener
 	var clientConn net.Conn
 
@@ -1102,8 +1492,11 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 			Listener:           listener,
 			ProcessArgs:        processArgs,
 			AcceptMulti:        acceptMulti,
+			AcceptReadOnly:     acceptReadOnly,
+			IdleTimeout:        idleTimeout,
 			APIVersion:         apiVersion,
 			CheckLocalConnUser: checkLocalConnUser,
+			AuthToken:          authToken,
 			DisconnectChan:     disconnectChan,
 			Debugger: debugger.Config{
 				AttachPid:             attachPid,
@@ -1114,13 +1507,14 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 				Packages:              dlvArgs,
 				BuildFlags:            buildFlags,
 				ExecuteKind:           kind,
-				DebugInfoDirectories:  conf.DebugInfoDirectories,
+				DebugInfoDirectories:  debugInfoDirectories(conf),
 				CheckGoVersion:        checkGoVersion,
 				TTY:                   tty,
 				Stdin:                 redirects[0],
 				Stdout:                proc.OutputRedirect{Path: redirects[1]},
 				Stderr:                proc.OutputRedirect{Path: redirects[2]},
 				DisableASLR:           disableASLR,
+				RecordState:           recordState,
 				RrOnProcessPid:        rrOnProcessPid,
 				AttachWaitFor:         attachWaitFor,
 				AttachWaitForInterval: attachWaitForInterval,
@@ -1153,10 +1547,25 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 	if headless {
 		if continueOnStart {
 			addr := listener.Addr().String()
-			if _, isuds := listener.(*net.UnixListener); isuds {
+			innerListener := listener
+			tlsl, istls := listener.(tlsListener)
+			if istls {
+				innerListener = tlsl.inner
+			}
+			if _, isuds := innerListener.(*net.UnixListener); isuds {
 				addr = "unix:" + addr
 			}
-			client := rpc2.NewClientFromConn(netDial(addr))
+			conn := netDial(addr)
+			if conn != nil && istls {
+				// This is a connection to ourselves, there is no need to verify
+				// the certificate of the server. The server certificate is
+				// presented in case client certificates are required.
+				conn = tls.Client(conn, &tls.Config{InsecureSkipVerify: true, Certificates: tlsl.config.Certificates}) //#nosec G402
+			}
+			if conn != nil && authToken != "" {
+				authtoken.Send(conn, authToken)
+			}
+			client := rpc2.NewClientFromConn(conn)
 			client.Disconnect(true) // true = continue after disconnect
 		}
 		waitForDisconnectSignal(disconnectChan)
@@ -1171,6 +1580,26 @@ func execute(attachPid int, processArgs []string, conf *config.Config, coreFile
 	return connect(listener.Addr().String(), clientConn, conf)
 }
 
+// setupDwarfCache enables the cache of the debug info indexes, unless
+// --dwarf-cache=false was specified.
+func setupDwarfCache() {
+	if !dwarfCache {
+		return
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		proc.DwarfCacheDir = filepath.Join(dir, "dlv", "dwarf")
+	}
+}
+
+// debugInfoDirectories returns the directories where separate debug info
+// files are searched, including the .dSYM bundle specified with --dsym.
+func debugInfoDirectories(conf *config.Config) []string {
+	if dsymPath == "" {
+		return conf.DebugInfoDirectories
+	}
+	return append([]string{dsymPath}, conf.DebugInfoDirectories...)
+}
+
 func parseRedirects(redirects []string) ([3]string, error) {
 	r := [3]string{}
 	names := [3]string{"stdin", "stdout", "stderr"}
@@ -1210,13 +1639,129 @@ func configUsageFunc(cmd *cobra.Command) {
 
 const unixAddrPrefix = "unix:"
 
-func netListen(addr string) (net.Listener, error) {
+// listen creates a listener for addr, which can be prefixed with 'unix:'
+// to create a unix domain socket. The socket file is removed when the
+// listener is closed.
+func listen(addr string) (net.Listener, error) {
 	if strings.HasPrefix(addr, unixAddrPrefix) {
-		return net.Listen("unix", addr[len(unixAddrPrefix):])
+		path := addr[len(unixAddrPrefix):]
+		listener, err := net.Listen("unix", path)
+		if errors.Is(err, syscall.EADDRINUSE) && removeStaleSocket(path) {
+			listener, err = net.Listen("unix", path)
+		}
+		return listener, err
 	}
 	return net.Listen("tcp", addr)
 }
 
+// removeStaleSocket removes the unix domain socket at path if nothing is
+// listening on it, which happens when a previous instance of Delve was
+// killed before it could remove it. Returns true if the socket was removed.
+// It must only be called after listening on path failed, connecting to a
+// headless server that does not accept multiple clients would otherwise
+// take its only client slot.
+func removeStaleSocket(path string) bool {
+	fi, err := os.Lstat(path)
+	if err != nil || fi.Mode()&os.ModeSocket == 0 {
+		return false
+	}
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		conn.Close()
+		return false
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return false
+	}
+	return os.Remove(path) == nil
+}
+
+// netListen creates the listener of a headless server, using TLS if
+// requested by the command line options.
+func netListen(addr string) (net.Listener, error) {
+	listener, err := listen(addr)
+	if err != nil || (tlsCert == "" && tlsKey == "" && tlsCA == "") {
+		return listener, err
+	}
+	conf, err := tlsServerConfig()
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return tlsListener{tls.NewListener(listener, conf), listener, conf}, nil
+}
+
+// tlsListener is a TLS listener that remembers its configuration and the
+// underlying listener.
+type tlsListener struct {
+	net.Listener
+	inner  net.Listener
+	config *tls.Config
+}
+
+// tlsServerConfig returns the TLS configuration of the headless server,
+// specified by --tls-cert, --tls-key and --tls-ca.
+func tlsServerConfig() (*tls.Config, error) {
+	if tlsCert == "" || tlsKey == "" {
+		return nil, errors.New("--tls-cert and --tls-key must be specified together")
+	}
+	cert, err := tls.LoadX509KeyPair(tlsCert, tlsKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not load TLS certificate: %v", err)
+	}
+	conf := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if tlsCA != "" {
+		pool, err := loadCertPool(tlsCA)
+		if err != nil {
+			return nil, err
+		}
+		conf.ClientCAs = pool
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return conf, nil
+}
+
+// tlsClientConfig returns the TLS configuration used by 'dlv connect' to
+// connect to addr, specified by --tls-cert, --tls-key and --tls-ca.
+func tlsClientConfig(addr string) (*tls.Config, error) {
+	conf := &tls.Config{MinVersion: tls.VersionTLS12}
+	if strings.HasPrefix(addr, unixAddrPrefix) {
+		conf.ServerName = "localhost"
+	} else if host, _, err := net.SplitHostPort(addr); err == nil {
+		conf.ServerName = host
+	}
+	if tlsCert != "" || tlsKey != "" {
+		if tlsCert == "" || tlsKey == "" {
+			return nil, errors.New("--tls-cert and --tls-key must be specified together")
+		}
+		cert, err := tls.LoadX509KeyPair(tlsCert, tlsKey)
+		if err != nil {
+			return nil, fmt.Errorf("could not load TLS certificate: %v", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	if tlsCA != "" {
+		pool, err := loadCertPool(tlsCA)
+		if err != nil {
+			return nil, err
+		}
+		conf.RootCAs = pool
+	}
+	return conf, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(buf) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
 func netDial(addr string) net.Conn {
 	var conn net.Conn
 	var err error
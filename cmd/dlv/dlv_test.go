@@ -14,6 +14,7 @@ import (
 	"os/exec"
 	"os/user"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -209,12 +210,12 @@ func getDlvBin(t *testing.T) string {
 	// depending on the test ordering.
 	t.Setenv("CGO_LDFLAGS", ldFlags)
 	var tags string
-	if runtime.GOOS == "windows" && runtime.GOARCH == "arm64" {
-		tags = "-tags=exp.winarm64"
-	}
 	if runtime.GOOS == "linux" && runtime.GOARCH == "ppc64le" {
 		tags = "-tags=exp.linuxppc64le"
 	}
+	if runtime.GOOS == "linux" && runtime.GOARCH == "riscv64" {
+		tags = "-tags=exp.linuxriscv64"
+	}
 	return getDlvBinInternal(t, tags)
 }
 
@@ -574,6 +575,9 @@ func TestTypecheckRPC(t *testing.T) {
 		case "SetReturnValuesLoadConfig", "Disconnect":
 			// support functions
 			continue
+		case "IsReadOnly":
+			// implemented by rpccommon.RPCServer
+			continue
 		}
 
 		if fndecl.Name.Name == "Continue" || fndecl.Name.Name == "Rewind" || fndecl.Name.Name == "DirectionCongruentContinue" {
@@ -946,6 +950,30 @@ func TestTrace(t *testing.T) {
 	cmd.Wait()
 }
 
+func TestTraceDuration(t *testing.T) {
+	dlvbin := getDlvBin(t)
+
+	expected := regexp.MustCompile(`> goroutine\(1\): main.foo\(99, 9801\)\n>> goroutine\(1\): main.foo => \(9900\) in [0-9.]+[a-zµ]+s\n`)
+
+	fixtures := protest.FindFixturesDir()
+	cmd := exec.Command(dlvbin, "trace", "--duration", "--output", filepath.Join(t.TempDir(), "__debug"), filepath.Join(fixtures, "issue573.go"), "foo")
+	rdr, err := cmd.StderrPipe()
+	assertNoError(err, t, "stderr pipe")
+	defer rdr.Close()
+
+	cmd.Dir = filepath.Join(fixtures, "buildtest")
+
+	assertNoError(cmd.Start(), t, "running trace")
+
+	output, err := io.ReadAll(rdr)
+	assertNoError(err, t, "ReadAll")
+
+	if !expected.Match(output) {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, string(output))
+	}
+	cmd.Wait()
+}
+
 func TestTrace2(t *testing.T) {
 	dlvbin := getDlvBin(t)
 
@@ -970,6 +998,21 @@ func TestTrace2(t *testing.T) {
 	assertNoError(cmd.Wait(), t, "cmd.Wait()")
 }
 
+func TestTraceTestRun(t *testing.T) {
+	dlvbin := getDlvBin(t)
+
+	fixtures := protest.FindFixturesDir()
+	cmd := exec.Command(dlvbin, "trace", "--output", filepath.Join(t.TempDir(), "__debug"), "--test", "--run", "TestCurrentDirectory", filepath.Join(fixtures, "buildtest"), "os.Getwd", "--", "-test.v")
+	out, err := cmd.CombinedOutput()
+	assertNoError(err, t, "running trace")
+
+	for _, tgt := range []string{"=== RUN   TestCurrentDirectory\n", "> goroutine(", "): os.Getwd()\n"} {
+		if !bytes.Contains(out, []byte(tgt)) {
+			t.Errorf("expected %q in output:\n%s", tgt, out)
+		}
+	}
+}
+
 func TestTraceDirRecursion(t *testing.T) {
 	dlvbin := getDlvBin(t)
 
@@ -1350,6 +1393,30 @@ func TestDlvTestChdir(t *testing.T) {
 	dotest(files)
 }
 
+func TestDlvTestRun(t *testing.T) {
+	dlvbin := getDlvBin(t)
+
+	fixtures := protest.FindFixturesDir()
+
+	cmd := exec.Command(dlvbin, "--allow-non-terminal-interactive=true", "test", filepath.Join(fixtures, "buildtest"), "--run", "TestCurrentDirectory", "--break-tests", "--", "-test.v")
+	cmd.Stdin = strings.NewReader("continue\ncontinue\nexit\n")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("error executing Delve: %v", err)
+	}
+	t.Logf("output: %q", out)
+
+	for _, tgt := range []string{
+		"Breakpoint 1 set at",
+		"[Breakpoint 1] github.com/go-delve/delve/_fixtures/buildtest.TestCurrentDirectory()",
+		"=== RUN   TestCurrentDirectory",
+	} {
+		if !strings.Contains(string(out), tgt) {
+			t.Errorf("output did not contain expected string %q", tgt)
+		}
+	}
+}
+
 func TestVersion(t *testing.T) {
 	dlvbin := getDlvBin(t)
 
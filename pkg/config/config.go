@@ -20,6 +20,10 @@ const (
 	PositionSource      = "source"
 	PositionDisassembly = "disassembly"
 	PositionDefault     = "default"
+
+	PagerAuto   = "auto"
+	PagerOff    = "off"
+	PagerAlways = "always"
 )
 
 // SubstitutePathRule describes a rule for substitution of path to source code file.
@@ -114,6 +118,21 @@ type Config struct {
 	// TraceShowTimestamp controls whether to show timestamp in the trace
 	// output.
 	TraceShowTimestamp bool `yaml:"trace-show-timestamp"`
+
+	// TraceShowDuration controls whether to show the time elapsed between
+	// the entry and the return of functions in the trace output.
+	TraceShowDuration bool `yaml:"trace-show-duration"`
+
+	// Pager controls when the output of commands is sent to a pager
+	// ($DELVE_PAGER, $PAGER or more, in this order).
+	// There are three possible values:
+	//  - auto (or the empty string): use a pager when the output of a
+	//    command does not fit in the terminal.
+	//  - off: never use a pager.
+	//  - always: always use a pager for commands that support it.
+	// Unless $DELVE_PAGER is set the pager is never used when the output is
+	// not a terminal.
+	Pager string `yaml:"pager"`
 }
 
 func (c *Config) GetSourceListLineCount() int {
@@ -281,9 +300,16 @@ func writeDefaultConfig(f *os.File) error {
 # listing source code.
 # source-list-line-count: 5
 
+# Uncomment to change when the output of commands is sent to a pager, one of
+# "auto", "off" or "always".
+# pager: auto
+
 # Provided aliases will be added to the default aliases for a given command.
 aliases:
   # command: ["alias1", "alias2"]
+  # Aliases of a command template are expanded when invoked, replacing %1
+  # through %9 with their positional arguments and %* with all arguments.
+  # "break main.%1": ["bpm"]
 
 # Define sources path substitution rules. Can be used to rewrite a source path stored
 # in program's debug information, if the sources were moved to a different place
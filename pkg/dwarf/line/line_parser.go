@@ -121,6 +121,51 @@ func Parse(compdir string, buf *bytes.Buffer, debugLineStr []byte, logfn func(st
 	return dbl
 }
 
+// DebugLineHeader is the parsed header of a debug_line segment, it can be
+// saved and passed to FromHeader to avoid parsing the segment again.
+type DebugLineHeader struct {
+	Prologue    *DebugLinePrologue
+	IncludeDirs []string
+	FileNames   []*FileEntry
+	PtrSize     int
+}
+
+// Header returns the parsed header of dbl.
+func (dbl *DebugLineInfo) Header() *DebugLineHeader {
+	return &DebugLineHeader{
+		Prologue:    dbl.Prologue,
+		IncludeDirs: dbl.IncludeDirs,
+		FileNames:   dbl.FileNames,
+		PtrSize:     dbl.ptrSize,
+	}
+}
+
+// FromHeader returns the line table of a debug_line segment with the
+// specified header, previously returned by Header, and instructions.
+func FromHeader(hdr *DebugLineHeader, instructions []byte, logfn func(string, ...interface{}), staticBase uint64, normalizeBackslash bool) *DebugLineInfo {
+	dbl := new(DebugLineInfo)
+	dbl.Logf = logfn
+	if logfn == nil {
+		dbl.Logf = func(string, ...interface{}) {}
+	}
+	dbl.staticBase = staticBase
+	dbl.ptrSize = hdr.PtrSize
+	dbl.Prologue = hdr.Prologue
+	dbl.IncludeDirs = hdr.IncludeDirs
+	dbl.FileNames = hdr.FileNames
+	dbl.Lookup = make(map[string]*FileEntry, len(hdr.FileNames))
+	for _, entry := range hdr.FileNames {
+		dbl.Lookup[entry.Path] = entry
+	}
+	dbl.Instructions = instructions
+
+	dbl.stateMachineCache = make(map[uint64]*StateMachine)
+	dbl.lastMachineCache = make(map[uint64]*StateMachine)
+	dbl.normalizeBackslash = normalizeBackslash
+
+	return dbl
+}
+
 func parseDebugLinePrologue(dbl *DebugLineInfo, buf *bytes.Buffer) {
 	p := new(DebugLinePrologue)
 
@@ -295,6 +295,17 @@ func TestPCToLine(t *testing.T) {
 	runTestPCToLine(t, lineInfos, entries, basePCs, true, 0x10000)
 }
 
+func TestFromHeader(t *testing.T) {
+	lineInfos := loadBenchmarkData(t)
+	entries, basePCs := setupTestPCToLine(t, lineInfos)
+
+	restored := FromHeader(lineInfos[0].Header(), lineInfos[0].Instructions, nil, 0, true)
+	if len(restored.Lookup) != len(lineInfos[0].Lookup) {
+		t.Fatalf("lookup table mismatch: %d %d", len(restored.Lookup), len(lineInfos[0].Lookup))
+	}
+	runTestPCToLine(t, DebugLines{restored}, entries, basePCs, false, 0x10000)
+}
+
 func BenchmarkPCToLine(b *testing.B) {
 	lineInfos := loadBenchmarkData(b)
 
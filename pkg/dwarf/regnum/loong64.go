@@ -0,0 +1,65 @@
+package regnum
+
+import (
+	"fmt"
+)
+
+// The mapping between hardware registers and DWARF registers is specified
+// in the LoongArch ELF psABI, section DWARF Register Numbers
+// https://loongson.github.io/LoongArch-Documentation/LoongArch-ELF-ABI-EN.html
+
+const (
+	LOONG64_R0         = 0  // R1 through R31 follow
+	LOONG64_LR         = 1  // also R1, RA
+	LOONG64_TP         = 2  // also R2
+	LOONG64_SP         = 3  // also R3
+	LOONG64_BP         = 22 // also R22, FP, used by Go to hold the current goroutine
+	LOONG64_F0         = 32 // F1 through F31 follow
+	LOONG64_PC         = 64 // not defined by the psABI, the program counter isn't addressable
+	_LOONG64_MaxRegNum = LOONG64_PC
+)
+
+func LOONG64ToName(num uint64) string {
+	switch {
+	case num <= 31:
+		return fmt.Sprintf("R%d", num)
+	case num >= LOONG64_F0 && num <= 63:
+		return fmt.Sprintf("F%d", num-LOONG64_F0)
+	case num == LOONG64_PC:
+		return "PC"
+	default:
+		return fmt.Sprintf("unknown%d", num)
+	}
+}
+
+func LOONG64MaxRegNum() uint64 {
+	return _LOONG64_MaxRegNum
+}
+
+var LOONG64NameToDwarf = func() map[string]int {
+	r := make(map[string]int)
+	for i := 0; i <= 31; i++ {
+		r[fmt.Sprintf("r%d", i)] = LOONG64_R0 + i
+	}
+	r["zero"] = LOONG64_R0
+	r["ra"] = LOONG64_LR
+	r["tp"] = LOONG64_TP
+	r["sp"] = LOONG64_SP
+	for i := 0; i <= 7; i++ {
+		r[fmt.Sprintf("a%d", i)] = LOONG64_R0 + 4 + i
+	}
+	for i := 0; i <= 8; i++ {
+		r[fmt.Sprintf("t%d", i)] = LOONG64_R0 + 12 + i
+	}
+	r["fp"] = LOONG64_BP
+	for i := 0; i <= 8; i++ {
+		r[fmt.Sprintf("s%d", i)] = LOONG64_R0 + 23 + i
+	}
+	r["pc"] = LOONG64_PC
+
+	for i := 0; i <= 31; i++ {
+		r[fmt.Sprintf("f%d", i)] = LOONG64_F0 + i
+	}
+
+	return r
+}()
@@ -0,0 +1,65 @@
+package regnum
+
+import (
+	"fmt"
+)
+
+// The mapping between hardware registers and DWARF registers is specified
+// in the RISC-V ELF psABI, section DWARF Register Numbers
+// https://github.com/riscv-non-isa/riscv-elf-psabi-doc/blob/master/riscv-dwarf.adoc
+
+const (
+	RISCV64_X0         = 0  // X1 through X31 follow
+	RISCV64_LR         = 1  // also X1, RA
+	RISCV64_SP         = 2  // also X2
+	RISCV64_GP         = 3  // also X3
+	RISCV64_TP         = 4  // also X4
+	RISCV64_BP         = 8  // also X8, S0/FP
+	RISCV64_F0         = 32 // F1 through F31 follow
+	RISCV64_PC         = 65 // not defined by the psABI, the program counter isn't addressable
+	_RISCV64_MaxRegNum = RISCV64_PC
+)
+
+func RISCV64ToName(num uint64) string {
+	switch {
+	case num <= 31:
+		return fmt.Sprintf("X%d", num)
+	case num >= RISCV64_F0 && num <= 63:
+		return fmt.Sprintf("F%d", num-RISCV64_F0)
+	case num == RISCV64_PC:
+		return "PC"
+	default:
+		return fmt.Sprintf("unknown%d", num)
+	}
+}
+
+func RISCV64MaxRegNum() uint64 {
+	return _RISCV64_MaxRegNum
+}
+
+var RISCV64NameToDwarf = func() map[string]int {
+	r := make(map[string]int)
+	for i := 0; i <= 31; i++ {
+		r[fmt.Sprintf("x%d", i)] = RISCV64_X0 + i
+	}
+	for i, name := range []string{"zero", "ra", "sp", "gp", "tp", "t0", "t1", "t2", "s0", "s1"} {
+		r[name] = RISCV64_X0 + i
+	}
+	for i := 0; i <= 7; i++ {
+		r[fmt.Sprintf("a%d", i)] = RISCV64_X0 + 10 + i
+	}
+	for i := 2; i <= 11; i++ {
+		r[fmt.Sprintf("s%d", i)] = RISCV64_X0 + 16 + i
+	}
+	for i := 3; i <= 6; i++ {
+		r[fmt.Sprintf("t%d", i)] = RISCV64_X0 + 25 + i
+	}
+	r["fp"] = RISCV64_BP
+	r["pc"] = RISCV64_PC
+
+	for i := 0; i <= 31; i++ {
+		r[fmt.Sprintf("f%d", i)] = RISCV64_F0 + i
+	}
+
+	return r
+}()
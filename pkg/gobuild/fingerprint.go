@@ -0,0 +1,89 @@
+package gobuild
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fingerprintSuffix is appended to the path of a binary to obtain the
+// path of the file where its fingerprint is saved.
+const fingerprintSuffix = ".dlvbuild"
+
+// Fingerprint returns a hash of the source files of 'pkgs' and of their
+// dependencies, of 'buildflags' and of the environment variables that
+// affect the go command. Two builds with the same fingerprint produce
+// equivalent binaries.
+func Fingerprint(pkgs []string, buildflags string, isTest bool) (string, error) {
+	dashC, bfv := splitDashC(buildflags)
+	args := append(dashC, "-deps", "-json=Dir,GoFiles,CgoFiles,CFiles,CXXFiles,HFiles,SFiles,SysoFiles,EmbedFiles,TestGoFiles,XTestGoFiles")
+	if isTest {
+		args = append(args, "-test")
+	}
+	args = append(args, bfv...)
+	args = append(args, pkgs...)
+	_, goList := gocommandExecCmd("list", args...)
+	goList.Stderr = os.Stderr
+	out, err := goList.Output()
+	if err != nil {
+		return "", fmt.Errorf("could not list the dependencies of %s: %v", strings.Join(pkgs, " "), err)
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%q %v\n", args, isTest)
+	env := os.Environ()
+	sort.Strings(env)
+	for _, kv := range env {
+		if strings.HasPrefix(kv, "GO") || strings.HasPrefix(kv, "CGO_") {
+			fmt.Fprintf(h, "%s\n", kv)
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var pkg struct {
+			Dir                                                 string
+			GoFiles, CgoFiles, CFiles, CXXFiles, HFiles, SFiles []string
+			SysoFiles, EmbedFiles, TestGoFiles, XTestGoFiles    []string
+		}
+		if err := dec.Decode(&pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+		for _, files := range [][]string{pkg.GoFiles, pkg.CgoFiles, pkg.CFiles, pkg.CXXFiles, pkg.HFiles, pkg.SFiles, pkg.SysoFiles, pkg.EmbedFiles, pkg.TestGoFiles, pkg.XTestGoFiles} {
+			for _, file := range files {
+				path := filepath.Join(pkg.Dir, file)
+				fi, err := os.Stat(path)
+				if err != nil {
+					return "", err
+				}
+				fmt.Fprintf(h, "%s %d %d\n", path, fi.Size(), fi.ModTime().UnixNano())
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// UpToDate returns true if the binary at 'debugname' exists and was built
+// from sources with the specified fingerprint.
+func UpToDate(debugname, fingerprint string) bool {
+	if _, err := os.Stat(debugname); err != nil {
+		return false
+	}
+	buf, err := os.ReadFile(debugname + fingerprintSuffix)
+	return err == nil && strings.TrimSpace(string(buf)) == fingerprint
+}
+
+// SaveFingerprint records that the binary at 'debugname' was built from
+// sources with the specified fingerprint.
+func SaveFingerprint(debugname, fingerprint string) error {
+	return os.WriteFile(debugname+fingerprintSuffix, []byte(fingerprint+"\n"), 0o600)
+}
@@ -67,29 +67,32 @@ func GoTestBuildCombinedOutput(debugname string, pkgs []string, buildflags inter
 }
 
 func goBuildArgs(debugname string, pkgs []string, buildflags string, isTest bool) []string {
-	var args []string
-
-	bfv := config.SplitQuotedFields(buildflags, '\'')
-	if len(bfv) >= 2 && bfv[0] == "-C" {
-		args = append(args, bfv[:2]...)
-		bfv = bfv[2:]
-	} else if len(bfv) >= 1 && strings.HasPrefix(bfv[0], "-C=") {
-		args = append(args, bfv[0])
-		bfv = bfv[1:]
-	}
+	args, bfv := splitDashC(buildflags)
 
 	args = append(args, "-o", debugname)
 	if isTest {
 		args = append([]string{"-c"}, args...)
 	}
-	args = append(args, "-gcflags", "all=-N -l")
-	if buildflags != "" {
-		args = append(args, bfv...)
+	if !hasGcflags(bfv) {
+		args = append(args, "-gcflags", "all=-N -l")
 	}
+	args = append(args, bfv...)
 	args = append(args, pkgs...)
 	return args
 }
 
+// splitDashC splits buildflags into its fields, separating the -C flag,
+// which must come first on the command line, from the others.
+func splitDashC(buildflags string) (dashC, rest []string) {
+	bfv := config.SplitQuotedFields(buildflags, '\'')
+	if len(bfv) >= 2 && bfv[0] == "-C" {
+		return bfv[:2:2], bfv[2:]
+	} else if len(bfv) >= 1 && strings.HasPrefix(bfv[0], "-C=") {
+		return bfv[:1:1], bfv[1:]
+	}
+	return nil, bfv
+}
+
 // goBuildArgs2 is like goBuildArgs, but takes either string or []string.
 func goBuildArgs2(debugname string, pkgs []string, buildflags interface{}, isTest bool) ([]string, error) {
 	var args []string
@@ -107,10 +110,25 @@ func goBuildArgs2(debugname string, pkgs []string, buildflags interface{}, isTes
 	if isTest {
 		args = append([]string{"-c"}, args...)
 	}
-	args = append(args, "-gcflags", "all=-N -l")
+	if !hasGcflags(args) {
+		args = append(args, "-gcflags", "all=-N -l")
+	}
 	return append(args, pkgs...), nil
 }
 
+// hasGcflags returns true if flags contains a -gcflags flag. The user is
+// then responsible for passing the -N -l flags needed for debugging, since
+// they can't be merged with the user's value without changing it.
+func hasGcflags(flags []string) bool {
+	for _, flag := range flags {
+		name, _, _ := strings.Cut(flag, "=")
+		if name == "-gcflags" || name == "--gcflags" {
+			return true
+		}
+	}
+	return false
+}
+
 func gocommandRun(command string, args ...string) error {
 	_, goBuild := gocommandExecCmd(command, args...)
 	goBuild.Stderr = os.Stdout
@@ -1,8 +1,11 @@
 package gobuild
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/go-delve/delve/pkg/config"
 )
@@ -25,3 +28,79 @@ func TestGoBuildArgsDashC(t *testing.T) {
 		}
 	}
 }
+
+func TestGoBuildArgsFlags(t *testing.T) {
+	testCases := []struct {
+		in  string
+		tgt []string
+	}{
+		{"-tags=foo -mod=vendor", []string{"-o", "debug", "-gcflags", "all=-N -l", "-tags=foo", "-mod=vendor", "pkg"}},
+		{`-ldflags='-X main.Version=1.0 -s'`, []string{"-o", "debug", "-gcflags", "all=-N -l", "-ldflags=-X main.Version=1.0 -s", "pkg"}},
+		{`-ldflags='-X "main.Version=1 0"'`, []string{"-o", "debug", "-gcflags", "all=-N -l", `-ldflags=-X "main.Version=1 0"`, "pkg"}},
+		{`-ldflags='-X main.Quote=\''`, []string{"-o", "debug", "-gcflags", "all=-N -l", `-ldflags=-X main.Quote='`, "pkg"}},
+		{"-gcflags=-m", []string{"-o", "debug", "-gcflags=-m", "pkg"}},
+		{"-tags=foo -gcflags 'all=-N -l -d=checkptr'", []string{"-o", "debug", "-tags=foo", "-gcflags", "all=-N -l -d=checkptr", "pkg"}},
+	}
+
+	for _, tc := range testCases {
+		out := goBuildArgs("debug", []string{"pkg"}, tc.in, false)
+		if !reflect.DeepEqual(out, tc.tgt) {
+			t.Errorf("output mismatch input %q\noutput %q\ntarget %q", tc.in, out, tc.tgt)
+		}
+	}
+
+	out, err := goBuildArgs2("debug", []string{"pkg"}, []string{"-gcflags=-m", "-tags=foo"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tgt := []string{"-gcflags=-m", "-tags=foo", "-o", "debug", "pkg"}; !reflect.DeepEqual(out, tgt) {
+		t.Errorf("output mismatch\noutput %q\ntarget %q", out, tgt)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com/fp\n\ngo 1.21\n")
+	write("main.go", "package main\n\nfunc main() {}\n")
+
+	fingerprint := func(buildflags string) string {
+		t.Helper()
+		fp, err := Fingerprint([]string{"."}, "-C "+dir+" "+buildflags, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fp
+	}
+
+	fp := fingerprint("")
+	if fp2 := fingerprint(""); fp2 != fp {
+		t.Errorf("fingerprint changed without changing the sources: %s %s", fp, fp2)
+	}
+	if fp2 := fingerprint("-tags=debug"); fp2 == fp {
+		t.Errorf("fingerprint did not change with the build flags")
+	}
+
+	debugname := filepath.Join(dir, "debug")
+	if UpToDate(debugname, fp) {
+		t.Errorf("missing binary is up to date")
+	}
+	write("debug", "")
+	if err := SaveFingerprint(debugname, fp); err != nil {
+		t.Fatal(err)
+	}
+	if !UpToDate(debugname, fp) {
+		t.Errorf("binary is not up to date after saving its fingerprint")
+	}
+
+	write("main.go", "package main\n\nfunc main() { println() }\n")
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(dir, "main.go"), future, future)
+	if UpToDate(debugname, fingerprint("")) {
+		t.Errorf("binary is up to date after changing the sources")
+	}
+}
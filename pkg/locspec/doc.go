@@ -3,11 +3,12 @@
 //
 // Location spec examples:
 //
-//	locStr ::= <filename>:<line> | <function>[:<line>] | /<regex>/ | (+|-)<offset> | <line> | *<address>
+//	locStr ::= <filename>:<line> | <function>[:[+]<line>] | /<regex>/ | (+|-)<offset> | <line> | *<address>
 //
 //	* <filename> can be the full path of a file or just a suffix
 //	* <function> ::= <package>.<receiver type>.<name> | <package>.(*<receiver type>).<name> | <receiver type>.<name> | <package>.<name> | (*<receiver type>).<name> | <name>
 //	  <function> must be unambiguous
+//	* <function>:<line> and <function>:+<line> return a location for the line that is <line> lines after the line where the function is declared
 //	* /<regex>/ will return a location for each function matched by regex
 //	* +<offset> returns a location for the line that is <offset> lines after the current line
 //	* -<offset> returns a location for the line that is <offset> lines before the current line
@@ -30,6 +30,9 @@ type NormalLocationSpec struct {
 	Base       string
 	FuncBase   *FuncLocationSpec
 	LineOffset int
+	// RelativeLine is true if the line was specified as +<offset>, which is
+	// only valid for functions.
+	RelativeLine bool
 }
 
 // RegexLocationSpec represents a regular expression
@@ -137,6 +140,7 @@ func parseLocationSpecDefault(locStr, rest string) (LocationSpec, error) {
 	}
 
 	rest = v[1]
+	spec.RelativeLine = strings.HasPrefix(rest, "+")
 
 	var err error
 	spec.LineOffset, err = strconv.Atoi(rest)
@@ -419,6 +423,9 @@ func (loc *NormalLocationSpec) Find(t *proc.Target, processArgs []string, scope
 			//lint:ignore ST1005 backwards compatibility
 			return nil, "", errors.New("Malformed breakpoint location, no line offset specified")
 		}
+		if loc.RelativeLine {
+			return nil, "", fmt.Errorf("relative line offset can not be used with file %s", candidateFiles[0])
+		}
 		addrs, err = proc.FindFileLocation(t, candidateFiles[0], loc.LineOffset)
 		if includeNonExecutableLines {
 			if _, isCouldNotFindLine := err.(*proc.ErrCouldNotFindLine); isCouldNotFindLine {
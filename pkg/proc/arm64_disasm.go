@@ -5,6 +5,9 @@
 package proc
 
 import (
+	"encoding/binary"
+	"fmt"
+
 	"github.com/go-delve/delve/pkg/dwarf/op"
 	"github.com/go-delve/delve/pkg/dwarf/regnum"
 
@@ -37,9 +40,51 @@ func arm64AsmDecode(asmInst *AsmInstruction, mem []byte, regs *op.DwarfRegisters
 
 	asmInst.DestLoc = resolveCallArgARM64(&inst, asmInst.Loc.PC, asmInst.AtPC, regs, memrw, bi)
 
+	if asmInst.Loc.PC >= 4 {
+		// Go and C compilers load the address of global symbols using an ADRP
+		// instruction followed by an ADD or a load/store, decode the pair into
+		// the address it refers to.
+		var prev [4]byte
+		if _, err := memrw.ReadMemory(prev[:], asmInst.Loc.PC-4); err == nil {
+			if addr, ok := arm64PCRelPairAddr(binary.LittleEndian.Uint32(prev[:]), binary.LittleEndian.Uint32(mem), asmInst.Loc.PC-4); ok {
+				asmInst.Inst = &arm64AddrInst{arm64ArchInst: arm64ArchInst(inst), addr: addr}
+			}
+		}
+	}
+
 	return nil
 }
 
+// arm64PCRelPairAddr returns the address computed by the ADRP instruction
+// adrp, located at pc, followed by the instruction next, which must be an
+// ADD or a load/store with an unsigned immediate offset using the register
+// written by adrp.
+func arm64PCRelPairAddr(adrp, next uint32, pc uint64) (uint64, bool) {
+	if adrp&0x9f000000 != 0x90000000 {
+		return 0, false
+	}
+	rd := adrp & 0x1f
+	if (next>>5)&0x1f != rd {
+		return 0, false
+	}
+	immlo := uint64(adrp>>29) & 0x3
+	immhi := uint64(adrp>>5) & 0x7ffff
+	page := int64((immhi<<2|immlo)<<43) >> 31 // sign extend the 21 bit immediate and multiply by 4096
+	addr := uint64(int64(pc&^0xfff) + page)
+
+	imm12 := uint64(next>>10) & 0xfff
+	switch {
+	case next&0xff800000 == 0x91000000: // ADD (immediate), 64bit
+		if next&(1<<22) != 0 {
+			imm12 <<= 12
+		}
+		return addr + imm12, true
+	case next&0x3f000000 == 0x39000000: // LDR/STR (immediate, unsigned offset)
+		return addr + imm12<<(next>>30), true
+	}
+	return 0, false
+}
+
 func resolveCallArgARM64(inst *arm64asm.Inst, instAddr uint64, currentGoroutine bool, regs *op.DwarfRegisters, mem MemoryReadWriter, bininfo *BinaryInfo) *Location {
 	switch inst.Op {
 	case arm64asm.BL, arm64asm.BLR, arm64asm.B, arm64asm.BR:
@@ -115,6 +160,26 @@ func (inst *arm64ArchInst) Text(flavour AssemblyFlavour, pc uint64, symLookup fu
 	return text
 }
 
+// arm64AddrInst is an instruction that, together with the ADRP instruction
+// preceding it, refers to addr.
+type arm64AddrInst struct {
+	arm64ArchInst
+	addr uint64
+}
+
+func (inst *arm64AddrInst) Text(flavour AssemblyFlavour, pc uint64, symLookup func(uint64) (string, uint64)) string {
+	text := inst.arm64ArchInst.Text(flavour, pc, symLookup)
+	name, base := symLookup(inst.addr)
+	switch {
+	case name == "":
+		return fmt.Sprintf("%s <%#x>", text, inst.addr)
+	case base == inst.addr:
+		return fmt.Sprintf("%s <%s>", text, name)
+	default:
+		return fmt.Sprintf("%s <%s+%#x>", text, name, inst.addr-base)
+	}
+}
+
 func (inst *arm64ArchInst) OpcodeEquals(op uint64) bool {
 	if inst == nil {
 		return false
@@ -16,6 +16,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -104,6 +106,12 @@ type BinaryInfo struct {
 	// dwrapUnwrapCache caches unwrapping of defer wrapper functions (dwrap)
 	dwrapUnwrapCache map[uint64]*Function
 
+	// fileLocCache and funcLocCache cache the results of FindFileLocation
+	// and FindFunctionLocation, they are cleared every time a new image is
+	// loaded.
+	fileLocCache map[fileLine][]uint64
+	funcLocCache map[funcLoc][]uint64
+
 	// Go 1.17 register ABI is enabled.
 	regabi bool
 
@@ -122,10 +130,12 @@ var (
 
 var (
 	supportedLinuxArch = map[elf.Machine]bool{
-		elf.EM_X86_64:  true,
-		elf.EM_AARCH64: true,
-		elf.EM_386:     true,
-		elf.EM_PPC64:   true,
+		elf.EM_X86_64:    true,
+		elf.EM_AARCH64:   true,
+		elf.EM_386:       true,
+		elf.EM_PPC64:     true,
+		elf.EM_RISCV:     true,
+		elf.EM_LOONGARCH: true,
 	}
 
 	supportedWindowsArch = map[_PEMachine]bool{
@@ -152,6 +162,22 @@ func (err *ErrFunctionNotFound) Error() string {
 // FindFileLocation returns the PC for a given file:line.
 // Assumes that `file` is normalized to lower case and '/' on Windows.
 func FindFileLocation(p Process, filename string, lineno int) ([]uint64, error) {
+	bi := p.BinInfo()
+	if pcs, ok := bi.fileLocCache[fileLine{filename, lineno}]; ok {
+		return slices.Clone(pcs), nil
+	}
+	pcs, err := findFileLocation(p, filename, lineno)
+	if err != nil {
+		return nil, err
+	}
+	if bi.fileLocCache == nil {
+		bi.fileLocCache = make(map[fileLine][]uint64)
+	}
+	bi.fileLocCache[fileLine{filename, lineno}] = slices.Clone(pcs)
+	return pcs, nil
+}
+
+func findFileLocation(p Process, filename string, lineno int) ([]uint64, error) {
 	// A single file:line can appear in multiple concrete functions, because of
 	// generics instantiation as well as multiple inlined calls into other
 	// concrete functions.
@@ -318,9 +344,31 @@ func (bi *BinaryInfo) FindFunction(funcName string) ([]*Function, error) {
 	return fns, nil
 }
 
+// funcLoc is the key of BinaryInfo.funcLocCache.
+type funcLoc struct {
+	name       string
+	lineOffset int
+}
+
 // FindFunctionLocation finds address of a function's line
 // If lineOffset is passed FindFunctionLocation will return the address of that line
 func FindFunctionLocation(p Process, funcName string, lineOffset int) ([]uint64, error) {
+	bi := p.BinInfo()
+	if pcs, ok := bi.funcLocCache[funcLoc{funcName, lineOffset}]; ok {
+		return slices.Clone(pcs), nil
+	}
+	pcs, err := findFunctionLocation(p, funcName, lineOffset)
+	if err != nil {
+		return nil, err
+	}
+	if bi.funcLocCache == nil {
+		bi.funcLocCache = make(map[funcLoc][]uint64)
+	}
+	bi.funcLocCache[funcLoc{funcName, lineOffset}] = slices.Clone(pcs)
+	return pcs, nil
+}
+
+func findFunctionLocation(p Process, funcName string, lineOffset int) ([]uint64, error) {
 	bi := p.BinInfo()
 	origfns, err := bi.FindFunction(funcName)
 	if err != nil {
@@ -802,6 +850,10 @@ func NewBinaryInfo(goos, goarch string) *BinaryInfo {
 		r.Arch = ARM64Arch(goos)
 	case "ppc64le":
 		r.Arch = PPC64LEArch(goos)
+	case "riscv64":
+		r.Arch = RISCV64Arch(goos)
+	case "loong64":
+		r.Arch = LOONG64Arch(goos)
 	}
 	return r
 }
@@ -1458,6 +1510,16 @@ func (bi *BinaryInfo) openSeparateDebugInfo(image *Image, exe *elf.File, debugIn
 				suffix := filepath.Join(filepath.Dir(exePath)[1:], debugLink)
 				find(nil, suffix)
 			}
+			if debugFilePath == "" {
+				// The default debug info directory is the .build-id
+				// subdirectory of the global debug directory, where GDB
+				// looks for debug links.
+				for _, dir := range debugInfoDirectories {
+					if filepath.Base(dir) == ".build-id" && check(filepath.Join(filepath.Dir(dir), filepath.Dir(exePath)[1:], debugLink)) {
+						break
+					}
+				}
+			}
 			if debugFilePath == "" {
 				bi.logger.Warnf("gnu_debuglink link %q not found in any debug info directory", debugLink)
 			}
@@ -1803,6 +1865,18 @@ func (bi *BinaryInfo) setGStructOffsetElf(image *Image, exe *elf.File, wg *sync.
 	case elf.EM_PPC64:
 		_ = getSymbol(image, bi.logger, exe, "runtime.tls_g")
 
+	case elf.EM_RISCV:
+		tlsg := getSymbol(image, bi.logger, exe, "runtime.tls_g")
+		if tlsg == nil || tls == nil {
+			return
+		}
+
+		// The thread pointer points to the start of the TLS block.
+		bi.gStructOffset = tlsg.Value + (tls.Vaddr & (tls.Align - 1))
+
+	case elf.EM_LOONGARCH:
+		// Go code always keeps the current goroutine in R22.
+
 	default:
 		// we should never get here
 		panic("architecture not supported")
@@ -1970,8 +2044,20 @@ func loadBinaryInfoMacho(bi *BinaryInfo, image *Image, path string, entryPoint u
 	if !supportedDarwinArch[exe.Cpu] {
 		return &ErrUnsupportedArch{os: "darwin", cpuArch: exe.Cpu}
 	}
+	dwarfFile := exe
 	var dwerr error
 	image.dwarf, dwerr = exe.DWARF()
+	if dwerr != nil {
+		if dsym, err := bi.openDSYM(exe, path); err == nil {
+			image.dwarf, dwerr = dsym.DWARF()
+			if dwerr == nil {
+				dwarfFile = dsym
+				image.sepDebugCloser = dsym
+			} else {
+				dsym.Close()
+			}
+		}
+	}
 	if dwerr != nil {
 		if len(bi.Images) <= 1 {
 			fmt.Fprintln(os.Stderr, "Warning: no debug info found, some functionality will be missing such as stack traces and variable evaluation.")
@@ -1982,32 +2068,102 @@ func loadBinaryInfoMacho(bi *BinaryInfo, image *Image, path string, entryPoint u
 		}
 		return nil
 	}
-	debugInfoBytes, err := godwarf.GetDebugSectionMacho(exe, "info")
+	debugInfoBytes, err := godwarf.GetDebugSectionMacho(dwarfFile, "info")
 	if err != nil {
 		return err
 	}
 
 	image.dwarfReader = image.dwarf.Reader()
 
-	debugLineBytes, err := godwarf.GetDebugSectionMacho(exe, "line")
+	debugLineBytes, err := godwarf.GetDebugSectionMacho(dwarfFile, "line")
 	if err != nil {
 		return err
 	}
-	debugLocBytes, _ := godwarf.GetDebugSectionMacho(exe, "loc")
+	debugLocBytes, _ := godwarf.GetDebugSectionMacho(dwarfFile, "loc")
 	image.loclist2 = loclist.NewDwarf2Reader(debugLocBytes, bi.Arch.PtrSize())
-	debugLoclistBytes, _ := godwarf.GetDebugSectionMacho(exe, "loclists")
+	debugLoclistBytes, _ := godwarf.GetDebugSectionMacho(dwarfFile, "loclists")
 	image.loclist5 = loclist.NewDwarf5Reader(debugLoclistBytes)
-	debugAddrBytes, _ := godwarf.GetDebugSectionMacho(exe, "addr")
+	debugAddrBytes, _ := godwarf.GetDebugSectionMacho(dwarfFile, "addr")
 	image.debugAddr = godwarf.ParseAddr(debugAddrBytes)
-	debugLineStrBytes, _ := godwarf.GetDebugSectionMacho(exe, "line_str")
+	debugLineStrBytes, _ := godwarf.GetDebugSectionMacho(dwarfFile, "line_str")
 	image.debugLineStr = debugLineStrBytes
 
 	wg.Add(2)
-	go bi.parseDebugFrameMacho(image, exe, debugInfoBytes, wg)
+	go bi.parseDebugFrameMacho(image, dwarfFile, debugInfoBytes, wg)
 	go bi.loadDebugInfoMaps(image, debugInfoBytes, debugLineBytes, wg, bi.setGStructOffsetMacho)
 	return nil
 }
 
+// machoLoadCmdUUID is the LC_UUID load command, not defined by debug/macho.
+const machoLoadCmdUUID = 0x1b
+
+// machoUUID returns the UUID of f, which identifies the build of an
+// executable and of its debug info, or nil if f doesn't have one.
+func machoUUID(f *macho.File) []byte {
+	for _, ld := range f.Loads {
+		raw := ld.Raw()
+		if len(raw) >= 24 && macho.LoadCmd(f.ByteOrder.Uint32(raw)) == machoLoadCmdUUID {
+			return raw[8:24]
+		}
+	}
+	return nil
+}
+
+// openDSYM searches the .dSYM bundle containing the debug info of exe,
+// the executable at path. The bundle is looked for next to the executable
+// and in every debug info directory, which can also be the path of a .dSYM
+// bundle or of the debug info file inside it. The UUID of the debug info
+// must match the one of the executable.
+func (bi *BinaryInfo) openDSYM(exe *macho.File, path string) (*macho.File, error) {
+	uuid := machoUUID(exe)
+	if uuid == nil {
+		return nil, ErrNoDebugInfoFound
+	}
+	name := filepath.Base(path)
+	bundles := []string{path + ".dSYM"}
+	for _, dir := range bi.DebugInfoDirectories {
+		if strings.HasSuffix(dir, ".dSYM") {
+			bundles = append(bundles, dir)
+		} else if fi, err := os.Stat(dir); err == nil && !fi.IsDir() {
+			bundles = append(bundles, dir)
+		} else {
+			bundles = append(bundles, filepath.Join(dir, name+".dSYM"))
+		}
+	}
+	for _, bundle := range bundles {
+		var candidates []string
+		if fi, err := os.Stat(bundle); err != nil {
+			continue
+		} else if !fi.IsDir() {
+			candidates = []string{bundle}
+		} else {
+			// The debug info file is usually named like the executable,
+			// but the bundle could have been built for a renamed copy.
+			dir := filepath.Join(bundle, "Contents", "Resources", "DWARF")
+			candidates = []string{filepath.Join(dir, name)}
+			entries, _ := os.ReadDir(dir)
+			for _, entry := range entries {
+				if entry.Name() != name {
+					candidates = append(candidates, filepath.Join(dir, entry.Name()))
+				}
+			}
+		}
+		for _, candidate := range candidates {
+			f, err := macho.Open(candidate)
+			if err != nil {
+				continue
+			}
+			if !bytes.Equal(machoUUID(f), uuid) {
+				bi.logger.Warnf("UUID of %s does not match the one of %s", candidate, path)
+				f.Close()
+				continue
+			}
+			return f, nil
+		}
+	}
+	return nil, ErrNoDebugInfoFound
+}
+
 func (bi *BinaryInfo) setGStructOffsetMacho() {
 	// In go1.11 it's 0x30, before 0x8a0, see:
 	// https://github.com/golang/go/issues/23617
@@ -2345,6 +2501,37 @@ func complexType(typename string) bool {
 	return false
 }
 
+// registerCType adds a type defined by a C compile unit to bi.types, with
+// its name prefixed by "C." and, for structs, unions and enums, also with
+// the name cgo uses for it (for example C.struct_foo for struct foo).
+// Declarations of incomplete types are replaced by the first complete
+// definition found.
+func (bi *BinaryInfo) registerCType(ctxt *loadDebugInfoMapsContext, image *Image, entry *dwarf.Entry, name string) {
+	names := []string{"C." + name}
+	switch entry.Tag {
+	case dwarf.TagStructType:
+		names = append(names, "C.struct_"+name)
+	case dwarf.TagUnionType:
+		names = append(names, "C.union_"+name)
+	case dwarf.TagEnumerationType:
+		names = append(names, "C.enum_"+name)
+	}
+	decl, _ := entry.Val(dwarf.AttrDeclaration).(bool)
+	for _, name := range names {
+		_, exists := bi.types[name]
+		_, isdecl := ctxt.cTypeDecls[name]
+		if exists && (!isdecl || decl) {
+			continue
+		}
+		bi.types[name] = dwarfRef{image.index, entry.Offset}
+		if decl {
+			ctxt.cTypeDecls[name] = struct{}{}
+		} else {
+			delete(ctxt.cTypeDecls, name)
+		}
+	}
+}
+
 func (bi *BinaryInfo) registerTypeToPackageMap(entry *dwarf.Entry) {
 	if entry.Tag != dwarf.TagTypedef && entry.Tag != dwarf.TagBaseType && entry.Tag != dwarf.TagClassType && entry.Tag != dwarf.TagStructType {
 		return
@@ -2391,7 +2578,51 @@ func (bi *BinaryInfo) loadDebugInfoMaps(image *Image, debugInfoBytes, debugLineB
 
 	image.runtimeTypeToDIE = make(map[uint64]runtimeTypeDIE)
 
-	ctxt := newLoadDebugInfoMapsContext(bi, image, pdwarf.ReadUnitVersions(debugInfoBytes))
+	offsetToVersion := pdwarf.ReadUnitVersions(debugInfoBytes)
+	cacheKey := bi.dwarfCacheKey(image)
+	if cacheKey == "" || !bi.loadDwarfCache(image, cacheKey, offsetToVersion, debugLineBytes) {
+		bi.loadDebugInfoMapsDwarf(image, offsetToVersion, debugLineBytes)
+		if cacheKey != "" {
+			bi.saveDwarfCache(image, cacheKey, debugLineBytes)
+		}
+	}
+
+	bi.lookupFunc = nil
+	bi.lookupGenericFunc = nil
+	bi.fileLocCache = nil
+	bi.funcLocCache = nil
+
+	for _, cu := range image.compileUnits {
+		if cu.lineInfo != nil {
+			for _, fileEntry := range cu.lineInfo.FileNames {
+				bi.Sources = append(bi.Sources, fileEntry.Path)
+			}
+		}
+	}
+	sort.Strings(bi.Sources)
+	bi.Sources = uniq(bi.Sources)
+
+	if cont != nil {
+		cont()
+	}
+}
+
+// loadDebugInfoMapsDwarf reads all the entries of debug_info of image and
+// fills the maps of bi with them.
+func (bi *BinaryInfo) loadDebugInfoMapsDwarf(image *Image, offsetToVersion map[dwarf.Offset]uint8, debugLineBytes []byte) {
+	ctxt := newLoadDebugInfoMapsContext(bi, image, offsetToVersion)
+
+	// Line tables are needed while reading the entries of compile units, to
+	// resolve the files of inlined calls, all of them are parsed upfront.
+	offsets := make([]dwarf.Offset, 0, len(offsetToVersion))
+	for off := range offsetToVersion {
+		offsets = append(offsets, off)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	cuByOffset := make(map[dwarf.Offset]*compileUnit, len(offsets))
+	for _, cu := range bi.loadCompileUnits(image, offsets, offsetToVersion, debugLineBytes) {
+		cuByOffset[cu.offset] = cu
+	}
 
 	reader := image.DwarfReader()
 
@@ -2406,51 +2637,10 @@ func (bi *BinaryInfo) loadDebugInfoMaps(image *Image, debugInfoBytes, debugLineB
 		}
 		switch entry.Tag {
 		case dwarf.TagCompileUnit:
-			cu := &compileUnit{}
-			cu.image = image
-			cu.entry = entry
-			cu.offset = entry.Offset
-			cu.Version = ctxt.offsetToVersion[cu.offset]
-			if lang, _ := entry.Val(dwarf.AttrLanguage).(int64); lang == dwarfGoLanguage {
-				cu.isgo = true
-			}
-			cu.name, _ = entry.Val(dwarf.AttrName).(string)
-			compdir, _ := entry.Val(dwarf.AttrCompDir).(string)
-			if compdir != "" {
-				cu.name = filepath.Join(compdir, cu.name)
-			}
-			cu.ranges, _ = image.dwarf.Ranges(entry)
-			for i := range cu.ranges {
-				cu.ranges[i][0] += image.StaticBase
-				cu.ranges[i][1] += image.StaticBase
-			}
-			if len(cu.ranges) >= 1 {
-				cu.lowPC = cu.ranges[0][0]
-			}
-			lineInfoOffset, hasLineInfo := entry.Val(dwarf.AttrStmtList).(int64)
-			if hasLineInfo && lineInfoOffset >= 0 && lineInfoOffset < int64(len(debugLineBytes)) {
-				var logfn func(string, ...interface{})
-				if logflags.DebugLineErrors() {
-					logfn = logflags.DebugLineLogger().Debugf
-				}
-				cu.lineInfo = line.Parse(compdir, bytes.NewBuffer(debugLineBytes[lineInfoOffset:]), image.debugLineStr, logfn, image.StaticBase, bi.GOOS == "windows", bi.Arch.PtrSize())
-			}
-			cu.producer, _ = entry.Val(dwarf.AttrProducer).(string)
-			if cu.isgo && cu.producer != "" {
-				semicolon := strings.Index(cu.producer, ";")
-				if semicolon < 0 {
-					cu.optimized = goversion.ProducerAfterOrEqual(cu.producer, 1, 10)
-				} else {
-					cu.optimized = !strings.Contains(cu.producer[semicolon:], "-N") || !strings.Contains(cu.producer[semicolon:], "-l")
-					const regabi = " regabi"
-					if i := strings.Index(cu.producer[semicolon:], regabi); i > 0 {
-						i += semicolon
-						if i+len(regabi) >= len(cu.producer) || cu.producer[i+len(regabi)] == ' ' {
-							bi.regabi = true
-						}
-					}
-					cu.producer = cu.producer[:semicolon]
-				}
+			cu := cuByOffset[entry.Offset]
+			if cu == nil {
+				cu = bi.newCompileUnit(image, entry, ctxt.offsetToVersion)
+				bi.parseLineInfo(image, []*compileUnit{cu}, debugLineBytes)
 			}
 			gopkg, _ := entry.Val(godwarf.AttrGoPackageName).(string)
 			if cu.isgo && gopkg != "" {
@@ -2473,23 +2663,98 @@ func (bi *BinaryInfo) loadDebugInfoMaps(image *Image, debugInfoBytes, debugLineB
 	sort.Sort(compileUnitsByOffset(image.compileUnits))
 	sort.Sort(functionsDebugInfoByEntry(bi.Functions))
 	sort.Sort(packageVarsByAddr(bi.packageVars))
+}
 
-	bi.lookupFunc = nil
-	bi.lookupGenericFunc = nil
+// loadCompileUnits reads the compile units starting at the specified
+// offsets of debug_info, offsets of other kinds of units are ignored, and
+// parses their line tables.
+func (bi *BinaryInfo) loadCompileUnits(image *Image, offsets []dwarf.Offset, offsetToVersion map[dwarf.Offset]uint8, debugLineBytes []byte) []*compileUnit {
+	cus := make([]*compileUnit, 0, len(offsets))
+	rdr := image.DwarfReader()
+	for _, off := range offsets {
+		rdr.Seek(off)
+		entry, err := rdr.Next()
+		if err != nil || entry == nil || entry.Tag != dwarf.TagCompileUnit {
+			continue
+		}
+		cus = append(cus, bi.newCompileUnit(image, entry, offsetToVersion))
+	}
+	bi.parseLineInfo(image, cus, debugLineBytes)
+	return cus
+}
 
-	for _, cu := range image.compileUnits {
-		if cu.lineInfo != nil {
-			for _, fileEntry := range cu.lineInfo.FileNames {
-				bi.Sources = append(bi.Sources, fileEntry.Path)
+// parseLineInfo parses the line tables of cus. Compile units are
+// independent of each other so their line tables are parsed in parallel.
+func (bi *BinaryInfo) parseLineInfo(image *Image, cus []*compileUnit, debugLineBytes []byte) {
+	var logfn func(string, ...interface{})
+	if logflags.DebugLineErrors() {
+		logfn = logflags.DebugLineLogger().Debugf
+	}
+	cuch := make(chan *compileUnit)
+	var wg sync.WaitGroup
+	for i := 0; i < min(runtime.GOMAXPROCS(0), len(cus)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cu := range cuch {
+				lineInfoOffset, hasLineInfo := cu.entry.Val(dwarf.AttrStmtList).(int64)
+				if !hasLineInfo || lineInfoOffset < 0 || lineInfoOffset >= int64(len(debugLineBytes)) {
+					continue
+				}
+				compdir, _ := cu.entry.Val(dwarf.AttrCompDir).(string)
+				cu.lineInfo = line.Parse(compdir, bytes.NewBuffer(debugLineBytes[lineInfoOffset:]), image.debugLineStr, logfn, image.StaticBase, bi.GOOS == "windows", bi.Arch.PtrSize())
 			}
-		}
+		}()
 	}
-	sort.Strings(bi.Sources)
-	bi.Sources = uniq(bi.Sources)
+	for _, cu := range cus {
+		cuch <- cu
+	}
+	close(cuch)
+	wg.Wait()
+}
 
-	if cont != nil {
-		cont()
+// newCompileUnit returns the compile unit described by entry, its line
+// table is parsed separately by parseLineInfo.
+func (bi *BinaryInfo) newCompileUnit(image *Image, entry *dwarf.Entry, offsetToVersion map[dwarf.Offset]uint8) *compileUnit {
+	cu := &compileUnit{}
+	cu.image = image
+	cu.entry = entry
+	cu.offset = entry.Offset
+	cu.Version = offsetToVersion[cu.offset]
+	if lang, _ := entry.Val(dwarf.AttrLanguage).(int64); lang == dwarfGoLanguage {
+		cu.isgo = true
+	}
+	cu.name, _ = entry.Val(dwarf.AttrName).(string)
+	compdir, _ := entry.Val(dwarf.AttrCompDir).(string)
+	if compdir != "" {
+		cu.name = filepath.Join(compdir, cu.name)
+	}
+	cu.ranges, _ = image.dwarf.Ranges(entry)
+	for i := range cu.ranges {
+		cu.ranges[i][0] += image.StaticBase
+		cu.ranges[i][1] += image.StaticBase
+	}
+	if len(cu.ranges) >= 1 {
+		cu.lowPC = cu.ranges[0][0]
+	}
+	cu.producer, _ = entry.Val(dwarf.AttrProducer).(string)
+	if cu.isgo && cu.producer != "" {
+		semicolon := strings.Index(cu.producer, ";")
+		if semicolon < 0 {
+			cu.optimized = goversion.ProducerAfterOrEqual(cu.producer, 1, 10)
+		} else {
+			cu.optimized = !strings.Contains(cu.producer[semicolon:], "-N") || !strings.Contains(cu.producer[semicolon:], "-l")
+			const regabi = " regabi"
+			if i := strings.Index(cu.producer[semicolon:], regabi); i > 0 {
+				i += semicolon
+				if i+len(regabi) >= len(cu.producer) || cu.producer[i+len(regabi)] == ' ' {
+					bi.regabi = true
+				}
+			}
+			cu.producer = cu.producer[:semicolon]
+		}
 	}
+	return cu
 }
 
 // LookupGenericFunc returns a map that allows searching for instantiations of generic function by specifying a function name without type parameters.
@@ -2558,9 +2823,8 @@ func (bi *BinaryInfo) loadDebugInfoMapsCompileUnit(ctxt *loadDebugInfoMapsContex
 		case dwarf.TagArrayType, dwarf.TagBaseType, dwarf.TagClassType, dwarf.TagStructType, dwarf.TagUnionType, dwarf.TagConstType, dwarf.TagVolatileType, dwarf.TagRestrictType, dwarf.TagEnumerationType, dwarf.TagPointerType, dwarf.TagSubroutineType, dwarf.TagTypedef, dwarf.TagUnspecifiedType:
 			if name, ok := entry.Val(dwarf.AttrName).(string); ok {
 				if !cu.isgo {
-					name = "C." + name
-				}
-				if _, exists := bi.types[name]; !exists {
+					bi.registerCType(ctxt, image, entry, name)
+				} else if _, exists := bi.types[name]; !exists {
 					bi.types[name] = dwarfRef{image.index, entry.Offset}
 				}
 			}
@@ -14,6 +14,7 @@ import (
 	"github.com/go-delve/delve/pkg/dwarf/op"
 	"github.com/go-delve/delve/pkg/dwarf/reader"
 	"github.com/go-delve/delve/pkg/goversion"
+	"github.com/go-delve/delve/pkg/proc/evalop"
 	"github.com/go-delve/delve/pkg/proc/internal/ebpf"
 )
 
@@ -82,6 +83,10 @@ type Breaklet struct {
 	// Cond: if not nil the breakpoint will be triggered only if evaluating Cond returns true
 	Cond ast.Expr
 
+	// condCache caches the compiled form of Cond, so that it isn't compiled
+	// again every time the breakpoint is hit.
+	condCache compiledCond
+
 	// DeferReturns: when kind == NextDeferBreakpoint this breakpoint
 	// will also check if the caller is runtime.gopanic or if the return
 	// address is in the DeferReturns array.
@@ -269,7 +274,7 @@ func (bpstate *BreakpointState) checkCond(tgt *Target, breaklet *Breaklet, threa
 	var condErr error
 	active := true
 	if breaklet.Cond != nil {
-		active, condErr = evalBreakpointCondition(tgt, thread, breaklet.Cond)
+		active, condErr = evalBreakpointCondition(tgt, thread, breaklet.Cond, &breaklet.condCache)
 	}
 
 	if condErr != nil && bpstate.CondError == nil {
@@ -447,7 +452,18 @@ func (bp *Breakpoint) UserBreaklet() *Breaklet {
 	return nil
 }
 
-func evalBreakpointCondition(tgt *Target, thread Thread, cond ast.Expr) (bool, error) {
+// compiledCond is the compiled form of a breakpoint condition. Compiling
+// an expression only depends on the types of the target, not on the scope
+// where it is evaluated, so it can be reused every time the breakpoint is
+// hit.
+type compiledCond struct {
+	cond ast.Expr
+	ops  []evalop.Op
+}
+
+// evalBreakpointCondition evaluates cond on thread. If cache is not nil it
+// is used to avoid compiling cond every time it is evaluated.
+func evalBreakpointCondition(tgt *Target, thread Thread, cond ast.Expr, cache *compiledCond) (bool, error) {
 	if cond == nil {
 		return true, nil
 	}
@@ -458,7 +474,21 @@ func evalBreakpointCondition(tgt *Target, thread Thread, cond ast.Expr) (bool, e
 			return true, err
 		}
 	}
-	v, err := scope.evalAST(cond)
+	var ops []evalop.Op
+	if cache != nil && cache.cond == cond {
+		ops = cache.ops
+	} else {
+		ops, err = evalop.CompileAST(scopeToEvalLookup{scope}, cond)
+		if err != nil {
+			return true, fmt.Errorf("error evaluating expression: %v", err)
+		}
+		if cache != nil {
+			*cache = compiledCond{cond: cond, ops: ops}
+		}
+	}
+	stack := &evalStack{}
+	stack.eval(scope, ops)
+	v, err := stack.result(nil)
 	if err != nil {
 		return true, fmt.Errorf("error evaluating expression: %v", err)
 	}
@@ -1000,6 +1030,8 @@ type LogicalBreakpoint struct {
 
 	Tracepoint  bool // Tracepoint flag
 	TraceReturn bool
+	Tags        []string // User defined tags, used to operate on groups of breakpoints
+	Commands    []string // Terminal commands executed when the breakpoint is hit
 	Goroutine   bool     // Retrieve goroutine information
 	Stacktrace  int      // Number of stack frames to retrieve
 	Variables   []string // Variables to evaluate
@@ -156,9 +156,11 @@ func (r *offsetReaderAt) ReadMemory(buf []byte, addr uint64) (n int, err error)
 
 // process represents a core file.
 type process struct {
-	mem     proc.MemoryReader
-	Threads map[int]*thread
-	pid     int
+	mem       proc.MemoryReader
+	closer    io.Closer             // closes mem, may be nil
+	memoryMap []proc.MemoryMapEntry // memory map of the process, nil if not available
+	Threads   map[int]*thread
+	pid       int
 
 	entryPoint uint64
 
@@ -453,6 +455,9 @@ func (p *process) Detach(int, bool) error {
 }
 
 func (p *process) Close() error {
+	if p.closer != nil {
+		return p.closer.Close()
+	}
 	return nil
 }
 
@@ -483,7 +488,10 @@ func (p *process) FindThread(threadID int) (proc.Thread, bool) {
 }
 
 func (p *process) MemoryMap() ([]proc.MemoryMapEntry, error) {
-	return nil, proc.ErrMemoryMapNotSupported
+	if p.memoryMap == nil {
+		return nil, proc.ErrMemoryMapNotSupported
+	}
+	return p.memoryMap, nil
 }
 
 func (p *process) DumpProcessNotes(notes []elfwriter.Note, threadDone func()) (threadsDone bool, out []elfwriter.Note, err error) {
@@ -2,6 +2,8 @@ package core
 
 import (
 	"bytes"
+	"debug/elf"
+	"encoding/binary"
 	"flag"
 	"fmt"
 	"go/constant"
@@ -13,7 +15,10 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-delve/delve/pkg/dwarf/regnum"
+	"github.com/go-delve/delve/pkg/elfwriter"
 	"github.com/go-delve/delve/pkg/goversion"
 	"github.com/go-delve/delve/pkg/proc"
 	"github.com/go-delve/delve/pkg/proc/test"
@@ -306,6 +311,29 @@ func TestCore(t *testing.T) {
 		t.Errorf("main.msg = %q, want %q", msg.Value, "BOOM!")
 	}
 
+	regions, err := proc.GetMemoryRegions(p)
+	if err != nil {
+		t.Fatalf("GetMemoryRegions: %v", err)
+	}
+	var foundText, foundHeap, foundStack bool
+	for _, reg := range regions {
+		t.Logf("%#x-%#x %v %v %v %s heap:%v goroutines:%v", reg.Addr, reg.Addr+reg.Size, reg.Read, reg.Write, reg.Exec, reg.Filename, reg.Heap, reg.Goroutines)
+		if reg.Exec && reg.Filename != "" {
+			foundText = true
+		}
+		if reg.Heap {
+			foundHeap = true
+		}
+		for _, id := range reg.Goroutines {
+			if id == panicking.ID {
+				foundStack = true
+			}
+		}
+	}
+	if !foundText || !foundHeap || !foundStack {
+		t.Errorf("wrong memory map, text: %v heap: %v stack of goroutine %d: %v", foundText, foundHeap, panicking.ID, foundStack)
+	}
+
 	regs, err := p.CurrentThread().Registers()
 	if err != nil {
 		t.Fatalf("Couldn't get current thread registers: %v", err)
@@ -445,6 +473,53 @@ mainSearch:
 	t.Logf("s = %#v\n", v2)
 }
 
+func TestOpenLive(t *testing.T) {
+	if runtime.GOOS != "linux" || (runtime.GOARCH != "amd64" && runtime.GOARCH != "arm64") {
+		t.Skip("unsupported")
+	}
+	var buildFlags test.BuildFlags
+	if buildMode == "pie" {
+		buildFlags = test.BuildModePIE
+	}
+	fix := test.BuildFixture("sleep", buildFlags)
+	cmd := exec.Command(fix.Path)
+	assertNoError(cmd.Start(), t, "Start")
+	defer cmd.Process.Kill()
+	time.Sleep(500 * time.Millisecond)
+
+	grp, err := OpenLive(cmd.Process.Pid, fix.Path, []string{})
+	assertNoError(err, t, "OpenLive")
+	defer grp.Detach(false)
+	p := grp.Selected
+
+	gs, _, err := proc.GoroutinesInfo(p, 0, 0)
+	assertNoError(err, t, "GoroutinesInfo")
+
+	found := false
+	for _, g := range gs {
+		stack, err := proc.GoroutineStacktrace(p, g, 10, 0)
+		assertNoError(err, t, "Stacktrace()")
+		for _, frame := range stack {
+			if frame.Current.Fn != nil && frame.Current.Fn.Name == "main.f" {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Fatal("could not find main.f frame")
+	}
+
+	if _, err := p.Memory().WriteMemory(0, []byte{0}); err == nil {
+		t.Fatal("writing memory of a live snapshot did not fail")
+	}
+
+	status, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", cmd.Process.Pid))
+	assertNoError(err, t, "ReadFile")
+	if fields := strings.Fields(string(status[bytes.LastIndexByte(status, ')')+1:])); len(fields) == 0 || fields[0] == "t" {
+		t.Fatalf("target process was stopped: %q", status)
+	}
+}
+
 func TestMinidump(t *testing.T) {
 	if runtime.GOOS != "windows" || runtime.GOARCH != "amd64" {
 		t.Skip("minidumps can only be produced on windows/amd64")
@@ -525,3 +600,60 @@ func procdump(t *testing.T, exePath string) string {
 	t.Fatalf("could not find dump file")
 	return ""
 }
+
+func TestCoreLOONG64Registers(t *testing.T) {
+	// Core files of loong64 processes are read using the register layout
+	// of the kernel, check it with a core file containing only notes.
+	writeELF := func(name string, typ elf.Type, notes []elfwriter.Note) string {
+		path := filepath.Join(t.TempDir(), name)
+		fh, err := os.Create(path)
+		assertNoError(err, t, "Create")
+		w := elfwriter.New(fh, &elf.FileHeader{Class: elf.ELFCLASS64, Data: elf.ELFDATA2LSB, Version: elf.EV_CURRENT, Type: typ, Machine: elf.EM_LOONGARCH})
+		if len(notes) > 0 {
+			w.Progs = append(w.Progs, w.WriteNotes(notes))
+		}
+		w.WriteProgramHeaders()
+		assertNoError(w.Err, t, "writing ELF file")
+		assertNoError(fh.Close(), t, "Close")
+		return path
+	}
+
+	var prstatus linuxPrStatusLOONG64
+	prstatus.Pid = 42
+	for i := range prstatus.Reg.Regs {
+		prstatus.Reg.Regs[i] = uint64(0x100 + i)
+	}
+	prstatus.Reg.Era = 0x12345
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, &prstatus)
+	fpregs := make([]byte, 32*8+8+4)
+	binary.LittleEndian.PutUint64(fpregs[8:], 0x3ff0000000000000) // F1 = 1.0
+
+	exePath := writeELF("exe", elf.ET_EXEC, nil)
+	corePath := writeELF("core", elf.ET_CORE, []elfwriter.Note{
+		{Type: elf.NT_PRSTATUS, Name: "CORE", Data: buf.Bytes()},
+		{Type: _NT_FPREGSET, Name: "CORE", Data: fpregs},
+	})
+
+	p, thread, err := readLinuxOrPlatformIndependentCore(corePath, exePath)
+	assertNoError(err, t, "readLinuxOrPlatformIndependentCore")
+	if p.bi.Arch.Name != "loong64" {
+		t.Fatalf("wrong architecture %q", p.bi.Arch.Name)
+	}
+	if thread == nil || thread.ThreadID() != 42 {
+		t.Fatalf("wrong current thread %v", thread)
+	}
+	regs, err := thread.Registers()
+	assertNoError(err, t, "Registers")
+	if regs.PC() != 0x12345 || regs.SP() != 0x103 || regs.LR() != 0x101 {
+		t.Errorf("wrong registers PC=%#x SP=%#x LR=%#x", regs.PC(), regs.SP(), regs.LR())
+	}
+	if g, ok := regs.GAddr(); !ok || g != 0x116 {
+		t.Errorf("wrong g address %#x %v", g, ok)
+	}
+
+	dregs := p.bi.Arch.RegistersToDwarfRegisters(0, regs)
+	if r := dregs.Reg(regnum.LOONG64_F0 + 1); r == nil || r.Uint64Val != 0x3ff0000000000000 {
+		t.Errorf("wrong F1 register %v", r)
+	}
+}
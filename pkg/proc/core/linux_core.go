@@ -40,6 +40,7 @@ const _NT_FPREGSET elf.NType = 0x2
 const (
 	_EM_AARCH64          = 183
 	_EM_X86_64           = 62
+	_EM_LOONGARCH        = 258
 	_ARM_FP_HEADER_START = 512
 )
 
@@ -49,6 +50,7 @@ func linuxThreadsFromNotes(p *process, notes []*note, machineType elf.Machine) p
 	var currentThread proc.Thread
 	var lastThreadAMD *linuxAMD64Thread
 	var lastThreadARM *linuxARM64Thread
+	var lastThreadLOONG64 *linuxLOONG64Thread
 	for _, note := range notes {
 		switch note.Type {
 		case elf.NT_PRSTATUS:
@@ -66,12 +68,23 @@ func linuxThreadsFromNotes(p *process, notes []*note, machineType elf.Machine) p
 				if currentThread == nil {
 					currentThread = p.Threads[int(t.Pid)]
 				}
+			} else if machineType == _EM_LOONGARCH {
+				t := note.Desc.(*linuxPrStatusLOONG64)
+				lastThreadLOONG64 = &linuxLOONG64Thread{linutil.LOONG64Registers{Regs: &t.Reg}, t}
+				p.Threads[int(t.Pid)] = &thread{lastThreadLOONG64, p, proc.CommonThread{}}
+				if currentThread == nil {
+					currentThread = p.Threads[int(t.Pid)]
+				}
 			}
 		case _NT_FPREGSET:
 			if machineType == _EM_AARCH64 {
 				if lastThreadARM != nil {
 					lastThreadARM.regs.Fpregs = note.Desc.(*linutil.ARM64PtraceFpRegs).Decode()
 				}
+			} else if machineType == _EM_LOONGARCH {
+				if lastThreadLOONG64 != nil {
+					lastThreadLOONG64.regs.Fpregs = note.Desc.(*linutil.LOONG64PtraceFpRegs).Decode()
+				}
 			}
 		case _NT_X86_XSTATE:
 			if machineType == _EM_X86_64 {
@@ -147,6 +160,8 @@ func readLinuxOrPlatformIndependentCore(corePath, exePath string) (*process, pro
 			bi = proc.NewBinaryInfo("linux", "amd64")
 		case _EM_AARCH64:
 			bi = proc.NewBinaryInfo("linux", "arm64")
+		case _EM_LOONGARCH:
+			bi = proc.NewBinaryInfo("linux", "loong64")
 		default:
 			return nil, nil, errors.New("unsupported machine type")
 		}
@@ -156,6 +171,7 @@ func readLinuxOrPlatformIndependentCore(corePath, exePath string) (*process, pro
 
 	p := &process{
 		mem:         memory,
+		memoryMap:   buildMemoryMap(coreFile, notes),
 		Threads:     map[int]*thread{},
 		entryPoint:  entryPoint,
 		bi:          bi,
@@ -181,6 +197,11 @@ type linuxARM64Thread struct {
 	t    *linuxPrStatusARM64
 }
 
+type linuxLOONG64Thread struct {
+	regs linutil.LOONG64Registers
+	t    *linuxPrStatusLOONG64
+}
+
 func (t *linuxAMD64Thread) registers() (proc.Registers, error) {
 	var r linutil.AMD64Registers
 	r.Regs = t.regs.Regs
@@ -195,6 +216,13 @@ func (t *linuxARM64Thread) registers() (proc.Registers, error) {
 	return &r, nil
 }
 
+func (t *linuxLOONG64Thread) registers() (proc.Registers, error) {
+	var r linutil.LOONG64Registers
+	r.Regs = t.regs.Regs
+	r.Fpregs = t.regs.Fpregs
+	return &r, nil
+}
+
 func (t *linuxAMD64Thread) pid() int {
 	return int(t.t.Pid)
 }
@@ -203,6 +231,10 @@ func (t *linuxARM64Thread) pid() int {
 	return int(t.t.Pid)
 }
 
+func (t *linuxLOONG64Thread) pid() int {
+	return int(t.t.Pid)
+}
+
 // Note is a note from the PT_NOTE prog.
 // Relevant types:
 // - NT_FILE: File mapping information, e.g. program text mappings. Desc is a LinuxNTFile.
@@ -286,6 +318,8 @@ func readNote(r io.ReadSeeker, machineType elf.Machine) (*note, error) {
 			note.Desc = &linuxPrStatusAMD64{}
 		case _EM_AARCH64:
 			note.Desc = &linuxPrStatusARM64{}
+		case _EM_LOONGARCH:
+			note.Desc = &linuxPrStatusLOONG64{}
 		default:
 			return nil, errors.New("unsupported machine type")
 		}
@@ -301,7 +335,7 @@ func readNote(r io.ReadSeeker, machineType elf.Machine) (*note, error) {
 		// No good documentation reference, but the structure is
 		// simply a header, including entry count, followed by that
 		// many entries, and then the file name of each entry,
-		// null-delimited. Not reading the names here.
+		// null-delimited.
 		data := &linuxNTFile{}
 		if err := binary.Read(descReader, binary.LittleEndian, &data.linuxNTFileHdr); err != nil {
 			return nil, fmt.Errorf("reading NT_FILE header: %v", err)
@@ -313,6 +347,11 @@ func readNote(r io.ReadSeeker, machineType elf.Machine) (*note, error) {
 			}
 			data.entries = append(data.entries, entry)
 		}
+		names, err := io.ReadAll(descReader)
+		if err != nil {
+			return nil, fmt.Errorf("reading NT_FILE names: %v", err)
+		}
+		data.filenames = strings.Split(strings.TrimRight(string(names), "\x00"), "\x00")
 		note.Desc = data
 	case _NT_X86_XSTATE:
 		if machineType == _EM_X86_64 {
@@ -332,6 +371,8 @@ func readNote(r io.ReadSeeker, machineType elf.Machine) (*note, error) {
 				return nil, err
 			}
 			note.Desc = fpregs
+		} else if machineType == _EM_LOONGARCH {
+			note.Desc = &linutil.LOONG64PtraceFpRegs{Fregs: desc}
 		}
 	}
 	if err := skipPadding(r, 4); err != nil {
@@ -394,6 +435,42 @@ func buildMemory(core, exeELF *elf.File, exe io.ReaderAt, notes []*note) proc.Me
 	return memory
 }
 
+// buildMemoryMap returns the memory map of the process described by the
+// PT_LOAD program headers of the core file, the NT_FILE note is used to
+// find which file is mapped to each region.
+func buildMemoryMap(core *elf.File, notes []*note) []proc.MemoryMapEntry {
+	var fileNote *linuxNTFile
+	for _, note := range notes {
+		if note.Type == _NT_FILE {
+			fileNote = note.Desc.(*linuxNTFile)
+		}
+	}
+	r := []proc.MemoryMapEntry{}
+	for _, prog := range core.Progs {
+		if prog.Type != elf.PT_LOAD {
+			continue
+		}
+		entry := proc.MemoryMapEntry{
+			Addr:  prog.Vaddr,
+			Size:  prog.Memsz,
+			Read:  prog.Flags&elf.PF_R != 0,
+			Write: prog.Flags&elf.PF_W != 0,
+			Exec:  prog.Flags&elf.PF_X != 0,
+		}
+		if fileNote != nil {
+			for i, fileEntry := range fileNote.entries {
+				if fileEntry.Start <= entry.Addr && entry.Addr < fileEntry.End && i < len(fileNote.filenames) {
+					entry.Filename = fileNote.filenames[i]
+					entry.Offset = fileEntry.FileOfs*fileNote.PageSize + (entry.Addr - fileEntry.Start)
+					break
+				}
+			}
+		}
+		r = append(r, entry)
+	}
+	return r
+}
+
 func findEntryPoint(notes []*note, ptrSize int) uint64 {
 	for _, note := range notes {
 		if note.Type == _NT_AUXV {
@@ -446,6 +523,19 @@ type linuxPrStatusARM64 struct {
 	Fpvalid                      int32
 }
 
+// LinuxPrStatusLOONG64 is a copy of the prstatus kernel struct.
+type linuxPrStatusLOONG64 struct {
+	Siginfo                      linuxSiginfo
+	Cursig                       uint16
+	_                            [2]uint8
+	Sigpend                      uint64
+	Sighold                      uint64
+	Pid, Ppid, Pgrp, Sid         int32
+	Utime, Stime, CUtime, CStime linuxCoreTimeval
+	Reg                          linutil.LOONG64PtraceRegs
+	Fpvalid                      int32
+}
+
 // LinuxSiginfo is a copy of the
 // siginfo kernel struct.
 type linuxSiginfo struct {
@@ -457,7 +547,8 @@ type linuxSiginfo struct {
 // LinuxNTFile contains information on mapped files.
 type linuxNTFile struct {
 	linuxNTFileHdr
-	entries []*linuxNTFileEntry
+	entries   []*linuxNTFileEntry
+	filenames []string // file name of each entry
 }
 
 // LinuxNTFileHdr is a header struct for NTFile.
@@ -0,0 +1,147 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/pkg/proc/linutil"
+)
+
+// ErrLiveUnsupported is returned by OpenLive on operating systems that do
+// not have a /proc filesystem.
+var ErrLiveUnsupported = errors.New("read-only inspection of a running process is only supported on linux")
+
+// OpenLive opens the running process pid for read-only inspection, without
+// attaching to it with ptrace.
+// Memory is read through /proc/<pid>/mem and the registers of each thread
+// are reconstructed from /proc/<pid>/task/<tid>/syscall (or stat), which
+// means that only the stack pointer and program counter of threads blocked
+// in the kernel are available.
+// The threads of the target process keep running while it is being
+// inspected so the state returned may be inconsistent.
+func OpenLive(pid int, exePath string, debugInfoDirs []string) (*proc.TargetGroup, error) {
+	if runtime.GOOS != "linux" {
+		return nil, ErrLiveUnsupported
+	}
+	if exePath == "" {
+		exePath = fmt.Sprintf("/proc/%d/exe", pid)
+	}
+
+	mem, err := os.Open(fmt.Sprintf("/proc/%d/mem", pid))
+	if err != nil {
+		return nil, err
+	}
+
+	bi := proc.NewBinaryInfo(runtime.GOOS, runtime.GOARCH)
+
+	var entryPoint uint64
+	if auxv, err := os.ReadFile(fmt.Sprintf("/proc/%d/auxv", pid)); err == nil {
+		entryPoint = linutil.EntryPointFromAuxv(auxv, bi.Arch.PtrSize())
+	}
+
+	p := &process{
+		mem:         &offsetReaderAt{reader: mem, offset: 0},
+		closer:      mem,
+		Threads:     map[int]*thread{},
+		pid:         pid,
+		entryPoint:  entryPoint,
+		bi:          bi,
+		breakpoints: proc.NewBreakpointMap(),
+	}
+
+	tids, err := filepath.Glob(fmt.Sprintf("/proc/%d/task/*", pid))
+	if err != nil {
+		mem.Close()
+		return nil, err
+	}
+	var currentThread proc.Thread
+	for _, taskDir := range tids {
+		tid, err := strconv.Atoi(filepath.Base(taskDir))
+		if err != nil {
+			continue
+		}
+		sp, pc := liveThreadSPPC(taskDir)
+		th := &thread{&liveThread{tid: tid, regs: liveRegisters(sp, pc)}, p, proc.CommonThread{}}
+		p.Threads[tid] = th
+		if tid == pid || currentThread == nil {
+			currentThread = th
+		}
+	}
+	if currentThread == nil {
+		mem.Close()
+		return nil, ErrNoThreads
+	}
+
+	grp, addTarget := proc.NewGroup(p, proc.NewTargetGroupConfig{
+		DebugInfoDirs:       debugInfoDirs,
+		DisableAsyncPreempt: false,
+		CanDump:             false,
+	})
+	_, err = addTarget(p, p.pid, currentThread, exePath, proc.StopAttached, "")
+	return grp, err
+}
+
+// liveThreadSPPC returns the stack pointer and program counter of the
+// thread described by taskDir. If the thread is executing in user space
+// both values will be zero.
+func liveThreadSPPC(taskDir string) (sp, pc uint64) {
+	// The syscall file contains the syscall number, followed by its six
+	// arguments, the stack pointer and the program counter, or the word
+	// "running" if the thread is not blocked.
+	if buf, err := os.ReadFile(filepath.Join(taskDir, "syscall")); err == nil {
+		fields := strings.Fields(string(buf))
+		if len(fields) >= 3 && fields[0] != "running" {
+			sp, _ = strconv.ParseUint(fields[len(fields)-2], 0, 64)
+			pc, _ = strconv.ParseUint(fields[len(fields)-1], 0, 64)
+			return sp, pc
+		}
+	}
+	// Fall back to the kstkesp and kstkeip fields of stat, these are only
+	// filled in by some kernels.
+	buf, err := os.ReadFile(filepath.Join(taskDir, "stat"))
+	if err != nil {
+		return 0, 0
+	}
+	s := string(buf)
+	// The second field (comm) can contain spaces, skip past it.
+	if i := strings.LastIndex(s, ")"); i >= 0 {
+		s = s[i+1:]
+	}
+	fields := strings.Fields(s)
+	const kstkespField, kstkeipField = 29 - 3, 30 - 3
+	if len(fields) <= kstkeipField {
+		return 0, 0
+	}
+	sp, _ = strconv.ParseUint(fields[kstkespField], 10, 64)
+	pc, _ = strconv.ParseUint(fields[kstkeipField], 10, 64)
+	return sp, pc
+}
+
+func liveRegisters(sp, pc uint64) proc.Registers {
+	switch runtime.GOARCH {
+	case "arm64":
+		return &linutil.ARM64Registers{Regs: &linutil.ARM64PtraceRegs{Sp: sp, Pc: pc}}
+	default:
+		return &linutil.AMD64Registers{Regs: &linutil.AMD64PtraceRegs{Rsp: sp, Rip: pc}}
+	}
+}
+
+// liveThread represents a thread of a process opened with OpenLive.
+type liveThread struct {
+	tid  int
+	regs proc.Registers
+}
+
+func (t *liveThread) registers() (proc.Registers, error) {
+	return t.regs, nil
+}
+
+func (t *liveThread) pid() int {
+	return t.tid
+}
@@ -2,6 +2,7 @@ package proc
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/go-delve/delve/pkg/dwarf/op"
 )
@@ -14,6 +15,10 @@ type AsmInstruction struct {
 	Breakpoint bool
 	AtPC       bool
 
+	// Symbol is the name of the function or ELF symbol starting at this
+	// instruction, if any.
+	Symbol string
+
 	Size int
 	Kind AsmInstructionKind
 
@@ -159,6 +164,15 @@ func disassemble(memrw MemoryReadWriter, regs Registers, breakpoints *Breakpoint
 		inst.Loc = Location{PC: pc, File: file, Line: line, Fn: fn}
 		inst.Breakpoint = atbp
 		inst.AtPC = (regs != nil) && (curpc == pc)
+		if fn != nil {
+			if fn.Entry == pc {
+				inst.Symbol = fn.Name
+			}
+		} else if sym := bi.SymNames[pc]; sym != nil {
+			// Ranges without debug symbols (assembly trampolines, C code
+			// compiled without DWARF) can still be labeled using the symbol table.
+			inst.Symbol = sym.Name
+		}
 
 		bi.Arch.asmDecode(&inst, mem, dregs, memrw, bi)
 
@@ -176,6 +190,35 @@ func disassemble(memrw MemoryReadWriter, regs Registers, breakpoints *Breakpoint
 
 // Text will return the assembly instructions in human readable format according to
 // the flavour specified.
+// The destination of call and jump instructions is annotated with the name
+// of the function it belongs to, unless it's already part of the text or
+// it's a jump inside the current function.
 func (instr *AsmInstruction) Text(flavour AssemblyFlavour, bi *BinaryInfo) string {
-	return instr.Inst.Text(flavour, instr.Loc.PC, bi.symLookup)
+	text := instr.Inst.Text(flavour, instr.Loc.PC, bi.symLookup)
+	if instr.DestLoc == nil || (instr.Kind != CallInstruction && instr.Kind != JmpInstruction) {
+		return text
+	}
+	if instr.Kind == JmpInstruction && instr.DestLoc.Fn != nil && instr.DestLoc.Fn == instr.Loc.Fn {
+		return text
+	}
+	if dest := destSymbol(instr.DestLoc, bi); dest != "" && !strings.Contains(text, dest) {
+		text += " <" + dest + ">"
+	}
+	return text
+}
+
+// destSymbol returns the name of the function containing loc, followed by
+// the offset of loc from its entry point. Addresses outside of any function
+// known to DWARF are resolved using the symbol table, if possible.
+func destSymbol(loc *Location, bi *BinaryInfo) string {
+	if loc.Fn != nil {
+		if loc.PC == loc.Fn.Entry {
+			return loc.Fn.Name
+		}
+		return fmt.Sprintf("%s+%#x", loc.Fn.Name, loc.PC-loc.Fn.Entry)
+	}
+	if sym := bi.SymNames[loc.PC]; sym != nil {
+		return sym.Name
+	}
+	return ""
 }
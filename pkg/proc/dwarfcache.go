@@ -0,0 +1,348 @@
+package proc
+
+import (
+	"crypto/sha256"
+	"debug/dwarf"
+	"encoding/gob"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-delve/delve/pkg/dwarf/line"
+	"github.com/go-delve/delve/pkg/logflags"
+)
+
+// DwarfCacheDir is the directory where the indexes built from the debug
+// info of executables are saved, so that they don't need to be rebuilt the
+// next time the same executable is loaded. If it is empty the cache is
+// disabled.
+var DwarfCacheDir string
+
+const (
+	// dwarfCacheVersion must be incremented every time the format of
+	// dwarfCache changes.
+	dwarfCacheVersion = 2
+	// dwarfCacheMaxEntries is the maximum number of executables kept in the
+	// cache, the least recently used ones are removed first.
+	dwarfCacheMaxEntries = 16
+	dwarfCacheExt        = ".gob"
+)
+
+// dwarfCache contains the indexes built by loadDebugInfoMaps for an image.
+// Addresses are relative to the static base of the image and compile
+// units are referenced by their index in dwarfCache.CompileUnits (-1 if
+// the compile unit is not set).
+type dwarfCache struct {
+	Version          int
+	Key              string
+	CompileUnits     []dwarf.Offset
+	LineTables       []dwarfCacheLineTable // line tables of CompileUnits
+	Functions        []dwarfCacheFunction
+	PackageVars      []dwarfCachePackageVar
+	Types            map[string]dwarf.Offset
+	Consts           map[dwarf.Offset][]dwarfCacheConst
+	PackageMap       map[string][]string
+	RuntimeTypeToDIE map[uint64]dwarf.Offset
+	InlinedCallLines []dwarfCacheInlinedCallLine
+}
+
+// dwarfCacheLineTable is the parsed header of the line table of a compile
+// unit, its instructions are read from debug_line starting at
+// InstructionsOff. Header is nil if the compile unit has no line table.
+type dwarfCacheLineTable struct {
+	Header          *line.DebugLineHeader
+	InstructionsOff int
+	InstructionsLen int
+}
+
+type dwarfCacheFunction struct {
+	Name         string
+	Entry, End   uint64
+	Offset       dwarf.Offset
+	CU           int
+	Trampoline   bool
+	InlinedCalls []dwarfCacheInlinedCall
+}
+
+type dwarfCacheInlinedCall struct {
+	CU            int
+	LowPC, HighPC uint64
+}
+
+type dwarfCachePackageVar struct {
+	Name   string
+	CU     int
+	Offset dwarf.Offset
+	Addr   uint64
+}
+
+type dwarfCacheConst struct {
+	Name  string
+	Value int64
+}
+
+type dwarfCacheInlinedCallLine struct {
+	File string
+	Line int
+	PCs  []uint64
+}
+
+// dwarfCacheKey returns the key used to save the indexes of image to the
+// cache, or the empty string if they can not be cached. Only the
+// executable is cached, and only if it is the first image loaded, so that
+// the indexes of bi contain exclusively its entries.
+// The key is a hash of the contents of the executable, build IDs can not
+// be used because the Go linker only emits them on some platforms.
+func (bi *BinaryInfo) dwarfCacheKey(image *Image) string {
+	if DwarfCacheDir == "" || image.index != 0 || len(bi.Functions) != 0 || len(bi.packageVars) != 0 || len(bi.types) != 0 {
+		return ""
+	}
+	fh, err := os.Open(image.Path)
+	if err != nil {
+		return ""
+	}
+	defer fh.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, fh); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func dwarfCachePath(key string) string {
+	return filepath.Join(DwarfCacheDir, key+dwarfCacheExt)
+}
+
+// loadDwarfCache fills the maps of bi with the indexes of image saved in
+// the cache with the specified key, returns false if they are not in the
+// cache.
+func (bi *BinaryInfo) loadDwarfCache(image *Image, key string, offsetToVersion map[dwarf.Offset]uint8, debugLineBytes []byte) bool {
+	path := dwarfCachePath(key)
+	fh, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer fh.Close()
+	var c dwarfCache
+	if err := gob.NewDecoder(fh).Decode(&c); err != nil || c.Version != dwarfCacheVersion || c.Key != key || len(c.LineTables) != len(c.CompileUnits) {
+		bi.logger.Debugf("discarding debug info cache %s: %v", path, err)
+		return false
+	}
+
+	// Only the entries of compile units are read again from debug_info,
+	// their line tables are restored from the cache.
+	var logfn func(string, ...interface{})
+	if logflags.DebugLineErrors() {
+		logfn = logflags.DebugLineLogger().Debugf
+	}
+	rdr := image.DwarfReader()
+	cus := make([]*compileUnit, 0, len(c.CompileUnits))
+	for i, off := range c.CompileUnits {
+		rdr.Seek(off)
+		entry, err := rdr.Next()
+		if err != nil || entry == nil || entry.Tag != dwarf.TagCompileUnit {
+			bi.logger.Debugf("discarding debug info cache %s: compile units mismatch", path)
+			return false
+		}
+		cu := bi.newCompileUnit(image, entry, offsetToVersion)
+		if lt := c.LineTables[i]; lt.Header != nil {
+			if lt.InstructionsOff < 0 || lt.InstructionsLen < 0 || lt.InstructionsOff+lt.InstructionsLen > len(debugLineBytes) {
+				bi.logger.Debugf("discarding debug info cache %s: line tables mismatch", path)
+				return false
+			}
+			cu.lineInfo = line.FromHeader(lt.Header, debugLineBytes[lt.InstructionsOff:][:lt.InstructionsLen], logfn, image.StaticBase, bi.GOOS == "windows")
+		}
+		cus = append(cus, cu)
+	}
+	cu := func(i int) *compileUnit {
+		if i < 0 || i >= len(cus) {
+			return nil
+		}
+		return cus[i]
+	}
+	base := image.StaticBase
+
+	image.compileUnits = cus
+	bi.Functions = make([]Function, len(c.Functions))
+	for i, cfn := range c.Functions {
+		fn := &bi.Functions[i]
+		fn.Name = cfn.Name
+		fn.Entry = cfn.Entry + base
+		fn.End = cfn.End + base
+		fn.offset = cfn.Offset
+		fn.cu = cu(cfn.CU)
+		fn.trampoline = cfn.Trampoline
+		if len(cfn.InlinedCalls) > 0 {
+			fn.InlinedCalls = make([]InlinedCall, len(cfn.InlinedCalls))
+			for j, call := range cfn.InlinedCalls {
+				fn.InlinedCalls[j] = InlinedCall{cu: cu(call.CU), LowPC: call.LowPC + base, HighPC: call.HighPC + base}
+			}
+		}
+	}
+	bi.packageVars = make([]packageVar, len(c.PackageVars))
+	for i, v := range c.PackageVars {
+		bi.packageVars[i] = packageVar{name: v.Name, cu: cu(v.CU), offset: v.Offset, addr: v.Addr + base}
+	}
+	for name, off := range c.Types {
+		bi.types[name] = dwarfRef{image.index, off}
+	}
+	for off, values := range c.Consts {
+		ct := &constantType{}
+		for _, v := range values {
+			ct.values = append(ct.values, constantValue{name: v.Name, fullName: v.Name, value: v.Value})
+		}
+		bi.consts[dwarfRef{image.index, off}] = ct
+	}
+	for name, paths := range c.PackageMap {
+		bi.PackageMap[name] = append(bi.PackageMap[name], paths...)
+	}
+	for off, dieOff := range c.RuntimeTypeToDIE {
+		image.runtimeTypeToDIE[off] = runtimeTypeDIE{dieOff, -1}
+	}
+	for _, l := range c.InlinedCallLines {
+		pcs := make([]uint64, len(l.PCs))
+		for i := range l.PCs {
+			pcs[i] = l.PCs[i] + base
+		}
+		bi.inlinedCallLines[fileLine{l.File, l.Line}] = pcs
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now) // marks the entry as recently used
+	bi.logger.Debugf("debug info indexes loaded from cache %s", path)
+	return true
+}
+
+// saveDwarfCache saves the indexes of image to the cache with the
+// specified key.
+func (bi *BinaryInfo) saveDwarfCache(image *Image, key string, debugLineBytes []byte) {
+	image.loadErrMu.Lock()
+	loadErr := image.loadErr
+	image.loadErrMu.Unlock()
+	if loadErr != nil {
+		return
+	}
+	base := image.StaticBase
+	c := dwarfCache{
+		Version:          dwarfCacheVersion,
+		Key:              key,
+		CompileUnits:     make([]dwarf.Offset, len(image.compileUnits)),
+		LineTables:       make([]dwarfCacheLineTable, len(image.compileUnits)),
+		Functions:        make([]dwarfCacheFunction, len(bi.Functions)),
+		PackageVars:      make([]dwarfCachePackageVar, len(bi.packageVars)),
+		Types:            make(map[string]dwarf.Offset, len(bi.types)),
+		Consts:           make(map[dwarf.Offset][]dwarfCacheConst, len(bi.consts)),
+		PackageMap:       bi.PackageMap,
+		RuntimeTypeToDIE: make(map[uint64]dwarf.Offset, len(image.runtimeTypeToDIE)),
+	}
+	cuIndex := make(map[*compileUnit]int, len(image.compileUnits))
+	for i, cu := range image.compileUnits {
+		c.CompileUnits[i] = cu.offset
+		cuIndex[cu] = i
+		if cu.lineInfo == nil {
+			continue
+		}
+		// The instructions of a line table are a subslice of debugLineBytes.
+		off := cap(debugLineBytes) - cap(cu.lineInfo.Instructions)
+		if off < 0 || off+len(cu.lineInfo.Instructions) > len(debugLineBytes) {
+			return
+		}
+		c.LineTables[i] = dwarfCacheLineTable{Header: cu.lineInfo.Header(), InstructionsOff: off, InstructionsLen: len(cu.lineInfo.Instructions)}
+	}
+	cu := func(cu *compileUnit) int {
+		if i, ok := cuIndex[cu]; ok {
+			return i
+		}
+		return -1
+	}
+	for i := range bi.Functions {
+		fn := &bi.Functions[i]
+		cfn := &c.Functions[i]
+		*cfn = dwarfCacheFunction{Name: fn.Name, Entry: fn.Entry - base, End: fn.End - base, Offset: fn.offset, CU: cu(fn.cu), Trampoline: fn.trampoline}
+		for _, call := range fn.InlinedCalls {
+			cfn.InlinedCalls = append(cfn.InlinedCalls, dwarfCacheInlinedCall{CU: cu(call.cu), LowPC: call.LowPC - base, HighPC: call.HighPC - base})
+		}
+	}
+	for i, v := range bi.packageVars {
+		c.PackageVars[i] = dwarfCachePackageVar{Name: v.name, CU: cu(v.cu), Offset: v.offset, Addr: v.addr - base}
+	}
+	for name, ref := range bi.types {
+		c.Types[name] = ref.offset
+	}
+	for ref, ct := range bi.consts {
+		values := make([]dwarfCacheConst, len(ct.values))
+		for i, v := range ct.values {
+			values[i] = dwarfCacheConst{Name: v.name, Value: v.value}
+		}
+		c.Consts[ref.offset] = values
+	}
+	for off, die := range image.runtimeTypeToDIE {
+		c.RuntimeTypeToDIE[off] = die.offset
+	}
+	for fl, pcs := range bi.inlinedCallLines {
+		l := dwarfCacheInlinedCallLine{File: fl.file, Line: fl.line, PCs: make([]uint64, len(pcs))}
+		for i := range pcs {
+			l.PCs[i] = pcs[i] - base
+		}
+		c.InlinedCallLines = append(c.InlinedCallLines, l)
+	}
+
+	if err := os.MkdirAll(DwarfCacheDir, 0o700); err != nil {
+		bi.logger.Debugf("could not create debug info cache: %v", err)
+		return
+	}
+	// Write to a temporary file first so that concurrent instances of Delve
+	// never read a partially written entry.
+	fh, err := os.CreateTemp(DwarfCacheDir, key+"-*.tmp")
+	if err != nil {
+		bi.logger.Debugf("could not create debug info cache: %v", err)
+		return
+	}
+	err = gob.NewEncoder(fh).Encode(&c)
+	if cerr := fh.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(fh.Name(), dwarfCachePath(key))
+	}
+	if err != nil {
+		os.Remove(fh.Name())
+		bi.logger.Debugf("could not save debug info cache: %v", err)
+		return
+	}
+	pruneDwarfCache()
+}
+
+// pruneDwarfCache removes the least recently used entries of the cache
+// until it contains at most dwarfCacheMaxEntries entries.
+func pruneDwarfCache() {
+	entries, err := os.ReadDir(DwarfCacheDir)
+	if err != nil {
+		return
+	}
+	type cacheEntry struct {
+		path    string
+		modTime time.Time
+	}
+	var cached []cacheEntry
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != dwarfCacheExt {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		cached = append(cached, cacheEntry{filepath.Join(DwarfCacheDir, entry.Name()), fi.ModTime()})
+	}
+	if len(cached) <= dwarfCacheMaxEntries {
+		return
+	}
+	sort.Slice(cached, func(i, j int) bool { return cached[i].modTime.After(cached[j].modTime) })
+	for _, entry := range cached[dwarfCacheMaxEntries:] {
+		os.Remove(entry.path)
+	}
+}
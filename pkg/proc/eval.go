@@ -2,7 +2,9 @@ package proc
 
 import (
 	"bytes"
+	"compress/gzip"
 	"debug/dwarf"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"go/ast"
@@ -10,6 +12,7 @@ import (
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"io"
 	"reflect"
 	"runtime/debug"
 	"sort"
@@ -50,7 +53,8 @@ type EvalScope struct {
 	// injection protocol is handled.
 	callCtx *callContext
 
-	dictAddr uint64 // dictionary address for instantiated generic functions
+	dictAddr uint64                  // dictionary address for instantiated generic functions
+	typeArgs map[string]godwarf.Type // type arguments of instantiated generic functions, indexed by shape name
 
 	enclosingRangeScopes []*EvalScope
 	rangeFrames          []Stackframe
@@ -296,6 +300,78 @@ func (scope *EvalScope) ChanGoroutines(expr string, start, count int) ([]int64,
 	return goids, nil
 }
 
+// ChanBuffer returns the values queued in the buffer of the channel
+// specified by expr, in the order in which they will be received.
+// The returned variable is an array, at most cfg.MaxArrayValues of its
+// elements are loaded.
+func (scope *EvalScope) ChanBuffer(expr string, cfg LoadConfig) (*Variable, error) {
+	t, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	v, err := scope.evalAST(t)
+	if err != nil {
+		return nil, err
+	}
+	if v.Unreadable != nil {
+		return nil, v.Unreadable
+	}
+	chanType, ok := v.RealType.(*godwarf.ChanType)
+	if !ok {
+		return nil, fmt.Errorf("%s (type %s) is not a channel", expr, v.TypeString())
+	}
+	elemType := chanType.ElemType
+
+	sv := v.clone()
+	sv.RealType = resolveTypedef(&(chanType.TypedefType))
+	sv = sv.maybeDereference() // +rtype hchan
+	if sv.Unreadable != nil {
+		return nil, sv.Unreadable
+	}
+
+	var qcount, dataqsiz, recvx int64
+	var buf uint64
+	if sv.Addr != 0 {
+		qcount, err = runtimeInt(sv.structMember("qcount")) // +rtype uint
+		if err != nil {
+			return nil, err
+		}
+		dataqsiz, err = runtimeInt(sv.structMember("dataqsiz")) // +rtype uint
+		if err != nil {
+			return nil, err
+		}
+		recvx, err = runtimeInt(sv.structMember("recvx")) // +rtype uint
+		if err != nil {
+			return nil, err
+		}
+		bufv, err := sv.structMember("buf") // +rtype unsafe.Pointer
+		if err != nil {
+			return nil, err
+		}
+		buf, err = readUintRaw(bufv.mem, bufv.Addr, int64(scope.BinInfo.Arch.PtrSize()))
+		if err != nil {
+			return nil, err
+		}
+		if qcount < 0 || qcount > dataqsiz || (dataqsiz > 0 && (recvx < 0 || recvx >= dataqsiz)) {
+			return nil, fmt.Errorf("inconsistent channel buffer (qcount=%d dataqsiz=%d recvx=%d)", qcount, dataqsiz, recvx)
+		}
+	}
+
+	r := newVariable("", 0, fakeArrayType(uint64(qcount), elemType), scope.BinInfo, sv.mem)
+	r.Len = qcount
+	r.Cap = qcount
+	r.loaded = true
+
+	stride := alignAddr(elemType.Size(), elemType.Align())
+	for i := int64(0); i < qcount && i < int64(cfg.MaxArrayValues); i++ {
+		idx := (recvx + i) % dataqsiz
+		ev := newVariable("", buf+uint64(idx*stride), elemType, scope.BinInfo, sv.mem)
+		ev.loadValueInternal(1, cfg)
+		r.Children = append(r.Children, *ev)
+	}
+	return r, nil
+}
+
 // Locals returns all variables in 'scope' named wantedName, or all of them
 // if wantedName is "".
 // If scope is the scope for a range-over-func closure body it will merge in
@@ -409,7 +485,7 @@ func (scope *EvalScope) simpleLocals(flags localsFlags, wantedName string) ([]*V
 		for _, entry := range varEntries {
 			name, _ := entry.Val(dwarf.AttrName).(string)
 			if name == goDictionaryName {
-				dictVar, err := extractVarInfoFromEntry(scope.target, scope.BinInfo, scope.image(), scope.Regs, scope.Mem, entry.Tree, 0)
+				dictVar, err := extractVarInfoFromEntry(scope.target, scope.BinInfo, scope.image(), scope.Regs, scope.Mem, entry.Tree, 0, nil)
 				if err != nil {
 					logflags.DebuggerLogger().Errorf("could not load %s variable: %v", name, err)
 				} else if dictVar.Unreadable != nil {
@@ -418,6 +494,8 @@ func (scope *EvalScope) simpleLocals(flags localsFlags, wantedName string) ([]*V
 					scope.dictAddr, err = readUintRaw(dictVar.mem, dictVar.Addr, int64(scope.BinInfo.Arch.PtrSize()))
 					if err != nil {
 						logflags.DebuggerLogger().Errorf("could not load %s variable: %v", name, err)
+					} else {
+						scope.typeArgs = shapeTypeArgs(scope.BinInfo, scope.Mem, scope.Fn, scope.dictAddr)
 					}
 				}
 				break
@@ -448,7 +526,7 @@ func (scope *EvalScope) simpleLocals(flags localsFlags, wantedName string) ([]*V
 				continue
 			}
 		}
-		val, err := extractVarInfoFromEntry(scope.target, scope.BinInfo, scope.image(), scope.Regs, scope.Mem, entry.Tree, scope.dictAddr)
+		val, err := extractVarInfoFromEntry(scope.target, scope.BinInfo, scope.image(), scope.Regs, scope.Mem, entry.Tree, scope.dictAddr, scope.typeArgs)
 		if err != nil {
 			// skip variables that we can't parse yet
 			continue
@@ -461,6 +539,9 @@ func (scope *EvalScope) simpleLocals(flags localsFlags, wantedName string) ([]*V
 			addr = uint64(alignAddr(int64(addr), val.DwarfType.Align()))
 			val = newVariable(val.Name, addr, val.DwarfType, scope.BinInfo, scope.Mem)
 		}
+		if entry.Val(godwarf.AttrGoClosureOffset) != nil {
+			val.Flags |= VariableCaptured
+		}
 		vars = append(vars, val)
 		depth := entry.Depth
 		if entry.Tag == dwarf.TagFormalParameter {
@@ -489,12 +570,13 @@ func (scope *EvalScope) simpleLocals(flags localsFlags, wantedName string) ([]*V
 		if name := v.Name; len(name) > 1 && name[0] == '&' {
 			locationExpr := v.LocationExpr
 			declLine := v.DeclLine
+			captured := v.Flags & VariableCaptured
 			v = v.maybeDereference()
 			if v.Addr == 0 && v.Unreadable == nil {
 				v.Unreadable = errors.New("no address for escaped variable")
 			}
 			v.Name = name[1:]
-			v.Flags |= VariableEscaped
+			v.Flags |= VariableEscaped | captured
 			// See https://github.com/go-delve/delve/issues/2049 for details
 			if locationExpr != nil {
 				locationExpr.isEscaped = true
@@ -509,9 +591,41 @@ func (scope *EvalScope) simpleLocals(flags localsFlags, wantedName string) ([]*V
 		lvn[v.Name] = v
 	}
 
+	prefetchVariables(scope.Mem, vars)
+
 	return vars, nil
 }
 
+// prefetchVariables arranges for the memory of the variables in vars that
+// is not already cached to be read with as few operations as possible,
+// the first time one of them is loaded.
+func prefetchVariables(mem MemoryReadWriter, vars []*Variable) {
+	framemem, _ := mem.(*memCache)
+	var ranges [][2]uint64
+	var prefetched []*Variable
+	for _, v := range vars {
+		if v.mem != mem || v.Addr == 0 || v.Unreadable != nil || v.RealType == nil || v.Flags&VariableFakeAddress != 0 {
+			continue
+		}
+		sz := uint64(v.RealType.Size())
+		if sz == 0 || sz > maxFramePrefetchSize || v.Addr+sz < v.Addr {
+			continue
+		}
+		if framemem != nil && framemem.contains(v.Addr, int(sz)) {
+			continue
+		}
+		ranges = append(ranges, [2]uint64{v.Addr, v.Addr + sz})
+		prefetched = append(prefetched, v)
+	}
+	if len(ranges) < 2 {
+		return
+	}
+	cmem := coalesceMemory(mem, ranges, ReadCoalesceGap)
+	for _, v := range prefetched {
+		v.mem = cmem
+	}
+}
+
 func afterLastArgAddr(vars []*Variable) uint64 {
 	for i := len(vars) - 1; i >= 0; i-- {
 		v := vars[i]
@@ -539,6 +653,10 @@ func (scope *EvalScope) setValue(dstv, srcv *Variable, srcExpr string) error {
 
 	typerr := srcv.isType(dstv.RealType, dstv.Kind)
 	if _, isTypeConvErr := typerr.(*typeConvErr); isTypeConvErr {
+		if dstv.Kind == reflect.Interface && dstv.RealType.String() != "interface {}" {
+			// attempt ptr-shaped -> iface conversions.
+			return scope.convertToIface(srcv, dstv)
+		}
 		// attempt iface -> eface and ptr-shaped -> eface conversions.
 		return convertToEface(srcv, dstv)
 	}
@@ -551,6 +669,10 @@ func (scope *EvalScope) setValue(dstv, srcv *Variable, srcExpr string) error {
 		return fmt.Errorf("Expression %q is unreadable: %v", srcExpr, srcv.Unreadable)
 	}
 
+	if dstv.Flags&VariableCPURegister != 0 {
+		return scope.setRegister(dstv, srcv)
+	}
+
 	// Numerical types
 	switch dstv.Kind {
 	case reflect.Float32, reflect.Float64:
@@ -608,6 +730,127 @@ func (scope *EvalScope) setValue(dstv, srcv *Variable, srcExpr string) error {
 	return fmt.Errorf("can not set variables of type %s (not implemented)", dstv.Kind.String())
 }
 
+// convertToIface converts srcv, which must have a pointer shaped type, to
+// the non-empty interface type of dstv and writes the result to dstv.
+// Since we can not create new itabs the target process must already
+// contain an itab for this conversion.
+func (scope *EvalScope) convertToIface(srcv, dstv *Variable) error {
+	typeAddr, typeKind, runtimeTypeFound, err := dwarfToRuntimeType(srcv.bi, dstv.mem, srcv.RealType)
+	if err != nil {
+		return err
+	}
+	if !runtimeTypeFound || typeKind&kindDirectIface == 0 {
+		return &typeConvErr{srcv.DwarfType, dstv.RealType}
+	}
+	interAddr, _, runtimeTypeFound, err := dwarfToRuntimeType(dstv.bi, dstv.mem, dstv.RealType)
+	if err != nil {
+		return err
+	}
+	if !runtimeTypeFound {
+		return &typeConvErr{srcv.DwarfType, dstv.RealType}
+	}
+	itabAddr, err := scope.findItab(interAddr, typeAddr)
+	if err != nil {
+		return err
+	}
+	if itabAddr == 0 {
+		return fmt.Errorf("can not convert %s to %s: no itab found in the target process", srcv.TypeString(), dstv.TypeString())
+	}
+
+	_, data, _ := dstv.readInterface()
+	if dstv.Unreadable != nil {
+		return dstv.Unreadable
+	}
+	for _, f := range resolveTypedef(&dstv.RealType.(*godwarf.InterfaceType).TypedefType).(*godwarf.StructType).Field {
+		if f.Name == "tab" {
+			tab, _ := dstv.toField(f)
+			if err := tab.writeUint(itabAddr, tab.RealType.Size()); err != nil {
+				return err
+			}
+		}
+	}
+	return data.writeCopy(srcv)
+}
+
+// findItab returns the address of the itab for interface type interAddr
+// and concrete type typeAddr, or 0 if it doesn't exist.
+// All itabs, including the ones created by the linker, are added to
+// runtime.itabTable when the program starts.
+func (scope *EvalScope) findItab(interAddr, typeAddr uint64) (uint64, error) {
+	gscope := globalScope(scope.target, scope.BinInfo, scope.BinInfo.Images[0], scope.Mem)
+	// +rtype -var itabTable *itabTableType
+	tablev, err := gscope.findGlobal("runtime", "itabTable")
+	if err != nil {
+		return 0, err
+	}
+	tablev = tablev.maybeDereference()
+	if tablev.Unreadable != nil {
+		return 0, tablev.Unreadable
+	}
+	// +rtype -field itabTableType.size uintptr
+	size, err := runtimeInt(tablev.structMember("size"))
+	if err != nil {
+		return 0, err
+	}
+	entriesv, err := tablev.structMember("entries")
+	if err != nil {
+		return 0, err
+	}
+	ptrSize := int64(scope.BinInfo.Arch.PtrSize())
+	for i := int64(0); i < size; i++ {
+		itabAddr, err := readUintRaw(tablev.mem, entriesv.Addr+uint64(i*ptrSize), ptrSize)
+		if err != nil {
+			return 0, err
+		}
+		if itabAddr == 0 {
+			continue
+		}
+		// The first two fields of an itab are the interface type and the
+		// concrete type.
+		inter, err := readUintRaw(tablev.mem, itabAddr, ptrSize)
+		if err != nil {
+			return 0, err
+		}
+		typ, err := readUintRaw(tablev.mem, itabAddr+uint64(ptrSize), ptrSize)
+		if err != nil {
+			return 0, err
+		}
+		if inter == interAddr && typ == typeAddr {
+			return itabAddr, nil
+		}
+	}
+	return 0, nil
+}
+
+// setRegister sets the CPU register dstv to the value of srcv. Registers
+// can only be changed in the topmost frame of a goroutine running on a
+// thread.
+func (scope *EvalScope) setRegister(dstv, srcv *Variable) error {
+	if dstv.Kind == reflect.String {
+		return fmt.Errorf("can not set register %s (not implemented)", dstv.Name)
+	}
+	regnum, ok := scope.BinInfo.Arch.RegisterNameToDwarf(dstv.Name)
+	if !ok {
+		return fmt.Errorf("unknown register %s", dstv.Name)
+	}
+	thread, ok := scope.target.FindThread(scope.threadID)
+	if scope.threadID == 0 || !ok {
+		return fmt.Errorf("can not set register %s: goroutine is not running on a thread", dstv.Name)
+	}
+	regs, err := thread.Registers()
+	if err != nil {
+		return err
+	}
+	if regs.PC() != scope.Regs.PC() || regs.SP() != scope.Regs.SP() {
+		return fmt.Errorf("can not set register %s: registers can only be set in the topmost frame", dstv.Name)
+	}
+	n, _ := constant.Uint64Val(srcv.Value)
+	err = thread.SetReg(uint64(regnum), op.DwarfRegisterFromUint64(n))
+	// changing a register can change the goroutine running on the thread
+	scope.target.ClearCaches()
+	return err
+}
+
 // SetVariable sets the value of the named variable
 func (scope *EvalScope) SetVariable(name, value string) error {
 	ops, err := evalop.CompileSet(scopeToEvalLookup{scope}, name, value)
@@ -666,15 +909,26 @@ func regsReplaceStaticBase(regs op.DwarfRegisters, image *Image) op.DwarfRegiste
 
 // PackageVariables returns the name, value, and type of all package variables in the application.
 func (scope *EvalScope) PackageVariables(cfg LoadConfig) ([]*Variable, error) {
-	pkgvars := make([]packageVar, len(scope.BinInfo.packageVars))
-	copy(pkgvars, scope.BinInfo.packageVars)
+	return scope.PackageVariablesMatching(nil, cfg)
+}
+
+// PackageVariablesMatching returns the package variables whose fully
+// qualified name is accepted by filter, all package variables if filter is
+// nil. The values of the other variables are not loaded.
+func (scope *EvalScope) PackageVariablesMatching(filter func(name string) bool, cfg LoadConfig) ([]*Variable, error) {
+	pkgvars := make([]packageVar, 0, len(scope.BinInfo.packageVars))
+	for _, pkgvar := range scope.BinInfo.packageVars {
+		if filter == nil || filter(pkgvar.name) {
+			pkgvars = append(pkgvars, pkgvar)
+		}
+	}
 	sort.Slice(pkgvars, func(i, j int) bool {
 		if pkgvars[i].cu.image.addr == pkgvars[j].cu.image.addr {
 			return pkgvars[i].offset < pkgvars[j].offset
 		}
 		return pkgvars[i].cu.image.addr < pkgvars[j].cu.image.addr
 	})
-	vars := make([]*Variable, 0, len(scope.BinInfo.packageVars))
+	vars := make([]*Variable, 0, len(pkgvars))
 	for _, pkgvar := range pkgvars {
 		reader := pkgvar.cu.image.dwarfReader
 		reader.Seek(pkgvar.offset)
@@ -684,7 +938,7 @@ func (scope *EvalScope) PackageVariables(cfg LoadConfig) ([]*Variable, error) {
 		}
 
 		// Ignore errors trying to extract values
-		val, err := extractVarInfoFromEntry(scope.target, scope.BinInfo, pkgvar.cu.image, regsReplaceStaticBase(scope.Regs, pkgvar.cu.image), scope.Mem, godwarf.EntryToTree(entry), 0)
+		val, err := extractVarInfoFromEntry(scope.target, scope.BinInfo, pkgvar.cu.image, regsReplaceStaticBase(scope.Regs, pkgvar.cu.image), scope.Mem, godwarf.EntryToTree(entry), 0, nil)
 		if val != nil && val.Kind == reflect.Invalid {
 			continue
 		}
@@ -734,7 +988,7 @@ func (scope *EvalScope) findGlobalInternal(name string) (*Variable, error) {
 			if err != nil {
 				return nil, err
 			}
-			return extractVarInfoFromEntry(scope.target, scope.BinInfo, pkgvar.cu.image, regsReplaceStaticBase(scope.Regs, pkgvar.cu.image), scope.Mem, godwarf.EntryToTree(entry), 0)
+			return extractVarInfoFromEntry(scope.target, scope.BinInfo, pkgvar.cu.image, regsReplaceStaticBase(scope.Regs, pkgvar.cu.image), scope.Mem, godwarf.EntryToTree(entry), 0, nil)
 		}
 	}
 	for _, fn := range scope.BinInfo.Functions {
@@ -1030,6 +1284,15 @@ func (stack *evalStack) executeOp() {
 				stack.err = fmt.Errorf("%q (type string) is not a struct", op.Name)
 				return
 			}
+			if op.Name == "C" {
+				// C.T where T is a C type evaluates to the zero value of T, so
+				// that its definition can be inspected with whatis and print.
+				if typ, err := scope.BinInfo.findType("C." + op.Sel); err == nil {
+					mem := &memCache{loaded: true, cacheAddr: fakeAddressUnresolv, cache: make([]byte, typ.Size()), mem: scope.Mem}
+					stack.push(newVariable("C."+op.Sel, fakeAddressUnresolv, typ, scope.BinInfo, mem))
+					break
+				}
+			}
 			found := stack.pushIdent(scope, op.Name)
 			if stack.err != nil {
 				return
@@ -1293,6 +1556,18 @@ func (scope *EvalScope) evalTypeCast(op *evalop.TypeCast, stack *evalStack) {
 
 	converr := fmt.Errorf("can not convert %q to %s", exprToString(op.Node.Args[0]), typ.String())
 
+	if argv == nilVariable {
+		// typed nil, for example (*T)(nil)
+		switch typ.(type) {
+		case *godwarf.PtrType, *godwarf.SliceType, *godwarf.MapType, *godwarf.ChanType, *godwarf.FuncType, *godwarf.InterfaceType:
+			v := newVariable("", fakeAddressUnresolv, op.DwarfType, scope.BinInfo, CreateLoadedCachedMemory(make([]byte, typ.Size())))
+			stack.push(v)
+		default:
+			stack.err = converr
+		}
+		return
+	}
+
 	// compatible underlying types
 	if typeCastCompatibleTypes(argv.RealType, typ) {
 		if ptyp, isptr := typ.(*godwarf.PtrType); argv.Kind == reflect.Ptr && argv.loaded && len(argv.Children) > 0 && isptr {
@@ -1418,7 +1693,7 @@ func (scope *EvalScope) evalTypeCast(op *evalop.TypeCast, stack *evalStack) {
 			fallthrough
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 			fallthrough
-		case reflect.Float32, reflect.Float64:
+		case reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
 			v.Value = argv.Value
 			stack.push(v)
 			return
@@ -1650,6 +1925,11 @@ var supportedBuiltins = map[string]func([]*Variable, []ast.Expr) (*Variable, err
 	"real":    realBuiltin,
 	"min":     minBuiltin,
 	"max":     maxBuiltin,
+	"closed":  closedBuiltin,
+	"peek":    peekBuiltin,
+
+	"base64decode": base64decodeBuiltin,
+	"gunzip":       gunzipBuiltin,
 }
 
 func capBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
@@ -1727,6 +2007,87 @@ func lenBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
 	}
 }
 
+// closedBuiltin returns true if the channel passed as argument has been
+// closed, the nil channel is never closed.
+func closedBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("wrong number of arguments to closed: %d", len(args))
+	}
+	arg := args[0]
+	if arg.Kind != reflect.Chan {
+		return nil, fmt.Errorf("invalid argument %s (type %s) for closed", exprToString(nodeargs[0]), arg.TypeString())
+	}
+	hchanv := chanStruct(arg) // +rtype hchan
+	if hchanv.Unreadable != nil {
+		return nil, hchanv.Unreadable
+	}
+	if hchanv.Addr == 0 {
+		return newConstant(constant.MakeBool(false), arg.mem), nil
+	}
+	closed, err := runtimeInt(hchanv.structMember("closed")) // +rtype uint32
+	if err != nil {
+		return nil, err
+	}
+	return newConstant(constant.MakeBool(closed != 0), arg.mem), nil
+}
+
+// peekBuiltin returns the next element that will be received from the
+// buffered channel passed as argument, without receiving it.
+func peekBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("wrong number of arguments to peek: %d", len(args))
+	}
+	arg := args[0]
+	if arg.Kind != reflect.Chan {
+		return nil, fmt.Errorf("invalid argument %s (type %s) for peek", exprToString(nodeargs[0]), arg.TypeString())
+	}
+	hchanv := chanStruct(arg) // +rtype hchan
+	if hchanv.Unreadable != nil {
+		return nil, hchanv.Unreadable
+	}
+	if hchanv.Addr == 0 {
+		return nil, fmt.Errorf("can not peek %s: nil channel", exprToString(nodeargs[0]))
+	}
+	qcount, err := runtimeInt(hchanv.structMember("qcount")) // +rtype uint
+	if err != nil {
+		return nil, err
+	}
+	dataqsiz, err := runtimeInt(hchanv.structMember("dataqsiz")) // +rtype uint
+	if err != nil {
+		return nil, err
+	}
+	recvx, err := runtimeInt(hchanv.structMember("recvx")) // +rtype uint
+	if err != nil {
+		return nil, err
+	}
+	if dataqsiz == 0 {
+		return nil, fmt.Errorf("can not peek %s: unbuffered channel", exprToString(nodeargs[0]))
+	}
+	if qcount == 0 {
+		return nil, fmt.Errorf("can not peek %s: channel buffer is empty", exprToString(nodeargs[0]))
+	}
+	if recvx < 0 || recvx >= dataqsiz {
+		return nil, fmt.Errorf("can not peek %s: bad receive index %d", exprToString(nodeargs[0]), recvx)
+	}
+	bufv, err := hchanv.structMember("buf") // +rtype unsafe.Pointer
+	if err != nil {
+		return nil, err
+	}
+	buf, err := readUintRaw(bufv.mem, bufv.Addr, int64(arg.bi.Arch.PtrSize()))
+	if err != nil {
+		return nil, err
+	}
+	elemType := arg.RealType.(*godwarf.ChanType).ElemType
+	return arg.newVariable("", buf+uint64(recvx*elemType.Size()), elemType, arg.mem), nil
+}
+
+// chanStruct returns the runtime.hchan struct of the channel variable v.
+func chanStruct(v *Variable) *Variable {
+	sv := v.clone()
+	sv.RealType = resolveTypedef(&(sv.RealType.(*godwarf.ChanType).TypedefType))
+	return sv.maybeDereference()
+}
+
 func complexBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
 	if len(args) != 2 {
 		return nil, fmt.Errorf("wrong number of arguments to complex: %d", len(args))
@@ -1754,15 +2115,17 @@ func complexBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
 		return nil, fmt.Errorf("invalid argument 2 %s (type %s) to complex", exprToString(nodeargs[1]), imagev.TypeString())
 	}
 
+	// the size of the result is twice the size of its arguments, in bits
 	sz := int64(0)
 	if realev.RealType != nil {
-		sz = realev.RealType.(*godwarf.FloatType).Size()
+		sz = realev.RealType.Size() * 16
 	}
 	if imagev.RealType != nil {
-		isz := imagev.RealType.(*godwarf.FloatType).Size()
-		if isz > sz {
-			sz = isz
+		isz := imagev.RealType.Size() * 16
+		if sz != 0 && isz != sz {
+			return nil, fmt.Errorf("invalid operation: complex(%s, %s) (mismatched types %s and %s)", exprToString(nodeargs[0]), exprToString(nodeargs[1]), realev.TypeString(), imagev.TypeString())
 		}
+		sz = isz
 	}
 
 	if sz == 0 {
@@ -1792,7 +2155,7 @@ func imagBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
 		return nil, fmt.Errorf("invalid argument %s (type %s) to imag", exprToString(nodeargs[0]), arg.TypeString())
 	}
 
-	return newConstant(constant.Imag(arg.Value), arg.mem), nil
+	return complexPart(arg, constant.Imag(arg.Value)), nil
 }
 
 func realBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
@@ -1811,7 +2174,21 @@ func realBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
 		return nil, fmt.Errorf("invalid argument %s (type %s) to real", exprToString(nodeargs[0]), arg.TypeString())
 	}
 
-	return newConstant(constant.Real(arg.Value), arg.mem), nil
+	return complexPart(arg, constant.Real(arg.Value)), nil
+}
+
+// complexPart returns a variable containing val, the real or imaginary
+// part of arg. If arg has a complex type the result will have the
+// corresponding floating point type, otherwise it will be an untyped
+// constant.
+func complexPart(arg *Variable, val constant.Value) *Variable {
+	ctyp, ok := arg.RealType.(*godwarf.ComplexType)
+	if !ok {
+		return newConstant(val, arg.mem)
+	}
+	r := arg.newVariable("", 0, godwarf.FakeBasicType("float", int(ctyp.Size()*4)), nil)
+	r.Value = val
+	return r
 }
 
 func minBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
@@ -1865,6 +2242,93 @@ func minmaxBuiltin(name string, op token.Token, args []*Variable, nodeargs []ast
 	return best, nil
 }
 
+// maxDecodeLen is the maximum size of the input and of the output of the
+// base64decode and gunzip builtins.
+const maxDecodeLen = 16 * 1024 * 1024
+
+// decodeBuiltinArg returns the contents of the argument of a decoding
+// builtin, which must be a string or a slice or array of bytes.
+// The contents are read in their entirety, regardless of load limits.
+func decodeBuiltinArg(name string, args []*Variable, nodeargs []ast.Expr) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("wrong number of arguments to %s: %d", name, len(args))
+	}
+	arg := args[0]
+	if arg.Unreadable != nil {
+		return nil, arg.Unreadable
+	}
+	invalidArgErr := fmt.Errorf("invalid argument %s (type %s) for %s", exprToString(nodeargs[0]), arg.TypeString(), name)
+
+	switch arg.Kind {
+	case reflect.String:
+		if arg.Value != nil && int64(len(constant.StringVal(arg.Value))) == arg.Len {
+			// already fully loaded, for example a constant or the result of
+			// another builtin
+			return []byte(constant.StringVal(arg.Value)), nil
+		}
+	case reflect.Slice, reflect.Array:
+		if elem, _ := resolveTypedef(arg.fieldType).(*godwarf.UintType); elem == nil || elem.Size() != 1 {
+			return nil, invalidArgErr
+		}
+		if arg.loaded && int64(len(arg.Children)) == arg.Len {
+			buf := make([]byte, len(arg.Children))
+			for i := range arg.Children {
+				n, _ := constant.Int64Val(arg.Children[i].Value)
+				buf[i] = byte(n)
+			}
+			return buf, nil
+		}
+	default:
+		return nil, invalidArgErr
+	}
+	if arg.Len > maxDecodeLen {
+		return nil, fmt.Errorf("argument of %s too long: %d bytes", name, arg.Len)
+	}
+	buf := make([]byte, arg.Len)
+	if _, err := arg.mem.ReadMemory(buf, arg.Base); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func base64decodeBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+	buf, err := decodeBuiltinArg("base64decode", args, nodeargs)
+	if err != nil {
+		return nil, err
+	}
+	// Accept both the standard and the URL alphabets, with or without
+	// padding.
+	s := strings.TrimRight(strings.TrimSpace(string(buf)), "=")
+	enc := base64.RawStdEncoding
+	if strings.ContainsAny(s, "-_") {
+		enc = base64.RawURLEncoding
+	}
+	r, err := enc.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("base64decode: %v", err)
+	}
+	return newConstant(constant.MakeString(string(r)), args[0].mem), nil
+}
+
+func gunzipBuiltin(args []*Variable, nodeargs []ast.Expr) (*Variable, error) {
+	buf, err := decodeBuiltinArg("gunzip", args, nodeargs)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(buf))
+	if err != nil {
+		return nil, fmt.Errorf("gunzip: %v", err)
+	}
+	r, err := io.ReadAll(io.LimitReader(zr, maxDecodeLen+1))
+	if err != nil {
+		return nil, fmt.Errorf("gunzip: %v", err)
+	}
+	if len(r) > maxDecodeLen {
+		return nil, fmt.Errorf("gunzip: decompressed data larger than %d bytes", maxDecodeLen)
+	}
+	return newConstant(constant.MakeString(string(r)), args[0].mem), nil
+}
+
 // Evaluates expressions <subexpr>.<field name> where subexpr is not a package name
 func (scope *EvalScope) evalStructSelector(op *evalop.Select, stack *evalStack) {
 	xv := stack.pop()
@@ -1979,7 +2443,11 @@ func (scope *EvalScope) evalIndex(op *evalop.Index, stack *evalStack) {
 			stack.err = idxev.Unreadable
 			return
 		}
-		stack.pushErr(xev.mapAccess(idxev))
+		v, err := xev.mapAccess(idxev)
+		if err == errMapKeyNotFound && op.Assign {
+			err = fmt.Errorf("can not assign to %s: key not found (adding new keys to a map is not supported)", exprToString(op.Node))
+		}
+		stack.pushErr(v, err)
 		return
 	default:
 		stack.err = cantindex
@@ -2492,6 +2960,11 @@ func (err *typeConvErr) Error() string {
 	return fmt.Sprintf("can not convert value of type %s to %s", err.srcType.String(), err.dstType.String())
 }
 
+// isType returns an error if v can not be used as a value of type typ.
+// If v is an untyped constant it must be representable as a value of type
+// typ, following the rules of the Go specification, and its value is
+// converted to the corresponding constant kind (for example the untyped
+// constant 2.0 becomes the integer 2 when typ is an integer type).
 func (v *Variable) isType(typ godwarf.Type, kind reflect.Kind) error {
 	if v.DwarfType != nil {
 		if typ == nil || !sameType(typ, v.RealType) {
@@ -2520,17 +2993,19 @@ func (v *Variable) isType(typ godwarf.Type, kind reflect.Kind) error {
 	}
 
 	switch typ.(type) {
-	case *godwarf.IntType:
-		if v.Value.Kind() != constant.Int {
-			return converr
-		}
-	case *godwarf.UintType:
-		if v.Value.Kind() != constant.Int {
-			return converr
+	case *godwarf.IntType, *godwarf.UintType:
+		n, err := untypedToInt(v.Value, typ, converr)
+		if err != nil {
+			return err
 		}
+		v.Value = n
 	case *godwarf.FloatType:
 		if (v.Value.Kind() != constant.Int) && (v.Value.Kind() != constant.Float) {
-			return converr
+			x := constant.ToFloat(v.Value)
+			if x.Kind() != constant.Float {
+				return converr
+			}
+			v.Value = x
 		}
 	case *godwarf.BoolType:
 		if v.Value.Kind() != constant.Bool {
@@ -2551,6 +3026,31 @@ func (v *Variable) isType(typ godwarf.Type, kind reflect.Kind) error {
 	return nil
 }
 
+// untypedToInt converts the untyped constant x to an integer constant
+// representable by the integer type typ.
+func untypedToInt(x constant.Value, typ godwarf.Type, converr error) (constant.Value, error) {
+	n := constant.ToInt(x)
+	if n.Kind() != constant.Int {
+		if x.Kind() == constant.Float || x.Kind() == constant.Complex {
+			return nil, fmt.Errorf("%v: truncated to integer", converr)
+		}
+		return nil, converr
+	}
+	bits := uint(typ.Size() * 8)
+	var min, max constant.Value
+	if _, isuint := typ.(*godwarf.UintType); isuint {
+		min = constant.MakeInt64(0)
+		max = constant.BinaryOp(constant.Shift(constant.MakeInt64(1), token.SHL, bits), token.SUB, constant.MakeInt64(1))
+	} else {
+		min = constant.UnaryOp(token.SUB, constant.Shift(constant.MakeInt64(1), token.SHL, bits-1), 0)
+		max = constant.BinaryOp(constant.Shift(constant.MakeInt64(1), token.SHL, bits-1), token.SUB, constant.MakeInt64(1))
+	}
+	if constant.Compare(n, token.LSS, min) || constant.Compare(n, token.GTR, max) {
+		return nil, fmt.Errorf("constant %s overflows %s", x, typ.String())
+	}
+	return n, nil
+}
+
 func sameType(t1, t2 godwarf.Type) bool {
 	// Because of a bug in the go linker a type that refers to another type
 	// (for example a pointer type) will usually use the typedef but rarely use
@@ -2629,7 +3129,7 @@ func (v *Variable) mapAccess(idx *Variable) (*Variable, error) {
 
 	first := true
 	for it.next() {
-		key := it.key()
+		key := derefMapEntry(it.key(), it.indirectKeys)
 		key.loadValue(lcfg)
 		if key.Unreadable != nil {
 			return nil, fmt.Errorf("can not access unreadable map: %v", key.Unreadable)
@@ -2645,16 +3145,21 @@ func (v *Variable) mapAccess(idx *Variable) (*Variable, error) {
 			return nil, err
 		}
 		if eql {
-			return it.value(), nil
+			if it.values.fieldType.Size() == 0 {
+				return v.newVariable("", it.values.Addr, it.values.fieldType, DereferenceMemory(v.mem)), nil
+			}
+			return derefMapEntry(it.value(), it.indirectValues), nil
 		}
 	}
 	if v.Unreadable != nil {
 		return nil, v.Unreadable
 	}
 	// go would return zero for the map value type here, we do not have the ability to create zeroes
-	return nil, errors.New("key not found")
+	return nil, errMapKeyNotFound
 }
 
+var errMapKeyNotFound = errors.New("key not found")
+
 // LoadResliced returns a new array, slice or map that starts at index start and contains
 // up to cfg.MaxArrayValues children.
 func (v *Variable) LoadResliced(start int, cfg LoadConfig) (newV *Variable, err error) {
@@ -2922,7 +3427,7 @@ func validRegisterName(s string) string {
 		s = s[1:]
 	}
 	for i := range s {
-		if (s[i] < '0' || s[i] > '9') && (s[i] < 'A' || s[i] > 'Z') {
+		if (s[i] < '0' || s[i] > '9') && (s[i] < 'A' || s[i] > 'Z') && s[i] != '_' {
 			return ""
 		}
 	}
@@ -98,6 +98,9 @@ func CompileSet(lookup evalLookup, lhexpr, rhexpr string) ([]Op, error) {
 	if err != nil {
 		return nil, err
 	}
+	if idx, ok := ctx.ops[len(ctx.ops)-1].(*Index); ok {
+		idx.Assign = true
+	}
 
 	ctx.pushOp(&SetValue{lhe: lhe, Rhe: rhe})
 
@@ -258,7 +261,7 @@ func (ctx *compileCtx) compileAST(t ast.Expr) error {
 		return ctx.compileTypeAssert(node)
 
 	case *ast.IndexExpr:
-		return ctx.compileBinary(node.X, node.Index, nil, &Index{node})
+		return ctx.compileBinary(node.X, node.Index, nil, &Index{Node: node})
 
 	case *ast.SliceExpr:
 		if node.Slice3 {
@@ -140,8 +140,10 @@ func (op *Reslice) depthCheck() (npop, npush int) {
 }
 
 // Index pops two variables, idx and v, and pushes v[idx].
+// If Assign is set v[idx] is the destination of an assignment.
 type Index struct {
-	Node *ast.IndexExpr
+	Node   *ast.IndexExpr
+	Assign bool
 }
 
 func (*Index) depthCheck() (npop, npush int) { return 2, 1 }
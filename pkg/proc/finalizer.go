@@ -0,0 +1,213 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"go/constant"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+)
+
+// Finalizer describes the finalizer associated with a heap object.
+type Finalizer struct {
+	Addr    uint64    // address the finalizer was set on
+	Funcval uint64    // address of the runtime.funcval of the finalizer function
+	PC      uint64    // entry point of the finalizer function
+	Fn      *Function // finalizer function, nil if it could not be determined
+	Queued  bool      // the object is unreachable and the finalizer is queued to be executed
+}
+
+// maxFinqBlocks is the maximum number of blocks of the finalizer queue
+// that will be read by FindFinalizer.
+const maxFinqBlocks = 10000
+
+// FindFinalizer returns the finalizer associated with the heap object
+// containing addr, or nil if the object does not have a finalizer.
+// Finalizers of live objects are stored as special records of the span
+// containing the object, once the object becomes unreachable the record is
+// removed and the finalizer is moved to the queue of finalizers to be
+// executed, runtime.finq.
+func FindFinalizer(t *Target, addr uint64) (*Finalizer, error) {
+	if _, err := t.Valid(); err != nil {
+		return nil, err
+	}
+	bi := t.BinInfo()
+	mem := t.Memory()
+	span, err := findSpan(t, addr)
+	if err != nil {
+		return nil, err
+	}
+	idx := span.objIndex(addr)
+	if idx >= span.nelems {
+		return nil, fmt.Errorf("%#x is in the unused tail of a span", addr)
+	}
+	base := span.startAddr + idx*span.elemsize
+
+	fr, err := newFinalizerReader(t)
+	if err != nil {
+		return nil, err
+	}
+	var r *Finalizer
+	err = fr.specials(span.specials, func(offset, fn uint64) {
+		if r == nil && span.objIndex(span.startAddr+offset) == idx {
+			r = &Finalizer{Addr: span.startAddr + offset, Funcval: fn}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		r, err = fr.queued(base, base+span.elemsize)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r == nil {
+		return nil, nil
+	}
+	if r.Funcval != 0 {
+		r.PC, err = readUintRaw(mem, r.Funcval, int64(bi.Arch.PtrSize()))
+		if err != nil {
+			return nil, err
+		}
+		r.Fn = bi.PCToFunc(r.PC)
+	}
+	return r, nil
+}
+
+// finalizerReader reads the finalizers stored in the special records of
+// spans and in the finalizer queue.
+type finalizerReader struct {
+	t                   *Target
+	specialtyp          godwarf.Type
+	specialfinalizertyp godwarf.Type
+	finalizerKind       int64
+}
+
+func newFinalizerReader(t *Target) (*finalizerReader, error) {
+	bi := t.BinInfo()
+	fr := &finalizerReader{t: t, finalizerKind: 1}
+	var err error
+	fr.specialtyp, err = bi.findType("runtime.special")
+	if err != nil {
+		return nil, err
+	}
+	fr.specialfinalizertyp, err = bi.findType("runtime.specialfinalizer")
+	if err != nil {
+		return nil, err
+	}
+	// The value of _KindSpecialFinalizer changed in recent versions of Go,
+	// read it from the debug info when possible.
+	scope := globalScope(t, bi, bi.Images[0], t.Memory())
+	if kindv, err := scope.findGlobal("runtime", "_KindSpecialFinalizer"); err == nil && kindv.Value != nil {
+		fr.finalizerKind, _ = constant.Int64Val(kindv.Value)
+	}
+	return fr, nil
+}
+
+// specials calls fn for every finalizer in the list of special records
+// starting at addr, with the offset of the object from the start of its
+// span and the address of the funcval of the finalizer.
+func (fr *finalizerReader) specials(addr uint64, fn func(offset, funcval uint64)) error {
+	bi := fr.t.BinInfo()
+	mem := fr.t.Memory()
+	ptrSize := int64(bi.Arch.PtrSize())
+	seen := make(map[uint64]bool)
+	for addr != 0 && !seen[addr] {
+		seen[addr] = true
+		specialv := newVariable("", addr, fr.specialtyp, bi, mem) // +rtype special
+		kind, err := runtimeInt(specialv.structMember("kind"))    // +rtype byte
+		if err != nil {
+			return err
+		}
+		if kind == fr.finalizerKind {
+			offset, err := runtimeInt(specialv.structMember("offset")) // +rtype uintptr|uint16
+			if err != nil {
+				return err
+			}
+			finalizerv := newVariable("", addr, fr.specialfinalizertyp, bi, mem) // +rtype specialfinalizer
+			fnv, err := finalizerv.structMember("fn")                            // +rtype *funcval
+			if err != nil {
+				return err
+			}
+			funcval, err := readUintRaw(mem, fnv.Addr, ptrSize)
+			if err != nil {
+				return err
+			}
+			fn(uint64(offset), funcval)
+		}
+		nextv, err := specialv.structMember("next") // +rtype *special
+		if err != nil {
+			return err
+		}
+		addr, err = readUintRaw(mem, nextv.Addr, ptrSize)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queued returns the first finalizer in the finalizer queue whose argument
+// is in the [start, end) range, or nil if there isn't one.
+func (fr *finalizerReader) queued(start, end uint64) (*Finalizer, error) {
+	bi := fr.t.BinInfo()
+	mem := fr.t.Memory()
+	ptrSize := int64(bi.Arch.PtrSize())
+	scope := globalScope(fr.t, bi, bi.Images[0], mem)
+
+	// +rtype -var finq *finblock|*finBlock
+	finqv, err := scope.findGlobal("runtime", "finq")
+	if err != nil {
+		return nil, err
+	}
+	blockv := finqv.maybeDereference() // +rtype finblock|finBlock
+	for i := 0; blockv.Addr != 0 && i < maxFinqBlocks; i++ {
+		if blockv.Unreadable != nil {
+			return nil, blockv.Unreadable
+		}
+		cnt, err := runtimeInt(blockv.structMember("cnt")) // +rtype uint32
+		if err != nil {
+			return nil, err
+		}
+		finv, err := blockv.structMember("fin") // +rtype anytype
+		if err != nil {
+			return nil, err
+		}
+		fintyp, _ := resolveTypedef(finv.RealType).(*godwarf.ArrayType)
+		if fintyp == nil {
+			return nil, errors.New("unexpected type for runtime.finblock.fin")
+		}
+		for j := int64(0); j < cnt && j < fintyp.Count; j++ {
+			// +rtype -field finalizer.fn *funcval
+			// +rtype -field finalizer.arg unsafe.Pointer
+			elemv := newVariable("", finv.Addr+uint64(j*fintyp.Type.Size()), fintyp.Type, bi, mem)
+			argv, err := elemv.structMember("arg")
+			if err != nil {
+				return nil, err
+			}
+			arg, err := readUintRaw(mem, argv.Addr, ptrSize)
+			if err != nil {
+				return nil, err
+			}
+			if arg < start || arg >= end {
+				continue
+			}
+			fnv, err := elemv.structMember("fn")
+			if err != nil {
+				return nil, err
+			}
+			funcval, err := readUintRaw(mem, fnv.Addr, ptrSize)
+			if err != nil {
+				return nil, err
+			}
+			return &Finalizer{Addr: arg, Funcval: funcval, Queued: true}, nil
+		}
+		nextv, err := blockv.structMember("next") // +rtype *finblock|*finBlock
+		if err != nil {
+			return nil, err
+		}
+		blockv = nextv.maybeDereference()
+	}
+	return nil, nil
+}
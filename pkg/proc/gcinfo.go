@@ -0,0 +1,119 @@
+package proc
+
+import (
+	"fmt"
+	"go/constant"
+	"time"
+)
+
+// GC phases, see runtime/mgc.go
+const (
+	GCoff             uint64 = iota // 0
+	GCmark                          // 1
+	GCmarktermination               // 2
+)
+
+// GCInfo describes the state of the garbage collector and of the heap of
+// the target process.
+// Fields that could not be read from the target are left set to zero.
+type GCInfo struct {
+	Phase         uint64    // value of runtime.gcphase
+	NumGC         uint64    // number of completed GC cycles
+	NumForcedGC   uint64    // number of GC cycles forced by the application calling runtime.GC
+	LastGC        time.Time // time the last GC cycle finished, zero if no cycles completed
+	PauseTotal    time.Duration
+	GCCPUFraction float64
+
+	HeapInuse    uint64 // bytes in in-use spans, same as MemStats.HeapInuse
+	HeapIdle     uint64 // bytes in idle spans, same as MemStats.HeapIdle
+	HeapReleased uint64 // bytes of physical memory returned to the OS, same as MemStats.HeapReleased
+	HeapLive     uint64 // bytes marked live by the last GC plus bytes allocated since
+	HeapMarked   uint64 // bytes marked live by the last GC
+	NextGC       uint64 // target heap size of the next GC cycle, same as MemStats.NextGC
+}
+
+// PhaseString returns a description of the GC phase.
+func (gci *GCInfo) PhaseString() string {
+	switch gci.Phase {
+	case GCoff:
+		return "off"
+	case GCmark:
+		return "mark"
+	case GCmarktermination:
+		return "mark termination"
+	default:
+		return fmt.Sprintf("unknown(%d)", gci.Phase)
+	}
+}
+
+// GetGCInfo reads the state of the garbage collector and heap statistics
+// from runtime.memstats, runtime.gcController and runtime.gcphase.
+func GetGCInfo(t *Target) (*GCInfo, error) {
+	if _, err := t.Valid(); err != nil {
+		return nil, err
+	}
+	scope := globalScope(t, t.BinInfo(), t.BinInfo().Images[0], t.Memory())
+
+	r := &GCInfo{}
+
+	// +rtype -var gcphase uint32
+	gcphasev, err := scope.findGlobal("runtime", "gcphase")
+	if err == nil {
+		phase, _ := runtimeInt(gcphasev, nil)
+		r.Phase = uint64(phase)
+	}
+
+	// +rtype -var memstats mstats
+	memstatsv, err := scope.findGlobal("runtime", "memstats") // +rtype mstats
+	if err != nil {
+		return nil, err
+	}
+	numgc, err := runtimeInt(memstatsv.structMember("numgc")) // +rtype uint32
+	if err != nil {
+		return nil, err
+	}
+	r.NumGC = uint64(numgc)
+	numforcedgc, _ := runtimeInt(memstatsv.structMember("numforcedgc")) // +rtype uint32
+	r.NumForcedGC = uint64(numforcedgc)
+	lastgc, _ := runtimeInt(memstatsv.structMember("last_gc_unix")) // +rtype uint64
+	if lastgc != 0 {
+		r.LastGC = time.Unix(0, lastgc)
+	}
+	pausetotal, _ := runtimeInt(memstatsv.structMember("pause_total_ns")) // +rtype uint64
+	r.PauseTotal = time.Duration(pausetotal)
+	cpufractionv := memstatsv.loadFieldNamed("gc_cpu_fraction") // +rtype float64
+	if cpufractionv != nil && cpufractionv.Value != nil {
+		r.GCCPUFraction, _ = constant.Float64Val(cpufractionv.Value)
+	}
+
+	heapInuse, err := runtimeInt(memstatsv.structMember("heap_inuse")) // +rtype -opt uint64
+	// Before Go 1.20 heap statistics were kept in memstats, after that they
+	// were moved to gcController.
+	if err == nil {
+		heapIdle, _ := runtimeInt(memstatsv.structMember("heap_idle"))         // +rtype -opt uint64
+		heapReleased, _ := runtimeInt(memstatsv.structMember("heap_released")) // +rtype -opt uint64
+		r.HeapInuse, r.HeapIdle, r.HeapReleased = uint64(heapInuse), uint64(heapIdle), uint64(heapReleased)
+	}
+
+	// +rtype -var gcController gcControllerState
+	gcControllerv, err := scope.findGlobal("runtime", "gcController") // +rtype gcControllerState
+	if err != nil {
+		return r, nil
+	}
+	heapInuse, err = runtimeInt(gcControllerv.structMember("heapInUse")) // +rtype -opt sysMemStat
+	if err == nil {
+		heapFree, _ := runtimeInt(gcControllerv.structMember("heapFree"))         // +rtype -opt sysMemStat
+		heapReleased, _ := runtimeInt(gcControllerv.structMember("heapReleased")) // +rtype -opt sysMemStat
+		r.HeapInuse = uint64(heapInuse)
+		r.HeapIdle = uint64(heapFree + heapReleased)
+		r.HeapReleased = uint64(heapReleased)
+	}
+	heapLive, _ := runtimeInt(gcControllerv.structMember("heapLive")) // +rtype uint64|runtime/internal/atomic.Uint64|internal/runtime/atomic.Uint64
+	r.HeapLive = uint64(heapLive)
+	heapMarked, _ := runtimeInt(gcControllerv.structMember("heapMarked")) // +rtype uint64
+	r.HeapMarked = uint64(heapMarked)
+	heapGoal, _ := runtimeInt(gcControllerv.structMember("gcPercentHeapGoal")) // +rtype -opt runtime/internal/atomic.Uint64|internal/runtime/atomic.Uint64
+	r.NextGC = uint64(heapGoal)
+
+	return r, nil
+}
@@ -0,0 +1,95 @@
+package proc
+
+import (
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+	"github.com/go-delve/delve/pkg/goversion"
+)
+
+// gFieldFallback describes the position of a field of runtime.g, it is
+// used to read the field when the debug info of the target process does
+// not describe it.
+type gFieldFallback struct {
+	off32, off64 int64 // offset of the field on 32bit and 64bit architectures
+	size         int64 // size of the field in bytes, 0 for pointer sized fields
+	signed       bool
+}
+
+// gFieldFallbacks contains, for each version of Go, the position of the
+// scalar fields of runtime.g read by parseG.
+// The entry for a version of Go must match the debug info produced by
+// that version, see TestGFieldFallbacks.
+var gFieldFallbacks = map[[2]int]map[string]gFieldFallback{
+	{1, 19}: {
+		"atomicstatus": {off32: 72, off64: 144, size: 4},
+		"goid":         {off32: 80, off64: 152, size: 8, signed: true},
+		"waitsince":    {off32: 92, off64: 168, size: 8, signed: true},
+		"waitreason":   {off32: 100, off64: 176, size: 1},
+		"gopc":         {off32: 184, off64: 296},
+		"startpc":      {off32: 192, off64: 312},
+	},
+	{1, 20}: {
+		"atomicstatus": {off32: 72, off64: 144, size: 4},
+		"goid":         {off32: 80, off64: 152, size: 8},
+		"waitsince":    {off32: 92, off64: 168, size: 8, signed: true},
+		"waitreason":   {off32: 100, off64: 176, size: 1},
+		"gopc":         {off32: 184, off64: 296},
+		"startpc":      {off32: 192, off64: 312},
+	},
+	{1, 21}: {
+		"atomicstatus": {off32: 72, off64: 144, size: 4},
+		"goid":         {off32: 80, off64: 152, size: 8},
+		"waitsince":    {off32: 92, off64: 168, size: 8, signed: true},
+		"waitreason":   {off32: 100, off64: 176, size: 1},
+		"gopc":         {off32: 172, off64: 280},
+		"startpc":      {off32: 180, off64: 296},
+	},
+	{1, 22}: {
+		"atomicstatus": {off32: 72, off64: 144, size: 4},
+		"goid":         {off32: 80, off64: 152, size: 8},
+		"waitsince":    {off32: 92, off64: 168, size: 8, signed: true},
+		"waitreason":   {off32: 100, off64: 176, size: 1},
+		"gopc":         {off32: 172, off64: 280},
+		"startpc":      {off32: 180, off64: 296},
+	},
+}
+
+// lookupGFieldFallback returns the fallback position of field name of
+// runtime.g for the version of Go that produced the target.
+func lookupGFieldFallback(bi *BinaryInfo, name string) (gFieldFallback, bool) {
+	producer := bi.Producer()
+	if producer == "" {
+		return gFieldFallback{}, false
+	}
+	ver := goversion.ParseProducer(producer)
+	f, ok := gFieldFallbacks[[2]int{ver.Major, ver.Minor}][name]
+	return f, ok
+}
+
+// loadGFieldFallback reads field name of the runtime.g struct v using
+// gFieldFallbacks, it returns nil if the position of the field is not
+// known or it could not be read.
+func (v *Variable) loadGFieldFallback(name string) *Variable {
+	f, ok := lookupGFieldFallback(v.bi, name)
+	if !ok {
+		return nil
+	}
+	ptrSize := int64(v.bi.Arch.PtrSize())
+	off := f.off64
+	if ptrSize == 4 {
+		off = f.off32
+	}
+	size := f.size
+	if size == 0 {
+		size = ptrSize
+	}
+	kind := "uint"
+	if f.signed {
+		kind = "int"
+	}
+	fv := v.newVariable(name, v.Addr+uint64(off), godwarf.FakeBasicType(kind, int(size*8)), v.mem)
+	fv.loadValue(loadSingleValue)
+	if fv.Unreadable != nil {
+		return nil
+	}
+	return fv
+}
@@ -0,0 +1,293 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+)
+
+const (
+	mSpanInUse       = 1    // +rtype mSpanInUse
+	mallocHeaderSize = 8    // runtime.mallocHeaderSize, only exists since Go 1.22
+	pageSize         = 8192 // +rtype _PageSize
+)
+
+// HeapObject describes the object of the Go heap containing an address.
+type HeapObject struct {
+	Addr      uint64       // address of the object, after its malloc header if it has one
+	Size      uint64       // size of the object, excluding its malloc header
+	SizeClass int64        // size class of the span containing the object, 0 for large objects
+	Noscan    bool         // the span containing the object only holds objects without pointers
+	Free      bool         // the object is not allocated
+	Type      godwarf.Type // type of the object, nil if it could not be determined
+}
+
+// FindHeapObject returns the heap object containing addr.
+// The span containing addr is found through the arena index in
+// runtime.mheap_.arenas, the base of the object is computed from the
+// element size of the span and the allocation bitmap of the span is used
+// to determine whether the object is allocated.
+// The type of the object can only be recovered for objects containing
+// pointers allocated by Go 1.22 or later, which have a malloc header or,
+// for large objects, store their type in the span.
+func FindHeapObject(t *Target, addr uint64) (*HeapObject, error) {
+	if _, err := t.Valid(); err != nil {
+		return nil, err
+	}
+	span, err := findSpan(t, addr)
+	if err != nil {
+		return nil, err
+	}
+	idx := span.objIndex(addr)
+	if idx >= span.nelems {
+		return nil, fmt.Errorf("%#x is in the unused tail of a span", addr)
+	}
+	allocBits, err := span.allocBitmap(t.Memory())
+	if err != nil {
+		return nil, err
+	}
+	return span.heapObject(t.BinInfo(), t.Memory(), idx, !span.isAllocated(allocBits, idx)), nil
+}
+
+// findSpan returns the in use span containing addr.
+func findSpan(t *Target, addr uint64) (*mspan, error) {
+	bi := t.BinInfo()
+	mem := t.Memory()
+	ptrSize := uint64(bi.Arch.PtrSize())
+	errNotHeap := fmt.Errorf("%#x is not in the Go heap", addr)
+
+	scope := globalScope(t, bi, bi.Images[0], mem)
+	mheapv, err := scope.findGlobal("runtime", "mheap_") // +rtype mheap
+	if err != nil {
+		return nil, err
+	}
+	arenasv, err := mheapv.structMember("arenas") // +rtype anytype
+	if err != nil {
+		return nil, err
+	}
+
+	// mheap_.arenas has type [1 << arenaL1Bits]*[1 << arenaL2Bits]*heapArena
+	l1typ, _ := resolveTypedef(arenasv.RealType).(*godwarf.ArrayType)
+	if l1typ == nil {
+		return nil, errors.New("unexpected type for runtime.mheap_.arenas")
+	}
+	l2ptrtyp, _ := resolveTypedef(l1typ.Type).(*godwarf.PtrType)
+	if l2ptrtyp == nil {
+		return nil, errors.New("unexpected type for runtime.mheap_.arenas")
+	}
+	l2typ, _ := resolveTypedef(l2ptrtyp.Type).(*godwarf.ArrayType)
+	if l2typ == nil {
+		return nil, errors.New("unexpected type for runtime.mheap_.arenas")
+	}
+	arenaptrtyp, _ := resolveTypedef(l2typ.Type).(*godwarf.PtrType)
+	if arenaptrtyp == nil {
+		return nil, errors.New("unexpected type for runtime.mheap_.arenas")
+	}
+
+	ri := (addr - arenaBaseOffset(bi)) / heapArenaBytes(bi)
+	if ri >= uint64(l1typ.Count*l2typ.Count) {
+		return nil, errNotHeap
+	}
+	l1, l2 := ri/uint64(l2typ.Count), ri%uint64(l2typ.Count)
+	l2addr, err := readUintRaw(mem, arenasv.Addr+l1*ptrSize, int64(ptrSize))
+	if err != nil {
+		return nil, err
+	}
+	if l2addr == 0 {
+		return nil, errNotHeap
+	}
+	arenaAddr, err := readUintRaw(mem, l2addr+l2*ptrSize, int64(ptrSize))
+	if err != nil {
+		return nil, err
+	}
+	if arenaAddr == 0 {
+		return nil, errNotHeap
+	}
+
+	arenav := newVariable("", arenaAddr, arenaptrtyp.Type, bi, mem) // +rtype heapArena
+	spansv, err := arenav.structMember("spans")                     // +rtype anytype
+	if err != nil {
+		return nil, err
+	}
+	spanstyp, _ := resolveTypedef(spansv.RealType).(*godwarf.ArrayType)
+	if spanstyp == nil {
+		return nil, errors.New("unexpected type for runtime.heapArena.spans")
+	}
+	spanptrtyp, _ := resolveTypedef(spanstyp.Type).(*godwarf.PtrType)
+	if spanptrtyp == nil {
+		return nil, errors.New("unexpected type for runtime.heapArena.spans")
+	}
+	spanAddr, err := readUintRaw(mem, spansv.Addr+((addr/pageSize)%uint64(spanstyp.Count))*ptrSize, int64(ptrSize))
+	if err != nil {
+		return nil, err
+	}
+	if spanAddr == 0 {
+		return nil, errNotHeap
+	}
+
+	span, err := loadMspan(bi, mem, spanAddr, spanptrtyp.Type)
+	if err != nil {
+		return nil, err
+	}
+	if !span.inUse() || !span.contains(addr) {
+		return nil, errNotHeap
+	}
+	return span, nil
+}
+
+// mspan holds the fields of a runtime.mspan used by Delve.
+type mspan struct {
+	state     int64
+	startAddr uint64
+	npages    uint64
+	elemsize  uint64
+	nelems    uint64
+	spanclass int64
+	freeindex uint64
+	allocBits uint64 // address of the allocation bitmap
+	specials  uint64 // address of the first special record
+	largeType uint64 // type of the object in spans with a single object
+
+	mallocHeaders bool // the runtime stores the type of objects in malloc headers (Go 1.22 and later)
+}
+
+// loadMspan reads the runtime.mspan struct at addr, typ must be
+// runtime.mspan.
+func loadMspan(bi *BinaryInfo, mem MemoryReadWriter, addr uint64, typ godwarf.Type) (*mspan, error) {
+	ptrSize := int64(bi.Arch.PtrSize())
+	mem = cacheMemory(mem, addr, int(typ.Size()))
+	spanv := newVariable("", addr, typ, bi, mem) // +rtype mspan
+	readPtr := func(v *Variable, err error) (uint64, error) {
+		if err != nil {
+			return 0, err
+		}
+		return readUintRaw(mem, v.Addr, ptrSize)
+	}
+	readUint := func(v *Variable, err error) (uint64, error) {
+		n, err := runtimeInt(v, err)
+		return uint64(n), err
+	}
+
+	s := &mspan{}
+	statev, err := spanv.structMember("state") // +rtype mSpanStateBox
+	if err != nil {
+		return nil, err
+	}
+	if statev.Kind == reflect.Struct {
+		statev, err = statev.structMember("s")
+		if err != nil {
+			return nil, err
+		}
+	}
+	if s.state, err = runtimeInt(statev, nil); err != nil {
+		return nil, err
+	}
+	s.startAddr, err = readUint(spanv.structMember("startAddr")) // +rtype uintptr
+	if err != nil {
+		return nil, err
+	}
+	s.npages, err = readUint(spanv.structMember("npages")) // +rtype uintptr
+	if err != nil {
+		return nil, err
+	}
+	s.elemsize, err = readUint(spanv.structMember("elemsize")) // +rtype uintptr
+	if err != nil {
+		return nil, err
+	}
+	s.nelems, err = readUint(spanv.structMember("nelems")) // +rtype uint16|uintptr
+	if err != nil {
+		return nil, err
+	}
+	s.spanclass, err = runtimeInt(spanv.structMember("spanclass")) // +rtype spanClass
+	if err != nil {
+		return nil, err
+	}
+	s.freeindex, err = readUint(spanv.structMember("freeindex")) // +rtype uint16|uintptr
+	if err != nil {
+		return nil, err
+	}
+	s.allocBits, err = readPtr(spanv.structMember("allocBits")) // +rtype *gcBits
+	if err != nil {
+		return nil, err
+	}
+	s.specials, err = readPtr(spanv.structMember("specials")) // +rtype *special
+	if err != nil {
+		return nil, err
+	}
+	largeTypev, err := spanv.structMember("largeType") // +rtype -opt *_type|*internal/abi.Type
+	if err == nil {
+		s.mallocHeaders = true
+		s.largeType, _ = readPtr(largeTypev, nil)
+	}
+	if s.inUse() && s.elemsize == 0 {
+		return nil, errors.New("invalid span element size")
+	}
+	return s, nil
+}
+
+func (s *mspan) inUse() bool {
+	return s.state == mSpanInUse
+}
+
+func (s *mspan) noscan() bool {
+	return s.spanclass&1 != 0
+}
+
+// contains returns true if addr is inside the memory of the span.
+func (s *mspan) contains(addr uint64) bool {
+	return addr >= s.startAddr && addr < s.startAddr+s.npages*pageSize
+}
+
+// objIndex returns the index of the object containing addr, which must be
+// inside the span.
+func (s *mspan) objIndex(addr uint64) uint64 {
+	return (addr - s.startAddr) / s.elemsize
+}
+
+// allocBitmap reads the allocation bitmap of the span.
+func (s *mspan) allocBitmap(mem MemoryReadWriter) ([]byte, error) {
+	buf := make([]byte, (s.nelems+7)/8)
+	_, err := mem.ReadMemory(buf, s.allocBits)
+	return buf, err
+}
+
+// isAllocated returns true if the idx-th object of the span is allocated.
+// Objects before freeindex are allocated, the others are allocated only if
+// their bit in the allocation bitmap is set.
+func (s *mspan) isAllocated(allocBits []byte, idx uint64) bool {
+	return idx < s.freeindex || allocBits[idx/8]&(1<<(idx%8)) != 0
+}
+
+// heapObject returns a description of the idx-th object of the span.
+func (s *mspan) heapObject(bi *BinaryInfo, mem MemoryReadWriter, idx uint64, free bool) *HeapObject {
+	r := &HeapObject{
+		Addr:      s.startAddr + idx*s.elemsize,
+		Size:      s.elemsize,
+		SizeClass: s.spanclass >> 1,
+		Noscan:    s.noscan(),
+		Free:      free,
+	}
+
+	// Objects smaller than minSizeForMallocHeader keep their pointer bitmap
+	// at the end of the span instead of having a malloc header, see
+	// typePointersOfUnchecked in $GOROOT/src/runtime/mbitmap.go.
+	ptrSize := uint64(bi.Arch.PtrSize())
+	minSizeForMallocHeader := ptrSize * ptrSize * 8
+	if !s.mallocHeaders || r.Noscan || r.Free || s.elemsize <= minSizeForMallocHeader {
+		return r
+	}
+	var rtypeAddr uint64
+	if r.SizeClass == 0 {
+		rtypeAddr = s.largeType
+	} else {
+		rtypeAddr, _ = readUintRaw(mem, r.Addr, int64(ptrSize))
+		r.Addr += mallocHeaderSize
+		r.Size -= mallocHeaderSize
+	}
+	if rtypeAddr != 0 {
+		r.Type, _ = runtimeTypeAt(bi, mem, rtypeAddr)
+	}
+	return r
+}
@@ -102,6 +102,18 @@ type RecordingManipulationInternal interface {
 	Restart(cctx *ContinueOnceContext, pos string) (Thread, error)
 }
 
+// StateRecorder is an interface that a Delve backend can implement if it
+// can save the state of the target process every time it is resumed and
+// restore it later, see LaunchRecordState.
+type StateRecorder interface {
+	// RecordState saves the current state of the target process.
+	RecordState(currentThread Thread) error
+	// RestoreState restores the state saved by the last call to RecordState
+	// and discards it. Returns the thread that was current when the state
+	// was saved.
+	RestoreState() (Thread, error)
+}
+
 // Direction is the direction of execution for the target process.
 type Direction int8
 
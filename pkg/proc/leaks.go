@@ -0,0 +1,208 @@
+package proc
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+)
+
+// scanSpan is an in use span of the heap being scanned by
+// FindUnreachableObjects.
+type scanSpan struct {
+	*mspan
+	allocBits []byte
+	marked    []bool
+}
+
+// FindUnreachableObjects returns the allocated objects of the Go heap that
+// can not be reached from any root, in order of address.
+// The roots are the stacks of goroutines, the registers of threads, the
+// data and bss sections of all modules and the objects with a finalizer
+// (everything they reference is reachable but the objects themselves are
+// not, like the runtime does).
+// The scan is conservative: every pointer-sized word of a root or of a
+// reachable object containing pointers is considered a pointer. Memory
+// allocated by the runtime outside of the heap is not scanned, objects
+// referenced only from there (for example the current block of the tiny
+// allocator) and objects that became unreachable after the last GC cycle
+// are reported as unreachable.
+// At most max objects are returned, if max is greater than zero. The
+// second return value is true if more unreachable objects exist.
+func FindUnreachableObjects(t *Target, max int) ([]HeapObject, bool, error) {
+	if _, err := t.Valid(); err != nil {
+		return nil, false, err
+	}
+	bi := t.BinInfo()
+	mem := t.Memory()
+
+	spans, err := loadScanSpans(t)
+	if err != nil {
+		return nil, false, err
+	}
+
+	type object struct {
+		span *scanSpan
+		idx  uint64
+	}
+	var queue []object
+
+	// mark marks the object containing word, if word is a pointer into an
+	// allocated object of the heap.
+	mark := func(_, word uint64) bool {
+		i := sort.Search(len(spans), func(i int) bool { return spans[i].startAddr+spans[i].npages*pageSize > word })
+		if i >= len(spans) || !spans[i].contains(word) {
+			return true
+		}
+		s := spans[i]
+		idx := s.objIndex(word)
+		if idx >= s.nelems || s.marked[idx] || !s.isAllocated(s.allocBits, idx) {
+			return true
+		}
+		s.marked[idx] = true
+		if !s.noscan() {
+			queue = append(queue, object{s, idx})
+		}
+		return true
+	}
+
+	gs, _, err := GoroutinesInfo(t, 0, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, g := range gs {
+		if g.Unreadable != nil || g.Status == Gdead || g.stack.lo == 0 || g.stack.hi <= g.stack.lo {
+			continue
+		}
+		frames, _ := GoroutineStacktrace(t, g, 1, 0)
+		scanMemory(t, liveStackStart(g, frames), g.stack.hi, mark)
+	}
+
+	for _, thread := range t.ThreadList() {
+		regs, err := thread.Registers()
+		if err != nil {
+			continue
+		}
+		regslice, err := regs.Slice(false)
+		if err != nil {
+			continue
+		}
+		for _, reg := range regslice {
+			if reg.Reg != nil {
+				mark(0, reg.Reg.Uint64Val)
+			}
+		}
+	}
+
+	mds, err := LoadModuleData(bi, mem)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, md := range mds {
+		scanMemory(t, md.data, md.edata, mark)
+		scanMemory(t, md.bss, md.ebss, mark)
+	}
+
+	if err := scanFinalizers(t, spans, mark); err != nil {
+		return nil, false, err
+	}
+
+	for len(queue) > 0 {
+		obj := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		base := obj.span.startAddr + obj.idx*obj.span.elemsize
+		scanMemory(t, base, base+obj.span.elemsize, mark)
+	}
+
+	var r []HeapObject
+	for _, s := range spans {
+		for idx := uint64(0); idx < s.nelems; idx++ {
+			if s.marked[idx] || !s.isAllocated(s.allocBits, idx) {
+				continue
+			}
+			if max > 0 && len(r) >= max {
+				return r, true, nil
+			}
+			r = append(r, *s.heapObject(bi, mem, idx, false))
+		}
+	}
+	return r, false, nil
+}
+
+// loadScanSpans returns all the in use spans listed in
+// runtime.mheap_.allspans sorted by address.
+func loadScanSpans(t *Target) ([]*scanSpan, error) {
+	bi := t.BinInfo()
+	mem := t.Memory()
+	ptrSize := int64(bi.Arch.PtrSize())
+	scope := globalScope(t, bi, bi.Images[0], mem)
+	mheapv, err := scope.findGlobal("runtime", "mheap_") // +rtype mheap
+	if err != nil {
+		return nil, err
+	}
+	allspansv, err := mheapv.structMember("allspans") // +rtype []*mspan
+	if err != nil {
+		return nil, err
+	}
+	if allspansv.Unreadable != nil {
+		return nil, allspansv.Unreadable
+	}
+	slicetyp, _ := resolveTypedef(allspansv.RealType).(*godwarf.SliceType)
+	if slicetyp == nil {
+		return nil, errors.New("unexpected type for runtime.mheap_.allspans")
+	}
+	spanptrtyp, _ := resolveTypedef(slicetyp.ElemType).(*godwarf.PtrType)
+	if spanptrtyp == nil {
+		return nil, errors.New("unexpected type for runtime.mheap_.allspans")
+	}
+
+	buf := make([]byte, allspansv.Len*ptrSize)
+	if _, err := mem.ReadMemory(buf, allspansv.Base); err != nil {
+		return nil, err
+	}
+	spans := make([]*scanSpan, 0, allspansv.Len)
+	for i := int64(0); i < int64(len(buf)); i += ptrSize {
+		var spanAddr uint64
+		if ptrSize == 4 {
+			spanAddr = uint64(binary.LittleEndian.Uint32(buf[i:]))
+		} else {
+			spanAddr = binary.LittleEndian.Uint64(buf[i:])
+		}
+		if spanAddr == 0 {
+			continue
+		}
+		s, err := loadMspan(bi, mem, spanAddr, spanptrtyp.Type)
+		if err != nil || !s.inUse() || s.nelems == 0 {
+			continue
+		}
+		allocBits, err := s.allocBitmap(mem)
+		if err != nil {
+			continue
+		}
+		spans = append(spans, &scanSpan{mspan: s, allocBits: allocBits, marked: make([]bool, s.nelems)})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].startAddr < spans[j].startAddr })
+	return spans, nil
+}
+
+// scanFinalizers calls mark on every word of the objects that have a
+// finalizer and on their finalizer functions, see markrootSpans in
+// $GOROOT/src/runtime/mgcmark.go.
+func scanFinalizers(t *Target, spans []*scanSpan, mark func(addr, word uint64) bool) error {
+	fr, err := newFinalizerReader(t)
+	if err != nil {
+		return err
+	}
+	for _, s := range spans {
+		err := fr.specials(s.specials, func(offset, fn uint64) {
+			base := s.startAddr + offset
+			scanMemory(t, base, base+s.elemsize, mark)
+			mark(0, fn)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -196,6 +196,10 @@ func (r *AMD64Registers) SetReg(regNum uint64, reg *op.DwarfRegister) (bool, err
 		p = &r.Regs.Rip
 	case regnum.AMD64_Rflags:
 		p = &r.Regs.Eflags
+	case regnum.AMD64_Fs_base:
+		p = &r.Regs.Fs_base
+	case regnum.AMD64_Gs_base:
+		p = &r.Regs.Gs_base
 	}
 
 	if p != nil {
@@ -0,0 +1,115 @@
+package linutil
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/go-delve/delve/pkg/dwarf/op"
+	"github.com/go-delve/delve/pkg/dwarf/regnum"
+	"github.com/go-delve/delve/pkg/proc"
+)
+
+// LOONG64Registers implements the proc.Registers interface for the core/linux
+// backend on LOONG64.
+type LOONG64Registers struct {
+	Regs   *LOONG64PtraceRegs // general-purpose registers
+	Fpregs []proc.Register    // Formatted floating point registers
+}
+
+// LOONG64PtraceRegs is the struct used by the linux kernel to return the
+// general purpose registers for LOONG64 CPUs, see user_pt_regs in
+// arch/loongarch/include/uapi/asm/ptrace.h.
+type LOONG64PtraceRegs struct {
+	Regs     [32]uint64
+	OrigA0   uint64
+	Era      uint64 // the program counter
+	Badv     uint64
+	Reserved [10]uint64
+}
+
+// Slice returns the registers as a list of (name, value) pairs.
+func (r *LOONG64Registers) Slice(floatingPoint bool) ([]proc.Register, error) {
+	out := make([]proc.Register, 0, len(r.Regs.Regs)+2+len(r.Fpregs))
+	for i := range r.Regs.Regs {
+		out = proc.AppendUint64Register(out, fmt.Sprintf("R%d", i), r.Regs.Regs[i])
+	}
+	out = proc.AppendUint64Register(out, "PC", r.Regs.Era)
+	out = proc.AppendUint64Register(out, "BADV", r.Regs.Badv)
+	if floatingPoint {
+		out = append(out, r.Fpregs...)
+	}
+	return out, nil
+}
+
+// PC returns the value of the PC register.
+func (r *LOONG64Registers) PC() uint64 {
+	return r.Regs.Era
+}
+
+// SP returns the value of the SP register (R3).
+func (r *LOONG64Registers) SP() uint64 {
+	return r.Regs.Regs[regnum.LOONG64_SP]
+}
+
+// BP returns the value of the frame pointer register (R22).
+func (r *LOONG64Registers) BP() uint64 {
+	return r.Regs.Regs[regnum.LOONG64_BP]
+}
+
+// TLS returns the address of the thread local storage memory segment.
+func (r *LOONG64Registers) TLS() uint64 {
+	return 0
+}
+
+// GAddr returns the address of the G variable, Go keeps it in R22.
+func (r *LOONG64Registers) GAddr() (uint64, bool) {
+	return r.Regs.Regs[22], true
+}
+
+// LR returns the link register (R1).
+func (r *LOONG64Registers) LR() uint64 {
+	return r.Regs.Regs[regnum.LOONG64_LR]
+}
+
+// Copy returns a copy of these registers that is guaranteed not to change.
+func (r *LOONG64Registers) Copy() (proc.Registers, error) {
+	var rr LOONG64Registers
+	rr.Regs = &LOONG64PtraceRegs{}
+	*(rr.Regs) = *(r.Regs)
+	if r.Fpregs != nil {
+		rr.Fpregs = make([]proc.Register, len(r.Fpregs))
+		copy(rr.Fpregs, r.Fpregs)
+	}
+	return &rr, nil
+}
+
+func (r *LOONG64Registers) SetReg(regNum uint64, reg *op.DwarfRegister) (fpchanged bool, err error) {
+	switch {
+	case regNum == regnum.LOONG64_PC:
+		r.Regs.Era = reg.Uint64Val
+		return false, nil
+	case regNum > regnum.LOONG64_R0 && regNum <= regnum.LOONG64_R0+31:
+		r.Regs.Regs[regNum-regnum.LOONG64_R0] = reg.Uint64Val
+		return false, nil
+	default:
+		return false, fmt.Errorf("changing register %d not implemented", regNum)
+	}
+}
+
+// LOONG64PtraceFpRegs is the floating point register set saved by the
+// linux kernel, see user_fp_state in arch/loongarch/include/uapi/asm/ptrace.h.
+type LOONG64PtraceFpRegs struct {
+	Fregs []byte // F0 through F31, followed by FCC and FCSR
+}
+
+func (fpregs *LOONG64PtraceFpRegs) Decode() (regs []proc.Register) {
+	const fccOff = 32 * 8
+	for i := 0; i+8 <= len(fpregs.Fregs) && i < fccOff; i += 8 {
+		regs = proc.AppendBytesRegister(regs, fmt.Sprintf("F%d", i/8), fpregs.Fregs[i:i+8])
+	}
+	if len(fpregs.Fregs) >= fccOff+8+4 {
+		regs = proc.AppendUint64Register(regs, "FCC", binary.LittleEndian.Uint64(fpregs.Fregs[fccOff:]))
+		regs = proc.AppendUint64Register(regs, "FCSR", uint64(binary.LittleEndian.Uint32(fpregs.Fregs[fccOff+8:])))
+	}
+	return
+}
@@ -0,0 +1,168 @@
+package linutil
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/go-delve/delve/pkg/dwarf/op"
+	"github.com/go-delve/delve/pkg/dwarf/regnum"
+	"github.com/go-delve/delve/pkg/proc"
+)
+
+// RISCV64Registers implements the proc.Registers interface for the native/linux
+// backend and core/linux backends, on RISCV64.
+type RISCV64Registers struct {
+	Regs     *RISCV64PtraceRegs // general-purpose registers
+	iscgo    bool
+	Fpregs   []proc.Register // Formatted floating point registers
+	Fpregset []byte          // holding all floating point register values
+
+	loadFpRegs func(*RISCV64Registers) error
+}
+
+func NewRISCV64Registers(regs *RISCV64PtraceRegs, iscgo bool, loadFpRegs func(*RISCV64Registers) error) *RISCV64Registers {
+	return &RISCV64Registers{Regs: regs, iscgo: iscgo, loadFpRegs: loadFpRegs}
+}
+
+// RISCV64PtraceRegs is the struct used by the linux kernel to return the
+// general purpose registers for RISCV64 CPUs, see user_regs_struct in
+// arch/riscv/include/uapi/asm/ptrace.h.
+// The program counter takes the place of X0, which is always zero.
+type RISCV64PtraceRegs struct {
+	Pc   uint64
+	Regs [31]uint64 // X1 through X31
+}
+
+// X returns the value of register Xi.
+func (r *RISCV64PtraceRegs) X(i int) uint64 {
+	if i == 0 {
+		return 0
+	}
+	return r.Regs[i-1]
+}
+
+// Slice returns the registers as a list of (name, value) pairs.
+func (r *RISCV64Registers) Slice(floatingPoint bool) ([]proc.Register, error) {
+	out := make([]proc.Register, 0, len(r.Regs.Regs)+1+len(r.Fpregs))
+	for i := range r.Regs.Regs {
+		out = proc.AppendUint64Register(out, fmt.Sprintf("X%d", i+1), r.Regs.Regs[i])
+	}
+	out = proc.AppendUint64Register(out, "PC", r.Regs.Pc)
+	var floatLoadError error
+	if floatingPoint {
+		if r.loadFpRegs != nil {
+			floatLoadError = r.loadFpRegs(r)
+			r.loadFpRegs = nil
+		}
+		out = append(out, r.Fpregs...)
+	}
+	return out, floatLoadError
+}
+
+// PC returns the value of the PC register.
+func (r *RISCV64Registers) PC() uint64 {
+	return r.Regs.Pc
+}
+
+// SP returns the value of the SP register (X2).
+func (r *RISCV64Registers) SP() uint64 {
+	return r.Regs.X(regnum.RISCV64_SP)
+}
+
+// BP returns the value of the frame pointer register (X8).
+func (r *RISCV64Registers) BP() uint64 {
+	return r.Regs.X(regnum.RISCV64_BP)
+}
+
+// TLS returns the address of the thread local storage memory segment,
+// stored in the thread pointer register (X4).
+func (r *RISCV64Registers) TLS() uint64 {
+	if !r.iscgo {
+		return 0
+	}
+	return r.Regs.X(regnum.RISCV64_TP)
+}
+
+// GAddr returns the address of the G variable if it is known, 0 and false
+// otherwise.
+func (r *RISCV64Registers) GAddr() (uint64, bool) {
+	return r.Regs.X(27), !r.iscgo
+}
+
+// LR returns the link register (X1).
+func (r *RISCV64Registers) LR() uint64 {
+	return r.Regs.X(regnum.RISCV64_LR)
+}
+
+// Copy returns a copy of these registers that is guaranteed not to change.
+func (r *RISCV64Registers) Copy() (proc.Registers, error) {
+	if r.loadFpRegs != nil {
+		err := r.loadFpRegs(r)
+		r.loadFpRegs = nil
+		if err != nil {
+			return nil, err
+		}
+	}
+	var rr RISCV64Registers
+	rr.Regs = &RISCV64PtraceRegs{}
+	*(rr.Regs) = *(r.Regs)
+	rr.iscgo = r.iscgo
+	if r.Fpregs != nil {
+		rr.Fpregs = make([]proc.Register, len(r.Fpregs))
+		copy(rr.Fpregs, r.Fpregs)
+	}
+	if r.Fpregset != nil {
+		rr.Fpregset = make([]byte, len(r.Fpregset))
+		copy(rr.Fpregset, r.Fpregset)
+	}
+	return &rr, nil
+}
+
+func (r *RISCV64Registers) SetReg(regNum uint64, reg *op.DwarfRegister) (fpchanged bool, err error) {
+	switch {
+	case regNum == regnum.RISCV64_PC:
+		r.Regs.Pc = reg.Uint64Val
+		return false, nil
+
+	case regNum > regnum.RISCV64_X0 && regNum <= regnum.RISCV64_X0+31:
+		r.Regs.Regs[regNum-regnum.RISCV64_X0-1] = reg.Uint64Val
+		return false, nil
+
+	case regNum >= regnum.RISCV64_F0 && regNum <= regnum.RISCV64_F0+31:
+		if r.loadFpRegs != nil {
+			err := r.loadFpRegs(r)
+			r.loadFpRegs = nil
+			if err != nil {
+				return false, err
+			}
+		}
+		i := regNum - regnum.RISCV64_F0
+		if r.Fpregset == nil || len(r.Fpregset) < int(8*(i+1)) {
+			return false, fmt.Errorf("floating point registers not available")
+		}
+		reg.FillBytes()
+		copy(r.Fpregset[8*i:8*(i+1)], reg.Bytes)
+		return true, nil
+
+	default:
+		return false, fmt.Errorf("changing register %d not implemented", regNum)
+	}
+}
+
+// RISCV64PtraceFpRegs is the floating point register set returned by the
+// linux kernel for the D extension, see __riscv_d_ext_state in
+// arch/riscv/include/uapi/asm/ptrace.h.
+type RISCV64PtraceFpRegs struct {
+	Fregs []byte // F0 through F31 followed by FCSR
+}
+
+func (fpregs *RISCV64PtraceFpRegs) Decode() (regs []proc.Register) {
+	const fcsrOff = 32 * 8
+	for i := 0; i+8 <= len(fpregs.Fregs) && i < fcsrOff; i += 8 {
+		regs = proc.AppendBytesRegister(regs, fmt.Sprintf("F%d", i/8), fpregs.Fregs[i:i+8])
+	}
+	if len(fpregs.Fregs) >= fcsrOff+4 {
+		regs = proc.AppendUint64Register(regs, "FCSR", uint64(binary.LittleEndian.Uint32(fpregs.Fregs[fcsrOff:])))
+	}
+	return
+}
@@ -0,0 +1,230 @@
+package proc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/dwarf/frame"
+	"github.com/go-delve/delve/pkg/dwarf/op"
+	"github.com/go-delve/delve/pkg/dwarf/regnum"
+)
+
+// loong64BreakInstruction is BREAK 0, the instruction used by
+// runtime.Breakpoint.
+var loong64BreakInstruction = []byte{0x00, 0x00, 0x2a, 0x00}
+
+// LOONG64Arch returns an initialized LOONG64 struct.
+func LOONG64Arch(goos string) *Arch {
+	return &Arch{
+		Name:                             "loong64",
+		ptrSize:                          8,
+		maxInstructionLength:             4,
+		breakpointInstruction:            loong64BreakInstruction,
+		breakInstrMovesPC:                false,
+		derefTLS:                         false,
+		prologues:                        prologuesLOONG64,
+		fixFrameUnwindContext:            loong64FixFrameUnwindContext,
+		switchStack:                      loong64SwitchStack,
+		regSize:                          loong64RegSize,
+		RegistersToDwarfRegisters:        loong64RegistersToDwarfRegisters,
+		addrAndStackRegsToDwarfRegisters: loong64AddrAndStackRegsToDwarfRegisters,
+		DwarfRegisterToString:            loong64DwarfRegisterToString,
+		inhibitStepInto:                  func(*BinaryInfo, uint64) bool { return false },
+		asmDecode:                        loong64AsmDecode,
+		usesLR:                           true,
+		PCRegNum:                         regnum.LOONG64_PC,
+		SPRegNum:                         regnum.LOONG64_SP,
+		ContextRegNum:                    regnum.LOONG64_R0 + 29,
+		LRRegNum:                         regnum.LOONG64_LR,
+		asmRegisters:                     loong64AsmRegisters,
+		RegisterNameToDwarf:              nameToDwarfFunc(regnum.LOONG64NameToDwarf),
+		RegnumToString:                   regnum.LOONG64ToName,
+	}
+}
+
+func loong64FixFrameUnwindContext(fctxt *frame.FrameContext, pc uint64, bi *BinaryInfo) *frame.FrameContext {
+	a := bi.Arch
+	if a.sigreturnfn == nil {
+		a.sigreturnfn = bi.lookupOneFunc("runtime.sigreturn")
+	}
+
+	if fctxt == nil || (a.sigreturnfn != nil && pc >= a.sigreturnfn.Entry && pc < a.sigreturnfn.End) {
+		// Go doesn't maintain a frame pointer on loong64, when there's no frame
+		// descriptor entry assume we are in a leaf function:
+		// - the return address is in the link register
+		// - cfa is sp
+		return &frame.FrameContext{
+			RetAddrReg: regnum.LOONG64_PC,
+			Regs: map[uint64]frame.DWRule{
+				regnum.LOONG64_PC: {
+					Rule: frame.RuleRegister,
+					Reg:  regnum.LOONG64_LR,
+				},
+				regnum.LOONG64_SP: {
+					Rule:   frame.RuleValOffset,
+					Offset: 0,
+				},
+			},
+			CFA: frame.DWRule{
+				Rule:   frame.RuleCFA,
+				Reg:    regnum.LOONG64_SP,
+				Offset: 0,
+			},
+		}
+	}
+
+	if a.crosscall2fn == nil {
+		a.crosscall2fn = bi.lookupOneFunc("crosscall2")
+	}
+
+	if a.crosscall2fn != nil && pc >= a.crosscall2fn.Entry && pc < a.crosscall2fn.End {
+		rule := fctxt.CFA
+		if rule.Offset == crosscall2SPOffsetBad {
+			rule.Offset += crosscall2SPOffset
+		}
+		fctxt.CFA = rule
+	}
+
+	if fctxt.Regs[regnum.LOONG64_LR].Rule == frame.RuleUndefined {
+		fctxt.Regs[regnum.LOONG64_LR] = frame.DWRule{
+			Rule:   frame.RuleRegister,
+			Reg:    regnum.LOONG64_LR,
+			Offset: 0,
+		}
+	}
+
+	return fctxt
+}
+
+const loong64cgocallSPOffsetSaveSlot = 0x8
+const loong64prevG0schedSPOffsetSaveSlot = 0x8
+
+func loong64SwitchStack(it *stackIterator, callFrameRegs *op.DwarfRegisters) bool {
+	if it.frame.Current.Fn == nil {
+		if it.systemstack && it.g != nil && it.top {
+			it.switchToGoroutineStack()
+			return true
+		}
+		return false
+	}
+	switch it.frame.Current.Fn.Name {
+	case "runtime.asmcgocall", "runtime.cgocallback_gofunc", "runtime.sigpanic", "runtime.cgocallback":
+		//do nothing
+	case "runtime.goexit", "runtime.rt0_go":
+		// Look for "top of stack" functions.
+		it.atend = true
+		return true
+	case "runtime.mcall":
+		if it.systemstack && it.g != nil {
+			it.switchToGoroutineStack()
+			return true
+		}
+		it.atend = true
+		return true
+	case "crosscall2":
+		// The offsets get from runtime/cgo/asm_loong64.s
+		newsp := it.regs.SP() + 8*23
+		newlr, _ := readUintRaw(it.mem, it.regs.SP()+8*22, int64(it.bi.Arch.PtrSize()))
+		it.regs.Reg(it.regs.LRRegNum).Uint64Val = newlr
+		it.regs.Reg(it.regs.SPRegNum).Uint64Val = newsp
+		it.pc = newlr
+		return true
+	default:
+		if it.systemstack && it.top && it.g != nil && strings.HasPrefix(it.frame.Current.Fn.Name, "runtime.") && it.frame.Current.Fn.Name != "runtime.fatalthrow" {
+			// The runtime switches to the system stack in multiple places.
+			// This usually happens through a call to runtime.systemstack but there
+			// are functions that switch to the system stack manually (for example
+			// runtime.morestack).
+			// Since we are only interested in printing the system stack for cgo
+			// calls we switch directly to the goroutine stack if we detect that the
+			// function at the top of the stack is a runtime function.
+			it.switchToGoroutineStack()
+			return true
+		}
+	}
+
+	fn := it.bi.PCToFunc(it.frame.Ret)
+	if fn == nil {
+		return false
+	}
+	switch fn.Name {
+	case "runtime.asmcgocall":
+		if !it.systemstack {
+			return false
+		}
+
+		// This function is called by a goroutine to execute a C function and
+		// switches from the goroutine stack to the system stack.
+		// Since we are unwinding the stack from callee to caller we have to switch
+		// from the system stack to the goroutine stack.
+		off, _ := readIntRaw(it.mem, callFrameRegs.SP()+loong64cgocallSPOffsetSaveSlot, int64(it.bi.Arch.PtrSize()))
+		oldsp := callFrameRegs.SP()
+		newsp := uint64(int64(it.stackhi) - off)
+
+		// runtime.asmcgocall can also be called from inside the system stack,
+		// in that case no stack switch actually happens
+		if newsp == oldsp {
+			return false
+		}
+		it.systemstack = false
+		callFrameRegs.Reg(callFrameRegs.SPRegNum).Uint64Val = uint64(int64(newsp))
+		return false
+
+	case "runtime.cgocallback_gofunc", "runtime.cgocallback":
+		// For a detailed description of how this works read the long comment at
+		// the start of $GOROOT/src/runtime/cgocall.go and the source code of
+		// runtime.cgocallback in $GOROOT/src/runtime/asm_loong64.s
+		if it.systemstack {
+			return false
+		}
+
+		it.loadG0SchedSP()
+		if it.g0_sched_sp <= 0 {
+			return false
+		}
+		// entering the system stack
+		callFrameRegs.Reg(callFrameRegs.SPRegNum).Uint64Val = it.g0_sched_sp
+		// reads the previous value of g0.sched.sp that runtime.cgocallback saved on the stack
+		it.g0_sched_sp, _ = readUintRaw(it.mem, callFrameRegs.SP()+loong64prevG0schedSPOffsetSaveSlot, int64(it.bi.Arch.PtrSize()))
+		it.systemstack = true
+		return false
+	}
+
+	return false
+}
+
+// loong64RegSize returns the size (in bytes) of register regnum.
+func loong64RegSize(regnum uint64) int {
+	return 8 // integer registers and double precision floating point registers
+}
+
+func loong64RegistersToDwarfRegisters(staticBase uint64, regs Registers) *op.DwarfRegisters {
+	dregs := initDwarfRegistersFromSlice(int(regnum.LOONG64MaxRegNum()), regs, regnum.LOONG64NameToDwarf)
+	dr := op.NewDwarfRegisters(staticBase, dregs, binary.LittleEndian, regnum.LOONG64_PC, regnum.LOONG64_SP, regnum.LOONG64_BP, regnum.LOONG64_LR)
+	dr.SetLoadMoreCallback(loadMoreDwarfRegistersFromSliceFunc(dr, regs, regnum.LOONG64NameToDwarf))
+	return dr
+}
+
+func loong64AddrAndStackRegsToDwarfRegisters(staticBase, pc, sp, bp, lr uint64) op.DwarfRegisters {
+	dregs := make([]*op.DwarfRegister, regnum.LOONG64_PC+1)
+	dregs[regnum.LOONG64_PC] = op.DwarfRegisterFromUint64(pc)
+	dregs[regnum.LOONG64_SP] = op.DwarfRegisterFromUint64(sp)
+	dregs[regnum.LOONG64_BP] = op.DwarfRegisterFromUint64(bp)
+	dregs[regnum.LOONG64_LR] = op.DwarfRegisterFromUint64(lr)
+
+	return *op.NewDwarfRegisters(staticBase, dregs, binary.LittleEndian, regnum.LOONG64_PC, regnum.LOONG64_SP, regnum.LOONG64_BP, regnum.LOONG64_LR)
+}
+
+func loong64DwarfRegisterToString(i int, reg *op.DwarfRegister) (name string, floatingPoint bool, repr string) {
+	name = regnum.LOONG64ToName(uint64(i))
+
+	if reg == nil {
+		return name, false, ""
+	}
+
+	if name[0] == 'F' {
+		return name, true, fmt.Sprintf("%#016x", reg.Uint64Val)
+	}
+	return name, false, fmt.Sprintf("%#016x", reg.Uint64Val)
+}
@@ -0,0 +1,70 @@
+// TODO: disassembler support should be compiled in unconditionally,
+// instead of being decided by the build-target architecture, and be
+// part of the Arch object instead.
+
+package proc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/go-delve/delve/pkg/dwarf/op"
+	"github.com/go-delve/delve/pkg/dwarf/regnum"
+)
+
+// loong64AsmDecode only splits mem into instructions, golang.org/x/arch
+// does not have a LoongArch decoder yet. This is enough to inspect core
+// files, which never need to step through instructions.
+func loong64AsmDecode(asmInst *AsmInstruction, mem []byte, regs *op.DwarfRegisters, memrw MemoryReadWriter, bi *BinaryInfo) error {
+	if len(mem) < 4 {
+		asmInst.Size = len(mem)
+		asmInst.Bytes = mem
+		asmInst.Inst = (*loong64ArchInst)(nil)
+		return errors.New("instruction too short")
+	}
+	asmInst.Size = 4
+	asmInst.Bytes = mem[:asmInst.Size]
+	inst := loong64ArchInst(binary.LittleEndian.Uint32(mem))
+	asmInst.Inst = &inst
+	asmInst.Kind = OtherInstruction
+	if inst == 0x002a0000 {
+		asmInst.Kind = HardBreakInstruction
+	}
+	return nil
+}
+
+var prologuesLOONG64 []opcodeSeq
+
+type loong64ArchInst uint32
+
+func (inst *loong64ArchInst) Text(flavour AssemblyFlavour, pc uint64, symLookup func(uint64) (string, uint64)) string {
+	if inst == nil {
+		return "?"
+	}
+	if *inst == 0x002a0000 {
+		if flavour == GNUFlavour {
+			return "break 0x0"
+		}
+		return "BREAK"
+	}
+	if flavour == GNUFlavour {
+		return fmt.Sprintf(".word %#08x", uint32(*inst))
+	}
+	return fmt.Sprintf("WORD $%#x", uint32(*inst))
+}
+
+func (inst *loong64ArchInst) OpcodeEquals(op uint64) bool {
+	if inst == nil {
+		return false
+	}
+	return uint64(*inst) == op
+}
+
+var loong64AsmRegisters = func() map[int]asmRegister {
+	r := make(map[int]asmRegister)
+	for i := 0; i <= 31; i++ {
+		r[i] = asmRegister{regnum.LOONG64_R0 + uint64(i), 0, 0}
+	}
+	return r
+}()
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/go-delve/delve/pkg/dwarf/op"
 )
@@ -27,6 +28,61 @@ type MemoryReadWriter interface {
 	WriteMemory(addr uint64, data []byte) (written int, err error)
 }
 
+// MemoryRequest is a request to read len(Buf) bytes of memory at Addr,
+// see MultiMemoryReader.
+type MemoryRequest struct {
+	Addr uint64
+	Buf  []byte
+	Err  error // set if the memory could not be read completely
+}
+
+// MultiMemoryReader is implemented by memory readers that can read
+// several, possibly non contiguous, regions of memory with a single
+// operation.
+type MultiMemoryReader interface {
+	// ReadMemoryMulti reads every request in reqs, setting the Err field of
+	// the ones that could not be read.
+	ReadMemoryMulti(reqs []MemoryRequest)
+}
+
+// readMemoryMulti reads every request in reqs from mem, with a single
+// operation if mem supports it.
+func readMemoryMulti(mem MemoryReader, reqs []MemoryRequest) {
+	if mmem, ok := mem.(MultiMemoryReader); ok {
+		mmem.ReadMemoryMulti(reqs)
+		return
+	}
+	for i := range reqs {
+		_, reqs[i].Err = mem.ReadMemory(reqs[i].Buf, reqs[i].Addr)
+	}
+}
+
+// canReadMemoryMulti returns true if the memory reader at the bottom of
+// mem can read several regions of memory with a single operation.
+func canReadMemoryMulti(mem MemoryReader) bool {
+	switch mem := mem.(type) {
+	case *memCache:
+		return mem.mem != nil && canReadMemoryMulti(mem.mem)
+	case MultiMemoryReader:
+		return true
+	}
+	return false
+}
+
+// ConcurrentMemoryReader is implemented by memory readers that can be used
+// by multiple goroutines at the same time, as long as the target process
+// is stopped.
+type ConcurrentMemoryReader interface {
+	// ReadMemoryConcurrently returns true if ReadMemory can be called
+	// concurrently.
+	ReadMemoryConcurrently() bool
+}
+
+func canReadMemoryConcurrently(mem MemoryReader) bool {
+	cmem, ok := mem.(ConcurrentMemoryReader)
+	return ok && cmem.ReadMemoryConcurrently()
+}
+
 type memCache struct {
 	loaded    bool
 	cacheAddr uint64
@@ -59,6 +115,26 @@ func (m *memCache) ReadMemory(data []byte, addr uint64) (n int, err error) {
 	return m.mem.ReadMemory(data, addr)
 }
 
+func (m *memCache) ReadMemoryMulti(reqs []MemoryRequest) {
+	var uncached []MemoryRequest
+	var idx []int
+	for i := range reqs {
+		if m.contains(reqs[i].Addr, len(reqs[i].Buf)) {
+			_, reqs[i].Err = m.ReadMemory(reqs[i].Buf, reqs[i].Addr)
+			continue
+		}
+		uncached = append(uncached, reqs[i])
+		idx = append(idx, i)
+	}
+	if len(uncached) == 0 {
+		return
+	}
+	readMemoryMulti(m.mem, uncached)
+	for j, i := range idx {
+		reqs[i].Err = uncached[j].Err
+	}
+}
+
 func (m *memCache) WriteMemory(addr uint64, data []byte) (written int, err error) {
 	return m.mem.WriteMemory(addr, data)
 }
@@ -89,6 +165,114 @@ func cacheMemory(mem MemoryReadWriter, addr uint64, size int) MemoryReadWriter {
 	return &memCache{false, addr, make([]byte, size), mem}
 }
 
+// ReadCoalesceGap is the maximum number of bytes between two ranges of
+// memory that are read with a single operation, when loading the local
+// variables of a frame. Reading a few unneeded bytes is cheaper than
+// reading the target's memory twice, especially with backends where every
+// read is a round trip to a remote stub. If it is zero reads are only
+// coalesced if the ranges are contiguous.
+// ReadCoalesceGap is deliberately not user-configurable: there is no
+// command line flag, configuration file option or 'config' command for it.
+// It is a tuning knob for programs that use this package directly.
+var ReadCoalesceGap uint64 = 512
+
+// coalescedMemory caches several ranges of memory, each one of them is
+// read with a single operation the first time it is accessed. If the
+// underlying memory implements MultiMemoryReader all ranges are read
+// together, the first time any of them is accessed.
+type coalescedMemory struct {
+	ranges []coalescedRange // sorted by address and not overlapping
+	mem    MemoryReadWriter
+	multi  bool
+}
+
+type coalescedRange struct {
+	addr   uint64
+	buf    []byte
+	loaded bool
+	failed bool
+}
+
+// coalesceMemory returns a MemoryReadWriter that reads the specified
+// ranges of mem, merging the ones that are closer than gap bytes. Merged
+// ranges are never larger than maxFramePrefetchSize.
+func coalesceMemory(mem MemoryReadWriter, ranges [][2]uint64, gap uint64) MemoryReadWriter {
+	if !cacheEnabled || len(ranges) == 0 {
+		return mem
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	merged := [][2]uint64{ranges[0]}
+	for _, rng := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if rng[0] <= last[1]+gap && max(last[1], rng[1])-last[0] <= maxFramePrefetchSize {
+			last[1] = max(last[1], rng[1])
+		} else {
+			merged = append(merged, rng)
+		}
+	}
+	cmem := &coalescedMemory{mem: mem, ranges: make([]coalescedRange, len(merged)), multi: canReadMemoryMulti(mem)}
+	for i, rng := range merged {
+		cmem.ranges[i] = coalescedRange{addr: rng[0], buf: make([]byte, rng[1]-rng[0])}
+	}
+	return cmem
+}
+
+func (m *coalescedMemory) ReadMemory(data []byte, addr uint64) (int, error) {
+	end := addr + uint64(len(data))
+	i := sort.Search(len(m.ranges), func(i int) bool {
+		return m.ranges[i].addr+uint64(len(m.ranges[i].buf)) >= end
+	})
+	if i < len(m.ranges) && end >= addr && addr >= m.ranges[i].addr {
+		rng := &m.ranges[i]
+		if !rng.loaded && !rng.failed {
+			// If the range can't be read, for example because the bytes between
+			// two variables are not mapped, every variable is read separately.
+			if m.multi {
+				m.loadAll()
+			} else {
+				_, err := m.mem.ReadMemory(rng.buf, rng.addr)
+				rng.loaded = err == nil
+				rng.failed = err != nil
+			}
+		}
+		if rng.loaded {
+			copy(data, rng.buf[addr-rng.addr:])
+			return len(data), nil
+		}
+	}
+	return m.mem.ReadMemory(data, addr)
+}
+
+// loadAll reads all ranges that have not been loaded yet with a single
+// operation.
+func (m *coalescedMemory) loadAll() {
+	var reqs []MemoryRequest
+	var idx []int
+	for i := range m.ranges {
+		rng := &m.ranges[i]
+		if !rng.loaded && !rng.failed {
+			reqs = append(reqs, MemoryRequest{Addr: rng.addr, Buf: rng.buf})
+			idx = append(idx, i)
+		}
+	}
+	readMemoryMulti(m.mem, reqs)
+	for j, i := range idx {
+		m.ranges[i].loaded = reqs[j].Err == nil
+		m.ranges[i].failed = reqs[j].Err != nil
+	}
+}
+
+func (m *coalescedMemory) WriteMemory(addr uint64, data []byte) (int, error) {
+	end := addr + uint64(len(data))
+	for i := range m.ranges {
+		rng := &m.ranges[i]
+		if addr < rng.addr+uint64(len(rng.buf)) && rng.addr < end {
+			rng.loaded = false
+		}
+	}
+	return m.mem.WriteMemory(addr, data)
+}
+
 // compositeMemory represents a chunk of memory that is stored in CPU
 // registers or non-contiguously.
 //
@@ -4,6 +4,8 @@ package proc
 type ModuleData struct {
 	text, etext   uint64
 	types, etypes uint64
+	data, edata   uint64
+	bss, ebss     uint64
 	typemapVar    *Variable
 }
 
@@ -11,6 +13,8 @@ func LoadModuleData(bi *BinaryInfo, mem MemoryReadWriter) ([]ModuleData, error)
 	// +rtype -var firstmoduledata moduledata
 	// +rtype -field moduledata.text uintptr
 	// +rtype -field moduledata.types uintptr
+	// +rtype -field moduledata.data uintptr
+	// +rtype -field moduledata.bss uintptr
 
 	scope := globalScope(nil, bi, bi.Images[0], mem)
 	var md *Variable
@@ -27,12 +31,16 @@ func LoadModuleData(bi *BinaryInfo, mem MemoryReadWriter) ([]ModuleData, error)
 			etypesField  = "etypes"
 			textField    = "text"
 			etextField   = "etext"
+			dataField    = "data"
+			edataField   = "edata"
+			bssField     = "bss"
+			ebssField    = "ebss"
 			nextField    = "next"
 			typemapField = "typemap"
 		)
 		vars := map[string]*Variable{}
 
-		for _, fieldName := range []string{typesField, etypesField, textField, etextField, nextField, typemapField} {
+		for _, fieldName := range []string{typesField, etypesField, textField, etextField, dataField, edataField, bssField, ebssField, nextField, typemapField} {
 			var err error
 			vars[fieldName], err = md.structMember(fieldName)
 			if err != nil {
@@ -54,6 +62,8 @@ func LoadModuleData(bi *BinaryInfo, mem MemoryReadWriter) ([]ModuleData, error)
 		r = append(r, ModuleData{
 			types: touint(typesField), etypes: touint(etypesField),
 			text: touint(textField), etext: touint(etextField),
+			data: touint(dataField), edata: touint(edataField),
+			bss: touint(bssField), ebss: touint(ebssField),
 			typemapVar: vars[typemapField],
 		})
 		if err != nil {
@@ -49,6 +49,8 @@ type osProcessDetails struct {
 	comm string
 
 	ebpf *ebpf.EBPFContext
+
+	recorder *stateRecorder // saves the state of the process every time it is resumed, see LaunchRecordState
 }
 
 func (os *osProcessDetails) Close() {
@@ -127,6 +129,9 @@ func Launch(cmd []string, wd string, flags proc.LaunchFlags, debugInfoDirs []str
 	}
 	dbp.pid = process.Process.Pid
 	dbp.childProcess = true
+	if flags&proc.LaunchRecordState != 0 {
+		dbp.os.recorder = newStateRecorder()
+	}
 	_, _, err = dbp.wait(process.Process.Pid, 0)
 	if err != nil {
 		return nil, fmt.Errorf("waiting for target execve failed: %s", err)
@@ -426,6 +431,8 @@ func trapWaitInternal(procgrp *processGroup, pid int, options trapWaitOptions) (
 			th, ok = dbp.threads[wpid]
 			if ok {
 				th.Status = (*waitStatus)(status)
+				th.clearRegistersCache()
+				th.os.name = nil
 			}
 		} else {
 			dbp = procgrp.procs[0]
@@ -87,3 +87,21 @@ func ptraceSetRegset(id int, regset *amd64util.AMD64Xstate) error {
 	}
 	return nil
 }
+
+// ptraceGetDbRegs reads the debug registers DR0 through DR15 of thread tid.
+func ptraceGetDbRegs(tid int, dregs *[16]uint64) error {
+	ret, err := C.ptrace(C.PT_GETDBREGS, C.pid_t(tid), C.caddr_t(unsafe.Pointer(dregs)), C.int(0))
+	if ret != 0 {
+		return fmt.Errorf("failed to get debug registers: %v", err)
+	}
+	return nil
+}
+
+// ptraceSetDbRegs writes the debug registers DR0 through DR15 of thread tid.
+func ptraceSetDbRegs(tid int, dregs *[16]uint64) error {
+	ret, err := C.ptrace(C.PT_SETDBREGS, C.pid_t(tid), C.caddr_t(unsafe.Pointer(dregs)), C.int(0))
+	if ret != 0 {
+		return fmt.Errorf("failed to set debug registers: %v", err)
+	}
+	return nil
+}
@@ -2,8 +2,11 @@ package native
 
 import (
 	"syscall"
+	"unsafe"
 
 	sys "golang.org/x/sys/unix"
+
+	"github.com/go-delve/delve/pkg/proc"
 )
 
 // ptraceAttach executes the sys.PtraceAttach call.
@@ -41,3 +44,35 @@ type remoteIovec struct {
 	base uintptr
 	len  uintptr
 }
+
+// maxIovecs is the maximum number of iovecs that can be passed to
+// process_vm_readv (IOV_MAX).
+const maxIovecs = 1024
+
+// processVmReadMulti calls process_vm_readv with one remote iovec for each
+// request in reqs (at most maxIovecs), it returns the number of requests
+// that were read completely. All requests must have a non-empty buffer.
+func processVmReadMulti(tid int, reqs []proc.MemoryRequest) (int, error) {
+	if len(reqs) > maxIovecs {
+		reqs = reqs[:maxIovecs]
+	}
+	local_iov := make([]sys.Iovec, len(reqs))
+	remote_iov := make([]remoteIovec, len(reqs))
+	for i := range reqs {
+		local_iov[i].Base = &reqs[i].Buf[0]
+		local_iov[i].SetLen(len(reqs[i].Buf))
+		remote_iov[i] = remoteIovec{base: uintptr(reqs[i].Addr), len: uintptr(len(reqs[i].Buf))}
+	}
+	n, _, err := syscall.Syscall6(sys.SYS_PROCESS_VM_READV, uintptr(tid), uintptr(unsafe.Pointer(&local_iov[0])), uintptr(len(local_iov)), uintptr(unsafe.Pointer(&remote_iov[0])), uintptr(len(remote_iov)), 0)
+	if err != syscall.Errno(0) {
+		return 0, err
+	}
+	// Partial reads stop at the first remote iovec that can't be read
+	// completely.
+	done := 0
+	for done < len(reqs) && n >= uintptr(len(reqs[done].Buf)) {
+		n -= uintptr(len(reqs[done].Buf))
+		done++
+	}
+	return done, nil
+}
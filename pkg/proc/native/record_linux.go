@@ -0,0 +1,204 @@
+package native
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-delve/delve/pkg/proc"
+)
+
+// maxRecordedStates is the maximum number of states kept by stateRecorder.
+const maxRecordedStates = 100
+
+var errStateRecordingDisabled = errors.New("state recording is not enabled for this process")
+
+// stateRecorder saves the state of the target process every time it is
+// resumed so that it can be restored later.
+// The memory of the process is saved by keeping a copy (shadow) of all
+// writable pages and, for each saved state, the previous contents of the
+// pages that changed after it was saved.
+// Only registers and writable memory are saved, the state of the kernel
+// (open files, threads created after the state was saved, etc) is not.
+type stateRecorder struct {
+	pageSize uint64
+	// shadow is the contents of every page of writable memory at the time
+	// the last state in history was saved.
+	shadow  map[uint64][]byte
+	history []*recordedState
+}
+
+type recordedState struct {
+	currentThread int
+	regs          map[int]proc.Registers
+	// pages contains the contents of the pages that changed between this
+	// state and the one after it, at the time this state was saved.
+	pages map[uint64][]byte
+}
+
+func newStateRecorder() *stateRecorder {
+	return &stateRecorder{pageSize: uint64(os.Getpagesize())}
+}
+
+// RecordState saves the current state of the process. The state can be
+// restored later by calling RestoreState.
+func (dbp *nativeProcess) RecordState(currentThread proc.Thread) error {
+	r := dbp.os.recorder
+	if r == nil {
+		return nil
+	}
+	st := &recordedState{currentThread: currentThread.ThreadID(), regs: make(map[int]proc.Registers), pages: make(map[uint64][]byte)}
+	for _, th := range dbp.threads {
+		regs, err := th.Registers()
+		if err != nil {
+			return err
+		}
+		regs, err = regs.Copy()
+		if err != nil {
+			return err
+		}
+		st.regs[th.ID] = regs
+	}
+
+	if r.shadow == nil {
+		r.shadow = make(map[uint64][]byte)
+		err := r.changedPages(dbp, func(addr uint64, cur []byte) error {
+			r.shadow[addr] = cur
+			return nil
+		})
+		if err != nil {
+			r.shadow = nil
+			return err
+		}
+	} else if len(r.history) > 0 {
+		prev := r.history[len(r.history)-1]
+		err := r.changedPages(dbp, func(addr uint64, cur []byte) error {
+			prev.pages[addr] = r.shadow[addr]
+			r.shadow[addr] = cur
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	r.history = append(r.history, st)
+	if len(r.history) > maxRecordedStates {
+		r.history[0] = nil
+		r.history = r.history[1:]
+	}
+	return nil
+}
+
+// RestoreState restores the state saved by the last call to RecordState
+// and discards it. Returns the thread that was current when the state was
+// saved.
+func (dbp *nativeProcess) RestoreState() (proc.Thread, error) {
+	r := dbp.os.recorder
+	if r == nil {
+		return nil, errStateRecordingDisabled
+	}
+	if len(r.history) == 0 {
+		return nil, proc.ErrNoRecordedState
+	}
+	st := r.history[len(r.history)-1]
+	curthread := dbp.threads[st.currentThread]
+	if curthread == nil {
+		return nil, fmt.Errorf("could not restore state: thread %d has exited", st.currentThread)
+	}
+
+	err := r.changedPages(dbp, func(addr uint64, cur []byte) error {
+		old := r.shadow[addr]
+		if old == nil {
+			// this page did not exist when the state was saved
+			old = make([]byte, r.pageSize)
+		}
+		_, err := dbp.memthread.WriteMemory(addr, old)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for id, regs := range st.regs {
+		th := dbp.threads[id]
+		if th == nil {
+			continue
+		}
+		if err := th.RestoreRegisters(regs); err != nil {
+			return nil, err
+		}
+	}
+
+	r.history[len(r.history)-1] = nil
+	r.history = r.history[:len(r.history)-1]
+	if len(r.history) > 0 {
+		// The shadow copy must describe the memory of the process at the time
+		// the previous state was saved.
+		prev := r.history[len(r.history)-1]
+		for addr, buf := range prev.pages {
+			r.shadow[addr] = buf
+		}
+		prev.pages = make(map[uint64][]byte)
+	} else {
+		r.shadow = nil
+	}
+	return curthread, nil
+}
+
+// changedPages calls fn for every page of writable memory whose contents
+// are different from its shadow copy, with the current contents of the
+// page.
+func (r *stateRecorder) changedPages(dbp *nativeProcess, fn func(addr uint64, cur []byte) error) error {
+	mmap, err := dbp.MemoryMap()
+	if err != nil {
+		return err
+	}
+	pagemap, err := os.Open(fmt.Sprintf("/proc/%d/pagemap", dbp.pid))
+	if err != nil {
+		return err
+	}
+	defer pagemap.Close()
+
+	seen := make(map[uint64]bool)
+	for _, m := range mmap {
+		if !m.Write {
+			continue
+		}
+		npages := m.Size / r.pageSize
+		entries := make([]byte, npages*8)
+		if _, err := pagemap.ReadAt(entries, int64(m.Addr/r.pageSize*8)); err != nil {
+			return err
+		}
+		for i := uint64(0); i < npages; i++ {
+			addr := m.Addr + i*r.pageSize
+			entry := binary.LittleEndian.Uint64(entries[i*8:])
+			const pagePresent, pageSwapped = 1 << 63, 1 << 62
+			old, inShadow := r.shadow[addr]
+			if entry&(pagePresent|pageSwapped) == 0 && !inShadow {
+				continue
+			}
+			seen[addr] = true
+			cur := make([]byte, r.pageSize)
+			if _, err := dbp.memthread.ReadMemory(cur, addr); err != nil {
+				continue
+			}
+			if inShadow && bytes.Equal(old, cur) {
+				continue
+			}
+			if err := fn(addr, cur); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Pages that have been unmapped since the shadow copy was taken can not
+	// be restored, forget about them.
+	for addr := range r.shadow {
+		if !seen[addr] {
+			delete(r.shadow, addr)
+		}
+	}
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 
 // setPC sets EIP to the value specified by 'pc'.
 func (thread *nativeThread) setPC(pc uint64) error {
+	thread.clearRegistersCache()
 	ir, err := registers(thread)
 	if err != nil {
 		return err
@@ -25,6 +26,7 @@ func (thread *nativeThread) setPC(pc uint64) error {
 }
 
 func (thread *nativeThread) SetReg(regNum uint64, reg *op.DwarfRegister) error {
+	thread.clearRegistersCache()
 	ir, err := registers(thread)
 	if err != nil {
 		return err
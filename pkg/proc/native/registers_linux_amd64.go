@@ -15,6 +15,7 @@ import (
 
 // SetPC sets RIP to the value specified by 'pc'.
 func (thread *nativeThread) setPC(pc uint64) error {
+	thread.clearRegistersCache()
 	ir, err := registers(thread)
 	if err != nil {
 		return err
@@ -27,6 +28,7 @@ func (thread *nativeThread) setPC(pc uint64) error {
 
 // SetReg changes the value of the specified register.
 func (thread *nativeThread) SetReg(regNum uint64, reg *op.DwarfRegister) error {
+	thread.clearRegistersCache()
 	ir, err := registers(thread)
 	if err != nil {
 		return err
@@ -66,6 +66,7 @@ func ptraceGetFpRegset(tid int) (fpregset []byte, err error) {
 
 // setPC sets PC to the value specified by 'pc'.
 func (thread *nativeThread) setPC(pc uint64) error {
+	thread.clearRegistersCache()
 	ir, err := registers(thread)
 	if err != nil {
 		return err
@@ -77,6 +78,7 @@ func (thread *nativeThread) setPC(pc uint64) error {
 }
 
 func (thread *nativeThread) SetReg(regNum uint64, reg *op.DwarfRegister) error {
+	thread.clearRegistersCache()
 	ir, err := registers(thread)
 	if err != nil {
 		return err
@@ -51,6 +51,7 @@ func ptraceGetFpRegset(tid int) (fpregset []byte, err error) {
 
 // SetPC sets PC to the value specified by 'pc'.
 func (t *nativeThread) setPC(pc uint64) error {
+	t.clearRegistersCache()
 	ir, err := registers(t)
 	if err != nil {
 		return err
@@ -63,6 +64,7 @@ func (t *nativeThread) setPC(pc uint64) error {
 
 // SetReg changes the value of the specified register.
 func (thread *nativeThread) SetReg(regNum uint64, reg *op.DwarfRegister) error {
+	thread.clearRegistersCache()
 	ir, err := registers(thread)
 	if err != nil {
 		return err
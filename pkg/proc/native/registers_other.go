@@ -0,0 +1,10 @@
+//go:build !linux
+
+package native
+
+import "github.com/go-delve/delve/pkg/proc"
+
+// Registers obtains register values from the debugged process.
+func (t *nativeThread) Registers() (proc.Registers, error) {
+	return registers(t)
+}
@@ -160,11 +160,6 @@ func (t *nativeThread) clearSoftwareBreakpoint(bp *proc.Breakpoint) error {
 	return nil
 }
 
-// Registers obtains register values from the debugged process.
-func (t *nativeThread) Registers() (proc.Registers, error) {
-	return registers(t)
-}
-
 // RestoreRegisters will set the value of the CPU registers to those
 // passed in via 'savedRegs'.
 func (t *nativeThread) RestoreRegisters(savedRegs proc.Registers) error {
@@ -99,7 +99,24 @@ func (t *nativeThread) ReadMemory(data []byte, addr uint64) (n int, err error) {
 }
 
 func (t *nativeThread) withDebugRegisters(f func(*amd64util.DebugRegisters) error) error {
-	return proc.ErrHWBreakUnsupported
+	var err error
+	t.dbp.execPtraceFunc(func() {
+		var debugregs [16]uint64
+		if err = ptraceGetDbRegs(t.ID, &debugregs); err != nil {
+			return
+		}
+
+		drs := amd64util.NewDebugRegisters(&debugregs[0], &debugregs[1], &debugregs[2], &debugregs[3], &debugregs[6], &debugregs[7])
+
+		err = f(drs)
+
+		if drs.Dirty {
+			if err2 := ptraceSetDbRegs(t.ID, &debugregs); err == nil {
+				err = err2
+			}
+		}
+	})
+	return err
 }
 
 // SoftExc returns true if this thread received a software exception during the last resume.
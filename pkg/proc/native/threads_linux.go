@@ -2,6 +2,8 @@ package native
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	sys "golang.org/x/sys/unix"
 
@@ -17,6 +19,46 @@ type osSpecificDetails struct {
 	running             bool
 	setbp               bool
 	phantomBreakpointPC uint64
+
+	// regs caches the registers of the thread while it is stopped.
+	regs proc.Registers
+
+	// name caches the name of the thread while it is stopped, nil if it
+	// hasn't been read since the thread last stopped.
+	name *string
+
+	// stepBreakpoints are the temporary breakpoints used to single step
+	// on architectures without hardware single stepping.
+	stepBreakpoints []stepBreakpoint
+}
+
+// stepBreakpoint is a temporary breakpoint set by singleStepResume.
+type stepBreakpoint struct {
+	addr uint64
+	orig []byte // original contents of memory at addr
+}
+
+// Registers obtains register values from the debugged process.
+// Registers are only read once every time the thread stops, single
+// stepping a large number of instructions would otherwise allocate a new
+// set of registers for every caller, on every step.
+func (t *nativeThread) Registers() (proc.Registers, error) {
+	if t.os.regs != nil {
+		return t.os.regs, nil
+	}
+	regs, err := registers(t)
+	if err != nil {
+		return nil, err
+	}
+	t.os.regs = regs
+	return regs, nil
+}
+
+// clearRegistersCache discards the registers cached by Registers, it must
+// be called every time the thread stops and every time its registers are
+// changed.
+func (t *nativeThread) clearRegistersCache() {
+	t.os.regs = nil
 }
 
 func (t *nativeThread) stop() (err error) {
@@ -53,12 +95,15 @@ func (t *nativeThread) resumeWithSig(sig int) (err error) {
 func (procgrp *processGroup) singleStep(t *nativeThread) (err error) {
 	sig := 0
 	for {
-		t.dbp.execPtraceFunc(func() { err = ptraceSingleStep(t.ID, sig) })
+		err = t.singleStepResume(sig)
 		sig = 0
 		if err != nil {
 			return err
 		}
 		wpid, status, err := t.dbp.waitFast(t.ID)
+		t.clearRegistersCache()
+		t.os.name = nil
+		t.clearSingleStepBreakpoints()
 		if err != nil {
 			return err
 		}
@@ -119,7 +164,65 @@ func (t *nativeThread) ReadMemory(data []byte, addr uint64) (n int, err error) {
 	return
 }
 
+// ReadMemoryMulti reads all requests in reqs with as few calls to
+// process_vm_readv as possible, requests that can not be read this way are
+// read one by one with ReadMemory.
+func (t *nativeThread) ReadMemoryMulti(reqs []proc.MemoryRequest) {
+	if ok, err := t.dbp.Valid(); !ok {
+		for i := range reqs {
+			reqs[i].Err = err
+		}
+		return
+	}
+	batch := make([]proc.MemoryRequest, 0, len(reqs))
+	idx := make([]int, 0, len(reqs))
+	for i := range reqs {
+		reqs[i].Err = nil
+		if len(reqs[i].Buf) > 0 {
+			batch = append(batch, reqs[i])
+			idx = append(idx, i)
+		}
+	}
+	for len(batch) > 0 {
+		done, _ := processVmReadMulti(t.ID, batch)
+		batch, idx = batch[done:], idx[done:]
+		if len(batch) == 0 {
+			break
+		}
+		// The first remaining request could not be read (or could only be
+		// read partially), fall back to reading it by itself.
+		_, reqs[idx[0]].Err = t.ReadMemory(batch[0].Buf, batch[0].Addr)
+		batch, idx = batch[1:], idx[1:]
+	}
+}
+
+// ReadMemoryConcurrently returns true, reads done with process_vm_readv
+// can happen in parallel and the ones that fall back to ptrace are
+// serialized by execPtraceFunc.
+func (t *nativeThread) ReadMemoryConcurrently() bool {
+	return true
+}
+
 // SoftExc returns true if this thread received a software exception during the last resume.
 func (t *nativeThread) SoftExc() bool {
 	return t.os.setbp
 }
+
+// Name returns the name of the thread, as set by prctl(PR_SET_NAME) or
+// pthread_setname_np. Threads that still have the name of the process
+// are considered unnamed and the empty string is returned for them.
+func (t *nativeThread) Name() string {
+	if t.os.name != nil {
+		return *t.os.name
+	}
+	name := ""
+	comm, err := os.ReadFile(fmt.Sprintf("/proc/%d/task/%d/comm", t.dbp.pid, t.ID))
+	if err == nil {
+		name = strings.TrimSuffix(string(comm), "\n")
+		if name == strings.ReplaceAll(t.dbp.os.comm, "%%", "%") {
+			name = ""
+		}
+	}
+	t.os.name = &name
+	return name
+}
@@ -12,6 +12,7 @@ import (
 )
 
 func (t *nativeThread) restoreRegisters(savedRegs proc.Registers) error {
+	t.clearRegistersCache()
 	sr := savedRegs.(*linutil.AMD64Registers)
 
 	var restoreRegistersErr error
@@ -25,6 +25,7 @@ func (thread *nativeThread) fpRegisters() ([]proc.Register, []byte, error) {
 }
 
 func (t *nativeThread) restoreRegisters(savedRegs proc.Registers) error {
+	t.clearRegistersCache()
 	sr := savedRegs.(*linutil.ARM64Registers)
 
 	var restoreRegistersErr error
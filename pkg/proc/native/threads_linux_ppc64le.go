@@ -26,6 +26,7 @@ func (t *nativeThread) fpRegisters() ([]proc.Register, []byte, error) {
 }
 
 func (t *nativeThread) restoreRegisters(savedRegs proc.Registers) error {
+	t.clearRegistersCache()
 	sr := savedRegs.(*linutil.PPC64LERegisters)
 
 	var restoreRegistersErr error
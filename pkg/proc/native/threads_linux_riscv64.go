@@ -0,0 +1,100 @@
+package native
+
+import (
+	"debug/elf"
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	sys "golang.org/x/sys/unix"
+
+	"github.com/go-delve/delve/pkg/proc"
+	"github.com/go-delve/delve/pkg/proc/linutil"
+)
+
+func (t *nativeThread) fpRegisters() ([]proc.Register, []byte, error) {
+	var regs []proc.Register
+	var fpregs linutil.RISCV64PtraceFpRegs
+	var err error
+
+	t.dbp.execPtraceFunc(func() { fpregs.Fregs, err = ptraceGetFpRegset(t.ID) })
+	regs = fpregs.Decode()
+	if err != nil {
+		err = fmt.Errorf("could not get floating point registers: %v", err.Error())
+	}
+	return regs, fpregs.Fregs, err
+}
+
+func (t *nativeThread) restoreRegisters(savedRegs proc.Registers) error {
+	t.clearRegistersCache()
+	sr := savedRegs.(*linutil.RISCV64Registers)
+
+	var restoreRegistersErr error
+	t.dbp.execPtraceFunc(func() {
+		restoreRegistersErr = ptraceSetGRegs(t.ID, sr.Regs)
+		if restoreRegistersErr != syscall.Errno(0) && restoreRegistersErr != nil {
+			return
+		}
+		if sr.Fpregset != nil {
+			iov := sys.Iovec{Base: &sr.Fpregset[0], Len: uint64(len(sr.Fpregset))}
+			_, _, restoreRegistersErr = syscall.Syscall6(syscall.SYS_PTRACE, sys.PTRACE_SETREGSET, uintptr(t.ID), uintptr(elf.NT_FPREGSET), uintptr(unsafe.Pointer(&iov)), 0, 0)
+		}
+	})
+	if restoreRegistersErr == syscall.Errno(0) {
+		restoreRegistersErr = nil
+	}
+	return restoreRegistersErr
+}
+
+// singleStepResume resumes the thread so that it executes a single
+// instruction. Linux does not implement PTRACE_SINGLESTEP on riscv64, the
+// step is emulated by placing temporary breakpoints on every instruction
+// that can follow the current one.
+func (t *nativeThread) singleStepResume(sig int) error {
+	regs, err := t.Registers()
+	if err != nil {
+		return err
+	}
+	pc := regs.PC()
+	insts, err := proc.Disassemble(t, regs, t.dbp.Breakpoints(), t.BinInfo(), pc, pc+uint64(t.BinInfo().Arch.MaxInstructionLength()))
+	if err != nil {
+		return err
+	}
+	if len(insts) == 0 {
+		return errors.New("could not decode current instruction")
+	}
+	next := []uint64{pc + uint64(insts[0].Size)}
+	if insts[0].DestLoc != nil && insts[0].DestLoc.PC != next[0] {
+		next = append(next, insts[0].DestLoc.PC)
+	}
+
+	bpinstr := t.BinInfo().Arch.BreakpointInstruction()
+	for _, addr := range next {
+		orig := make([]byte, len(bpinstr))
+		if _, err := t.ReadMemory(orig, addr); err != nil {
+			t.clearSingleStepBreakpoints()
+			return err
+		}
+		if _, err := t.WriteMemory(addr, bpinstr); err != nil {
+			t.clearSingleStepBreakpoints()
+			return err
+		}
+		t.os.stepBreakpoints = append(t.os.stepBreakpoints, stepBreakpoint{addr: addr, orig: orig})
+	}
+
+	t.dbp.execPtraceFunc(func() { err = ptraceCont(t.ID, sig) })
+	if err != nil {
+		t.clearSingleStepBreakpoints()
+	}
+	return err
+}
+
+// clearSingleStepBreakpoints removes the temporary breakpoints set by
+// singleStepResume.
+func (t *nativeThread) clearSingleStepBreakpoints() {
+	for _, bp := range t.os.stepBreakpoints {
+		t.WriteMemory(bp.addr, bp.orig)
+	}
+	t.os.stepBreakpoints = t.os.stepBreakpoints[:0]
+}
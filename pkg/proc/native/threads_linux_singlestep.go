@@ -0,0 +1,15 @@
+//go:build linux && !riscv64
+
+package native
+
+// singleStepResume resumes the thread so that it executes a single
+// instruction.
+func (t *nativeThread) singleStepResume(sig int) (err error) {
+	t.dbp.execPtraceFunc(func() { err = ptraceSingleStep(t.ID, sig) })
+	return
+}
+
+// clearSingleStepBreakpoints is a no-op, the hardware single steps the
+// thread without the help of temporary breakpoints.
+func (t *nativeThread) clearSingleStepBreakpoints() {
+}
@@ -3,10 +3,12 @@ package proc
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"testing"
 	"unsafe"
 
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
 	protest "github.com/go-delve/delve/pkg/proc/test"
 )
 
@@ -190,3 +192,54 @@ func TestGenericFunctionParser(t *testing.T) {
 		}
 	}
 }
+
+func TestSubstTypeArgs(t *testing.T) {
+	shapeInt := &godwarf.IntType{BasicType: godwarf.BasicType{CommonType: godwarf.CommonType{Name: "go.shape.int", ByteSize: 8, ReflectKind: reflect.Int}}}
+	shapeInt64 := &godwarf.IntType{BasicType: godwarf.BasicType{CommonType: godwarf.CommonType{Name: "go.shape.int64", ByteSize: 8, ReflectKind: reflect.Int64}}}
+	myint := &godwarf.TypedefType{CommonType: godwarf.CommonType{Name: "main.myint", ByteSize: 8, ReflectKind: reflect.Int}, Type: shapeInt}
+
+	items := &godwarf.SliceType{ElemType: shapeInt}
+	items.Name = "[]go.shape.int"
+	stack := &godwarf.StructType{CommonType: godwarf.CommonType{Name: "main.Stack[go.shape.int,go.shape.int64]"}, StructName: "main.Stack[go.shape.int,go.shape.int64]", Kind: "struct"}
+	stackPtr := &godwarf.PtrType{CommonType: godwarf.CommonType{Name: "*main.Stack[go.shape.int,go.shape.int64]"}, Type: stack}
+	stack.Field = []*godwarf.StructField{
+		{Name: "items", Type: items},
+		{Name: "n", Type: shapeInt64},
+		{Name: "next", Type: stackPtr},
+	}
+
+	typeArgs := map[string]godwarf.Type{"go.shape.int": myint}
+	r := substTypeArgs(stackPtr, typeArgs).(*godwarf.PtrType)
+	if r.Name != "*main.Stack[main.myint,go.shape.int64]" {
+		t.Errorf("wrong pointer type name %q", r.Name)
+	}
+	rstack := r.Type.(*godwarf.StructType)
+	if rstack.Name != "main.Stack[main.myint,go.shape.int64]" {
+		t.Errorf("wrong struct type name %q", rstack.Name)
+	}
+	if ritems := rstack.Field[0].Type.(*godwarf.SliceType); ritems.Name != "[]main.myint" || ritems.ElemType != myint {
+		t.Errorf("wrong slice type %q", ritems.Name)
+	}
+	if rstack.Field[1].Type != shapeInt64 {
+		t.Errorf("unexpected substitution of %q", rstack.Field[1].Type.Common().Name)
+	}
+	if rstack.Field[2].Type != r {
+		t.Errorf("recursive type not substituted")
+	}
+	if stack.Name != "main.Stack[go.shape.int,go.shape.int64]" || items.Name != "[]go.shape.int" {
+		t.Errorf("original types modified")
+	}
+
+	for _, tc := range []struct {
+		in  string
+		out []string
+	}{
+		{"go.shape.int", []string{"go.shape.int"}},
+		{"go.shape.int,go.shape.string", []string{"go.shape.int", "go.shape.string"}},
+		{"go.shape.func(int, string),go.shape.map[int]int", []string{"go.shape.func(int, string)", "go.shape.map[int]int"}},
+	} {
+		if out := splitTypeParams(tc.in); !reflect.DeepEqual(out, tc.out) {
+			t.Errorf("splitTypeParams(%q) = %q, expected %q", tc.in, out, tc.out)
+		}
+	}
+}
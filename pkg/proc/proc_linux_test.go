@@ -77,6 +77,63 @@ func TestGnuDebuglink(t *testing.T) {
 	}
 }
 
+func TestGnuDebuglinkGlobalDebugDir(t *testing.T) {
+	mustHaveObjcopy(t)
+	// The debug info directory is the .build-id subdirectory of the global
+	// debug directory, where the debug link is looked up.
+	fixture := protest.BuildFixture("math", 0)
+	buf, err := os.ReadFile(fixture.Path)
+	assertNoError(err, t, "ReadFile")
+	tmpdir := t.TempDir()
+	exePath := filepath.Join(tmpdir, "bin", "math")
+	assertNoError(os.MkdirAll(filepath.Dir(exePath), 0o755), t, "MkdirAll")
+	assertNoError(os.WriteFile(exePath, buf, 0o755), t, "WriteFile")
+
+	run := func(exe string, args ...string) {
+		cmd := exec.Command(exe, args...)
+		out, err := cmd.CombinedOutput()
+		assertNoError(err, t, fmt.Sprintf("%s %q: %s", cmd, strings.Join(args, " "), out))
+	}
+
+	debugDir := filepath.Join(tmpdir, "debug")
+	dwoPath := filepath.Join(debugDir, filepath.Dir(exePath)[1:], "math.dwo")
+	assertNoError(os.MkdirAll(filepath.Dir(dwoPath), 0o755), t, "MkdirAll")
+	run("objcopy", "--only-keep-debug", exePath, dwoPath)
+	run("objcopy", "--strip-debug", exePath)
+	run("objcopy", "--add-gnu-debuglink="+dwoPath, exePath)
+
+	bi := proc.NewBinaryInfo(runtime.GOOS, runtime.GOARCH)
+	assertNoError(bi.LoadBinaryInfo(exePath, 0, []string{filepath.Join(debugDir, ".build-id")}), t, "LoadBinaryInfo")
+	if len(bi.LookupFunc()["main.main"]) == 0 {
+		t.Fatalf("main.main not found")
+	}
+}
+
+func TestCompressedDebugSections(t *testing.T) {
+	mustHaveObjcopy(t)
+	fixture := protest.BuildFixture("math", 0)
+	for _, compression := range []string{"zlib-gnu", "zlib-gabi", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			// zlib-gnu renames the debug sections to .zdebug_*, the others
+			// mark them as SHF_COMPRESSED.
+			exePath := filepath.Join(t.TempDir(), "math")
+			out, err := exec.Command("objcopy", "--compress-debug-sections="+compression, fixture.Path, exePath).CombinedOutput()
+			if err != nil {
+				t.Skipf("objcopy does not support %s compression: %v %s", compression, err, out)
+			}
+			bi := proc.NewBinaryInfo(runtime.GOOS, runtime.GOARCH)
+			assertNoError(bi.LoadBinaryInfo(exePath, 0, nil), t, "LoadBinaryInfo")
+			fns := bi.LookupFunc()["main.main"]
+			if len(fns) == 0 {
+				t.Fatalf("main.main not found")
+			}
+			if file, _ := bi.EntryLineForFunc(fns[0]); file != fixture.Source {
+				t.Fatalf("wrong file for main.main: %q", file)
+			}
+		})
+	}
+}
+
 func stripAndCopyDebugInfo(f protest.Fixture, t *testing.T) {
 	name := filepath.Base(f.Path)
 	// Copy the debug information to an external file.
@@ -93,3 +150,54 @@ func stripAndCopyDebugInfo(f protest.Fixture, t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestSingleStepRegistersCache(t *testing.T) {
+	if testBackend != "native" {
+		t.Skip("registers are only cached by the native backend")
+	}
+	withTestProcess("loopprog", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.loop")
+		assertNoError(grp.Continue(), t, "Continue()")
+
+		thread := p.CurrentThread()
+		regs1, err := thread.Registers()
+		assertNoError(err, t, "Registers()")
+		regs2, err := thread.Registers()
+		assertNoError(err, t, "Registers()")
+		if regs1 != regs2 {
+			t.Errorf("registers read twice while the thread was stopped")
+		}
+
+		// Registers read before the thread is resumed must not be returned
+		// after it stops again.
+		pc := regs1.PC()
+		assertNoError(grp.StepInstruction(false), t, "StepInstruction()")
+		regs3, err := thread.Registers()
+		assertNoError(err, t, "Registers()")
+		if regs3.PC() == pc {
+			t.Errorf("stale registers after StepInstruction %#x", pc)
+		}
+		if regs1.PC() != pc {
+			t.Errorf("registers changed after StepInstruction")
+		}
+
+		// Stepping a large number of instructions must not grow memory.
+		heapInUse := func() uint64 {
+			runtime.GC()
+			var ms runtime.MemStats
+			runtime.ReadMemStats(&ms)
+			return ms.HeapInuse
+		}
+		const steps = 10000
+		var before uint64
+		for i := 0; i < steps; i++ {
+			if i == steps/10 {
+				before = heapInUse()
+			}
+			assertNoError(grp.StepInstruction(false), t, "StepInstruction()")
+		}
+		if after := heapInUse(); after > before+4*1024*1024 {
+			t.Errorf("memory grew while single stepping: %d -> %d", before, after)
+		}
+	})
+}
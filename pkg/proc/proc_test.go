@@ -2,6 +2,7 @@ package proc_test
 
 import (
 	"bytes"
+	"debug/macho"
 	"encoding/binary"
 	"errors"
 	"flag"
@@ -352,6 +353,27 @@ func TestStepInstruction(t *testing.T) {
 	})
 }
 
+func TestStepInstructionTwice(t *testing.T) {
+	// Registers read before a step must not be returned after the thread
+	// stops again, in particular on architectures where single stepping is
+	// emulated by reading the current instruction.
+	protest.AllowRecording(t)
+	withTestProcess("testprog", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.helloworld")
+		assertNoError(grp.Continue(), t, "Continue()")
+
+		pc := getRegisters(p, t).PC()
+		for i := 0; i < 2; i++ {
+			assertNoError(grp.StepInstruction(false), t, "StepInstruction()")
+			newpc := getRegisters(p, t).PC()
+			if newpc == pc {
+				t.Fatalf("PC did not change after StepInstruction %d: %#x", i, pc)
+			}
+			pc = newpc
+		}
+	})
+}
+
 func TestNextInstruction(t *testing.T) {
 	protest.AllowRecording(t)
 	withTestProcess("testprog", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
@@ -1115,6 +1137,38 @@ func TestStacktraceGoroutine(t *testing.T) {
 	})
 }
 
+func TestGoroutinesStacktraces(t *testing.T) {
+	// Stacktraces computed in parallel must be the same as the ones computed
+	// one goroutine at a time.
+	protest.AllowRecording(t)
+	withTestProcess("goroutinestackprog", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.stacktraceme")
+		assertNoError(grp.Continue(), t, "Continue()")
+
+		gs, _, err := proc.GoroutinesInfo(p, 0, 0)
+		assertNoError(err, t, "GoroutinesInfo")
+
+		stacks, errs := proc.GoroutinesStacktraces(p, gs, 40, 0)
+		if len(stacks) != len(gs) || len(errs) != len(gs) {
+			t.Fatalf("wrong number of stacktraces %d %d (expected %d)", len(stacks), len(errs), len(gs))
+		}
+		for i, g := range gs {
+			locations, err := proc.GoroutineStacktrace(p, g, 40, 0)
+			if (err == nil) != (errs[i] == nil) {
+				t.Fatalf("goroutine %d: mismatched errors %v %v", g.ID, err, errs[i])
+			}
+			if len(locations) != len(stacks[i]) {
+				t.Fatalf("goroutine %d: mismatched stack lengths %d %d", g.ID, len(locations), len(stacks[i]))
+			}
+			for j := range locations {
+				if locations[j].Current.PC != stacks[i][j].Current.PC || locations[j].Regs.CFA != stacks[i][j].Regs.CFA {
+					t.Fatalf("goroutine %d: mismatched frame %d", g.ID, j)
+				}
+			}
+		}
+	})
+}
+
 func TestKill(t *testing.T) {
 	withTestProcess("testprog", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
 		if err := grp.Detach(true); err != nil {
@@ -1815,6 +1869,35 @@ func TestCondBreakpoint(t *testing.T) {
 	})
 }
 
+func TestCondBreakpointChanged(t *testing.T) {
+	// The compiled form of a condition is reused every time the breakpoint
+	// is hit, until the condition is changed.
+	protest.AllowRecording(t)
+	withTestProcess("loopprog", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		bp := setFileBreakpoint(p, t, fixture.Source, 9)
+		assertI := func(tgt int64) {
+			t.Helper()
+			if n, _ := constant.Int64Val(evalVariable(p, t, "i").Value); n != tgt {
+				t.Fatalf("wrong value of i: got %d expected %d", n, tgt)
+			}
+		}
+
+		cond, err := parser.ParseExpr("int64(i) % 3 == 0")
+		assertNoError(err, t, "ParseExpr")
+		bp.UserBreaklet().Cond = cond
+		for _, tgt := range []int64{3, 6, 9} {
+			assertNoError(grp.Continue(), t, "Continue()")
+			assertI(tgt)
+		}
+
+		cond, err = parser.ParseExpr("i == 20")
+		assertNoError(err, t, "ParseExpr")
+		bp.UserBreaklet().Cond = cond
+		assertNoError(grp.Continue(), t, "Continue()")
+		assertI(20)
+	})
+}
+
 func TestCondBreakpointWithFrame(t *testing.T) {
 	protest.AllowRecording(t)
 	withTestProcess("condframe", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
@@ -2745,6 +2828,32 @@ func TestStepOutDeferReturnAndDirectCall(t *testing.T) {
 		{contStepout, 28}})
 }
 
+func TestStepIntoNamedFunction(t *testing.T) {
+	withTestProcess("stepintofunc", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		setFileBreakpoint(p, t, fixture.Source, 13)
+		setFileBreakpoint(p, t, fixture.Source, 15)
+		assertNoError(grp.Continue(), t, "Continue()")
+		assertLineNumber(p, t, 13, "Continue()")
+
+		// h is called through a function pointer
+		assertNoError(grp.StepIntoFunction("h"), t, "StepIntoFunction(h)")
+		assertFunctionName(p, t, "main.h", "StepIntoFunction(h)")
+		assertNoError(grp.StepOut(), t, "StepOut()")
+		assertLineNumber(p, t, 13, "StepOut()")
+
+		assertNoError(grp.StepIntoFunction("main.f"), t, "StepIntoFunction(main.f)")
+		assertFunctionName(p, t, "main.f", "StepIntoFunction(main.f)")
+
+		assertNoError(grp.Continue(), t, "Continue()")
+		assertLineNumber(p, t, 15, "Continue()")
+		if err := grp.StepIntoFunction("main.nonexistent"); err == nil {
+			t.Fatal("StepIntoFunction(main.nonexistent) did not return an error")
+		}
+		assertNoError(grp.StepIntoFunction("h"), t, "StepIntoFunction(h)")
+		assertFunctionName(p, t, "main.h", "StepIntoFunction(h)")
+	})
+}
+
 func TestStepOnCallPtrInstr(t *testing.T) {
 	protest.AllowRecording(t)
 	withTestProcess("teststepprog", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
@@ -3831,6 +3940,53 @@ func TestDisassembleGlobalVars(t *testing.T) {
 	})
 }
 
+func TestDisassembleCallAnnotation(t *testing.T) {
+	if runtime.GOARCH != "amd64" {
+		t.Skip("call destination annotations only tested on amd64")
+	}
+	withTestProcess("locationsprog2", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		assertNoError(grp.Continue(), t, "Continue()")
+		mainfn := p.BinInfo().LookupFunc()["main.main"][0]
+
+		disassemble := func() []proc.AsmInstruction {
+			regs, err := p.CurrentThread().Registers()
+			assertNoError(err, t, "Registers()")
+			text, err := proc.Disassemble(p.Memory(), regs, p.Breakpoints(), p.BinInfo(), mainfn.Entry, mainfn.End)
+			assertNoError(err, t, "Disassemble")
+			return text
+		}
+
+		// The static call to afunction on line 29 already names its destination.
+		for _, inst := range disassemble() {
+			if inst.Loc.Line == 29 && inst.IsCall() {
+				text := inst.Text(proc.IntelFlavour, p.BinInfo())
+				t.Logf("%#x %s", inst.Loc.PC, text)
+				if strings.Contains(text, "<") {
+					t.Errorf("static call annotated: %q", text)
+				}
+			}
+		}
+
+		// The indirect call through fn1 on line 28 is annotated when the
+		// destination can be computed.
+		for i := 0; i < 50; i++ {
+			for _, inst := range disassemble() {
+				if !inst.AtPC || !inst.IsCall() {
+					continue
+				}
+				text := inst.Text(proc.IntelFlavour, p.BinInfo())
+				t.Logf("%#x %s", inst.Loc.PC, text)
+				if !strings.HasSuffix(text, " <main.afunction>") {
+					t.Fatalf("indirect call not annotated: %q", text)
+				}
+				return
+			}
+			assertNoError(grp.StepInstruction(false), t, "StepInstruction()")
+		}
+		t.Fatal("indirect call not found")
+	})
+}
+
 func checkFrame(frame proc.Stackframe, fnname, file string, line int, inlined bool) error {
 	if frame.Call.Fn == nil || frame.Call.Fn.Name != fnname {
 		return fmt.Errorf("wrong function name: %s", fnname)
@@ -4681,6 +4837,42 @@ func TestPluginStepping(t *testing.T) {
 		{contNext, "plugintest2.go:42"}})
 }
 
+func TestPluginGenericBreakpoint(t *testing.T) {
+	// Tests that a breakpoint inside a generic function is also set on the
+	// instantiations contained in plugins loaded after it was created.
+	protest.MustHaveCgo(t)
+	if !goversion.VersionAfterOrEqual(runtime.Version(), 1, 18) {
+		t.Skip("generics not supported")
+	}
+	pluginFixtures := protest.WithPlugins(t, protest.AllNonOptimized, "plugin1/", "plugin2/")
+
+	withTestProcessArgs("plugintest2", t, ".", []string{pluginFixtures[0].Path, pluginFixtures[1].Path}, protest.AllNonOptimized, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		countAddrs := func(id int) int {
+			n := 0
+			for _, bp := range p.Breakpoints().M {
+				if bp.LogicalID() == id {
+					n++
+				}
+			}
+			return n
+		}
+
+		maxFile := filepath.Join(filepath.Dir(fixture.Source), "internal", "pluginsupport", "pluginsupport.go")
+		grp.LogicalBreakpoints[1] = &proc.LogicalBreakpoint{LogicalID: 1, Set: proc.SetBreakpoint{File: maxFile, Line: 12}, HitCount: make(map[int64]uint64)}
+		assertNoError(grp.EnableBreakpoint(grp.LogicalBreakpoints[1]), t, "EnableBreakpoint(pluginsupport.go:12)")
+		if n := countAddrs(1); n != 1 {
+			t.Fatalf("wrong number of physical breakpoints before loading plugins: %d", n)
+		}
+
+		setFileBreakpoint(p, t, fixture.Source, 42)
+		assertNoError(grp.Continue(), t, "Continue")
+		assertLineNumber(p, t, 42, "")
+		if n := countAddrs(1); n != 2 {
+			t.Fatalf("wrong number of physical breakpoints after loading plugins: %d", n)
+		}
+	})
+}
+
 func TestIssue1601(t *testing.T) {
 	protest.MustHaveCgo(t)
 	// Tests that recursive types involving C qualifiers and typedefs are parsed correctly
@@ -5446,7 +5638,6 @@ func TestVariablesWithExternalLinking(t *testing.T) {
 }
 
 func TestWatchpointsBasic(t *testing.T) {
-	skipOn(t, "not implemented", "freebsd")
 	skipOn(t, "not implemented", "386")
 	skipOn(t, "not implemented", "ppc64le")
 	skipOn(t, "see https://github.com/go-delve/delve/issues/2768", "windows")
@@ -5501,7 +5692,6 @@ func TestWatchpointsBasic(t *testing.T) {
 }
 
 func TestWatchpointCounts(t *testing.T) {
-	skipOn(t, "not implemented", "freebsd")
 	skipOn(t, "not implemented", "386")
 	skipOn(t, "see https://github.com/go-delve/delve/issues/2768", "windows")
 	skipOn(t, "not implemented", "ppc64le")
@@ -5620,7 +5810,6 @@ func TestDwrapStartLocation(t *testing.T) {
 }
 
 func TestWatchpointStack(t *testing.T) {
-	skipOn(t, "not implemented", "freebsd")
 	skipOn(t, "not implemented", "386")
 	skipOn(t, "not implemented", "ppc64le")
 	skipOn(t, "see https://github.com/go-delve/delve/issues/2768", "windows")
@@ -7344,7 +7533,6 @@ func TestRangeOverFuncNextInlined(t *testing.T) {
 }
 
 func TestStackwatchClearBug(t *testing.T) {
-	skipOn(t, "not implemented", "freebsd")
 	skipOn(t, "not implemented", "386")
 	skipOn(t, "not implemented", "ppc64le")
 	skipOn(t, "see https://github.com/go-delve/delve/issues/2768", "windows")
@@ -7390,3 +7578,441 @@ func TestStackwatchClearBug(t *testing.T) {
 		}
 	})
 }
+
+func TestGetScheduler(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("goroutinestackprog", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		setFunctionBreakpoint(p, t, "main.stacktraceme")
+		assertNoError(grp.Continue(), t, "Continue()")
+
+		sched, err := proc.GetScheduler(p)
+		assertNoError(err, t, "GetScheduler()")
+		if len(sched.Ms) == 0 || len(sched.Ps) == 0 {
+			t.Fatalf("expected at least one M and one P, got %#v", sched)
+		}
+		if int64(len(sched.Ps)) != sched.Gomaxprocs {
+			t.Errorf("expected %d Ps got %d", sched.Gomaxprocs, len(sched.Ps))
+		}
+
+		g, err := proc.GetG(p.CurrentThread())
+		assertNoError(err, t, "GetG()")
+
+		// The M running the current goroutine must be bound to a running P
+		// that points back to it.
+		found := false
+		for _, m := range sched.Ms {
+			if m.CurG != g.ID {
+				continue
+			}
+			found = true
+			if m.ThreadID != p.CurrentThread().ThreadID() {
+				t.Errorf("M %d running goroutine %d has thread %d, expected %d", m.ID, g.ID, m.ThreadID, p.CurrentThread().ThreadID())
+			}
+			for _, pp := range sched.Ps {
+				if pp.ID == m.PID {
+					if pp.MID != m.ID || pp.Status != proc.Prunning {
+						t.Errorf("P %d bound to M %d: %#v", pp.ID, m.ID, pp)
+					}
+				}
+			}
+		}
+		if !found {
+			t.Errorf("could not find M running goroutine %d: %#v", g.ID, sched.Ms)
+		}
+	})
+}
+
+func TestGetGCInfo(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("gcinfo", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		assertNoError(grp.Continue(), t, "Continue()")
+
+		gci, err := proc.GetGCInfo(p)
+		assertNoError(err, t, "GetGCInfo()")
+		t.Logf("%#v", gci)
+
+		numGC, _ := constant.Int64Val(evalVariable(p, t, "ms.NumGC").Value)
+		if gci.NumGC != uint64(numGC) {
+			t.Errorf("NumGC mismatch: %d, ReadMemStats returned %d", gci.NumGC, numGC)
+		}
+		if gci.NumForcedGC < 2 {
+			t.Errorf("expected at least two forced GC cycles, got %d", gci.NumForcedGC)
+		}
+		if gci.Phase != proc.GCoff {
+			t.Errorf("unexpected GC phase %s", gci.PhaseString())
+		}
+		if gci.LastGC.IsZero() {
+			t.Errorf("LastGC not set")
+		}
+		if gci.HeapInuse == 0 || gci.NextGC == 0 {
+			t.Errorf("heap statistics not loaded")
+		}
+	})
+}
+
+func TestChanBuffer(t *testing.T) {
+	protest.AllowRecording(t)
+	withTestProcess("chanbuf", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		assertNoError(grp.Continue(), t, "Continue()")
+
+		scope, err := proc.GoroutineScope(p, p.CurrentThread())
+		assertNoError(err, t, "GoroutineScope()")
+
+		for _, tc := range []struct {
+			expr string
+			cfg  proc.LoadConfig
+			tgt  string
+		}{
+			{"ch", pnormalLoadConfig, "[3]int [3,4,5]"},
+			{"ch", proc.LoadConfig{MaxArrayValues: 2}, "[3]int [3,4,...+1 more]"},
+			{"itemch", pnormalLoadConfig, `[1]main.item [{n: 1, name: "one"}]`},
+			{"nilch", pnormalLoadConfig, "[0]int []"},
+			{"unbuffered", pnormalLoadConfig, "[0]int []"},
+		} {
+			v, err := scope.ChanBuffer(tc.expr, tc.cfg)
+			assertNoError(err, t, fmt.Sprintf("ChanBuffer(%q)", tc.expr))
+			if out := api.ConvertVar(v).SinglelineString(); out != tc.tgt {
+				t.Errorf("ChanBuffer(%q): got %q expected %q", tc.expr, out, tc.tgt)
+			}
+		}
+
+		if _, err := scope.ChanBuffer("len(ch)", pnormalLoadConfig); err == nil {
+			t.Errorf("ChanBuffer of a non-channel expression did not return an error")
+		}
+	})
+}
+
+func TestStepBack(t *testing.T) {
+	if testBackend != "native" || runtime.GOOS != "linux" {
+		t.Skip("state recording is only supported by the native backend on linux")
+	}
+	var buildFlags protest.BuildFlags
+	if buildMode == "pie" {
+		buildFlags |= protest.BuildModePIE
+	}
+	fixture := protest.BuildFixture("loopprog", buildFlags)
+	grp, err := native.Launch([]string{fixture.Path}, ".", proc.LaunchRecordState, []string{}, "", "", proc.OutputRedirect{}, proc.OutputRedirect{})
+	assertNoError(err, t, "Launch")
+	defer grp.Detach(true)
+	p := grp.Selected
+
+	assertI := func(tgt int64) {
+		t.Helper()
+		if n, _ := constant.Int64Val(evalVariable(p, t, "i").Value); n != tgt {
+			t.Fatalf("wrong value of i: got %d expected %d", n, tgt)
+		}
+	}
+
+	setFileBreakpoint(p, t, fixture.Source, 8)
+	for i := 0; i < 3; i++ {
+		assertNoError(grp.Continue(), t, "Continue")
+	}
+	assertI(2)
+
+	assertNoError(grp.StepBack(), t, "StepBack")
+	assertLineNumber(p, t, 8, "after StepBack")
+	assertI(1)
+
+	assertNoError(grp.StepBack(), t, "StepBack")
+	assertI(0)
+
+	assertNoError(grp.Continue(), t, "Continue")
+	assertLineNumber(p, t, 8, "after Continue")
+	assertI(1)
+
+	// Only the state at the start of next must be recorded, not the states
+	// of the internal steps it uses.
+	assertNoError(grp.Next(), t, "Next")
+	assertLineNumber(p, t, 9, "after Next")
+	assertNoError(grp.StepBack(), t, "StepBack")
+	assertLineNumber(p, t, 8, "after StepBack")
+	assertI(1)
+	assertNoError(grp.StepBack(), t, "StepBack")
+	assertI(0)
+
+	for {
+		err := grp.StepBack()
+		if err == proc.ErrNoRecordedState {
+			break
+		}
+		assertNoError(err, t, "StepBack")
+	}
+}
+
+func TestLoadDSYM(t *testing.T) {
+	// Builds a macOS executable without debug info and a .dSYM bundle
+	// containing the debug info of an identical executable.
+	fixturesDir := protest.FindFixturesDir()
+	tmpdir := t.TempDir()
+	exePath := filepath.Join(tmpdir, "math")
+	dwarfPath := filepath.Join(tmpdir, "math.dSYM", "Contents", "Resources", "DWARF", "math")
+	assertNoError(os.MkdirAll(filepath.Dir(dwarfPath), 0o755), t, "MkdirAll")
+
+	build := func(outfile string, ldflags string) {
+		cmd := exec.Command("go", "build", "-gcflags=all=-N -l", "-ldflags="+ldflags, "-o", outfile, filepath.Join(fixturesDir, "math.go"))
+		cmd.Env = append(os.Environ(), "GOOS=darwin", "GOARCH=arm64", "CGO_ENABLED=0")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("go build failed: %v: %s", err, out)
+		}
+	}
+	build(dwarfPath, "")
+	build(exePath, "-w")
+
+	uuid := func(path string) []byte {
+		f, err := macho.Open(path)
+		assertNoError(err, t, "macho.Open")
+		defer f.Close()
+		for _, ld := range f.Loads {
+			if raw := ld.Raw(); len(raw) >= 24 && f.ByteOrder.Uint32(raw) == 0x1b {
+				return append([]byte(nil), raw[8:24]...)
+			}
+		}
+		t.Skipf("no UUID in %s", path)
+		return nil
+	}
+
+	// Without debug info the sources and functions are read from the Go
+	// symbol table, only types come exclusively from the debug info.
+	hasDebugInfo := func(bi *proc.BinaryInfo) bool {
+		types, _ := bi.Types()
+		return len(types) != 0
+	}
+
+	setUUID := func(u []byte) {
+		buf, err := os.ReadFile(exePath)
+		assertNoError(err, t, "ReadFile")
+		buf = bytes.Replace(buf, uuid(exePath), u, 1)
+		assertNoError(os.WriteFile(exePath, buf, 0o755), t, "WriteFile")
+	}
+
+	// The debug info doesn't match the executable until they have the same
+	// UUID. Depending on the version of Go the two builds could already have
+	// the same UUID, change it to make sure it's different.
+	wrongUUID := uuid(dwarfPath)
+	for i := range wrongUUID {
+		wrongUUID[i] ^= 0xff
+	}
+	setUUID(wrongUUID)
+	bi := proc.NewBinaryInfo("darwin", "arm64")
+	if err := bi.LoadBinaryInfo(exePath, 0, nil); err == nil && hasDebugInfo(bi) {
+		t.Fatalf("debug info loaded from a .dSYM bundle with the wrong UUID")
+	}
+
+	setUUID(uuid(dwarfPath))
+
+	for _, dirs := range [][]string{nil, {filepath.Join(tmpdir, "math.dSYM")}} {
+		if dirs != nil {
+			// move the bundle so that it can only be found through the debug info directories
+			moved := filepath.Join(tmpdir, "elsewhere", "math.dSYM")
+			assertNoError(os.MkdirAll(filepath.Dir(moved), 0o755), t, "MkdirAll")
+			assertNoError(os.Rename(dirs[0], moved), t, "Rename")
+			dirs[0] = moved
+		}
+		bi := proc.NewBinaryInfo("darwin", "arm64")
+		assertNoError(bi.LoadBinaryInfo(exePath, 0, dirs), t, "LoadBinaryInfo")
+		if !hasDebugInfo(bi) || len(bi.LookupFunc()["main.main"]) == 0 {
+			t.Fatalf("debug info not loaded from .dSYM bundle (directories %q)", dirs)
+		}
+	}
+}
+
+func TestDwarfCache(t *testing.T) {
+	proc.DwarfCacheDir = t.TempDir()
+	defer func() { proc.DwarfCacheDir = "" }()
+
+	fixture := protest.BuildFixture("consts", 0)
+	load := func() *proc.BinaryInfo {
+		bi := proc.NewBinaryInfo(runtime.GOOS, runtime.GOARCH)
+		assertNoError(bi.LoadBinaryInfo(fixture.Path, 0, nil), t, "LoadBinaryInfo")
+		return bi
+	}
+	bi1 := load()
+	entries, _ := filepath.Glob(filepath.Join(proc.DwarfCacheDir, "*.gob"))
+	if len(entries) != 1 {
+		t.Fatalf("expected one cache entry, got %v", entries)
+	}
+	bi2 := load()
+
+	if len(bi1.Functions) != len(bi2.Functions) {
+		t.Fatalf("function count mismatch: %d %d", len(bi1.Functions), len(bi2.Functions))
+	}
+	for i := range bi1.Functions {
+		fn1, fn2 := &bi1.Functions[i], &bi2.Functions[i]
+		if fn1.Name != fn2.Name || fn1.Entry != fn2.Entry || fn1.End != fn2.End || len(fn1.InlinedCalls) != len(fn2.InlinedCalls) {
+			t.Fatalf("function mismatch: %#v %#v", fn1, fn2)
+		}
+		for j := range fn1.InlinedCalls {
+			if fn1.InlinedCalls[j].LowPC != fn2.InlinedCalls[j].LowPC || fn1.InlinedCalls[j].HighPC != fn2.InlinedCalls[j].HighPC {
+				t.Fatalf("inlined calls mismatch for %s", fn1.Name)
+			}
+		}
+		file1, line1, _ := bi1.PCToLine(fn1.Entry)
+		file2, line2, _ := bi2.PCToLine(fn2.Entry)
+		if file1 != file2 || line1 != line2 {
+			t.Fatalf("line table mismatch for %s: %s:%d %s:%d", fn1.Name, file1, line1, file2, line2)
+		}
+	}
+	if !reflect.DeepEqual(bi1.Sources, bi2.Sources) {
+		t.Errorf("sources mismatch")
+	}
+	if !reflect.DeepEqual(bi1.PackageMap, bi2.PackageMap) {
+		t.Errorf("package map mismatch")
+	}
+	types1, _ := bi1.Types()
+	types2, _ := bi2.Types()
+	sort.Strings(types1)
+	sort.Strings(types2)
+	if !reflect.DeepEqual(types1, types2) {
+		t.Errorf("types mismatch")
+	}
+
+	// The cached addresses must be relocated when the executable is loaded at
+	// a different address.
+	var buildFlags protest.BuildFlags
+	if runtime.GOOS == "linux" {
+		buildFlags |= protest.BuildModePIE
+	}
+	for i := 0; i < 2; i++ {
+		withTestProcessArgs("consts", t, ".", []string{}, buildFlags, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+			setFunctionBreakpoint(p, t, "main.main")
+			assertNoError(grp.Continue(), t, "Continue")
+			if fn := p.BinInfo().PCToFunc(currentPC(p, t)); fn == nil || fn.Name != "main.main" {
+				t.Fatalf("wrong function %v", fn)
+			}
+			assertNoError(grp.Continue(), t, "Continue")
+			v := evalVariable(p, t, "c")
+			if s := api.ConvertVar(v).SinglelineString(); s != "bitZero|bitOne (3)" {
+				t.Errorf("wrong value of c: %s", s)
+			}
+		})
+	}
+}
+
+func TestThreadName(t *testing.T) {
+	skipUnlessOn(t, "linux only", "linux")
+	if testBackend != "native" {
+		t.Skip("thread names are only supported by the native backend")
+	}
+	withTestProcess("threadname", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		assertNoError(grp.Continue(), t, "Continue")
+		if name := proc.ThreadName(p.CurrentThread()); name != "dlvworker" {
+			t.Errorf("wrong thread name %q", name)
+		}
+	})
+}
+
+func TestGoroutineSelectCases(t *testing.T) {
+	withTestProcess("selectblock", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		assertNoError(grp.Continue(), t, "Continue")
+		_, err := proc.GoroutineSelectCases(p, p.SelectedGoroutine())
+		if err == nil {
+			t.Errorf("no error for a goroutine that is not blocked in a select statement")
+		}
+		gs, _, err := proc.GoroutinesInfo(p, 0, 0)
+		assertNoError(err, t, "GoroutinesInfo")
+		var sg *proc.G
+		for _, g := range gs {
+			if loc := g.UserCurrent(); loc.Fn != nil && loc.Fn.Name == "main.selector" {
+				sg = g
+				break
+			}
+		}
+		if sg == nil {
+			t.Fatal("could not find goroutine running main.selector")
+		}
+		cases, err := proc.GoroutineSelectCases(p, sg)
+		assertNoError(err, t, "GoroutineSelectCases")
+		got := []string{}
+		for _, sc := range cases {
+			if sc.Chan == 0 {
+				t.Errorf("no channel address for case %#v", sc)
+			}
+			typ := "<nil>"
+			if sc.ElemType != nil {
+				typ = sc.ElemType.String()
+			}
+			got = append(got, fmt.Sprintf("%v %s", sc.Dir, typ))
+		}
+		sort.Strings(got)
+		if want := []string{"<-chan int", "chan<- string"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("wrong select cases %q, expected %q", got, want)
+		}
+	})
+}
+
+func TestFindFinalizer(t *testing.T) {
+	withTestProcess("finalizers", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		assertNoError(grp.Continue(), t, "Continue")
+		withfin := evalVariable(p, t, "withfin")
+		fin, err := proc.FindFinalizer(p, withfin.Children[0].Addr)
+		assertNoError(err, t, "FindFinalizer(withfin)")
+		if fin == nil {
+			t.Fatal("no finalizer found for withfin")
+		}
+		if fin.Addr != withfin.Children[0].Addr {
+			t.Errorf("wrong finalizer address %#x, expected %#x", fin.Addr, withfin.Children[0].Addr)
+		}
+		if fin.Fn == nil || fin.Fn.Name != "main.closeResource" {
+			t.Errorf("wrong finalizer function %#v", fin.Fn)
+		}
+		if fin.Queued {
+			t.Errorf("finalizer of a live object is queued")
+		}
+		nofin := evalVariable(p, t, "nofin")
+		fin, err = proc.FindFinalizer(p, nofin.Children[0].Addr)
+		assertNoError(err, t, "FindFinalizer(nofin)")
+		if fin != nil {
+			t.Errorf("unexpected finalizer for nofin: %#v", fin)
+		}
+	})
+}
+
+func TestSetSegmentBaseRegisters(t *testing.T) {
+	skipUnlessOn(t, "linux/amd64 only", "linux", "amd64")
+	if testBackend != "native" {
+		t.Skip("segment base registers can only be changed by the native backend")
+	}
+	withTestProcess("testvariables2", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		assertNoError(grp.Continue(), t, "Continue")
+		regs, err := p.CurrentThread().Registers()
+		assertNoError(err, t, "Registers")
+		fsbase := evalVariable(p, t, "FS_BASE")
+		if n, _ := constant.Uint64Val(fsbase.Value); n != regs.TLS() {
+			t.Errorf("wrong value for FS_BASE %#x, expected %#x", n, regs.TLS())
+		}
+		assertNoError(setVariable(p, "GS_BASE", "0x1234"), t, "SetVariable(GS_BASE)")
+		gsbase := evalVariable(p, t, "GS_BASE")
+		if n, _ := constant.Uint64Val(gsbase.Value); n != 0x1234 {
+			t.Errorf("wrong value for GS_BASE after set %#x", n)
+		}
+		assertNoError(setVariable(p, "FS_BASE", fmt.Sprintf("%#x", regs.TLS())), t, "SetVariable(FS_BASE)")
+		g, err := proc.GetG(p.CurrentThread())
+		assertNoError(err, t, "GetG")
+		if g == nil {
+			t.Errorf("could not find goroutine after setting FS_BASE")
+		}
+	})
+}
+
+func TestWaitReasonString(t *testing.T) {
+	withTestProcess("changoroutines", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		assertNoError(grp.Continue(), t, "Continue")
+		gs, _, err := proc.GoroutinesInfo(p, 0, 0)
+		assertNoError(err, t, "GoroutinesInfo")
+		count := map[string]int{}
+		for _, g := range gs {
+			if g.Status != proc.Gwaiting || g.WaitReason == 0 {
+				continue
+			}
+			s := proc.WaitReasonString(p, g.WaitReason)
+			if strings.HasPrefix(s, "unknown wait reason") {
+				t.Errorf("goroutine %d: %s", g.ID, s)
+			}
+			count[s]++
+		}
+		if count["chan send"] != 2 || count["chan receive"] != 1 {
+			t.Errorf("wrong wait reasons %v", count)
+		}
+	})
+}
@@ -1,7 +1,14 @@
 package proc
 
 import (
+	"errors"
+	"reflect"
+	"runtime"
 	"testing"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+	"github.com/go-delve/delve/pkg/goversion"
+	protest "github.com/go-delve/delve/pkg/proc/test"
 )
 
 func TestAlignAddr(t *testing.T) {
@@ -96,3 +103,309 @@ func TestConvertInt(t *testing.T) {
 		}
 	}
 }
+
+func TestTypesLoadedOnDemand(t *testing.T) {
+	// Types are only parsed when they are first used, loading the binary
+	// must not parse any of them.
+	fixture := protest.BuildFixture("testvariables2", 0)
+	bi := NewBinaryInfo(runtime.GOOS, runtime.GOARCH)
+	if err := bi.LoadBinaryInfo(fixture.Path, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	image := bi.Images[0]
+	if n := len(image.typeCache); n != 0 {
+		t.Fatalf("%d types parsed while loading the binary", n)
+	}
+	typ, err := bi.findType("main.astruct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image.typeCache[typ.Common().Offset] != typ {
+		t.Fatalf("main.astruct not cached after its first use")
+	}
+}
+
+// binInfoProcess is a Process that only has debug info.
+type binInfoProcess struct {
+	bi  *BinaryInfo
+	bps BreakpointMap
+}
+
+func (p *binInfoProcess) BinInfo() *BinaryInfo                   { return p.bi }
+func (p *binInfoProcess) EntryPoint() (uint64, error)            { return 0, nil }
+func (p *binInfoProcess) FindThread(threadID int) (Thread, bool) { return nil, false }
+func (p *binInfoProcess) ThreadList() []Thread                   { return nil }
+func (p *binInfoProcess) Breakpoints() *BreakpointMap            { return &p.bps }
+func (p *binInfoProcess) Memory() MemoryReadWriter               { return nil }
+
+func TestLocationLookupsCached(t *testing.T) {
+	fixture := protest.BuildFixture("testnextprog", 0)
+	bi := NewBinaryInfo(runtime.GOOS, runtime.GOARCH)
+	if err := bi.LoadBinaryInfo(fixture.Path, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	p := &binInfoProcess{bi: bi, bps: NewBreakpointMap()}
+
+	pcs, err := FindFunctionLocation(p, "main.helloworld", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bi.funcLocCache) != 1 || len(bi.fileLocCache) != 1 {
+		t.Fatalf("lookups not cached: %d %d", len(bi.funcLocCache), len(bi.fileLocCache))
+	}
+	want := append([]uint64(nil), pcs...)
+	pcs[0] = 0 // changing the result must not change the cache
+	pcs2, err := FindFunctionLocation(p, "main.helloworld", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pcs2, want) {
+		t.Fatalf("mismatched cached result %#x %#x", pcs2, want)
+	}
+
+	// Failed lookups are not cached.
+	if _, err := FindFunctionLocation(p, "main.nonexistent", 0); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(bi.funcLocCache) != 1 {
+		t.Fatalf("failed lookup cached")
+	}
+}
+
+type countingMemory struct {
+	data  []byte
+	reads int
+}
+
+func (mem *countingMemory) ReadMemory(buf []byte, addr uint64) (int, error) {
+	mem.reads++
+	if addr+uint64(len(buf)) > uint64(len(mem.data)) {
+		return 0, errors.New("out of bounds")
+	}
+	return copy(buf, mem.data[addr:]), nil
+}
+
+func (mem *countingMemory) WriteMemory(addr uint64, data []byte) (int, error) {
+	return copy(mem.data[addr:], data), nil
+}
+
+// multiCountingMemory is a countingMemory that implements MultiMemoryReader.
+type multiCountingMemory struct {
+	countingMemory
+	multiReads int
+}
+
+func (mem *multiCountingMemory) ReadMemoryMulti(reqs []MemoryRequest) {
+	mem.multiReads++
+	for i := range reqs {
+		if reqs[i].Addr+uint64(len(reqs[i].Buf)) > uint64(len(mem.data)) {
+			reqs[i].Err = errors.New("out of bounds")
+			continue
+		}
+		copy(reqs[i].Buf, mem.data[reqs[i].Addr:])
+	}
+}
+
+func TestCoalesceMemoryMulti(t *testing.T) {
+	mem := &multiCountingMemory{countingMemory: countingMemory{data: make([]byte, 0x1000)}}
+	for i := range mem.data {
+		mem.data[i] = byte(i)
+	}
+	// The frame cache must not hide the ability of mem to read several
+	// ranges at once.
+	framemem := cacheMemory(mem, 0x400, 0x100)
+	cmem := coalesceMemory(framemem, [][2]uint64{{0x100, 0x108}, {0x800, 0x808}, {0xff8, 0x1008}}, 0)
+	for _, addr := range []uint64{0x800, 0x100} {
+		buf := make([]byte, 8)
+		if _, err := cmem.ReadMemory(buf, addr); err != nil {
+			t.Fatalf("reading %#x: %v", addr, err)
+		}
+		if buf[0] != byte(addr) {
+			t.Fatalf("wrong data read at %#x", addr)
+		}
+	}
+	if mem.multiReads != 1 || mem.reads != 0 {
+		t.Errorf("expected 1 multi read and 0 reads, got %d and %d", mem.multiReads, mem.reads)
+	}
+	// The range that could not be read is read directly.
+	buf := make([]byte, 8)
+	cmem.ReadMemory(buf, 0xff8)
+	if mem.multiReads != 1 || mem.reads != 1 {
+		t.Errorf("expected 1 multi read and 1 read, got %d and %d", mem.multiReads, mem.reads)
+	}
+}
+
+func TestCoalesceMemory(t *testing.T) {
+	mem := &countingMemory{data: make([]byte, 0x1000)}
+	for i := range mem.data {
+		mem.data[i] = byte(i)
+	}
+	read := func(cmem MemoryReadWriter, addr uint64, size int) {
+		t.Helper()
+		buf := make([]byte, size)
+		if _, err := cmem.ReadMemory(buf, addr); err != nil {
+			t.Fatalf("reading %#x: %v", addr, err)
+		}
+		for i := range buf {
+			if buf[i] != byte(addr+uint64(i)) {
+				t.Fatalf("wrong data read at %#x", addr+uint64(i))
+			}
+		}
+	}
+
+	// The first three ranges are close enough to be read together, the last
+	// one is too far.
+	cmem := coalesceMemory(mem, [][2]uint64{{0x140, 0x150}, {0x100, 0x108}, {0x110, 0x120}, {0x800, 0x808}}, 0x40)
+	read(cmem, 0x100, 8)
+	read(cmem, 0x110, 16)
+	read(cmem, 0x148, 8)
+	if mem.reads != 1 {
+		t.Errorf("expected 1 read, got %d", mem.reads)
+	}
+	read(cmem, 0x800, 8)
+	read(cmem, 0x200, 8) // not prefetched
+	if mem.reads != 3 {
+		t.Errorf("expected 3 reads, got %d", mem.reads)
+	}
+
+	// Writes invalidate the cached ranges.
+	cmem.WriteMemory(0x100, []byte{0xff})
+	buf := make([]byte, 1)
+	cmem.ReadMemory(buf, 0x100)
+	if buf[0] != 0xff {
+		t.Errorf("stale data read after write: %#x", buf[0])
+	}
+	mem.data[0x100] = 0x00
+
+	// A range that can't be read falls back to reading every variable
+	// separately.
+	mem.reads = 0
+	cmem = coalesceMemory(mem, [][2]uint64{{0xff0, 0xff8}, {0xff8, 0x1008}}, 0)
+	read(cmem, 0xff0, 8)
+	if mem.reads != 2 {
+		t.Errorf("expected 2 reads, got %d", mem.reads)
+	}
+}
+
+func TestARM64PCRelPairAddr(t *testing.T) {
+	const pc = 0x10000
+	for _, tc := range []struct {
+		adrp, next uint32
+		addr       uint64
+		ok         bool
+	}{
+		{0xb0000000, 0x91004000, 0x11010, true}, // adrp x0, 0x11000; add x0, x0, #0x10
+		{0xf0ffffe0, 0xf9400401, 0xf008, true},  // adrp x0, 0xf000; ldr x1, [x0, #8]
+		{0xb0000000, 0xb9400401, 0x11004, true}, // adrp x0, 0x11000; ldr w1, [x0, #4]
+		{0xb0000000, 0x91404000, 0x21000, true}, // adrp x0, 0x11000; add x0, x0, #0x10, lsl #12
+		{0xb0000000, 0x91004020, 0, false},      // adrp x0, 0x11000; add x0, x1, #0x10
+		{0x10000000, 0x91004000, 0, false},      // adr x0, 0x10000; add x0, x0, #0x10
+	} {
+		addr, ok := arm64PCRelPairAddr(tc.adrp, tc.next, pc)
+		if addr != tc.addr || ok != tc.ok {
+			t.Errorf("arm64PCRelPairAddr(%#x, %#x) = %#x, %v, expected %#x, %v", tc.adrp, tc.next, addr, ok, tc.addr, tc.ok)
+		}
+	}
+}
+
+func TestRISCV64Decode(t *testing.T) {
+	const pc = 0x1000
+	for _, tc := range []struct {
+		enc  uint32
+		size int
+		text string
+	}{
+		{0x010db303, 4, "LD 16(X27), X6"},
+		{0x00236663, 4, "BLTU X6, X2, 0x100c"},
+		{0x00736663, 4, "BLTU X6, X7, 0x100c"},
+		{0xff810393, 4, "ADDI $-8, X2, X7"},
+		{0xff5ff06f, 4, "JMP 0xff4"},
+		{0x00008067, 4, "RET"},
+		{0x02b504b3, 4, "MUL X11, X10, X9"},
+		{0x43f4d593, 4, "SRAI $63, X9, X11"},
+		{0x40b48533, 4, "SUB X11, X9, X10"},
+		{0x00048463, 4, "BEQ X9, X0, 0x1008"},
+		{0x0004c483, 4, "LBU 0(X9), X9"},
+		{0x000e2517, 4, "AUIPC $226, X10"},
+		{0x00100073, 4, "EBREAK"},
+		{0x1161, 2, "ADDI $-8, X2, X2"},  // c.addi sp, -8
+		{0xe006, 2, "SD X1, 0(X2)"},      // c.sdsp ra, 0(sp)
+		{0x6082, 2, "LD 0(X2), X1"},      // c.ldsp ra, 0(sp)
+		{0x65c2, 2, "LD 16(X2), X11"},    // c.ldsp a1, 16(sp)
+		{0xec2a, 2, "SD X10, 24(X2)"},    // c.sdsp a0, 24(sp)
+		{0x8d05, 2, "SUB X9, X10, X10"},  // c.sub a0, s1
+		{0x567d, 2, "ADDI $-1, X0, X12"}, // c.li a2, -1
+		{0x94b2, 2, "ADD X12, X9, X9"},   // c.add s1, a2
+		{0x8485, 2, "SRAI $1, X9, X9"},   // c.srai s1, 1
+		{0x9002, 2, "EBREAK"},            // c.ebreak
+		{0xa001, 2, "JMP 0x1000"},        // c.j 0
+	} {
+		mem := []byte{byte(tc.enc), byte(tc.enc >> 8), byte(tc.enc >> 16), byte(tc.enc >> 24)}
+		inst, err := riscv64Decode(mem)
+		if err != nil {
+			t.Errorf("%#x: %v", tc.enc, err)
+			continue
+		}
+		text := (*riscv64ArchInst)(&inst).Text(GoFlavour, pc, func(uint64) (string, uint64) { return "", 0 })
+		if inst.Len != tc.size || text != tc.text {
+			t.Errorf("%#x: got %q (size %d), expected %q (size %d)", tc.enc, text, inst.Len, tc.text, tc.size)
+		}
+	}
+}
+
+func TestRISCV64AUIPCPair(t *testing.T) {
+	mem := &countingMemory{data: make([]byte, 0x200)}
+	copy(mem.data[0x100:], []byte{0x17, 0x25, 0x0e, 0x00, 0x13, 0x05, 0x05, 0xa5}) // auipc a0, 0xe2; addi a0, a0, -1456
+	var asmInst AsmInstruction
+	asmInst.Loc.PC = 0x104
+	if err := riscv64AsmDecode(&asmInst, mem.data[0x104:0x108], nil, mem, nil); err != nil {
+		t.Fatal(err)
+	}
+	text := asmInst.Inst.Text(GoFlavour, 0x104, func(uint64) (string, uint64) { return "", 0 })
+	if expected := "ADDI $-1456, X10, X10 <0xe1b50>"; text != expected {
+		t.Errorf("got %q, expected %q", text, expected)
+	}
+}
+
+func TestGFieldFallbacks(t *testing.T) {
+	// The fallback positions of the fields of runtime.g must match the debug
+	// info produced by the version of Go they are used for.
+	fixture := protest.BuildFixture("testnextprog", 0)
+	bi := NewBinaryInfo(runtime.GOOS, runtime.GOARCH)
+	if err := bi.LoadBinaryInfo(fixture.Path, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	ver := goversion.ParseProducer(bi.Producer())
+	fields, ok := gFieldFallbacks[[2]int{ver.Major, ver.Minor}]
+	if !ok {
+		t.Skipf("no fallback positions for the fields of runtime.g in Go %d.%d", ver.Major, ver.Minor)
+	}
+	typ, err := bi.findType("runtime.g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ptrSize := int64(bi.Arch.PtrSize())
+	for name, f := range fields {
+		var field *godwarf.StructField
+		for _, fld := range typ.(*godwarf.StructType).Field {
+			if fld.Name == name {
+				field = fld
+				break
+			}
+		}
+		if field == nil {
+			t.Errorf("runtime.g has no field %s", name)
+			continue
+		}
+		off, size := f.off64, f.size
+		if ptrSize == 4 {
+			off = f.off32
+		}
+		if size == 0 {
+			size = ptrSize
+		}
+		if field.ByteOffset != off || field.Type.Size() != size {
+			t.Errorf("wrong fallback position for runtime.g.%s: offset %d size %d, expected offset %d size %d", name, off, size, field.ByteOffset, field.Type.Size())
+		}
+	}
+}
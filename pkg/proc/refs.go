@@ -0,0 +1,197 @@
+package proc
+
+import (
+	"encoding/binary"
+)
+
+const (
+	// scanChunkSize is the amount of memory read at once by scanMemory.
+	scanChunkSize = 1 << 20
+	// refsStackDepth is the maximum depth of the stacktraces used to find
+	// the frame containing a reference.
+	refsStackDepth = 100
+)
+
+// Reference is a word of memory of the target process containing the
+// address searched by FindReferences.
+type Reference struct {
+	Addr uint64 // address of the word containing the reference
+
+	// Fields set for references found on the stack of a goroutine.
+	GoroutineID int64
+	Frame       int       // index of the frame containing the reference, -1 if it could not be determined
+	Function    *Function // function of the frame containing the reference
+	Variable    string    // local variable containing the reference, empty if it could not be determined
+	VarOffset   uint64    // offset of the reference from the start of Variable
+
+	// Object is the heap object containing the reference, nil for
+	// references found on the stack of a goroutine.
+	Object *HeapObject
+}
+
+// FindReferencesConfig controls the search done by FindReferences.
+type FindReferencesConfig struct {
+	StacksOnly bool // only search the stacks of goroutines
+	Max        int  // maximum number of references to return, 0 for no limit
+}
+
+// FindReferences searches the stacks of all goroutines and, unless
+// cfg.StacksOnly is set, the heap for pointer-sized words equal to addr.
+// References found on a stack are attributed to the frame and, if
+// possible, to the local variable containing them. In the heap only
+// allocated objects are searched.
+// The second return value is true if the search stopped after finding
+// cfg.Max references.
+// Scanning the heap reads all of its memory and can be slow for programs
+// with large heaps.
+func FindReferences(t *Target, addr uint64, cfg FindReferencesConfig) ([]Reference, bool, error) {
+	if _, err := t.Valid(); err != nil {
+		return nil, false, err
+	}
+	var refs []Reference
+	more := false
+	// add appends ref to refs, fill is called before appending ref to
+	// finish computing it.
+	add := func(ref Reference, fill func(*Reference)) bool {
+		if cfg.Max > 0 && len(refs) >= cfg.Max {
+			more = true
+			return false
+		}
+		if fill != nil {
+			fill(&ref)
+		}
+		refs = append(refs, ref)
+		return true
+	}
+
+	gs, _, err := GoroutinesInfo(t, 0, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, g := range gs {
+		if g.Unreadable != nil || g.stack.lo == 0 || g.stack.hi <= g.stack.lo {
+			continue
+		}
+		frames, _ := GoroutineStacktrace(t, g, refsStackDepth, 0)
+		locals := make(map[int][]*Variable)
+		scanMemory(t, liveStackStart(g, frames), g.stack.hi, func(wordAddr, word uint64) bool {
+			if word != addr {
+				return true
+			}
+			return add(Reference{Addr: wordAddr, GoroutineID: g.ID, Frame: -1}, func(ref *Reference) {
+				findReferenceFrame(t, g, frames, locals, ref)
+			})
+		})
+		if more {
+			return refs, true, nil
+		}
+	}
+
+	if cfg.StacksOnly {
+		return refs, false, nil
+	}
+
+	regions, err := GetMemoryRegions(t)
+	if err != nil {
+		return refs, false, err
+	}
+	for _, reg := range regions {
+		if !reg.Heap || !reg.Read {
+			continue
+		}
+		scanMemory(t, reg.Addr, reg.Addr+reg.Size, func(wordAddr, word uint64) bool {
+			if word != addr {
+				return true
+			}
+			// Goroutine stacks are also allocated in the heap arenas, they are
+			// skipped here since they aren't in an in use span.
+			obj, err := FindHeapObject(t, wordAddr)
+			if err != nil || obj.Free {
+				return true
+			}
+			return add(Reference{Addr: wordAddr, Frame: -1, Object: obj}, nil)
+		})
+		if more {
+			return refs, true, nil
+		}
+	}
+	return refs, false, nil
+}
+
+// liveStackStart returns the lowest address of the stack of g that is in
+// use, frames is the stacktrace of g.
+func liveStackStart(g *G, frames []Stackframe) uint64 {
+	if len(frames) > 0 && !frames[0].SystemStack && frames[0].Regs.SP() > g.stack.lo && frames[0].Regs.SP() < g.stack.hi {
+		// Memory below the stack pointer is not in use.
+		return frames[0].Regs.SP()
+	}
+	return g.stack.lo
+}
+
+// scanMemory calls fn with the address and value of every pointer-sized
+// word between start and end, until fn returns false. Memory that can not
+// be read is skipped.
+func scanMemory(t *Target, start, end uint64, fn func(addr, word uint64) bool) {
+	ptrSize := uint64(t.BinInfo().Arch.PtrSize())
+	start = (start + ptrSize - 1) &^ (ptrSize - 1)
+	if start >= end {
+		return
+	}
+	bufSize := end - start
+	if bufSize > scanChunkSize {
+		bufSize = scanChunkSize
+	}
+	buf := make([]byte, bufSize)
+	for chunkAddr := start; chunkAddr < end; chunkAddr += scanChunkSize {
+		sz := uint64(scanChunkSize)
+		if end-chunkAddr < sz {
+			sz = end - chunkAddr
+		}
+		n, _ := t.Memory().ReadMemory(buf[:sz], chunkAddr)
+		for off := uint64(0); off+ptrSize <= uint64(n); off += ptrSize {
+			var w uint64
+			if ptrSize == 4 {
+				w = uint64(binary.LittleEndian.Uint32(buf[off:]))
+			} else {
+				w = binary.LittleEndian.Uint64(buf[off:])
+			}
+			if !fn(chunkAddr+off, w) {
+				return
+			}
+		}
+	}
+}
+
+// findReferenceFrame fills in the frame, function and local variable
+// containing ref, which was found on the stack of g.
+// The local variables of each frame are loaded once and saved in locals.
+func findReferenceFrame(t *Target, g *G, frames []Stackframe, locals map[int][]*Variable, ref *Reference) {
+	for i := range frames {
+		if frames[i].SystemStack || ref.Addr < frames[i].Regs.SP() || ref.Addr >= uint64(frames[i].Regs.CFA) {
+			continue
+		}
+		ref.Frame = i
+		ref.Function = frames[i].Call.Fn
+		vars, ok := locals[i]
+		if !ok {
+			threadID := 0
+			if g.Thread != nil {
+				threadID = g.Thread.ThreadID()
+			}
+			scope := FrameToScope(t, t.Memory(), g, threadID, frames[i:]...)
+			vars, _ = scope.Locals(0, "")
+			locals[i] = vars
+		}
+		for _, v := range vars {
+			if v.Addr == 0 || v.RealType == nil {
+				continue
+			}
+			if ref.Addr >= v.Addr && ref.Addr < v.Addr+uint64(v.RealType.Size()) {
+				ref.Variable = v.Name
+				ref.VarOffset = ref.Addr - v.Addr
+				return
+			}
+		}
+		return
+	}
+}
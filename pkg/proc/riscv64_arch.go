@@ -0,0 +1,235 @@
+package proc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/dwarf/frame"
+	"github.com/go-delve/delve/pkg/dwarf/op"
+	"github.com/go-delve/delve/pkg/dwarf/regnum"
+)
+
+// riscv64BreakInstruction is C.EBREAK, Go emits compressed instructions
+// so breakpoints must not be longer than 2 bytes.
+var riscv64BreakInstruction = []byte{0x02, 0x90}
+
+// riscv64EBreakInstruction is the uncompressed EBREAK instruction, used by
+// runtime.Breakpoint.
+var riscv64EBreakInstruction = []byte{0x73, 0x00, 0x10, 0x00}
+
+// RISCV64Arch returns an initialized RISCV64 struct.
+func RISCV64Arch(goos string) *Arch {
+	return &Arch{
+		Name:                             "riscv64",
+		ptrSize:                          8,
+		maxInstructionLength:             4,
+		breakpointInstruction:            riscv64BreakInstruction,
+		altBreakpointInstruction:         riscv64EBreakInstruction,
+		breakInstrMovesPC:                false,
+		derefTLS:                         false,
+		prologues:                        prologuesRISCV64,
+		fixFrameUnwindContext:            riscv64FixFrameUnwindContext,
+		switchStack:                      riscv64SwitchStack,
+		regSize:                          riscv64RegSize,
+		RegistersToDwarfRegisters:        riscv64RegistersToDwarfRegisters,
+		addrAndStackRegsToDwarfRegisters: riscv64AddrAndStackRegsToDwarfRegisters,
+		DwarfRegisterToString:            riscv64DwarfRegisterToString,
+		inhibitStepInto:                  func(*BinaryInfo, uint64) bool { return false },
+		asmDecode:                        riscv64AsmDecode,
+		usesLR:                           true,
+		PCRegNum:                         regnum.RISCV64_PC,
+		SPRegNum:                         regnum.RISCV64_SP,
+		ContextRegNum:                    regnum.RISCV64_X0 + 26,
+		LRRegNum:                         regnum.RISCV64_LR,
+		asmRegisters:                     riscv64AsmRegisters,
+		RegisterNameToDwarf:              nameToDwarfFunc(regnum.RISCV64NameToDwarf),
+		RegnumToString:                   regnum.RISCV64ToName,
+	}
+}
+
+func riscv64FixFrameUnwindContext(fctxt *frame.FrameContext, pc uint64, bi *BinaryInfo) *frame.FrameContext {
+	a := bi.Arch
+	if a.sigreturnfn == nil {
+		a.sigreturnfn = bi.lookupOneFunc("runtime.sigreturn")
+	}
+
+	if fctxt == nil || (a.sigreturnfn != nil && pc >= a.sigreturnfn.Entry && pc < a.sigreturnfn.End) {
+		// Go doesn't maintain a frame pointer on riscv64, when there's no frame
+		// descriptor entry assume we are in a leaf function:
+		// - the return address is in the link register
+		// - cfa is sp
+		return &frame.FrameContext{
+			RetAddrReg: regnum.RISCV64_PC,
+			Regs: map[uint64]frame.DWRule{
+				regnum.RISCV64_PC: {
+					Rule: frame.RuleRegister,
+					Reg:  regnum.RISCV64_LR,
+				},
+				regnum.RISCV64_SP: {
+					Rule:   frame.RuleValOffset,
+					Offset: 0,
+				},
+			},
+			CFA: frame.DWRule{
+				Rule:   frame.RuleCFA,
+				Reg:    regnum.RISCV64_SP,
+				Offset: 0,
+			},
+		}
+	}
+
+	if a.crosscall2fn == nil {
+		a.crosscall2fn = bi.lookupOneFunc("crosscall2")
+	}
+
+	if a.crosscall2fn != nil && pc >= a.crosscall2fn.Entry && pc < a.crosscall2fn.End {
+		rule := fctxt.CFA
+		if rule.Offset == crosscall2SPOffsetBad {
+			rule.Offset += crosscall2SPOffset
+		}
+		fctxt.CFA = rule
+	}
+
+	if fctxt.Regs[regnum.RISCV64_LR].Rule == frame.RuleUndefined {
+		fctxt.Regs[regnum.RISCV64_LR] = frame.DWRule{
+			Rule:   frame.RuleRegister,
+			Reg:    regnum.RISCV64_LR,
+			Offset: 0,
+		}
+	}
+
+	return fctxt
+}
+
+const riscv64cgocallSPOffsetSaveSlot = 0x8
+const riscv64prevG0schedSPOffsetSaveSlot = 0x8
+
+func riscv64SwitchStack(it *stackIterator, callFrameRegs *op.DwarfRegisters) bool {
+	if it.frame.Current.Fn == nil {
+		if it.systemstack && it.g != nil && it.top {
+			it.switchToGoroutineStack()
+			return true
+		}
+		return false
+	}
+	switch it.frame.Current.Fn.Name {
+	case "runtime.asmcgocall", "runtime.cgocallback_gofunc", "runtime.sigpanic", "runtime.cgocallback":
+		//do nothing
+	case "runtime.goexit", "runtime.rt0_go":
+		// Look for "top of stack" functions.
+		it.atend = true
+		return true
+	case "runtime.mcall":
+		if it.systemstack && it.g != nil {
+			it.switchToGoroutineStack()
+			return true
+		}
+		it.atend = true
+		return true
+	case "crosscall2":
+		// The offsets get from runtime/cgo/asm_riscv64.s
+		newsp := it.regs.SP() + 8*29
+		newlr, _ := readUintRaw(it.mem, it.regs.SP()+8*16, int64(it.bi.Arch.PtrSize()))
+		it.regs.Reg(it.regs.LRRegNum).Uint64Val = newlr
+		it.regs.Reg(it.regs.SPRegNum).Uint64Val = newsp
+		it.pc = newlr
+		return true
+	default:
+		if it.systemstack && it.top && it.g != nil && strings.HasPrefix(it.frame.Current.Fn.Name, "runtime.") && it.frame.Current.Fn.Name != "runtime.fatalthrow" {
+			// The runtime switches to the system stack in multiple places.
+			// This usually happens through a call to runtime.systemstack but there
+			// are functions that switch to the system stack manually (for example
+			// runtime.morestack).
+			// Since we are only interested in printing the system stack for cgo
+			// calls we switch directly to the goroutine stack if we detect that the
+			// function at the top of the stack is a runtime function.
+			it.switchToGoroutineStack()
+			return true
+		}
+	}
+
+	fn := it.bi.PCToFunc(it.frame.Ret)
+	if fn == nil {
+		return false
+	}
+	switch fn.Name {
+	case "runtime.asmcgocall":
+		if !it.systemstack {
+			return false
+		}
+
+		// This function is called by a goroutine to execute a C function and
+		// switches from the goroutine stack to the system stack.
+		// Since we are unwinding the stack from callee to caller we have to switch
+		// from the system stack to the goroutine stack.
+		off, _ := readIntRaw(it.mem, callFrameRegs.SP()+riscv64cgocallSPOffsetSaveSlot, int64(it.bi.Arch.PtrSize()))
+		oldsp := callFrameRegs.SP()
+		newsp := uint64(int64(it.stackhi) - off)
+
+		// runtime.asmcgocall can also be called from inside the system stack,
+		// in that case no stack switch actually happens
+		if newsp == oldsp {
+			return false
+		}
+		it.systemstack = false
+		callFrameRegs.Reg(callFrameRegs.SPRegNum).Uint64Val = uint64(int64(newsp))
+		return false
+
+	case "runtime.cgocallback_gofunc", "runtime.cgocallback":
+		// For a detailed description of how this works read the long comment at
+		// the start of $GOROOT/src/runtime/cgocall.go and the source code of
+		// runtime.cgocallback in $GOROOT/src/runtime/asm_riscv64.s
+		if it.systemstack {
+			return false
+		}
+
+		it.loadG0SchedSP()
+		if it.g0_sched_sp <= 0 {
+			return false
+		}
+		// entering the system stack
+		callFrameRegs.Reg(callFrameRegs.SPRegNum).Uint64Val = it.g0_sched_sp
+		// reads the previous value of g0.sched.sp that runtime.cgocallback saved on the stack
+		it.g0_sched_sp, _ = readUintRaw(it.mem, callFrameRegs.SP()+riscv64prevG0schedSPOffsetSaveSlot, int64(it.bi.Arch.PtrSize()))
+		it.systemstack = true
+		return false
+	}
+
+	return false
+}
+
+// riscv64RegSize returns the size (in bytes) of register regnum.
+func riscv64RegSize(regnum uint64) int {
+	return 8 // integer registers and double precision floating point registers
+}
+
+func riscv64RegistersToDwarfRegisters(staticBase uint64, regs Registers) *op.DwarfRegisters {
+	dregs := initDwarfRegistersFromSlice(int(regnum.RISCV64MaxRegNum()), regs, regnum.RISCV64NameToDwarf)
+	dr := op.NewDwarfRegisters(staticBase, dregs, binary.LittleEndian, regnum.RISCV64_PC, regnum.RISCV64_SP, regnum.RISCV64_BP, regnum.RISCV64_LR)
+	dr.SetLoadMoreCallback(loadMoreDwarfRegistersFromSliceFunc(dr, regs, regnum.RISCV64NameToDwarf))
+	return dr
+}
+
+func riscv64AddrAndStackRegsToDwarfRegisters(staticBase, pc, sp, bp, lr uint64) op.DwarfRegisters {
+	dregs := make([]*op.DwarfRegister, regnum.RISCV64_PC+1)
+	dregs[regnum.RISCV64_PC] = op.DwarfRegisterFromUint64(pc)
+	dregs[regnum.RISCV64_SP] = op.DwarfRegisterFromUint64(sp)
+	dregs[regnum.RISCV64_BP] = op.DwarfRegisterFromUint64(bp)
+	dregs[regnum.RISCV64_LR] = op.DwarfRegisterFromUint64(lr)
+
+	return *op.NewDwarfRegisters(staticBase, dregs, binary.LittleEndian, regnum.RISCV64_PC, regnum.RISCV64_SP, regnum.RISCV64_BP, regnum.RISCV64_LR)
+}
+
+func riscv64DwarfRegisterToString(i int, reg *op.DwarfRegister) (name string, floatingPoint bool, repr string) {
+	name = regnum.RISCV64ToName(uint64(i))
+
+	if reg == nil {
+		return name, false, ""
+	}
+
+	if name[0] == 'F' {
+		return name, true, fmt.Sprintf("%#016x", reg.Uint64Val)
+	}
+	return name, false, fmt.Sprintf("%#016x", reg.Uint64Val)
+}
@@ -0,0 +1,666 @@
+// TODO: disassembler support should be compiled in unconditionally,
+// instead of being decided by the build-target architecture, and be
+// part of the Arch object instead.
+
+package proc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/dwarf/op"
+	"github.com/go-delve/delve/pkg/dwarf/regnum"
+)
+
+// riscv64Op is a RV64GC instruction, compressed instructions are decoded
+// into their base ISA equivalent.
+type riscv64Op uint16
+
+const (
+	riscv64Unknown riscv64Op = iota
+	riscv64LUI
+	riscv64AUIPC
+	riscv64JAL
+	riscv64JALR
+	riscv64BEQ
+	riscv64BNE
+	riscv64BLT
+	riscv64BGE
+	riscv64BLTU
+	riscv64BGEU
+	riscv64LB
+	riscv64LH
+	riscv64LW
+	riscv64LD
+	riscv64LBU
+	riscv64LHU
+	riscv64LWU
+	riscv64SB
+	riscv64SH
+	riscv64SW
+	riscv64SD
+	riscv64ADDI
+	riscv64SLTI
+	riscv64SLTIU
+	riscv64XORI
+	riscv64ORI
+	riscv64ANDI
+	riscv64SLLI
+	riscv64SRLI
+	riscv64SRAI
+	riscv64ADDIW
+	riscv64SLLIW
+	riscv64SRLIW
+	riscv64SRAIW
+	riscv64ADD
+	riscv64SUB
+	riscv64SLL
+	riscv64SLT
+	riscv64SLTU
+	riscv64XOR
+	riscv64SRL
+	riscv64SRA
+	riscv64OR
+	riscv64AND
+	riscv64ADDW
+	riscv64SUBW
+	riscv64SLLW
+	riscv64SRLW
+	riscv64SRAW
+	riscv64MUL
+	riscv64MULH
+	riscv64MULHSU
+	riscv64MULHU
+	riscv64DIV
+	riscv64DIVU
+	riscv64REM
+	riscv64REMU
+	riscv64MULW
+	riscv64DIVW
+	riscv64DIVUW
+	riscv64REMW
+	riscv64REMUW
+	riscv64FLW
+	riscv64FLD
+	riscv64FSW
+	riscv64FSD
+	riscv64FENCE
+	riscv64ECALL
+	riscv64EBREAK
+)
+
+var riscv64OpNames = [...]string{
+	riscv64Unknown: "?",
+	riscv64LUI:     "LUI",
+	riscv64AUIPC:   "AUIPC",
+	riscv64JAL:     "JAL",
+	riscv64JALR:    "JALR",
+	riscv64BEQ:     "BEQ",
+	riscv64BNE:     "BNE",
+	riscv64BLT:     "BLT",
+	riscv64BGE:     "BGE",
+	riscv64BLTU:    "BLTU",
+	riscv64BGEU:    "BGEU",
+	riscv64LB:      "LB",
+	riscv64LH:      "LH",
+	riscv64LW:      "LW",
+	riscv64LD:      "LD",
+	riscv64LBU:     "LBU",
+	riscv64LHU:     "LHU",
+	riscv64LWU:     "LWU",
+	riscv64SB:      "SB",
+	riscv64SH:      "SH",
+	riscv64SW:      "SW",
+	riscv64SD:      "SD",
+	riscv64ADDI:    "ADDI",
+	riscv64SLTI:    "SLTI",
+	riscv64SLTIU:   "SLTIU",
+	riscv64XORI:    "XORI",
+	riscv64ORI:     "ORI",
+	riscv64ANDI:    "ANDI",
+	riscv64SLLI:    "SLLI",
+	riscv64SRLI:    "SRLI",
+	riscv64SRAI:    "SRAI",
+	riscv64ADDIW:   "ADDIW",
+	riscv64SLLIW:   "SLLIW",
+	riscv64SRLIW:   "SRLIW",
+	riscv64SRAIW:   "SRAIW",
+	riscv64ADD:     "ADD",
+	riscv64SUB:     "SUB",
+	riscv64SLL:     "SLL",
+	riscv64SLT:     "SLT",
+	riscv64SLTU:    "SLTU",
+	riscv64XOR:     "XOR",
+	riscv64SRL:     "SRL",
+	riscv64SRA:     "SRA",
+	riscv64OR:      "OR",
+	riscv64AND:     "AND",
+	riscv64ADDW:    "ADDW",
+	riscv64SUBW:    "SUBW",
+	riscv64SLLW:    "SLLW",
+	riscv64SRLW:    "SRLW",
+	riscv64SRAW:    "SRAW",
+	riscv64MUL:     "MUL",
+	riscv64MULH:    "MULH",
+	riscv64MULHSU:  "MULHSU",
+	riscv64MULHU:   "MULHU",
+	riscv64DIV:     "DIV",
+	riscv64DIVU:    "DIVU",
+	riscv64REM:     "REM",
+	riscv64REMU:    "REMU",
+	riscv64MULW:    "MULW",
+	riscv64DIVW:    "DIVW",
+	riscv64DIVUW:   "DIVUW",
+	riscv64REMW:    "REMW",
+	riscv64REMUW:   "REMUW",
+	riscv64FLW:     "FLW",
+	riscv64FLD:     "FLD",
+	riscv64FSW:     "FSW",
+	riscv64FSD:     "FSD",
+	riscv64FENCE:   "FENCE",
+	riscv64ECALL:   "ECALL",
+	riscv64EBREAK:  "EBREAK",
+}
+
+// riscv64Inst is a decoded RISC-V instruction.
+type riscv64Inst struct {
+	Op           riscv64Op
+	Rd, Rs1, Rs2 uint8
+	Imm          int64
+	Len          int
+	Enc          uint32 // raw encoding of the instruction
+}
+
+// riscv64Format is the operand layout of an instruction.
+type riscv64Format uint8
+
+const (
+	riscv64FmtNone   riscv64Format = iota
+	riscv64FmtR                    // rd, rs1, rs2
+	riscv64FmtI                    // rd, rs1, imm
+	riscv64FmtU                    // rd, imm
+	riscv64FmtLoad                 // rd, imm(rs1)
+	riscv64FmtStore                // rs2, imm(rs1)
+	riscv64FmtBranch               // rs1, rs2, pc+imm
+	riscv64FmtJAL                  // rd, pc+imm
+	riscv64FmtJALR                 // rd, imm(rs1)
+)
+
+func (op riscv64Op) format() riscv64Format {
+	switch {
+	case op == riscv64LUI || op == riscv64AUIPC:
+		return riscv64FmtU
+	case op == riscv64JAL:
+		return riscv64FmtJAL
+	case op == riscv64JALR:
+		return riscv64FmtJALR
+	case op >= riscv64BEQ && op <= riscv64BGEU:
+		return riscv64FmtBranch
+	case op >= riscv64LB && op <= riscv64LWU, op == riscv64FLW, op == riscv64FLD:
+		return riscv64FmtLoad
+	case op >= riscv64SB && op <= riscv64SD, op == riscv64FSW, op == riscv64FSD:
+		return riscv64FmtStore
+	case op >= riscv64ADDI && op <= riscv64SRAIW:
+		return riscv64FmtI
+	case op >= riscv64ADD && op <= riscv64REMUW:
+		return riscv64FmtR
+	}
+	return riscv64FmtNone
+}
+
+// signExtend sign extends the n least significant bits of x.
+func signExtend(x uint32, n uint) int64 {
+	return int64(int32(x<<(32-n)) >> (32 - n))
+}
+
+// riscv64Decode decodes the instruction at the start of mem.
+func riscv64Decode(mem []byte) (riscv64Inst, error) {
+	if len(mem) < 2 {
+		return riscv64Inst{}, errors.New("instruction too short")
+	}
+	if mem[0]&0x3 != 0x3 {
+		inst := riscv64DecodeCompressed(uint32(binary.LittleEndian.Uint16(mem)))
+		inst.Len = 2
+		return inst, nil
+	}
+	if len(mem) < 4 {
+		return riscv64Inst{Len: 2}, errors.New("instruction too short")
+	}
+	w := binary.LittleEndian.Uint32(mem)
+	inst := riscv64Inst{
+		Rd:  uint8(w>>7) & 0x1f,
+		Rs1: uint8(w>>15) & 0x1f,
+		Rs2: uint8(w>>20) & 0x1f,
+		Len: 4,
+		Enc: w,
+	}
+	funct3 := (w >> 12) & 0x7
+	funct7 := w >> 25
+	immI := signExtend(w>>20, 12)
+	immS := signExtend((w>>25)<<5|(w>>7)&0x1f, 12)
+
+	switch w & 0x7f {
+	case 0x37:
+		inst.Op, inst.Imm = riscv64LUI, int64(w>>12)
+	case 0x17:
+		inst.Op, inst.Imm = riscv64AUIPC, int64(w>>12)
+	case 0x6f:
+		inst.Op = riscv64JAL
+		inst.Imm = signExtend((w>>31)<<20|((w>>12)&0xff)<<12|((w>>20)&0x1)<<11|((w>>21)&0x3ff)<<1, 21)
+	case 0x67:
+		if funct3 == 0 {
+			inst.Op, inst.Imm = riscv64JALR, immI
+		}
+	case 0x63:
+		inst.Imm = signExtend((w>>31)<<12|((w>>7)&0x1)<<11|((w>>25)&0x3f)<<5|((w>>8)&0xf)<<1, 13)
+		inst.Op = [8]riscv64Op{riscv64BEQ, riscv64BNE, riscv64Unknown, riscv64Unknown, riscv64BLT, riscv64BGE, riscv64BLTU, riscv64BGEU}[funct3]
+	case 0x03:
+		inst.Op, inst.Imm = [8]riscv64Op{riscv64LB, riscv64LH, riscv64LW, riscv64LD, riscv64LBU, riscv64LHU, riscv64LWU, riscv64Unknown}[funct3], immI
+	case 0x23:
+		inst.Op, inst.Imm = [8]riscv64Op{riscv64SB, riscv64SH, riscv64SW, riscv64SD}[funct3], immS
+	case 0x07:
+		inst.Op, inst.Imm = [8]riscv64Op{2: riscv64FLW, 3: riscv64FLD}[funct3], immI
+	case 0x27:
+		inst.Op, inst.Imm = [8]riscv64Op{2: riscv64FSW, 3: riscv64FSD}[funct3], immS
+	case 0x13:
+		inst.Imm = immI
+		switch funct3 {
+		case 1:
+			inst.Op, inst.Imm = riscv64SLLI, int64((w>>20)&0x3f)
+		case 5:
+			inst.Op, inst.Imm = riscv64SRLI, int64((w>>20)&0x3f)
+			if w&(1<<30) != 0 {
+				inst.Op = riscv64SRAI
+			}
+		default:
+			inst.Op = [8]riscv64Op{riscv64ADDI, 0, riscv64SLTI, riscv64SLTIU, riscv64XORI, 0, riscv64ORI, riscv64ANDI}[funct3]
+		}
+	case 0x1b:
+		switch funct3 {
+		case 0:
+			inst.Op, inst.Imm = riscv64ADDIW, immI
+		case 1:
+			inst.Op, inst.Imm = riscv64SLLIW, int64((w>>20)&0x1f)
+		case 5:
+			inst.Op, inst.Imm = riscv64SRLIW, int64((w>>20)&0x1f)
+			if w&(1<<30) != 0 {
+				inst.Op = riscv64SRAIW
+			}
+		}
+	case 0x33:
+		switch funct7 {
+		case 0x00:
+			inst.Op = [8]riscv64Op{riscv64ADD, riscv64SLL, riscv64SLT, riscv64SLTU, riscv64XOR, riscv64SRL, riscv64OR, riscv64AND}[funct3]
+		case 0x20:
+			inst.Op = [8]riscv64Op{0: riscv64SUB, 5: riscv64SRA}[funct3]
+		case 0x01:
+			inst.Op = [8]riscv64Op{riscv64MUL, riscv64MULH, riscv64MULHSU, riscv64MULHU, riscv64DIV, riscv64DIVU, riscv64REM, riscv64REMU}[funct3]
+		}
+	case 0x3b:
+		switch funct7 {
+		case 0x00:
+			inst.Op = [8]riscv64Op{0: riscv64ADDW, 1: riscv64SLLW, 5: riscv64SRLW}[funct3]
+		case 0x20:
+			inst.Op = [8]riscv64Op{0: riscv64SUBW, 5: riscv64SRAW}[funct3]
+		case 0x01:
+			inst.Op = [8]riscv64Op{0: riscv64MULW, 4: riscv64DIVW, 5: riscv64DIVUW, 6: riscv64REMW, 7: riscv64REMUW}[funct3]
+		}
+	case 0x0f:
+		if funct3 == 0 {
+			inst.Op = riscv64FENCE
+		}
+	case 0x73:
+		switch w {
+		case 0x00000073:
+			inst.Op = riscv64ECALL
+		case 0x00100073:
+			inst.Op = riscv64EBREAK
+		}
+	}
+	return inst, nil
+}
+
+// riscv64DecodeCompressed decodes the 16 bit instruction w, see chapter
+// "C" Standard Extension for Compressed Instructions of the RISC-V
+// unprivileged ISA specification.
+func riscv64DecodeCompressed(w uint32) riscv64Inst {
+	inst := riscv64Inst{Enc: w}
+	bit := func(i uint) uint32 { return (w >> i) & 1 }
+	bits := func(hi, lo uint) uint32 { return (w >> lo) & (1<<(hi-lo+1) - 1) }
+	rdFull := uint8(bits(11, 7))
+	rs2Full := uint8(bits(6, 2))
+	rdShort := uint8(8 + bits(4, 2))  // rd' and rs2'
+	rs1Short := uint8(8 + bits(9, 7)) // rs1' and rd'
+	imm6 := signExtend(bit(12)<<5|bits(6, 2), 6)
+
+	switch bits(1, 0)<<3 | bits(15, 13) {
+	case 0<<3 | 0: // C.ADDI4SPN
+		imm := bits(12, 11)<<4 | bits(10, 7)<<6 | bit(6)<<2 | bit(5)<<3
+		if imm != 0 {
+			inst.Op, inst.Rd, inst.Rs1, inst.Imm = riscv64ADDI, rdShort, regnum.RISCV64_SP, int64(imm)
+		}
+	case 0<<3 | 1: // C.FLD
+		inst.Op, inst.Rd, inst.Rs1, inst.Imm = riscv64FLD, rdShort, rs1Short, int64(bits(12, 10)<<3|bits(6, 5)<<6)
+	case 0<<3 | 2: // C.LW
+		inst.Op, inst.Rd, inst.Rs1, inst.Imm = riscv64LW, rdShort, rs1Short, int64(bits(12, 10)<<3|bit(6)<<2|bit(5)<<6)
+	case 0<<3 | 3: // C.LD
+		inst.Op, inst.Rd, inst.Rs1, inst.Imm = riscv64LD, rdShort, rs1Short, int64(bits(12, 10)<<3|bits(6, 5)<<6)
+	case 0<<3 | 5: // C.FSD
+		inst.Op, inst.Rs2, inst.Rs1, inst.Imm = riscv64FSD, rdShort, rs1Short, int64(bits(12, 10)<<3|bits(6, 5)<<6)
+	case 0<<3 | 6: // C.SW
+		inst.Op, inst.Rs2, inst.Rs1, inst.Imm = riscv64SW, rdShort, rs1Short, int64(bits(12, 10)<<3|bit(6)<<2|bit(5)<<6)
+	case 0<<3 | 7: // C.SD
+		inst.Op, inst.Rs2, inst.Rs1, inst.Imm = riscv64SD, rdShort, rs1Short, int64(bits(12, 10)<<3|bits(6, 5)<<6)
+
+	case 1<<3 | 0: // C.ADDI, C.NOP
+		inst.Op, inst.Rd, inst.Rs1, inst.Imm = riscv64ADDI, rdFull, rdFull, imm6
+	case 1<<3 | 1: // C.ADDIW
+		if rdFull != 0 {
+			inst.Op, inst.Rd, inst.Rs1, inst.Imm = riscv64ADDIW, rdFull, rdFull, imm6
+		}
+	case 1<<3 | 2: // C.LI
+		inst.Op, inst.Rd, inst.Imm = riscv64ADDI, rdFull, imm6
+	case 1<<3 | 3:
+		if rdFull == regnum.RISCV64_SP { // C.ADDI16SP
+			imm := signExtend(bit(12)<<9|bit(6)<<4|bit(5)<<6|bits(4, 3)<<7|bit(2)<<5, 10)
+			if imm != 0 {
+				inst.Op, inst.Rd, inst.Rs1, inst.Imm = riscv64ADDI, rdFull, rdFull, imm
+			}
+		} else if imm6 != 0 { // C.LUI
+			inst.Op, inst.Rd, inst.Imm = riscv64LUI, rdFull, imm6&0xfffff
+		}
+	case 1<<3 | 4:
+		inst.Rd, inst.Rs1, inst.Rs2 = rs1Short, rs1Short, rdShort
+		switch bits(11, 10) {
+		case 0: // C.SRLI
+			inst.Op, inst.Imm = riscv64SRLI, int64(bit(12)<<5|bits(6, 2))
+		case 1: // C.SRAI
+			inst.Op, inst.Imm = riscv64SRAI, int64(bit(12)<<5|bits(6, 2))
+		case 2: // C.ANDI
+			inst.Op, inst.Imm = riscv64ANDI, imm6
+		case 3:
+			inst.Op = [8]riscv64Op{riscv64SUB, riscv64XOR, riscv64OR, riscv64AND, riscv64SUBW, riscv64ADDW}[bit(12)<<2|bits(6, 5)]
+		}
+	case 1<<3 | 5: // C.J
+		inst.Op, inst.Imm = riscv64JAL, signExtend(bit(12)<<11|bit(11)<<4|bits(10, 9)<<8|bit(8)<<10|bit(7)<<6|bit(6)<<7|bits(5, 3)<<1|bit(2)<<5, 12)
+	case 1<<3 | 6, 1<<3 | 7: // C.BEQZ, C.BNEZ
+		inst.Op = riscv64BEQ
+		if bits(15, 13) == 7 {
+			inst.Op = riscv64BNE
+		}
+		inst.Rs1 = rs1Short
+		inst.Imm = signExtend(bit(12)<<8|bits(11, 10)<<3|bits(6, 5)<<6|bits(4, 3)<<1|bit(2)<<5, 9)
+
+	case 2<<3 | 0: // C.SLLI
+		inst.Op, inst.Rd, inst.Rs1, inst.Imm = riscv64SLLI, rdFull, rdFull, int64(bit(12)<<5|bits(6, 2))
+	case 2<<3 | 1: // C.FLDSP
+		inst.Op, inst.Rd, inst.Rs1, inst.Imm = riscv64FLD, rdFull, regnum.RISCV64_SP, int64(bit(12)<<5|bits(6, 5)<<3|bits(4, 2)<<6)
+	case 2<<3 | 2: // C.LWSP
+		inst.Op, inst.Rd, inst.Rs1, inst.Imm = riscv64LW, rdFull, regnum.RISCV64_SP, int64(bit(12)<<5|bits(6, 4)<<2|bits(3, 2)<<6)
+	case 2<<3 | 3: // C.LDSP
+		inst.Op, inst.Rd, inst.Rs1, inst.Imm = riscv64LD, rdFull, regnum.RISCV64_SP, int64(bit(12)<<5|bits(6, 5)<<3|bits(4, 2)<<6)
+	case 2<<3 | 4:
+		switch {
+		case bit(12) == 0 && rs2Full == 0: // C.JR
+			if rdFull != 0 {
+				inst.Op, inst.Rs1 = riscv64JALR, rdFull
+			}
+		case bit(12) == 0: // C.MV
+			inst.Op, inst.Rd, inst.Rs2 = riscv64ADD, rdFull, rs2Full
+		case rdFull == 0 && rs2Full == 0: // C.EBREAK
+			inst.Op = riscv64EBREAK
+		case rs2Full == 0: // C.JALR
+			inst.Op, inst.Rd, inst.Rs1 = riscv64JALR, regnum.RISCV64_LR, rdFull
+		default: // C.ADD
+			inst.Op, inst.Rd, inst.Rs1, inst.Rs2 = riscv64ADD, rdFull, rdFull, rs2Full
+		}
+	case 2<<3 | 5: // C.FSDSP
+		inst.Op, inst.Rs2, inst.Rs1, inst.Imm = riscv64FSD, rs2Full, regnum.RISCV64_SP, int64(bits(12, 10)<<3|bits(9, 7)<<6)
+	case 2<<3 | 6: // C.SWSP
+		inst.Op, inst.Rs2, inst.Rs1, inst.Imm = riscv64SW, rs2Full, regnum.RISCV64_SP, int64(bits(12, 9)<<2|bits(8, 7)<<6)
+	case 2<<3 | 7: // C.SDSP
+		inst.Op, inst.Rs2, inst.Rs1, inst.Imm = riscv64SD, rs2Full, regnum.RISCV64_SP, int64(bits(12, 10)<<3|bits(9, 7)<<6)
+	}
+	return inst
+}
+
+func riscv64AsmDecode(asmInst *AsmInstruction, mem []byte, regs *op.DwarfRegisters, memrw MemoryReadWriter, bi *BinaryInfo) error {
+	inst, err := riscv64Decode(mem)
+	asmInst.Size = inst.Len
+	if asmInst.Size == 0 {
+		asmInst.Size = len(mem)
+	}
+	asmInst.Bytes = mem[:asmInst.Size]
+	if err != nil {
+		asmInst.Inst = (*riscv64ArchInst)(nil)
+		return err
+	}
+
+	asmInst.Inst = (*riscv64ArchInst)(&inst)
+	asmInst.Kind = OtherInstruction
+
+	switch inst.Op {
+	case riscv64JAL, riscv64JALR:
+		switch {
+		case inst.Rd != 0:
+			asmInst.Kind = CallInstruction
+		case inst.Op == riscv64JALR && inst.Rs1 == regnum.RISCV64_LR && inst.Imm == 0:
+			asmInst.Kind = RetInstruction
+		default:
+			asmInst.Kind = JmpInstruction
+		}
+	case riscv64EBREAK:
+		asmInst.Kind = HardBreakInstruction
+	}
+
+	asmInst.DestLoc = resolveCallArgRISCV64(&inst, asmInst.Loc.PC, asmInst.AtPC, regs, bi)
+
+	switch inst.Op.format() {
+	case riscv64FmtI, riscv64FmtLoad, riscv64FmtStore:
+		if asmInst.Loc.PC < 4 {
+			break
+		}
+		// Go and C compilers compute the address of global symbols using an
+		// AUIPC instruction followed by an ADDI or a load/store, decode the pair
+		// into the address it refers to.
+		var prev [4]byte
+		if _, err := memrw.ReadMemory(prev[:], asmInst.Loc.PC-4); err != nil {
+			break
+		}
+		if auipc, err := riscv64Decode(prev[:]); err == nil && auipc.Op == riscv64AUIPC && auipc.Rd != 0 && auipc.Rd == inst.Rs1 && (inst.Op == riscv64ADDI || inst.Op.format() != riscv64FmtI) {
+			addr := asmInst.Loc.PC - 4 + uint64(signExtend(uint32(auipc.Imm), 20)<<12) + uint64(inst.Imm)
+			asmInst.Inst = &riscv64AddrInst{riscv64ArchInst: riscv64ArchInst(inst), addr: addr}
+		}
+	}
+
+	return nil
+}
+
+// resolveCallArgRISCV64 returns the destination of jump, call and branch
+// instructions. The destination of indirect jumps can only be calculated
+// if inst is the current instruction.
+func resolveCallArgRISCV64(inst *riscv64Inst, instAddr uint64, currentGoroutine bool, regs *op.DwarfRegisters, bininfo *BinaryInfo) *Location {
+	var pc uint64
+	switch inst.Op.format() {
+	case riscv64FmtJAL, riscv64FmtBranch:
+		pc = uint64(int64(instAddr) + inst.Imm)
+	case riscv64FmtJALR:
+		if !currentGoroutine || regs == nil {
+			return nil
+		}
+		var base uint64
+		if inst.Rs1 != 0 {
+			var err error
+			base, err = bininfo.Arch.getAsmRegister(regs, int(inst.Rs1))
+			if err != nil {
+				return nil
+			}
+		}
+		pc = uint64(int64(base)+inst.Imm) &^ 1
+	default:
+		return nil
+	}
+
+	file, line, fn := bininfo.PCToLine(pc)
+	if fn == nil {
+		return &Location{PC: pc}
+	}
+	return &Location{PC: pc, File: file, Line: line, Fn: fn}
+}
+
+// Possible stacksplit prologues are inserted by stacksplit in
+// $GOROOT/src/cmd/internal/obj/riscv/obj.go.
+var prologuesRISCV64 []opcodeSeq
+
+func init() {
+	var smallStacksplit = opcodeSeq{uint64(riscv64BLTU)}
+	var largeStacksplit = opcodeSeq{uint64(riscv64ADDI), uint64(riscv64BLTU)}
+	var unixGetG = opcodeSeq{uint64(riscv64LD)}
+
+	prologuesRISCV64 = make([]opcodeSeq, 0, 2)
+	for _, getG := range []opcodeSeq{unixGetG} {
+		for _, stacksplit := range []opcodeSeq{smallStacksplit, largeStacksplit} {
+			prologue := make(opcodeSeq, 0, len(getG)+len(stacksplit))
+			prologue = append(prologue, getG...)
+			prologue = append(prologue, stacksplit...)
+			prologuesRISCV64 = append(prologuesRISCV64, prologue)
+		}
+	}
+}
+
+type riscv64ArchInst riscv64Inst
+
+func (inst *riscv64ArchInst) Text(flavour AssemblyFlavour, pc uint64, symLookup func(uint64) (string, uint64)) string {
+	if inst == nil {
+		return "?"
+	}
+
+	target := func() string {
+		addr := uint64(int64(pc) + inst.Imm)
+		if name, base := symLookup(addr); name != "" && base == addr {
+			if flavour == GNUFlavour {
+				return fmt.Sprintf("%#x <%s>", addr, name)
+			}
+			return name + "(SB)"
+		}
+		return fmt.Sprintf("%#x", addr)
+	}
+
+	if flavour == GNUFlavour {
+		x := func(r uint8) string { return fmt.Sprintf("x%d", r) }
+		name := strings.ToLower(riscv64OpNames[inst.Op])
+		switch inst.Op.format() {
+		case riscv64FmtR:
+			return fmt.Sprintf("%s %s,%s,%s", name, x(inst.Rd), x(inst.Rs1), x(inst.Rs2))
+		case riscv64FmtI:
+			return fmt.Sprintf("%s %s,%s,%d", name, x(inst.Rd), x(inst.Rs1), inst.Imm)
+		case riscv64FmtU:
+			return fmt.Sprintf("%s %s,%#x", name, x(inst.Rd), inst.Imm)
+		case riscv64FmtLoad:
+			if inst.Op == riscv64FLW || inst.Op == riscv64FLD {
+				return fmt.Sprintf("%s f%d,%d(%s)", name, inst.Rd, inst.Imm, x(inst.Rs1))
+			}
+			return fmt.Sprintf("%s %s,%d(%s)", name, x(inst.Rd), inst.Imm, x(inst.Rs1))
+		case riscv64FmtStore:
+			if inst.Op == riscv64FSW || inst.Op == riscv64FSD {
+				return fmt.Sprintf("%s f%d,%d(%s)", name, inst.Rs2, inst.Imm, x(inst.Rs1))
+			}
+			return fmt.Sprintf("%s %s,%d(%s)", name, x(inst.Rs2), inst.Imm, x(inst.Rs1))
+		case riscv64FmtBranch:
+			return fmt.Sprintf("%s %s,%s,%s", name, x(inst.Rs1), x(inst.Rs2), target())
+		case riscv64FmtJAL:
+			return fmt.Sprintf("%s %s,%s", name, x(inst.Rd), target())
+		case riscv64FmtJALR:
+			return fmt.Sprintf("%s %s,%d(%s)", name, x(inst.Rd), inst.Imm, x(inst.Rs1))
+		}
+		if inst.Op != riscv64Unknown {
+			return name
+		}
+		if inst.Len == 2 {
+			return fmt.Sprintf(".2byte %#04x", inst.Enc)
+		}
+		return fmt.Sprintf(".4byte %#08x", inst.Enc)
+	}
+
+	x := func(r uint8) string { return fmt.Sprintf("X%d", r) }
+	name := riscv64OpNames[inst.Op]
+	switch inst.Op.format() {
+	case riscv64FmtR:
+		return fmt.Sprintf("%s %s, %s, %s", name, x(inst.Rs2), x(inst.Rs1), x(inst.Rd))
+	case riscv64FmtI:
+		return fmt.Sprintf("%s $%d, %s, %s", name, inst.Imm, x(inst.Rs1), x(inst.Rd))
+	case riscv64FmtU:
+		return fmt.Sprintf("%s $%d, %s", name, inst.Imm, x(inst.Rd))
+	case riscv64FmtLoad:
+		if inst.Op == riscv64FLW || inst.Op == riscv64FLD {
+			return fmt.Sprintf("%s %d(%s), F%d", name, inst.Imm, x(inst.Rs1), inst.Rd)
+		}
+		return fmt.Sprintf("%s %d(%s), %s", name, inst.Imm, x(inst.Rs1), x(inst.Rd))
+	case riscv64FmtStore:
+		if inst.Op == riscv64FSW || inst.Op == riscv64FSD {
+			return fmt.Sprintf("%s F%d, %d(%s)", name, inst.Rs2, inst.Imm, x(inst.Rs1))
+		}
+		return fmt.Sprintf("%s %s, %d(%s)", name, x(inst.Rs2), inst.Imm, x(inst.Rs1))
+	case riscv64FmtBranch:
+		return fmt.Sprintf("%s %s, %s, %s", name, x(inst.Rs1), x(inst.Rs2), target())
+	case riscv64FmtJAL:
+		switch inst.Rd {
+		case 0:
+			return "JMP " + target()
+		case regnum.RISCV64_LR:
+			return "CALL " + target()
+		}
+		return fmt.Sprintf("%s %s, %s", name, x(inst.Rd), target())
+	case riscv64FmtJALR:
+		switch {
+		case inst.Rd == 0 && inst.Rs1 == regnum.RISCV64_LR && inst.Imm == 0:
+			return "RET"
+		case inst.Rd == 0:
+			return fmt.Sprintf("JMP %d(%s)", inst.Imm, x(inst.Rs1))
+		case inst.Rd == regnum.RISCV64_LR:
+			return fmt.Sprintf("CALL %d(%s)", inst.Imm, x(inst.Rs1))
+		}
+		return fmt.Sprintf("%s %s, %d(%s)", name, x(inst.Rd), inst.Imm, x(inst.Rs1))
+	}
+	if inst.Op != riscv64Unknown {
+		return name
+	}
+	return fmt.Sprintf("WORD $%#x", inst.Enc)
+}
+
+func (inst *riscv64ArchInst) OpcodeEquals(op uint64) bool {
+	if inst == nil {
+		return false
+	}
+	return uint64(inst.Op) == op
+}
+
+// riscv64AddrInst is an instruction that, together with the AUIPC
+// instruction preceding it, refers to addr.
+type riscv64AddrInst struct {
+	riscv64ArchInst
+	addr uint64
+}
+
+func (inst *riscv64AddrInst) Text(flavour AssemblyFlavour, pc uint64, symLookup func(uint64) (string, uint64)) string {
+	text := inst.riscv64ArchInst.Text(flavour, pc, symLookup)
+	name, base := symLookup(inst.addr)
+	switch {
+	case name == "":
+		return fmt.Sprintf("%s <%#x>", text, inst.addr)
+	case base == inst.addr:
+		return fmt.Sprintf("%s <%s>", text, name)
+	default:
+		return fmt.Sprintf("%s <%s+%#x>", text, name, inst.addr-base)
+	}
+}
+
+var riscv64AsmRegisters = func() map[int]asmRegister {
+	r := make(map[int]asmRegister)
+	for i := 0; i <= 31; i++ {
+		r[i] = asmRegister{regnum.RISCV64_X0 + uint64(i), 0, 0}
+	}
+	return r
+}()
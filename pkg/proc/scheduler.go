@@ -0,0 +1,226 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"go/constant"
+	"reflect"
+)
+
+// P status values, see runtime/runtime2.go
+const (
+	Pidle    uint64 = iota // 0
+	Prunning               // 1
+	Psyscall               // 2
+	Pgcstop                // 3
+	Pdead                  // 4
+)
+
+// SchedM describes a M (OS thread) of the Go runtime scheduler.
+type SchedM struct {
+	ID       int64  // value of m.id
+	ThreadID int    // OS thread ID (m.procid)
+	PID      int32  // ID of the P bound to this M, or -1
+	CurG     int64  // ID of the goroutine running on this M, or 0
+	Spinning bool   // the M is out of work and is actively looking for work
+	Addr     uint64 // address of the runtime.m struct
+}
+
+// SchedP describes a P (processor) of the Go runtime scheduler.
+type SchedP struct {
+	ID       int32
+	Status   uint64
+	RunqSize int   // number of goroutines in the local run queue, including runnext
+	MID      int64 // ID of the M this P is bound to, or -1
+	Addr     uint64
+}
+
+// Scheduler describes the state of the Go runtime scheduler.
+type Scheduler struct {
+	Gomaxprocs     int64
+	GlobalRunqSize int64
+	NMIdle         int64 // number of idle Ms waiting for work
+	NMSpinning     int64 // number of spinning Ms
+	NPIdle         int64 // number of idle Ps
+	Ms             []SchedM
+	Ps             []SchedP
+}
+
+// StatusString returns a description of the status of p.
+func (p *SchedP) StatusString() string {
+	switch p.Status {
+	case Pidle:
+		return "idle"
+	case Prunning:
+		return "running"
+	case Psyscall:
+		return "syscall"
+	case Pgcstop:
+		return "gcstop"
+	case Pdead:
+		return "dead"
+	default:
+		return fmt.Sprintf("unknown(%d)", p.Status)
+	}
+}
+
+// maxSchedMs is the maximum number of Ms that will be read by GetScheduler.
+const maxSchedMs = 10000
+
+// GetScheduler reads the state of the Go runtime scheduler from the
+// runtime.allm, runtime.allp and runtime.sched variables.
+func GetScheduler(t *Target) (*Scheduler, error) {
+	if _, err := t.Valid(); err != nil {
+		return nil, err
+	}
+	scope := globalScope(t, t.BinInfo(), t.BinInfo().Images[0], t.Memory())
+
+	r := &Scheduler{}
+
+	// +rtype -var gomaxprocs int32
+	gomaxprocsv, err := scope.findGlobal("runtime", "gomaxprocs")
+	if err == nil {
+		r.Gomaxprocs, _ = runtimeInt(gomaxprocsv, nil)
+	}
+
+	// +rtype -var sched schedt
+	schedv, err := scope.findGlobal("runtime", "sched") // +rtype schedt
+	if err == nil {
+		var err error
+		r.GlobalRunqSize, err = runtimeInt(schedv.structMember("runqsize")) // +rtype -opt int32
+		if err != nil {
+			runqv, _ := schedv.structMember("runq") // +rtype gQueue
+			if runqv != nil {
+				r.GlobalRunqSize, _ = runtimeInt(runqv.structMember("size")) // +rtype -opt int32
+			}
+		}
+		r.NMIdle, _ = runtimeInt(schedv.structMember("nmidle"))         // +rtype int32
+		r.NMSpinning, _ = runtimeInt(schedv.structMember("nmspinning")) // +rtype int32|runtime/internal/atomic.Int32|internal/runtime/atomic.Int32
+		r.NPIdle, _ = runtimeInt(schedv.structMember("npidle"))         // +rtype int32|runtime/internal/atomic.Int32|internal/runtime/atomic.Int32
+	}
+
+	// +rtype -var allm *m
+	allmv, err := scope.findGlobal("runtime", "allm")
+	if err != nil {
+		return nil, err
+	}
+	mPAddrs := []uint64{}          // address of the P bound to each M
+	mv := allmv.maybeDereference() // +rtype m
+	for mv.Addr != 0 && len(r.Ms) < maxSchedMs {
+		if mv.Unreadable != nil {
+			return nil, mv.Unreadable
+		}
+		m := SchedM{Addr: mv.Addr, PID: -1}
+		m.ID, err = runtimeInt(mv.structMember("id")) // +rtype int64
+		if err != nil {
+			return nil, err
+		}
+		procid, _ := runtimeInt(mv.structMember("procid")) // +rtype uint64
+		m.ThreadID = int(procid)
+		spinningv := mv.loadFieldNamed("spinning") // +rtype bool
+		if spinningv != nil && spinningv.Value != nil {
+			m.Spinning = constant.BoolVal(spinningv.Value)
+		}
+		curgv, _ := mv.structMember("curg") // +rtype *g
+		if curgv != nil {
+			gv := curgv.maybeDereference() // +rtype g
+			if gv.Addr != 0 && gv.Unreadable == nil {
+				m.CurG, _ = runtimeInt(gv.structMember("goid")) // +rtype int64|uint64
+			}
+		}
+		paddr, _ := runtimeInt(mv.structMember("p")) // +rtype puintptr
+		mPAddrs = append(mPAddrs, uint64(paddr))
+		r.Ms = append(r.Ms, m)
+
+		alllinkv, err := mv.structMember("alllink") // +rtype *m
+		if err != nil {
+			return nil, err
+		}
+		mv = alllinkv.maybeDereference()
+	}
+
+	mIDByAddr := map[uint64]int64{}
+	for _, m := range r.Ms {
+		mIDByAddr[m.Addr] = m.ID
+	}
+	pIDByAddr := map[uint64]int32{}
+
+	// +rtype -var allp []*p
+	allpv, err := scope.findGlobal("runtime", "allp")
+	if err != nil {
+		return nil, err
+	}
+	allpv.loadValue(LoadConfig{MaxArrayValues: maxSchedMs})
+	if allpv.Unreadable != nil {
+		return nil, allpv.Unreadable
+	}
+	for i := range allpv.Children {
+		pv := allpv.Children[i].maybeDereference() // +rtype p
+		if pv.Addr == 0 {
+			continue
+		}
+		if pv.Unreadable != nil {
+			return nil, pv.Unreadable
+		}
+		p := SchedP{Addr: pv.Addr, MID: -1}
+		id, err := runtimeInt(pv.structMember("id")) // +rtype int32
+		if err != nil {
+			return nil, err
+		}
+		p.ID = int32(id)
+		status, _ := runtimeInt(pv.structMember("status")) // +rtype uint32
+		p.Status = uint64(status)
+		head, _ := runtimeInt(pv.structMember("runqhead")) // +rtype uint32
+		tail, _ := runtimeInt(pv.structMember("runqtail")) // +rtype uint32
+		p.RunqSize = int(uint32(tail) - uint32(head))
+		runnext, _ := runtimeInt(pv.structMember("runnext")) // +rtype guintptr
+		if runnext != 0 {
+			p.RunqSize++
+		}
+		maddr, _ := runtimeInt(pv.structMember("m")) // +rtype muintptr
+		if mid, ok := mIDByAddr[uint64(maddr)]; ok && maddr != 0 {
+			p.MID = mid
+		}
+		pIDByAddr[p.Addr] = p.ID
+		r.Ps = append(r.Ps, p)
+	}
+
+	for i := range r.Ms {
+		if id, ok := pIDByAddr[mPAddrs[i]]; ok {
+			r.Ms[i].PID = id
+		}
+	}
+
+	return r, nil
+}
+
+// runtimeInt loads the value of v, which must be either an integer or one
+// of the types of runtime/internal/atomic wrapping an integer.
+// It accepts the return values of structMember so that calls can be
+// chained.
+func runtimeInt(v *Variable, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	if v.Unreadable != nil {
+		return 0, v.Unreadable
+	}
+	if v.Kind == reflect.Struct {
+		v, err = v.structMember("value")
+		if err != nil {
+			return 0, err
+		}
+	}
+	v.loadValue(loadSingleValue)
+	if v.Unreadable != nil {
+		return 0, v.Unreadable
+	}
+	if v.Value == nil || v.Value.Kind() != constant.Int {
+		return 0, errors.New("not an integer")
+	}
+	if n, exact := constant.Int64Val(v.Value); exact {
+		return n, nil
+	}
+	n, _ := constant.Uint64Val(v.Value)
+	return int64(n), nil
+}
@@ -0,0 +1,137 @@
+package proc
+
+import (
+	"fmt"
+	"go/constant"
+	"reflect"
+
+	"github.com/go-delve/delve/pkg/dwarf/godwarf"
+)
+
+// SelectCase describes one of the cases of the select statement a
+// goroutine is blocked on.
+type SelectCase struct {
+	Chan     uint64       // address of the runtime.hchan struct of the channel
+	ElemType godwarf.Type // element type of the channel, nil if it could not be determined
+	Dir      reflect.ChanDir
+}
+
+// maxSelectCases is the maximum number of cases that will be read by
+// GoroutineSelectCases, the compiler does not allow more than 65536 cases
+// in a select statement.
+const maxSelectCases = 1 << 16
+
+// maxWaitqLen is the maximum number of entries of a channel's wait queue
+// that will be scanned to determine the direction of a select case.
+const maxWaitqLen = 10000
+
+// GoroutineSelectCases returns the cases of the select statement that g
+// is blocked on.
+// When a goroutine blocks in a select statement the runtime creates one
+// sudog for each case with a non-nil channel, links them through
+// g.waiting and enqueues each one in the sendq or recvq of its channel.
+func GoroutineSelectCases(t *Target, g *G) ([]SelectCase, error) {
+	if g.Unreadable != nil {
+		return nil, g.Unreadable
+	}
+	if g.variable == nil {
+		return nil, fmt.Errorf("goroutine %d has no runtime.g struct", g.ID)
+	}
+	bi := t.BinInfo()
+
+	waitingv, err := g.variable.structMember("waiting") // +rtype *sudog
+	if err != nil {
+		return nil, err
+	}
+	sgv := waitingv.maybeDereference() // +rtype sudog
+	r := []SelectCase{}
+	for sgv.Addr != 0 && len(r) < maxSelectCases {
+		if sgv.Unreadable != nil {
+			return nil, sgv.Unreadable
+		}
+		isSelectv := sgv.loadFieldNamed("isSelect") // +rtype bool
+		if isSelectv == nil || isSelectv.Value == nil || !constant.BoolVal(isSelectv.Value) {
+			break
+		}
+		cv, err := sgv.structMember("c") // +rtype *hchan|maybeTraceableChan
+		if err != nil {
+			return nil, err
+		}
+		hchanv, err := sudogChan(bi, cv) // +rtype hchan
+		if err != nil {
+			return nil, err
+		}
+		sc := SelectCase{Chan: hchanv.Addr}
+		elemtypev, _ := hchanv.structMember("elemtype") // +rtype *_type|*internal/abi.Type
+		if elemtypev != nil {
+			typeAddr, err := readUintRaw(elemtypev.mem, elemtypev.Addr, int64(bi.Arch.PtrSize()))
+			if err == nil && typeAddr != 0 {
+				sc.ElemType, _ = runtimeTypeAt(bi, t.Memory(), typeAddr)
+			}
+		}
+		switch {
+		case waitqContains(hchanv, "sendq", sgv.Addr):
+			sc.Dir = reflect.SendDir
+		case waitqContains(hchanv, "recvq", sgv.Addr):
+			sc.Dir = reflect.RecvDir
+		}
+		r = append(r, sc)
+
+		waitlinkv, err := sgv.structMember("waitlink") // +rtype *sudog
+		if err != nil {
+			return nil, err
+		}
+		sgv = waitlinkv.maybeDereference()
+	}
+	if len(r) == 0 {
+		return nil, fmt.Errorf("goroutine %d is not blocked in a select statement", g.ID)
+	}
+	return r, nil
+}
+
+// sudogChan returns the channel referenced by cv, the c field of a
+// runtime.sudog struct. In older versions of Go the field is a *hchan, in
+// newer versions it is a maybeTraceableChan which stores the address of
+// the channel in its vu field.
+func sudogChan(bi *BinaryInfo, cv *Variable) (*Variable, error) {
+	if _, isptr := cv.RealType.(*godwarf.PtrType); isptr {
+		hchanv := cv.maybeDereference() // +rtype hchan
+		return hchanv, hchanv.Unreadable
+	}
+	addr, err := runtimeInt(cv.structMember("vu"))
+	if err != nil {
+		return nil, err
+	}
+	typ, err := bi.findType("runtime.hchan")
+	if err != nil {
+		return nil, err
+	}
+	return newVariable("", uint64(addr), typ, bi, cv.mem), nil
+}
+
+// waitqContains returns true if the sudog at address sgaddr is enqueued
+// in the wait queue called name of channel hchanv.
+func waitqContains(hchanv *Variable, name string, sgaddr uint64) bool {
+	// +rtype -field hchan.sendq waitq
+	// +rtype -field hchan.recvq waitq
+	qv, err := hchanv.structMember(name) // +rtype waitq
+	if err != nil {
+		return false
+	}
+	firstv, err := qv.structMember("first") // +rtype *sudog
+	if err != nil {
+		return false
+	}
+	sgv := firstv.maybeDereference() // +rtype sudog
+	for i := 0; sgv.Addr != 0 && sgv.Unreadable == nil && i < maxWaitqLen; i++ {
+		if sgv.Addr == sgaddr {
+			return true
+		}
+		nextv, err := sgv.structMember("next") // +rtype *sudog
+		if err != nil {
+			return false
+		}
+		sgv = nextv.maybeDereference()
+	}
+	return false
+}
@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"go/constant"
 	"reflect"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/go-delve/delve/pkg/dwarf/frame"
 	"github.com/go-delve/delve/pkg/dwarf/op"
@@ -154,10 +156,17 @@ const (
 // GoroutineStacktrace returns the stack trace for a goroutine.
 // Note the locations in the array are return addresses not call addresses.
 func GoroutineStacktrace(tgt *Target, g *G, depth int, opts StacktraceOptions) ([]Stackframe, error) {
+	return goroutineStacktrace(tgt, g, g.variable.mem, depth, opts)
+}
+
+// goroutineStacktrace is like GoroutineStacktrace but reads the stack of g
+// using mem.
+func goroutineStacktrace(tgt *Target, g *G, mem MemoryReadWriter, depth int, opts StacktraceOptions) ([]Stackframe, error) {
 	it, err := goroutineStackIterator(tgt, g, opts)
 	if err != nil {
 		return nil, err
 	}
+	it.mem = mem
 	frames, err := it.stacktrace(depth)
 	if err != nil {
 		return nil, err
@@ -168,6 +177,75 @@ func GoroutineStacktrace(tgt *Target, g *G, depth int, opts StacktraceOptions) (
 	return frames, nil
 }
 
+// GoroutinesStacktraces returns the stacktraces of the goroutines in gs, up
+// to depth frames each, and, for each goroutine, the error that prevented
+// its stacktrace from being read.
+// If the memory of the target can be read concurrently the stacks of the
+// goroutines are read in parallel by a bounded number of workers, while
+// unwinding them (which uses the caches of BinaryInfo) is done by the
+// calling goroutine as they become available.
+func GoroutinesStacktraces(tgt *Target, gs []*G, depth int, opts StacktraceOptions) ([][]Stackframe, []error) {
+	stacks := make([][]Stackframe, len(gs))
+	errs := make([]error, len(gs))
+
+	mem := tgt.Memory()
+	if !cacheEnabled || len(gs) < 2 || !canReadMemoryConcurrently(mem) {
+		for i, g := range gs {
+			stacks[i], errs[i] = GoroutineStacktrace(tgt, g, depth, opts)
+		}
+		return stacks, errs
+	}
+
+	type prefetchedStack struct {
+		i   int
+		mem MemoryReadWriter
+	}
+
+	workers := min(runtime.GOMAXPROCS(0), len(gs))
+	work := make(chan int)
+	prefetched := make(chan prefetchedStack, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				prefetched <- prefetchedStack{i, prefetchGoroutineStack(mem, gs[i])}
+			}
+		}()
+	}
+	go func() {
+		for i := range gs {
+			work <- i
+		}
+		close(work)
+		wg.Wait()
+		close(prefetched)
+	}()
+
+	for p := range prefetched {
+		stacks[p.i], errs[p.i] = goroutineStacktrace(tgt, gs[p.i], p.mem, depth, opts)
+	}
+	return stacks, errs
+}
+
+// prefetchGoroutineStack reads the used portion of the stack of g, up to
+// maxGoroutineStackPrefetch bytes, and returns a memory reader for g that
+// serves reads of it from a cache.
+func prefetchGoroutineStack(mem MemoryReader, g *G) MemoryReadWriter {
+	gmem := g.variable.mem
+	if g.Thread != nil || g.SP < g.stack.lo || g.SP >= g.stack.hi {
+		// Running goroutines are unwound starting from the registers of their
+		// thread, not from the saved stack pointer.
+		return gmem
+	}
+	buf := make([]byte, min(g.stack.hi-g.SP, maxGoroutineStackPrefetch))
+	if _, err := mem.ReadMemory(buf, g.SP); err != nil {
+		return gmem
+	}
+	return &memCache{loaded: true, cacheAddr: g.SP, cache: buf, mem: gmem}
+}
+
 // NullAddrError is an error for a null address.
 type NullAddrError struct{}
 
@@ -288,7 +366,7 @@ func (it *stackIterator) switchToGoroutineStack() {
 	it.pc = it.g.PC
 	it.regs.Reg(it.regs.SPRegNum).Uint64Val = it.g.SP
 	it.regs.AddReg(it.regs.BPRegNum, op.DwarfRegisterFromUint64(it.g.BP))
-	if it.bi.Arch.Name == "arm64" || it.bi.Arch.Name == "ppc64le" {
+	if it.bi.Arch.Name == "arm64" || it.bi.Arch.Name == "ppc64le" || it.bi.Arch.Name == "riscv64" || it.bi.Arch.Name == "loong64" {
 		it.regs.Reg(it.regs.LRRegNum).Uint64Val = it.g.LR
 	}
 }
@@ -539,7 +617,7 @@ func (it *stackIterator) advanceRegs() (callFrameRegs op.DwarfRegisters, ret uin
 		}
 	}
 
-	if it.bi.Arch.Name == "arm64" || it.bi.Arch.Name == "ppc64le" {
+	if it.bi.Arch.Name == "arm64" || it.bi.Arch.Name == "ppc64le" || it.bi.Arch.Name == "riscv64" || it.bi.Arch.Name == "loong64" {
 		if ret == 0 && it.regs.Reg(it.regs.LRRegNum) != nil {
 			ret = it.regs.Reg(it.regs.LRRegNum).Uint64Val
 		}
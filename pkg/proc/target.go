@@ -19,6 +19,10 @@ var (
 	// only possible on recorded (traced) programs.
 	ErrNotRecorded = errors.New("not a recording")
 
+	// ErrNoRecordedState is returned by StepBack when there is no saved
+	// state to restore.
+	ErrNoRecordedState = errors.New("no recorded state to restore")
+
 	// ErrNoRuntimeAllG is returned when the runtime.allg list could
 	// not be found.
 	ErrNoRuntimeAllG = errors.New("could not find goroutine array")
@@ -32,6 +36,10 @@ type LaunchFlags uint8
 const (
 	LaunchForeground LaunchFlags = 1 << iota
 	LaunchDisableASLR
+	// LaunchRecordState saves the state of the target every time it is
+	// resumed so that it can be restored with StepBack. Only supported by
+	// the native backend on linux.
+	LaunchRecordState
 )
 
 // Target represents the process being debugged.
@@ -68,6 +76,10 @@ type Target struct {
 	gcache goroutineCache
 	iscgo  *bool
 
+	// waitReasonStrings is the cached value of runtime.waitReasonStrings,
+	// see WaitReasonString.
+	waitReasonStrings []string
+
 	// exitStatus is the exit status of the process we are debugging.
 	// Saved here to relay to any future commands.
 	exitStatus int
@@ -584,6 +596,13 @@ func (t *Target) pluginOpenCallback(Thread, *Target) (bool, error) {
 			} else {
 				logger.Debugf("suspended breakpoint %d enabled", lbp.LogicalID)
 			}
+		} else if lbp.Enabled && (lbp.Set.File != "" || lbp.Set.FunctionName != "") {
+			// The plugin could contain new instantiations of a generic function,
+			// or new inlined calls, for the location of the breakpoint.
+			err := enableBreakpointOnTarget(t, lbp)
+			if err != nil {
+				logger.Debugf("could not update breakpoint %d: %v", lbp.LogicalID, err)
+			}
 		}
 	}
 	return false, nil
@@ -40,19 +40,29 @@ func (grp *TargetGroup) Next() (err error) {
 	if grp.HasSteppingBreakpoints() {
 		return errors.New("next while nexting")
 	}
+	grp.recordState()
 
 	if err = next(grp.Selected, false, false); err != nil {
 		grp.Selected.ClearSteppingBreakpoints()
 		return
 	}
 
-	return grp.Continue()
+	return grp.resume()
 }
 
 // Continue continues execution of the debugged
 // processes. It will continue until it hits a breakpoint
 // or is otherwise stopped.
 func (grp *TargetGroup) Continue() error {
+	grp.recordState()
+	return grp.resume()
+}
+
+// resume continues execution of the debugged processes like Continue but
+// does not record their state, it is used by the commands that continue
+// the processes internally: only the state at the start of a command
+// should be restored by StepBack.
+func (grp *TargetGroup) resume() error {
 	if grp.numValid() == 0 {
 		_, err := grp.targets[0].Valid()
 		return err
@@ -206,7 +216,7 @@ func (grp *TargetGroup) Continue() error {
 					if err := dbp.ClearSteppingBreakpoints(); err != nil {
 						return err
 					}
-					return grp.StepInstruction(false)
+					return grp.stepInstruction(false)
 				}
 			} else {
 				curthread.Common().returnValues = curbp.Breakpoint.returnInfo.Collect(dbp, curthread)
@@ -251,6 +261,63 @@ func (grp *TargetGroup) Continue() error {
 	}
 }
 
+// recordState saves the state of all valid targets whose backend supports
+// it, so that it can be restored later by StepBack. It is called at the
+// start of every command that resumes the targets and not when a command
+// resumes them internally, so that StepBack always goes back to a stop
+// that was visible to the user.
+func (grp *TargetGroup) recordState() {
+	for _, dbp := range grp.targets {
+		if isvalid, _ := dbp.Valid(); !isvalid {
+			continue
+		}
+		if sr, ok := dbp.proc.(StateRecorder); ok {
+			if err := sr.RecordState(dbp.CurrentThread()); err != nil {
+				logflags.DebuggerLogger().Errorf("could not record state of process %d: %v", dbp.Pid(), err)
+			}
+		}
+	}
+}
+
+// StepBack restores the state that the targets had at the start of the
+// last command that resumed them. This is only possible if the targets
+// were launched with LaunchRecordState.
+func (grp *TargetGroup) StepBack() error {
+	if grp.numValid() == 0 {
+		_, err := grp.targets[0].Valid()
+		return err
+	}
+	restored := false
+	for _, dbp := range grp.targets {
+		if isvalid, _ := dbp.Valid(); !isvalid {
+			continue
+		}
+		sr, ok := dbp.proc.(StateRecorder)
+		if !ok {
+			continue
+		}
+		dbp.ClearCaches()
+		curthread, err := sr.RestoreState()
+		if err != nil {
+			return err
+		}
+		restored = true
+		for _, thread := range dbp.ThreadList() {
+			thread.Breakpoint().Clear()
+			if err := thread.SetCurrentBreakpoint(false); err != nil {
+				return err
+			}
+		}
+		dbp.currentThread = curthread
+		dbp.selectedGoroutine, _ = GetG(curthread)
+		dbp.StopReason = StopManual
+	}
+	if !restored {
+		return errors.New("step-back is not supported by this backend")
+	}
+	return nil
+}
+
 func (grp *TargetGroup) finishManualStop() {
 	for _, dbp := range grp.targets {
 		if isvalid, _ := dbp.Valid(); !isvalid {
@@ -457,6 +524,7 @@ func (grp *TargetGroup) Step() (err error) {
 	if grp.HasSteppingBreakpoints() {
 		return errors.New("next while nexting")
 	}
+	grp.recordState()
 
 	if err = next(grp.Selected, true, false); err != nil {
 		_ = grp.Selected.ClearSteppingBreakpoints()
@@ -465,10 +533,41 @@ func (grp *TargetGroup) Step() (err error) {
 
 	if bpstate := grp.Selected.CurrentThread().Breakpoint(); bpstate.Breakpoint != nil && bpstate.Active && bpstate.SteppingInto && grp.GetDirection() == Backward {
 		grp.Selected.ClearSteppingBreakpoints()
-		return grp.StepInstruction(false)
+		return grp.stepInstruction(false)
 	}
 
-	return grp.Continue()
+	return grp.resume()
+}
+
+// StepIntoFunction resumes the processes in the group, continuing the
+// selected target until the function fnName, called from the current
+// source line, is entered. Calls to other functions made by the current
+// line are stepped over. An error is returned if the current line does not
+// call fnName, either directly or through an indirect call. If only
+// indirect calls are made and none of them reaches fnName execution stops
+// on the next source line, like Next.
+func (grp *TargetGroup) StepIntoFunction(fnName string) (err error) {
+	if _, err := grp.Valid(); err != nil {
+		return err
+	}
+	if grp.HasSteppingBreakpoints() {
+		return errors.New("next while nexting")
+	}
+	if grp.GetDirection() == Backward {
+		return errors.New("can not step into a function backwards")
+	}
+	grp.recordState()
+
+	if err = next(grp.Selected, false, false); err != nil {
+		_ = grp.Selected.ClearSteppingBreakpoints()
+		return err
+	}
+	if err = setStepIntoFunctionBreakpoints(grp.Selected, fnName); err != nil {
+		_ = grp.Selected.ClearSteppingBreakpoints()
+		return err
+	}
+
+	return grp.resume()
 }
 
 // sameGoroutineCondition returns an expression that evaluates to true when
@@ -500,6 +599,11 @@ func frameoffCondition(frame *Stackframe) ast.Expr {
 // until the current goroutine exits the function currently being
 // executed or a deferred function is executed
 func (grp *TargetGroup) StepOut() error {
+	grp.recordState()
+	return grp.stepOut()
+}
+
+func (grp *TargetGroup) stepOut() error {
 	backward := grp.GetDirection() == Backward
 	if _, err := grp.Valid(); err != nil {
 		return err
@@ -540,7 +644,7 @@ func (grp *TargetGroup) StepOut() error {
 		}
 
 		success = true
-		return grp.Continue()
+		return grp.resume()
 	}
 
 	sameGCond := sameGoroutineCondition(dbp.BinInfo(), selg, curthread.ThreadID())
@@ -551,7 +655,7 @@ func (grp *TargetGroup) StepOut() error {
 		}
 
 		success = true
-		return grp.Continue()
+		return grp.resume()
 	}
 
 	deferpc, err := setDeferBreakpoint(dbp, nil, topframe, sameGCond, false)
@@ -580,7 +684,7 @@ func (grp *TargetGroup) StepOut() error {
 	}
 
 	success = true
-	return grp.Continue()
+	return grp.resume()
 }
 
 // StepInstruction will continue the current thread for exactly
@@ -588,6 +692,11 @@ func (grp *TargetGroup) StepOut() error {
 // associated with the selected goroutine. All other
 // threads will remain stopped.
 func (grp *TargetGroup) StepInstruction(skipCalls bool) (err error) {
+	grp.recordState()
+	return grp.stepInstruction(skipCalls)
+}
+
+func (grp *TargetGroup) stepInstruction(skipCalls bool) (err error) {
 	dbp := grp.Selected
 	thread := dbp.CurrentThread()
 	g := dbp.SelectedGoroutine()
@@ -598,7 +707,7 @@ func (grp *TargetGroup) StepInstruction(skipCalls bool) (err error) {
 				sameGoroutineCondition(dbp.BinInfo(), dbp.SelectedGoroutine(), thread.ThreadID())); err != nil {
 				return err
 			}
-			return grp.Continue()
+			return grp.resume()
 		}
 		thread = g.Thread
 	}
@@ -627,7 +736,7 @@ func (grp *TargetGroup) StepInstruction(skipCalls bool) (err error) {
 	dbp.StopReason = StopNextFinished
 
 	if skipCalls && isCall {
-		return grp.StepOut()
+		return grp.stepOut()
 	}
 
 	return nil
@@ -922,6 +1031,99 @@ func setStepIntoBreakpoints(dbp *Target, curfn *Function, text []AsmInstruction,
 	return nil
 }
 
+// setStepIntoFunctionBreakpoints sets step-into breakpoints on the calls
+// to fnName made by the current source line. Calls with a destination that
+// can not be determined statically get a StepBreakpoint that checks the
+// destination when the call is reached.
+func setStepIntoFunctionBreakpoints(dbp *Target, fnName string) error {
+	selg := dbp.SelectedGoroutine()
+	curthread := dbp.CurrentThread()
+	bi := dbp.BinInfo()
+	topframe, _, err := topframe(dbp, selg, curthread)
+	if err != nil {
+		return err
+	}
+	if topframe.Current.Fn == nil {
+		return &ErrNoSourceForPC{topframe.Current.PC}
+	}
+	var regs Registers
+	if selg != nil && selg.Thread != nil {
+		regs, err = selg.Thread.Registers()
+		if err != nil {
+			return err
+		}
+	}
+	text, err := disassemble(dbp.Memory(), regs, dbp.Breakpoints(), bi, topframe.Current.Fn.Entry, topframe.Current.Fn.End, false)
+	if err != nil {
+		return err
+	}
+	sameGCond := sameGoroutineCondition(bi, selg, curthread.ThreadID())
+
+	found := false
+	for _, instr := range text {
+		if instr.Loc.File != topframe.Current.File || instr.Loc.Line != topframe.Current.Line || !instr.IsCall() {
+			continue
+		}
+		if instr.DestLoc != nil {
+			if fn, _ := skipAutogeneratedWrappersIn(dbp, instr.DestLoc.Fn, instr.DestLoc.PC, false); !stepIntoFunctionMatch(fn, fnName) {
+				continue
+			}
+			if err := setStepIntoBreakpoint(dbp, topframe.Current.Fn, []AsmInstruction{instr}, sameGCond); err != nil {
+				return err
+			}
+		} else {
+			bp, err := allowDuplicateBreakpoint(dbp.SetBreakpoint(0, instr.Loc.PC, StepBreakpoint, sameGCond))
+			if err != nil {
+				return err
+			}
+			breaklet := bp.Breaklets[len(bp.Breaklets)-1]
+			breaklet.callback = func(curthread Thread, p *Target) (bool, error) {
+				return stepIntoFunctionCallback(curthread, p, fnName)
+			}
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("no call to %s on the current line", fnName)
+	}
+	return nil
+}
+
+// stepIntoFunctionMatch returns true if fn is the function called fnName,
+// fnName can be a fully qualified name or omit the package path.
+func stepIntoFunctionMatch(fn *Function, fnName string) bool {
+	if fn == nil {
+		return false
+	}
+	return fn.Name == fnName || strings.HasSuffix(fn.Name, "."+fnName) || fn.BaseName() == fnName
+}
+
+// stepIntoFunctionCallback is the callback of the StepBreakpoints set by
+// setStepIntoFunctionBreakpoints, like stepIntoCallback it sets a
+// breakpoint on the destination of the current instruction but only if it
+// is the function fnName.
+func stepIntoFunctionCallback(curthread Thread, p *Target, fnName string) (bool, error) {
+	text, err := disassembleCurrentInstruction(p, curthread, 0)
+	if err != nil {
+		return false, err
+	}
+	if len(text) == 0 || text[0].DestLoc == nil {
+		return false, nil
+	}
+	if fn, _ := skipAutogeneratedWrappersIn(p, text[0].DestLoc.Fn, text[0].DestLoc.PC, false); !stepIntoFunctionMatch(fn, fnName) {
+		return false, nil
+	}
+	var fn *Function
+	if loc, _ := curthread.Location(); loc != nil {
+		fn = loc.Fn
+	}
+	g, _ := GetG(curthread)
+	if err = setStepIntoBreakpoint(p, fn, text, sameGoroutineCondition(p.BinInfo(), g, curthread.ThreadID())); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
 // stepIntoCallback is a callback called when a StepBreakpoint is hit, it
 // disassembles the current instruction to figure out its destination and
 // sets a breakpoint on it.
@@ -1530,7 +1732,7 @@ func (w *onNextGoroutineWalker) Visit(n ast.Node) ast.Visitor {
 	if binx, isbin := n.(*ast.BinaryExpr); isbin && binx.Op == token.EQL {
 		x := exprToString(binx.X)
 		if x == "runtime.curg.goid" || x == "runtime.threadid" {
-			w.ret, w.err = evalBreakpointCondition(w.tgt, w.thread, n.(ast.Expr))
+			w.ret, w.err = evalBreakpointCondition(w.tgt, w.thread, n.(ast.Expr), nil)
 			return nil
 		}
 	}
@@ -40,6 +40,24 @@ type Thread interface {
 	SetReg(uint64, *op.DwarfRegister) error
 }
 
+// NamedThread is implemented by threads that can report the name the
+// operating system associates with them.
+type NamedThread interface {
+	// Name returns the name of the thread, or the empty string if the
+	// thread doesn't have a name.
+	Name() string
+}
+
+// ThreadName returns the name of thread, or the empty string if the
+// backend does not support thread names.
+func ThreadName(thread Thread) string {
+	nthread, ok := thread.(NamedThread)
+	if !ok {
+		return ""
+	}
+	return nthread.Name()
+}
+
 // Location represents the location of a thread.
 // Holds information on the current instruction
 // address, the source file:line, and the function.
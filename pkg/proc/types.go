@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"go/constant"
 	"reflect"
+	"strings"
 
 	"github.com/go-delve/delve/pkg/dwarf/godwarf"
 	"github.com/go-delve/delve/pkg/dwarf/reader"
+	"github.com/go-delve/delve/pkg/logflags"
 )
 
 // The kind field in runtime._type is a reflect.Kind value plus
@@ -61,6 +63,7 @@ type loadDebugInfoMapsContext struct {
 	abstractOriginTable map[dwarf.Offset]int
 	knownPackageVars    map[string]struct{}
 	offsetToVersion     map[dwarf.Offset]uint8
+	cTypeDecls          map[string]struct{} // names of C types currently mapped to a declaration of an incomplete type
 }
 
 func newLoadDebugInfoMapsContext(bi *BinaryInfo, image *Image, offsetToVersion map[dwarf.Offset]uint8) *loadDebugInfoMapsContext {
@@ -69,6 +72,7 @@ func newLoadDebugInfoMapsContext(bi *BinaryInfo, image *Image, offsetToVersion m
 	ctxt.ardr = image.DwarfReader()
 	ctxt.abstractOriginTable = make(map[dwarf.Offset]int)
 	ctxt.offsetToVersion = offsetToVersion
+	ctxt.cTypeDecls = make(map[string]struct{})
 
 	ctxt.knownPackageVars = map[string]struct{}{}
 	for _, v := range bi.packageVars {
@@ -139,30 +143,238 @@ func resolveParametricType(bi *BinaryInfo, mem MemoryReadWriter, t godwarf.Type,
 	if dictAddr == 0 {
 		return ptyp.TypedefType.Type, errors.New("parametric type without a dictionary")
 	}
-	rtypeAddr, err := readUintRaw(mem, dictAddr+uint64(ptyp.DictIndex*int64(bi.Arch.PtrSize())), int64(bi.Arch.PtrSize()))
+	typ, err := dictEntryType(bi, mem, dictAddr, ptyp.DictIndex)
 	if err != nil {
 		return ptyp.TypedefType.Type, err
 	}
+	return typ, nil
+}
+
+// dictEntryType returns the type described by the runtime type stored in
+// the idx-th entry of the dictionary at dictAddr.
+func dictEntryType(bi *BinaryInfo, mem MemoryReadWriter, dictAddr uint64, idx int64) (godwarf.Type, error) {
+	rtypeAddr, err := readUintRaw(mem, dictAddr+uint64(idx*int64(bi.Arch.PtrSize())), int64(bi.Arch.PtrSize()))
+	if err != nil {
+		return nil, err
+	}
+	return runtimeTypeAt(bi, mem, rtypeAddr)
+}
+
+// runtimeTypeAt returns the type described by the runtime type stored at
+// rtypeAddr.
+func runtimeTypeAt(bi *BinaryInfo, mem MemoryReadWriter, rtypeAddr uint64) (godwarf.Type, error) {
 	runtimeType, err := bi.findType(bi.runtimeTypeTypename())
 	if err != nil {
-		return ptyp.TypedefType.Type, err
+		return nil, err
 	}
 	_type := newVariable("", rtypeAddr, runtimeType, bi, mem)
 
 	mds, err := LoadModuleData(bi, _type.mem)
 	if err != nil {
-		return ptyp.TypedefType.Type, fmt.Errorf("error loading module data: %v", err)
+		return nil, fmt.Errorf("error loading module data: %v", err)
 	}
 
 	typ, _, err := RuntimeTypeToDIE(_type, 0, mds)
 	if err != nil {
-		return ptyp.TypedefType.Type, err
+		return nil, err
 	}
 
 	return typ, nil
 }
 
+const shapePrefix = "go.shape."
+
+// shapeTypeArgs returns a map from the names of the shape types used to
+// instantiate fn to the type arguments they stand for, read from the
+// dictionary at dictAddr. The first entries of a dictionary are the type
+// arguments of the instantiation, in the same order as the shapes appear
+// in the name of the function.
+// Shapes standing for more than one type argument are left out since they
+// can not be resolved unambiguously.
+func shapeTypeArgs(bi *BinaryInfo, mem MemoryReadWriter, fn *Function, dictAddr uint64) map[string]godwarf.Type {
+	if fn == nil || dictAddr == 0 {
+		return nil
+	}
+	inst := fn.instRange()
+	if inst[0] == inst[1] {
+		return nil
+	}
+	typeArgs := make(map[string]godwarf.Type)
+	ambiguous := make(map[string]bool)
+	for i, shape := range splitTypeParams(fn.Name[inst[0]+1 : inst[1]]) {
+		if !strings.HasPrefix(shape, shapePrefix) || ambiguous[shape] {
+			continue
+		}
+		typ, err := dictEntryType(bi, mem, dictAddr, int64(i))
+		if err != nil {
+			logflags.DebuggerLogger().Errorf("could not resolve type argument %d of %s: %v", i, fn.Name, err)
+			continue
+		}
+		if prev := typeArgs[shape]; prev != nil && typeName(prev) != typeName(typ) {
+			delete(typeArgs, shape)
+			ambiguous[shape] = true
+			continue
+		}
+		typeArgs[shape] = typ
+	}
+	return typeArgs
+}
+
+// splitTypeParams splits a comma separated list of type parameters,
+// ignoring the commas nested inside brackets and parenthesis.
+func splitTypeParams(s string) []string {
+	var r []string
+	depth, start := 0, 0
+	for i, ch := range s {
+		switch ch {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				r = append(r, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(r, s[start:])
+}
+
+func typeName(t godwarf.Type) string {
+	if t.Common().Name != "" {
+		return t.Common().Name
+	}
+	return t.String()
+}
+
+// substTypeArgs returns a copy of t where shape types are replaced by the
+// corresponding type arguments in typeArgs, both in the name of t and in
+// the types it references.
+func substTypeArgs(t godwarf.Type, typeArgs map[string]godwarf.Type) godwarf.Type {
+	if len(typeArgs) == 0 || t == nil {
+		return t
+	}
+	s := &shapeSubst{typeArgs: typeArgs, done: make(map[godwarf.Type]godwarf.Type)}
+	return s.subst(t)
+}
+
+type shapeSubst struct {
+	typeArgs map[string]godwarf.Type
+	done     map[godwarf.Type]godwarf.Type // types already substituted, used to stop at recursive types
+}
+
+func (s *shapeSubst) subst(t godwarf.Type) godwarf.Type {
+	if t == nil {
+		return nil
+	}
+	if typ := s.typeArgs[t.Common().Name]; typ != nil {
+		return typ
+	}
+	if r := s.done[t]; r != nil {
+		return r
+	}
+	if !strings.Contains(t.String(), shapePrefix) {
+		return t
+	}
+	switch t := t.(type) {
+	case *godwarf.PtrType:
+		r := *t
+		s.done[t] = &r
+		r.Name = s.substName(t.Name)
+		r.Type = s.subst(t.Type)
+		return &r
+	case *godwarf.ArrayType:
+		r := *t
+		s.done[t] = &r
+		r.Name = s.substName(t.Name)
+		r.Type = s.subst(t.Type)
+		return &r
+	case *godwarf.StructType:
+		r := *t
+		s.done[t] = &r
+		s.substStruct(&r)
+		return &r
+	case *godwarf.SliceType:
+		r := *t
+		s.done[t] = &r
+		s.substStruct(&r.StructType)
+		r.ElemType = s.subst(t.ElemType)
+		return &r
+	case *godwarf.MapType:
+		r := *t
+		s.done[t] = &r
+		r.Name = s.substName(t.Name)
+		r.KeyType = s.subst(t.KeyType)
+		r.ElemType = s.subst(t.ElemType)
+		return &r
+	case *godwarf.ChanType:
+		r := *t
+		s.done[t] = &r
+		r.Name = s.substName(t.Name)
+		r.ElemType = s.subst(t.ElemType)
+		return &r
+	case *godwarf.TypedefType:
+		r := *t
+		s.done[t] = &r
+		r.Name = s.substName(t.Name)
+		r.Type = s.subst(t.Type)
+		return &r
+	}
+	return t
+}
+
+func (s *shapeSubst) substStruct(t *godwarf.StructType) {
+	t.Name = s.substName(t.Name)
+	t.StructName = s.substName(t.StructName)
+	fields := make([]*godwarf.StructField, len(t.Field))
+	for i, field := range t.Field {
+		f := *field
+		f.Type = s.subst(field.Type)
+		fields[i] = &f
+	}
+	t.Field = fields
+}
+
+// substName replaces the names of the shape types in typeArgs appearing in
+// name with the names of the corresponding type arguments.
+func (s *shapeSubst) substName(name string) string {
+	if !strings.Contains(name, shapePrefix) {
+		return name
+	}
+	var buf strings.Builder
+	for {
+		i := strings.Index(name, shapePrefix)
+		if i < 0 {
+			break
+		}
+		buf.WriteString(name[:i])
+		name = name[i:]
+		shape := ""
+		for k := range s.typeArgs {
+			if len(k) > len(shape) && strings.HasPrefix(name, k) && (len(name) == len(k) || strings.IndexByte(",])", name[len(k)]) >= 0) {
+				shape = k
+			}
+		}
+		if shape == "" {
+			buf.WriteString(shapePrefix)
+			name = name[len(shapePrefix):]
+			continue
+		}
+		buf.WriteString(typeName(s.typeArgs[shape]))
+		name = name[len(shape):]
+	}
+	buf.WriteString(name)
+	return buf.String()
+}
+
 func dwarfToRuntimeType(bi *BinaryInfo, mem MemoryReadWriter, typ godwarf.Type) (typeAddr uint64, typeKind uint64, found bool, err error) {
+	if _, isptr := typ.(*godwarf.PtrType); isptr && typ.Common().Offset == 0 {
+		// pointer type created by pointerTo, look for the real one.
+		if realtyp, err := bi.findType(typ.Common().Name); err == nil {
+			typ = realtyp
+		}
+	}
 	so := bi.typeToImage(typ)
 	rdr := so.DwarfReader()
 	rdr.Seek(typ.Common().Offset)
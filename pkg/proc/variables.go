@@ -36,9 +36,14 @@ const (
 	hashMinTopHashGo111 = 4 // +rtype minTopHash
 	// hashMinTopHashGo112 is used by map reading code, indicates minimum value of tophash that isn't empty or evacuated, in Go1.12
 	hashMinTopHashGo112 = 5 // +rtype minTopHash
+	// maxMapKeySize and maxMapElemSize are the maximum size of keys and values stored directly inside map buckets
+	maxMapKeySize  = 128 // +rtype maxKeySize
+	maxMapElemSize = 128 // +rtype maxElemSize
 
 	maxFramePrefetchSize = 1 * 1024 * 1024 // Maximum prefetch size for a stack frame
 
+	maxGoroutineStackPrefetch = 64 * 1024 // Maximum prefetch size for the stack of a goroutine when listing stacktraces
+
 	maxMapBucketsFactor = 100 // Maximum numbers of map buckets to read for every requested map entry when loading variables through (*EvalScope).LocalVariables and (*EvalScope).FunctionArguments.
 
 	maxGoroutineUserCurrentDepth = 30 // Maximum depth used by (*G).UserCurrent to search its location
@@ -86,6 +91,9 @@ const (
 	VariableCPtr
 	// VariableCPURegister means this variable is a CPU register.
 	VariableCPURegister
+	// VariableCaptured means this variable was captured by a closure and it
+	// is stored in the closure context instead of the stack frame.
+	VariableCaptured
 	// variableTrustLen means that when this variable is loaded its length
 	// should be trusted and used instead of MaxArrayValues
 	variableTrustLen
@@ -889,6 +897,9 @@ func (v *Variable) parseG() (*G, error) {
 
 	loadInt64Maybe := func(name string) int64 {
 		vv := v.loadFieldNamed(name)
+		if vv == nil {
+			vv = v.loadGFieldFallback(name)
+		}
 		if vv == nil {
 			unreadable = true
 			return 0
@@ -899,6 +910,9 @@ func (v *Variable) parseG() (*G, error) {
 
 	loadUint64Maybe := func(name string) uint64 {
 		vv := v.loadFieldNamed(name)
+		if vv == nil {
+			vv = v.loadGFieldFallback(name)
+		}
 		if vv == nil {
 			unreadable = true
 			return 0
@@ -930,7 +944,11 @@ func (v *Variable) parseG() (*G, error) {
 	}
 
 	status := uint64(0)
-	if atomicStatus := v.loadFieldNamed("atomicstatus"); /* +rtype uint32|runtime/internal/atomic.Uint32|internal/runtime/atomic.Uint32 */ atomicStatus != nil {
+	atomicStatus := v.loadFieldNamed("atomicstatus") // +rtype uint32|runtime/internal/atomic.Uint32|internal/runtime/atomic.Uint32
+	if atomicStatus == nil {
+		atomicStatus = v.loadGFieldFallback("atomicstatus")
+	}
+	if atomicStatus != nil {
 		if constant.Val(atomicStatus.Value) != nil {
 			status, _ = constant.Uint64Val(atomicStatus.Value)
 		} else {
@@ -1182,7 +1200,7 @@ func readVarEntry(entry *godwarf.Tree, image *Image) (name string, typ godwarf.T
 
 // Extracts the name and type of a variable from a dwarf entry
 // then executes the instructions given in the  DW_AT_location attribute to grab the variable's address
-func extractVarInfoFromEntry(tgt *Target, bi *BinaryInfo, image *Image, regs op.DwarfRegisters, mem MemoryReadWriter, entry *godwarf.Tree, dictAddr uint64) (*Variable, error) {
+func extractVarInfoFromEntry(tgt *Target, bi *BinaryInfo, image *Image, regs op.DwarfRegisters, mem MemoryReadWriter, entry *godwarf.Tree, dictAddr uint64, typeArgs map[string]godwarf.Type) (*Variable, error) {
 	if entry.Tag != dwarf.TagFormalParameter && entry.Tag != dwarf.TagVariable {
 		return nil, fmt.Errorf("invalid entry tag, only supports FormalParameter and Variable, got %s", entry.Tag.String())
 	}
@@ -1197,6 +1215,7 @@ func extractVarInfoFromEntry(tgt *Target, bi *BinaryInfo, image *Image, regs op.
 		// Log the error, keep going with t, which will be the shape type
 		logflags.DebuggerLogger().Errorf("could not resolve parametric type of %s: %v", n, err)
 	}
+	t = substTypeArgs(t, typeArgs)
 
 	addr, pieces, descr, err := bi.Location(entry, dwarf.AttrLocation, regs.PC(), regs, mem)
 	if pieces != nil {
@@ -1240,7 +1259,7 @@ func (v *Variable) maybeDereference() *Variable {
 			return &v.Children[0]
 		}
 		ptrval, err := readUintRaw(v.mem, v.Addr, t.ByteSize)
-		r := v.newVariable("", ptrval, t.Type, DereferenceMemory(v.mem))
+		r := v.newVariable("", ptrval, cgoCompleteType(v.bi, t.Type), DereferenceMemory(v.mem))
 		if err != nil {
 			r.Unreadable = err
 		}
@@ -1267,7 +1286,7 @@ func (v *Variable) loadPtr() {
 	if v.Unreadable == nil {
 		ptrval, err := readUintRaw(v.mem, v.Addr, t.ByteSize)
 		if err == nil {
-			child = v.newVariable("", ptrval, t.Type, DereferenceMemory(v.mem))
+			child = v.newVariable("", ptrval, cgoCompleteType(v.bi, t.Type), DereferenceMemory(v.mem))
 		} else {
 			// We failed to read the pointer value; mark v as unreadable.
 			v.Unreadable = err
@@ -1403,6 +1422,8 @@ func (v *Variable) loadValueInternal(recurseLevel int, cfg LoadConfig) {
 		}
 		if t.Name == "time.Time" {
 			v.formatTime()
+		} else if strings.HasPrefix(t.Name, "sync/atomic.") && recurseLevel <= cfg.MaxVariableRecurse {
+			v.formatAtomic(recurseLevel, cfg)
 		}
 
 	case reflect.Interface:
@@ -1467,7 +1488,7 @@ func convertToEface(srcv, dstv *Variable) error {
 		dstv.writeEmptyInterface(_type.Addr, data)
 		return nil
 	}
-	typeAddr, typeKind, runtimeTypeFound, err := dwarfToRuntimeType(srcv.bi, srcv.mem, srcv.RealType)
+	typeAddr, typeKind, runtimeTypeFound, err := dwarfToRuntimeType(srcv.bi, dstv.mem, srcv.RealType)
 	if err != nil {
 		return err
 	}
@@ -2026,6 +2047,10 @@ type mapIterator struct {
 	values    *Variable
 	overflow  *Variable
 
+	// keys and values larger than 128 bytes are stored in buckets
+	// indirectly, as pointers.
+	indirectKeys, indirectValues bool
+
 	maxNumBuckets uint64 // maximum number of buckets to scan
 
 	idx int64
@@ -2049,6 +2074,11 @@ func (v *Variable) mapIterator() *mapIterator {
 
 	it := &mapIterator{v: v, bidx: 0, b: nil, idx: 0}
 
+	if mt, ok := v.RealType.(*godwarf.MapType); ok {
+		it.indirectKeys = mt.KeyType.Size() > maxMapKeySize
+		it.indirectValues = mt.ElemType.Size() > maxMapElemSize
+	}
+
 	if sv.Addr == 0 {
 		it.numbuckets = 0
 		return it
@@ -2245,6 +2275,15 @@ func (it *mapIterator) value() *Variable {
 	return v
 }
 
+// derefMapEntry returns the key or value v of a map entry, dereferencing
+// it if the map stores it in its buckets indirectly, as a pointer.
+func derefMapEntry(v *Variable, indirect bool) *Variable {
+	if indirect && v != nil && v.Kind == reflect.Ptr {
+		return v.maybeDereference()
+	}
+	return v
+}
+
 func (it *mapIterator) mapEvacuated(b *Variable) bool {
 	if b.Addr == 0 {
 		return true
@@ -2506,6 +2545,38 @@ resolveQualTypedef:
 	return ischar || isuchar
 }
 
+// cgoCompleteType returns the C definition of typ if typ is the Go type
+// generated by cgo for a C struct or union that was incomplete in the
+// preamble, for example one only declared by the C headers and defined in
+// a C source file. Otherwise typ is returned.
+// The Go types generated by cgo for incomplete types have no fields so we
+// look up the C definition to be able to read their fields.
+func cgoCompleteType(bi *BinaryInfo, typ godwarf.Type) godwarf.Type {
+	const ctypePrefix = "._Ctype_"
+	name := typ.Common().Name
+	i := strings.Index(name, ctypePrefix)
+	if i < 0 {
+		return typ
+	}
+	styp, _ := resolveTypedef(typ).(*godwarf.StructType)
+	if styp == nil {
+		return typ
+	}
+	for _, field := range styp.Field {
+		if field.Name != "_" {
+			return typ
+		}
+	}
+	ctyp, err := bi.findType("C." + name[i+len(ctypePrefix):])
+	if err != nil {
+		return typ
+	}
+	if cstyp, _ := resolveTypedef(ctyp).(*godwarf.StructType); cstyp == nil || cstyp.Incomplete {
+		return typ
+	}
+	return ctyp
+}
+
 func (cm constantsMap) Get(typ godwarf.Type) *constantType {
 	ctyp := cm[dwarfRef{typ.Common().Index, typ.Common().Offset}]
 	if ctyp == nil {
@@ -2643,3 +2714,43 @@ func (v *Variable) formatTime() {
 		v.Value = constant.MakeString(t.Format(time.RFC3339))
 	}
 }
+
+// formatAtomic writes the value of the wrapper types of package sync/atomic
+// (Bool, Int32, Int64, Uint32, Uint64 and Uintptr) to v.Value.
+// For Pointer[T] the unsafe.Pointer field holding the value is replaced
+// with a variable of type *T.
+// See $GOROOT/src/sync/atomic/type.go for the definition of the wrapper
+// types.
+func (v *Variable) formatAtomic(recurseLevel int, cfg LoadConfig) {
+	t := v.RealType.(*godwarf.StructType)
+	valv := v.fieldVariable("v")
+	if valv == nil || valv.Unreadable != nil {
+		return
+	}
+	switch {
+	case t.Name == "sync/atomic.Bool":
+		if valv.Value != nil {
+			n, _ := constant.Uint64Val(valv.Value)
+			v.Value = constant.MakeString(strconv.FormatBool(n != 0))
+		}
+	case strings.HasPrefix(t.Name, "sync/atomic.Pointer["):
+		// The type of the pointer is only recorded in the _ [0]*T field.
+		for _, field := range t.Field {
+			if field.Name != "_" {
+				continue
+			}
+			if arr, ok := resolveTypedef(field.Type).(*godwarf.ArrayType); ok {
+				ptrv := v.newVariable("v", valv.Addr, arr.Type, v.mem)
+				ptrv.loadValueInternal(recurseLevel+1, cfg)
+				*valv = *ptrv
+			}
+		}
+	default:
+		switch valv.Kind {
+		case reflect.Int32, reflect.Int64, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if valv.Value != nil {
+				v.Value = constant.MakeString(valv.Value.String())
+			}
+		}
+	}
+}
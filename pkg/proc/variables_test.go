@@ -188,6 +188,12 @@ func TestSetVariable(t *testing.T) {
 		expr     string
 		finalVal string // new value of <name> after executing <name> = <expr>
 	}{
+		{"s4[8]", "int", "9", "42", "42"},
+		{"c1.sa[1]", "*main.astruct", "*main.astruct {A: 2, B: 3}", "c1.sa[0]", "*main.astruct {A: 1, B: 2}"},
+		{"m3[as1]", "int", "42", "44", "44"},
+		{`m1["Adenauer"]`, "main.astruct", "main.astruct {A: 0, B: 0}", "as1", "main.astruct {A: 1, B: 1}"},
+		{"m4[as1].A", "int", "11", "12", "12"},
+
 		{"b.ptr", "*main.A", "*main.A {val: 1337}", "nil", "*main.A nil"},
 		{"m2", "map[int]*main.astruct", "map[int]*main.astruct [1: *{A: 10, B: 11}, ]", "nil", "map[int]*main.astruct nil"},
 		{"fn1", "main.functype", "main.afunc", "nil", "nil"},
@@ -237,6 +243,53 @@ func TestSetVariable(t *testing.T) {
 				assertVariable(t, variable, varTest{tc.name, true, tc.finalVal, "", tc.typ, nil})
 			}
 		}
+
+		err := setVariable(p, `m1["nonexistent"]`, "as1")
+		if err == nil || !strings.Contains(err.Error(), "key not found") {
+			t.Errorf("expected key not found error assigning to a nonexistent map key, got %v", err)
+		}
+	})
+}
+
+func TestSetNil(t *testing.T) {
+	testcases := []struct {
+		name     string
+		expr     string
+		finalVal string // new value of <name> after executing <name> = <expr>
+		isnil    bool   // value of <name> == nil after the assignment
+	}{
+		{"p1", "nil", "*int nil", true},
+		{"fn1", "nil", "nil", true},
+		{"ch1", "nil", "chan int nil", true},
+		{"m1", "nil", "map[string]main.astruct nil", true},
+		{"s2", "([]main.astruct)(nil)", "[]main.astruct len: 0, cap: 0, nil", true},
+		{"err1", "nil", "error nil", true},
+		{"err1", "(*main.astruct)(nil)", "error(*main.astruct) nil", false},
+		{"err1", "nil", "error nil", true},
+		{"iface1", "nil", "interface {} nil", true},
+		{"iface1", "(*main.astruct)(nil)", "interface {}(*main.astruct) nil", false},
+	}
+
+	withTestProcess("testvariables2", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		assertNoError(grp.Continue(), t, "Continue()")
+
+		for _, tc := range testcases {
+			assertNoError(setVariable(p, tc.name, tc.expr), t, fmt.Sprintf("SetVariable(%q, %q)", tc.name, tc.expr))
+			variable, err := evalVariableWithCfg(p, tc.name, pnormalLoadConfig)
+			assertNoError(err, t, "EvalVariable()")
+			if out := api.ConvertVar(variable).SinglelineString(); out != tc.finalVal {
+				t.Errorf("%s = %s: got %q expected %q", tc.name, tc.expr, out, tc.finalVal)
+			}
+			variable, err = evalVariableWithCfg(p, tc.name+" == nil", pnormalLoadConfig)
+			assertNoError(err, t, "EvalVariable()")
+			if isnil := constant.BoolVal(variable.Value); isnil != tc.isnil {
+				t.Errorf("%s = %s: %s == nil is %v", tc.name, tc.expr, tc.name, isnil)
+			}
+		}
+
+		if err := setVariable(p, "err1", "([]int)(nil)"); err == nil {
+			t.Errorf("assigning a value of a type that does not implement error to err1 did not return an error")
+		}
 	})
 }
 
@@ -675,12 +728,32 @@ func getEvalExpressionTestCases() []varTest {
 		{"len(ch1)", false, "4", "4", "", nil},
 		{"cap(chnil)", false, "0", "0", "", nil},
 		{"len(chnil)", false, "0", "0", "", nil},
+		{"closed(ch1)", false, "false", "false", "", nil},
+		{"closed(chnil)", false, "false", "false", "", nil},
+		{"closed(chclosed)", false, "true", "true", "", nil},
+		{"closed(chclosed) && len(chclosed) == 1", false, "true", "true", "", nil},
+		{"closed(i1)", false, "", "", "", errors.New("invalid argument i1 (type int) for closed")},
+		{"peek(ch1)", false, "1", "1", "int", nil},
+		{"peek(int3chan)", false, "main.ThreeInts {a: 1, b: 0, c: 0}", "main.ThreeInts {a: 1, b: 0, c: 0}", "main.ThreeInts", nil},
+		{"peek(chclosed)", false, "1", "1", "int", nil},
+		{"peek(chnil)", false, "", "", "", errors.New("can not peek chnil: nil channel")},
+		{"peek(i1)", false, "", "", "", errors.New("invalid argument i1 (type int) for peek")},
 		{"len(m1)", false, "66", "66", "", nil},
 		{"len(mnil)", false, "0", "0", "", nil},
-		{"imag(cpx1)", false, "2", "2", "", nil},
-		{"real(cpx1)", false, "1", "1", "", nil},
+		{"imag(cpx1)", false, "2", "2", "float64", nil},
+		{"real(cpx1)", false, "1", "1", "float64", nil},
 		{"imag(3i)", false, "3", "3", "", nil},
 		{"real(4)", false, "4", "4", "", nil},
+		{"real(complex(float32(1), 2))", false, "1", "1", "float32", nil},
+		{"complex(float32(1), float32(2))", false, "(1 + 2i)", "(1 + 2i)", "complex64", nil},
+		{"complex(1, float64(2))", false, "(1 + 2i)", "(1 + 2i)", "complex128", nil},
+		{"complex(float32(1), float64(2))", false, "", "", "", errors.New("invalid operation: complex(float32(1), float64(2)) (mismatched types float32 and float64)")},
+		{"cpx1 + complex(1, 2)", false, "(2 + 4i)", "(2 + 4i)", "complex128", nil},
+		{"cpx1 - 3i", false, "(1 - 1i)", "(1 - 1i)", "complex128", nil},
+		{"cpx1 * cpx1", false, "(-3 + 4i)", "(-3 + 4i)", "complex128", nil},
+		{"cpx1 / 2", false, "(0.5 + 1i)", "(0.5 + 1i)", "complex128", nil},
+		{"-cpx1", false, "(-1 - 2i)", "(-1 - 2i)", "complex128", nil},
+		{"complex64(cpx1)", false, "(1 + 2i)", "(1 + 2i)", "complex64", nil},
 		{"max(1, 2, 3)", false, "3", "3", "", nil},
 		{`max("one", "two", "three")`, false, `"two"`, `"two"`, "", nil},
 		{`min("one", "two", "three")`, false, `"one"`, `"one"`, "", nil},
@@ -688,6 +761,12 @@ func getEvalExpressionTestCases() []varTest {
 		{"min(s1[0], s1[1], s1[2])", false, `"one"`, `"one"`, "string", nil},
 		{`max(s1[0], "two", s1[2])`, false, `"two"`, `"two"`, "", nil},
 		{`min(s1[0], "two", s1[2])`, false, `"one"`, `"one"`, "string", nil},
+		{`base64decode("aGVsbG8=")`, false, `"hello"`, `"hello"`, "", nil},
+		{`base64decode("aGVsbG8")`, false, `"hello"`, `"hello"`, "", nil},
+		{`gunzip(base64decode("H4sIAAAAAAACA8tIzcnJ11Eozy/KSQEAOnKr/wwAAAA="))`, false, `"hello, world"`, `"hello, world"`, "", nil},
+		{"len(base64decode(str1))", false, "8", "8", "", nil},
+		{"gunzip(longbyteslice)", false, "", "", "", errors.New("gunzip: gzip: invalid header")},
+		{"base64decode(s1)", false, "", "", "", errors.New("invalid argument s1 (type []string) for base64decode")},
 
 		// nil
 		{"nil", false, "nil", "nil", "", nil},
@@ -814,6 +893,23 @@ func getEvalExpressionTestCases() []varTest {
 		{"ni8 >> 1", false, "-3", "-3", "int8", nil},
 		{"bytearray[0] * bytearray[0]", false, "144", "144", "uint8", nil},
 
+		// untyped constants
+		{"ni8 == 2.0", false, "false", "false", "", nil},
+		{"ni8 + 2.0", false, "-3", "-3", "int8", nil},
+		{"ni8 / 2.0", false, "-2", "-2", "int8", nil},
+		{"ni8 < 1e2", false, "true", "true", "", nil},
+		{"bytetypearray[0] == 116", false, "true", "true", "", nil},
+		{"1 + bytetypearray[0]", false, "117", "117", "main.Byte", nil},
+		{"ni8 + 300", false, "", "", "", errors.New("constant 300 overflows int8")},
+		{"ni8 == -129", false, "", "", "", errors.New("constant -129 overflows int8")},
+		{"-1 == bytearray[0]", false, "", "", "", errors.New("constant -1 overflows uint8")},
+		{"ni8 * 1.5", false, "", "", "", errors.New("can not convert 1.5 constant to int8: truncated to integer")},
+		{"0x_1F", false, "31", "31", "", nil},
+		{"0b1_01", false, "5", "5", "", nil},
+		{"0o17 + 017", false, "30", "30", "", nil},
+		{"1_000_000", false, "1000000", "1000000", "", nil},
+		{"ni8 == -0x5", false, "true", "true", "", nil},
+
 		// function call / typecast errors
 		{"unknownthing(1, 2)", false, "", "", "", errors.New("could not find symbol value for unknownthing")},
 		{"(unknownthing)(1, 2)", false, "", "", "", errors.New("could not find symbol value for unknownthing")},
@@ -1583,6 +1679,8 @@ func TestCgoEval(t *testing.T) {
 		{"v_align_check", true, "*align_check {a: 0, b: 0}", "(*struct align_check)(…", "*struct align_check", nil},
 		{"v_align_check[1]", false, "align_check {a: 1, b: 1}", "align_check {a: 1, b: 1}", "align_check", nil},
 		{"v_align_check[90]", false, "align_check {a: 90, b: 90}", "align_check {a: 90, b: 90}", "align_check", nil},
+		{"C.struct_align_check", false, "align_check {a: 0, b: 0}", "align_check {a: 0, b: 0}", "align_check", nil},
+		{"*(*C.struct_align_check)(uintptr(v_align_check)+8)", false, "align_check {a: 1, b: 1}", "align_check {a: 1, b: 1}", "align_check", nil},
 	}
 
 	if runtime.GOOS == "darwin" && runtime.GOARCH == "arm64" {
@@ -1620,6 +1718,32 @@ func TestCgoEval(t *testing.T) {
 	})
 }
 
+func TestCgoOpaqueStruct(t *testing.T) {
+	// Fields of C structs that are incomplete in the cgo preamble can be
+	// read through the pointers returned by cgo calls.
+	protest.MustHaveCgo(t)
+	if runtime.GOOS == "darwin" && runtime.GOARCH == "arm64" {
+		t.Skip("cgo doesn't work on darwin/arm64")
+	}
+
+	testcases := []varTest{
+		{"o.a", false, "3", "", "int", nil},
+		{"o.pt", false, "point {x: 1, y: 2}", "", "point", nil},
+		{"o.next", false, "*opaque nil", "", "*struct opaque", nil},
+		{"C.struct_opaque", false, "opaque {a: 0, pt: point {x: 0, y: 0}, next: *opaque nil}", "", "opaque", nil},
+	}
+
+	protest.AllowRecording(t)
+	withTestProcess("cgoopaque/", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		assertNoError(grp.Continue(), t, "Continue()")
+		for _, tc := range testcases {
+			variable, err := evalVariableWithCfg(p, tc.name, pnormalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("EvalExpression(%s)", tc.name))
+			assertVariable(t, variable, tc)
+		}
+	})
+}
+
 func TestEvalExpressionGenerics(t *testing.T) {
 	if !goversion.VersionAfterOrEqual(runtime.Version(), 1, 18) {
 		t.Skip("generics not supported")
@@ -1639,6 +1763,13 @@ func TestEvalExpressionGenerics(t *testing.T) {
 			{"arg2", true, "main.astruct {x: 2, y: 3}", "", "main.astruct", nil},
 			{"m", true, "map[main.astruct]*main.astruct [{x: 2, y: 3}: *{x: 0, y: 1}, ]", "", "map[main.astruct]*main.astruct", nil},
 		},
+
+		// (*Stack[myint]).Push
+		{
+			{"s", true, "*main.Stack[main.myint] {items: []main.myint len: 1, cap: 1, [1]}", "", "*main.Stack[main.myint]", nil},
+			{"s.items", false, "[]main.myint len: 1, cap: 1, [1]", "", "[]main.myint", nil},
+			{"x", true, "1", "", "main.myint", nil},
+		},
 	}
 
 	withTestProcess("testvariables_generic", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
@@ -1744,3 +1875,28 @@ func TestCapturedVariable(t *testing.T) {
 		})
 	})
 }
+
+func TestAtomicVariables(t *testing.T) {
+	if !goversion.VersionAfterOrEqual(runtime.Version(), 1, 19) {
+		t.Skip("sync/atomic types not supported")
+	}
+
+	testcases := []varTest{
+		{"i32", true, "sync/atomic.Int32(-3)", "", "sync/atomic.Int32", nil},
+		{"i64", true, "sync/atomic.Int64(1099511627776)", "", "sync/atomic.Int64", nil},
+		{"u64", true, "sync/atomic.Uint64(42)", "", "sync/atomic.Uint64", nil},
+		{"b", true, "sync/atomic.Bool(true)", "", "sync/atomic.Bool", nil},
+		{"p", true, "sync/atomic.Pointer[main.astruct](*main.astruct {A: 1, B: 2})", "", "sync/atomic.Pointer[main.astruct]", nil},
+		{"pnil", true, "sync/atomic.Pointer[main.astruct](*main.astruct nil)", "", "sync/atomic.Pointer[main.astruct]", nil},
+		{"i64.v", false, "1099511627776", "", "int64", nil},
+	}
+
+	withTestProcess("atomicvars", t, func(p *proc.Target, grp *proc.TargetGroup, fixture protest.Fixture) {
+		assertNoError(grp.Continue(), t, "Continue()")
+		for _, tc := range testcases {
+			variable, err := evalVariableWithCfg(p, tc.name, pnormalLoadConfig)
+			assertNoError(err, t, fmt.Sprintf("EvalExpression(%s) returned an error", tc.name))
+			assertVariable(t, variable, tc)
+		}
+	})
+}
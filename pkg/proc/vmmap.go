@@ -0,0 +1,117 @@
+package proc
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// MemoryRegion is a mapped region of the address space of the target
+// process, annotated with what the Go runtime uses it for.
+type MemoryRegion struct {
+	MemoryMapEntry
+	Heap       bool    // the region contains at least one Go heap arena
+	Goroutines []int64 // goroutines with a stack inside the region
+}
+
+// GetMemoryRegions returns the memory map of the target process, read from
+// /proc/pid/maps for native Linux processes and from the program headers
+// for core files. Regions containing heap arenas and goroutine stacks are
+// annotated as such.
+func GetMemoryRegions(t *Target) ([]MemoryRegion, error) {
+	if _, err := t.Valid(); err != nil {
+		return nil, err
+	}
+	mmap, err := t.proc.MemoryMap()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(mmap, func(i, j int) bool { return mmap[i].Addr < mmap[j].Addr })
+	r := make([]MemoryRegion, len(mmap))
+	for i := range mmap {
+		r[i].MemoryMapEntry = mmap[i]
+	}
+	// first returns the index of the first region ending after addr.
+	first := func(addr uint64) int {
+		return sort.Search(len(r), func(i int) bool { return r[i].Addr+r[i].Size > addr })
+	}
+
+	arenaSize := heapArenaBytes(t.BinInfo())
+	for _, arena := range heapArenas(t) {
+		// An arena can span more than one mapping.
+		for i := first(arena); i < len(r) && r[i].Addr < arena+arenaSize; i++ {
+			r[i].Heap = true
+		}
+	}
+
+	gs, _, err := GoroutinesInfo(t, 0, 0)
+	if err == nil {
+		for _, g := range gs {
+			if g.stack.lo == 0 {
+				continue
+			}
+			if i := first(g.stack.lo); i < len(r) && r[i].Addr <= g.stack.lo {
+				r[i].Goroutines = append(r[i].Goroutines, g.ID)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// heapArenaBytes returns the size of a heap arena, see heapArenaBytes in
+// $GOROOT/src/runtime/malloc.go.
+func heapArenaBytes(bi *BinaryInfo) uint64 {
+	if bi.Arch.PtrSize() == 4 || bi.GOOS == "windows" {
+		return 4 << 20
+	}
+	return 64 << 20
+}
+
+// arenaBaseOffset returns the offset between the index of a heap arena and
+// its address, see arenaBaseOffset in $GOROOT/src/runtime/malloc.go.
+func arenaBaseOffset(bi *BinaryInfo) uint64 {
+	if bi.Arch.Name == "amd64" {
+		return 0xffff800000000000
+	}
+	return 0
+}
+
+// heapArenas returns the start address of every heap arena in use, read
+// from runtime.mheap_.heapArenas (runtime.mheap_.allArenas in older versions
+// of Go).
+func heapArenas(t *Target) []uint64 {
+	bi := t.BinInfo()
+	scope := globalScope(t, bi, bi.Images[0], t.Memory())
+	// +rtype -var mheap_ mheap
+	mheapv, err := scope.findGlobal("runtime", "mheap_") // +rtype mheap
+	if err != nil {
+		return nil
+	}
+	allArenasv, err := mheapv.structMember("heapArenas") // +rtype -opt []arenaIdx
+	if err != nil {
+		allArenasv, err = mheapv.structMember("allArenas") // +rtype -opt []arenaIdx
+	}
+	if err != nil || allArenasv.Unreadable != nil || allArenasv.Len <= 0 {
+		return nil
+	}
+	ptrSize := bi.Arch.PtrSize()
+	buf := make([]byte, allArenasv.Len*int64(ptrSize))
+	if _, err := t.Memory().ReadMemory(buf, allArenasv.Base); err != nil {
+		return nil
+	}
+
+	arenaBase := arenaBaseOffset(bi)
+	arenaSize := heapArenaBytes(bi)
+
+	r := make([]uint64, 0, allArenasv.Len)
+	for i := 0; i < len(buf); i += ptrSize {
+		var idx uint64
+		if ptrSize == 4 {
+			idx = uint64(binary.LittleEndian.Uint32(buf[i:]))
+		} else {
+			idx = binary.LittleEndian.Uint64(buf[i:])
+		}
+		r = append(r, idx*arenaSize+arenaBase)
+	}
+	return r
+}
@@ -0,0 +1,102 @@
+package proc
+
+import (
+	"fmt"
+	"go/constant"
+	"reflect"
+)
+
+// maxWaitReasonLen is the maximum length of the description of a wait
+// reason read from runtime.waitReasonStrings.
+const maxWaitReasonLen = 128
+
+// WaitReasonString returns the description of the wait reason of a
+// goroutine, as printed by the runtime in goroutine tracebacks.
+// The descriptions are read from runtime.waitReasonStrings, if the target
+// process does not have it waitReasonStringsFallback is used instead.
+func WaitReasonString(t *Target, waitReason int64) string {
+	return waitReasonString(t.loadWaitReasonStrings(), waitReason)
+}
+
+// FallbackWaitReasonString is like WaitReasonString but always uses
+// waitReasonStringsFallback, it can be used when the target process is not
+// available.
+func FallbackWaitReasonString(waitReason int64) string {
+	return waitReasonString(waitReasonStringsFallback[:], waitReason)
+}
+
+func waitReasonString(strs []string, waitReason int64) string {
+	if waitReason > 0 && waitReason < int64(len(strs)) && strs[waitReason] != "" {
+		return strs[waitReason]
+	}
+	return fmt.Sprintf("unknown wait reason %d", waitReason)
+}
+
+// loadWaitReasonStrings reads runtime.waitReasonStrings the first time it
+// is called and caches the result.
+func (t *Target) loadWaitReasonStrings() []string {
+	if t.waitReasonStrings != nil {
+		return t.waitReasonStrings
+	}
+	t.waitReasonStrings = waitReasonStringsFallback[:]
+	bi := t.BinInfo()
+	scope := globalScope(t, bi, bi.Images[0], t.Memory())
+	// +rtype -var waitReasonStrings anytype
+	strsv, err := scope.findGlobal("runtime", "waitReasonStrings")
+	if err != nil || strsv.Kind != reflect.Array {
+		return t.waitReasonStrings
+	}
+	strsv.loadValue(LoadConfig{MaxStringLen: maxWaitReasonLen, MaxArrayValues: int(strsv.Len)})
+	if strsv.Unreadable != nil {
+		return t.waitReasonStrings
+	}
+	strs := make([]string, len(strsv.Children))
+	for i := range strsv.Children {
+		if strsv.Children[i].Unreadable != nil || strsv.Children[i].Value == nil {
+			return t.waitReasonStrings
+		}
+		strs[i] = constant.StringVal(strsv.Children[i].Value)
+	}
+	t.waitReasonStrings = strs
+	return strs
+}
+
+// waitReasonStringsFallback is used for target processes that do not
+// have runtime.waitReasonStrings, it must be kept in sync with the
+// runtime.
+var waitReasonStringsFallback = [...]string{
+	"",
+	"GC assist marking",
+	"IO wait",
+	"chan receive (nil chan)",
+	"chan send (nil chan)",
+	"dumping heap",
+	"garbage collection",
+	"garbage collection scan",
+	"panicwait",
+	"select",
+	"select (no cases)",
+	"GC assist wait",
+	"GC sweep wait",
+	"GC scavenge wait",
+	"chan receive",
+	"chan send",
+	"finalizer wait",
+	"force gc (idle)",
+	"semacquire",
+	"sleep",
+	"sync.Cond.Wait",
+	"timer goroutine (idle)",
+	"trace reader (blocked)",
+	"wait for GC cycle",
+	"GC worker (idle)",
+	"preempted",
+	"debug call",
+	"GC mark termination",
+	"stopping the world",
+	"flushing proc caches",
+	"trace goroutine status",
+	"trace proc status",
+	"page trace flush",
+	"coroutine",
+}
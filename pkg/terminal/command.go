@@ -7,6 +7,8 @@ package terminal
 import (
 	"bufio"
 	"bytes"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"go/parser"
@@ -19,6 +21,7 @@ import (
 	"reflect"
 	"regexp"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -49,6 +52,10 @@ type callContext struct {
 	Prefix     cmdPrefix
 	Scope      api.EvalScope
 	Breakpoint *api.Breakpoint
+
+	// FrameSpecified is true if Scope.Frame was set by a frame, up or down
+	// prefix instead of being the current frame.
+	FrameSpecified bool
 }
 
 func (ctx *callContext) scoped() bool {
@@ -89,6 +96,10 @@ type Commands struct {
 	cmds   []command
 	client service.Client
 	frame  int // Current frame as set by frame/up/down commands.
+
+	// aliasTemplates maps user defined aliases to the command template they
+	// expand to, see expandAliasTemplate.
+	aliasTemplates map[string]string
 }
 
 var (
@@ -122,7 +133,13 @@ func DebugCommands(client service.Client) *Commands {
 Type "help" followed by the name of a command for more information about it.`},
 		{aliases: []string{"break", "b"}, group: breakCmds, cmdFn: breakpoint, helpMsg: `Sets a breakpoint.
 
-	break [name] [locspec] [if <condition>]
+	break [name] [locspec] [-tag=<tag>[,<tag>...]] [if <condition>]
+	break -goroutine-create [function] [if <condition>]
+	break -goroutine-exit [goroutine id] [if <condition>]
+	break -chan <expr> [send|recv]
+	break -mutex-contention [<expr>]
+	break -at-return [name] <function> [if <condition>]
+	break -import <file>
 
 Locspec is a location specifier in the form of:
 
@@ -149,6 +166,27 @@ Finally, you can assign a condition to the newly created breakpoint by using the
 
 Alternatively you can set a condition on a breakpoint after created by using the 'on' command.
 
+The -tag option assigns one or more tags to the breakpoint, the breakpoints with a tag can be toggled or deleted together with 'toggle -tag' and 'clear -tag':
+
+  break main.go:55 -tag=net
+  break net.Dial -tag=net,io
+
+The -import form recreates the breakpoints saved by 'breakpoints -export <file>', resolving their locations against the current program. Breakpoints whose location can not be found are reported.
+
+The -goroutine-create form sets a breakpoint that stops every time a new goroutine is created. When it is hit the frame of the creating goroutine that executed the go statement is selected. If function is specified only the creation of goroutines starting in function will stop the program, note that for go statements calling a function with arguments the compiler generates a wrapper function (for example main.main.gowrap1), which is where the new goroutine starts. The condition is evaluated in the frame of runtime.newproc, where fn.fn is the entry point of the new goroutine:
+
+  break -goroutine-create
+  break -goroutine-create main.main.gowrap1
+  break -goroutine-create if fn.fn == 0x4a5f20
+
+The -goroutine-exit form sets a breakpoint that stops when the specified goroutine (the selected goroutine if omitted) finishes, either by returning from its function or by calling runtime.Goexit. The program is stopped before the goroutine is destroyed so that its state can still be inspected.
+
+The -chan form sets breakpoints that stop when a value is sent to (send) or received from (recv) the channel that expr evaluates to, or both if neither is specified. When they are hit the frame that initiated the operation is selected. Operations executed by select statements with more than one case, other than default, do not stop the program.
+
+The -mutex-contention form sets a breakpoint that stops when a goroutine has to wait to lock a sync.Mutex because it is held by another goroutine. If expr is specified only contention on the mutex it evaluates to (either a sync.Mutex or a pointer to one) will stop the program. When the breakpoint is hit the frame that called Lock is selected and the address of the mutex is printed.
+
+The -at-return form sets a breakpoint on every return instruction of function, and on its calls to runtime.deferreturn, so that the values about to be returned can be inspected before the function returns.
+
 See also: "help on", "help cond" and "help clear"`},
 		{aliases: []string{"trace", "t"}, group: breakCmds, cmdFn: tracepoint, allowedPrefixes: onPrefix, helpMsg: `Set tracepoint.
 
@@ -156,8 +194,10 @@ See also: "help on", "help cond" and "help clear"`},
 
 A tracepoint is a breakpoint that does not stop the execution of the program, instead when the tracepoint is hit a notification is displayed. See Documentation/cli/locspec.md for the syntax of locspec. If locspec is omitted a tracepoint will be set on the current line.
 
+If locspec is a function the returns of the function will also be traced, showing its return values. The calls of traced functions are indented by their depth, counting only the calls of traced functions on the same goroutine. If the trace-show-duration configuration option is set the time elapsed between the entry and the return of each call is also displayed.
+
 See also: "help on", "help cond" and "help clear"`},
-		{aliases: []string{"watch"}, group: breakCmds, cmdFn: watchpoint, helpMsg: `Set watchpoint.
+		{aliases: []string{"watch"}, group: breakCmds, cmdFn: watchpoint, allowedPrefixes: revPrefix, helpMsg: `Set watchpoint.
 	
 	watch [-r|-w|-rw] <expr>
 	
@@ -174,6 +214,12 @@ will watch the address of variable 'v' and writes to an int at addr '0x1400007c0
 
 Note that writes that do not change the value of the watched memory address might not be reported.
 
+When used with the 'rev' prefix on a recorded target (see 'help rev') a temporary watchpoint is set and the program runs backwards until the memory location is accessed, for example:
+
+	rev watch -w v
+
+will stop at the last write to variable 'v'. The program will halt if a breakpoint is hit first.
+
 See also: "help print".`},
 		{aliases: []string{"restart", "r"}, group: runCmds, cmdFn: restart, helpMsg: `Restart process.
 
@@ -199,23 +245,63 @@ A list of file redirections can be specified after the new argument list to over
 		{aliases: []string{"rebuild"}, group: runCmds, cmdFn: c.rebuild, allowedPrefixes: revPrefix, helpMsg: "Rebuild the target executable and restarts it. It does not work if the executable was not built by delve."},
 		{aliases: []string{"continue", "c"}, group: runCmds, cmdFn: c.cont, allowedPrefixes: revPrefix, helpMsg: `Run until breakpoint or program termination.
 
-	continue [<locspec>]
+	continue [-count <n>] [<locspec>]
 
 Optional locspec argument allows you to continue until a specific location is reached. The program will halt if a breakpoint is hit before reaching the specified location.
 
+If -count is specified the program is resumed each time a breakpoint is hit, until the n-th breakpoint hit. If the program stops for any other reason, or exits, before then the number of breakpoint hits that occurred is reported.
+
 For example:
 
 	continue main.main
 	continue encoding/json.Marshal
+	continue -count 3
 `},
-		{aliases: []string{"step", "s"}, group: runCmds, cmdFn: c.step, allowedPrefixes: revPrefix, helpMsg: "Single step through program."},
+		{aliases: []string{"step", "s"}, group: runCmds, cmdFn: c.step, allowedPrefixes: revPrefix, helpMsg: `Single step through program.
+
+	step [count]
+	step -into <funcname>
+
+Optional [count] argument repeats the step count times, stopping early if a
+breakpoint is hit. Only the final location is printed.
+
+If -into is specified execution continues until the function <funcname>,
+called from the current source line, is entered, all other calls made by
+the current line are stepped over. The package path can be omitted from
+<funcname>. If the current line does not contain a call to the function an
+error is returned. Calls through function values and interfaces can't be
+resolved in advance, if none of them calls the function execution stops on
+the next line, like 'next' would.`},
 		{aliases: []string{"step-instruction", "si", "stepi"}, group: runCmds, allowedPrefixes: revPrefix, cmdFn: c.stepInstruction, helpMsg: "Single step a single cpu instruction."},
+		{aliases: []string{"stepi-until"}, group: runCmds, allowedPrefixes: revPrefix, cmdFn: c.stepInstructionUntil, helpMsg: `Single steps cpu instructions until a condition is true.
+
+	stepi-until [-max <n>] <condition>
+
+The condition is evaluated in the topmost frame of the current goroutine
+after every instruction, for example:
+
+	stepi-until RAX == 0
+	stepi-until *(*uint64)(RSP) == 0x1000
+
+Registers are referred to by their name in uppercase letters, see
+Documentation/cli/expr.md. Stepping stops before the condition is true if
+a breakpoint is hit or after <n> instructions (10000 by default).`},
+		{aliases: []string{"step-back"}, group: runCmds, cmdFn: c.stepBack, helpMsg: `Restores the state of the target to the previous stop (EXPERIMENTAL).
+
+Only available if the target was launched with the --record flag, using the native backend on linux. Every time a command (continue, next, step, etc) resumes the target its registers and writable memory are saved, step-back restores the last saved state. A bounded number of states is kept.
+
+Only the state of the target process is restored, the effects of system calls (for example writing to a file) and threads created after the state was saved are not undone.`},
 		{aliases: []string{"next-instruction", "ni", "nexti"}, group: runCmds, allowedPrefixes: revPrefix, cmdFn: c.nextInstruction, helpMsg: "Single step a single cpu instruction, skipping function calls."},
 		{aliases: []string{"next", "n"}, group: runCmds, cmdFn: c.next, allowedPrefixes: revPrefix, helpMsg: `Step over to next source line.
 
 	next [count]
 
-Optional [count] argument allows you to skip multiple lines.
+Optional [count] argument allows you to skip multiple lines, stopping early
+if a breakpoint is hit. Only the final location is printed.
+
+Only the current goroutine is stepped. If a breakpoint is hit by a different
+goroutine before the next completes a warning is printed and you will be
+asked whether to continue stepping the original goroutine.
 `},
 		{aliases: []string{"stepout", "so"}, group: runCmds, allowedPrefixes: revPrefix, cmdFn: c.stepout, helpMsg: "Step out of the current function."},
 		{aliases: []string{"call"}, group: runCmds, cmdFn: c.call, helpMsg: `Resumes process, injecting a function call (EXPERIMENTAL!!!)
@@ -234,21 +320,37 @@ Current limitations:
 - calling a function will resume execution of all goroutines.
 - only supported on linux's native backend.
 `},
-		{aliases: []string{"threads"}, group: goroutineCmds, cmdFn: threads, helpMsg: "Print out info for every traced thread."},
+		{aliases: []string{"threads"}, group: goroutineCmds, cmdFn: threads, helpMsg: `Print out info for every traced thread.
+
+For each thread the current instruction address, source location and the ID of the goroutine running on it (if any) are shown.`},
 		{aliases: []string{"thread", "tr"}, group: goroutineCmds, cmdFn: thread, helpMsg: `Switch to the specified thread.
 
-	thread <id>`},
+	thread <id>
+
+After switching, commands that operate on the current thread, such as 'regs', will use the selected thread. This also works for threads that are not running a goroutine, for example threads executing inside the scheduler.`},
 		{aliases: []string{"clear"}, group: breakCmds, cmdFn: clear, helpMsg: `Deletes breakpoint.
 
-	clear <breakpoint name or id>`},
+	clear <breakpoint name or id>
+	clear -tag <tag>
+
+The -tag form deletes all the breakpoints with the specified tag.`},
 		{aliases: []string{"clearall"}, group: breakCmds, cmdFn: clearAll, helpMsg: `Deletes multiple breakpoints.
 
 	clearall [<locspec>]
+	clearall -r <regex>
+
+If called with the locspec argument it will delete all the breakpoints matching the locspec. If locspec is omitted all breakpoints are deleted.
 
-If called with the locspec argument it will delete all the breakpoints matching the locspec. If locspec is omitted all breakpoints are deleted.`},
+If called with -r only the breakpoints whose function name or file name matches the regular expression are deleted, for example:
+
+	clearall -r ^main\.
+	clearall -r /pkg/proc/`},
 		{aliases: []string{"toggle"}, group: breakCmds, cmdFn: toggle, helpMsg: `Toggles on or off a breakpoint.
 
-toggle <breakpoint name or id>`},
+	toggle <breakpoint name or id>
+	toggle -tag <tag>
+
+The -tag form toggles all the breakpoints with the specified tag as a group: if any of them is enabled they are all disabled, otherwise they are all enabled.`},
 		{aliases: []string{"goroutines", "grs"}, group: goroutineCmds, cmdFn: c.goroutines, helpMsg: `List program goroutines.
 
 	goroutines [-u|-r|-g|-s] [-t [depth]] [-l] [-with loc expr] [-without loc expr] [-group argument] [-chan expr] [-exec command]
@@ -335,27 +437,95 @@ EXEC
 
 Runs the command on every goroutine.
 `},
+		{aliases: []string{"gc-info"}, group: dataCmds, cmdFn: gcInfo, helpMsg: `Print the state of the garbage collector and heap statistics.
+
+	gc-info
+
+Shows the current GC phase, the number of completed GC cycles, the time of the last GC and heap statistics read from the runtime of the target process. Fields with the same name as a field of runtime.MemStats have the same meaning.`},
+		{aliases: []string{"vmmap"}, group: dataCmds, cmdFn: vmmap, helpMsg: `Print the memory map of the target process.
+
+	vmmap
+
+Prints every mapped region of the address space of the target process with its permissions and the file mapped to it, if any. Regions containing Go heap arenas are marked with [heap], regions containing goroutine stacks list the goroutines they belong to.
+
+The memory map is read from /proc/<pid>/maps for native Linux processes and from the program headers of core files.`},
+		{aliases: []string{"whatobject"}, group: dataCmds, cmdFn: whatobject, helpMsg: `Print the Go heap object containing an address.
+
+	whatobject <address>
+	whatobject <expression>
+
+Looks up the span of the Go heap containing the address using the metadata of the runtime and prints the start address, size and size class of the object containing it, whether the object is allocated and, if it can be determined, its type. The argument can also be an expression evaluating to a pointer or an integer.
+
+The type of an object can only be determined for objects containing pointers allocated by Go 1.22 or later, and only if they are larger than 512 bytes (128 bytes on 32bit architectures).`},
+		{aliases: []string{"refsto"}, group: dataCmds, cmdFn: refsto, helpMsg: `Find the references to an address.
+
+	refsto [-stacks] [-max <n>] <address>
+	refsto [-stacks] [-max <n>] <expression>
+
+Searches the stacks of all goroutines and the Go heap for pointer-sized words equal to the address and prints where they were found: the goroutine, frame and, if possible, local variable for references on a stack or the heap object for references in the heap. Only allocated heap objects are searched. The argument can also be an expression evaluating to a pointer or an integer.
+
+Scanning the heap can be slow for programs with large heaps, the search stops after 100 references are found.
+
+	-stacks		only search goroutine stacks.
+	-max <n>	stop after n references are found, 0 for no limit.`},
+		{aliases: []string{"leaks"}, group: dataCmds, cmdFn: leaks, helpMsg: `Print the heap objects that are not reachable.
+
+	leaks [-max <n>]
+
+Scans the Go heap starting from the roots (goroutine stacks, thread registers, global variables and objects with a finalizer) and prints the address, size and, if it can be determined, type of every allocated object that can not be reached from them. Useful to find leak candidates in core dumps.
+
+The scan is conservative, every word of memory is considered a pointer. Objects that became unreachable since the last GC cycle and objects only referenced by memory that the runtime allocates outside of the heap are also reported.
+
+By default at most 100 objects are printed, use -max to change the limit, 0 for no limit.`},
+		{aliases: []string{"finalizer"}, group: dataCmds, cmdFn: finalizer, helpMsg: `Print the finalizer associated with a heap object.
+
+	finalizer <address>
+	finalizer <expression>
+
+Looks for the finalizer of the Go heap object containing the address, first in the special records of its span, which is where the runtime stores the finalizers of live objects, then in the queue of finalizers waiting to be executed. If one is found the name and entry point of the finalizer function are printed. The argument can also be an expression evaluating to a pointer or an integer.`},
+		{aliases: []string{"scheduler"}, group: goroutineCmds, cmdFn: scheduler, helpMsg: `Print the state of the Go runtime scheduler.
+
+	scheduler
+
+Prints the list of Ps (processors), with their status, the length of their local run queue and the M they are bound to, followed by the list of Ms (OS threads) with the P they hold and the goroutine currently running on them.`},
+		{aliases: []string{"selectinfo"}, group: goroutineCmds, cmdFn: selectInfo, helpMsg: `Print the cases of the select statement a goroutine is blocked on.
+
+	[goroutine <n>] selectinfo
+
+For each case prints whether the goroutine is waiting to send or to receive, the element type of the channel and the address of the channel. Cases with a nil channel are not shown.`},
 		{aliases: []string{"goroutine", "gr"}, group: goroutineCmds, allowedPrefixes: onPrefix, cmdFn: c.goroutine, helpMsg: `Shows or changes current goroutine
 
 	goroutine
 	goroutine <id>
 	goroutine <id> <command>
+	goroutine <id> frame <m> <command>
 
 Called without arguments it will show information about the current goroutine.
 Called with a single argument it will switch to the specified goroutine.
-Called with more arguments it will execute a command on the specified goroutine.`},
+Called with more arguments it will execute a command on the specified goroutine, starting from its topmost frame or from frame <m>, without changing the current goroutine and frame.`},
 		{aliases: []string{"breakpoints", "bp"}, group: breakCmds, cmdFn: breakpoints, helpMsg: `Print out info for active breakpoints.
 	
 	breakpoints [-a]
+	breakpoints -export <file>
 
-Specifying -a prints all physical breakpoint, including internal breakpoints.`},
+Specifying -a prints all physical breakpoint, including internal breakpoints.
+
+Specifying -export saves the definitions of all breakpoints and tracepoints to file, in JSON format, they can be recreated with 'break -import <file>'. Watchpoints and breakpoints without a source location are not exported.`},
 		{aliases: []string{"print", "p"}, group: dataCmds, allowedPrefixes: onPrefix | deferredPrefix, cmdFn: c.printVar, helpMsg: `Evaluate an expression.
 
-	[goroutine <n>] [frame <m>] print [%format] <expression>
+	[goroutine <n>] [frame <m>] print [-call|-chanbuf|-hexdump|-proto] [%format] <expression>
 
 See Documentation/cli/expr.md for a description of supported expressions.
 
-The optional format argument is a format specifier, like the ones used by the fmt package. For example "print %x v" will print v as an hexadecimal number.`},
+The optional format argument is a format specifier, like the ones used by the fmt package. For example "print %x v" will print v as an hexadecimal number.
+
+If -call is specified the expression can contain function calls, it will be evaluated using the same mechanism as the call command, which requires the current goroutine to be running and is only possible in the topmost frame. For example "print -call buf.Len()". The target process is resumed while the calls execute and it is stopped again if they do not complete within 5 seconds.
+
+If -chanbuf is specified the expression must be a channel and the values queued in its buffer will also be printed, in the order in which they will be received. The number of values printed is limited by the max-array-values configuration option.
+
+If -hexdump is specified the expression must be a string or a slice or array of bytes and its contents will be printed as a hex dump, with offsets, hexadecimal values and ASCII characters. The number of bytes printed is limited by the max-string-len configuration option for strings and by the max-array-values configuration option for slices and arrays.
+
+If -proto is specified the expression must be a string or a slice or array of bytes and its contents will be decoded as a protocol buffers message in wire format. Since the schema of the message is not known, fields are printed with their field number and the type inferred from their wire type: length-delimited fields are printed as nested messages, strings or bytes, depending on which interpretation is valid. The number of bytes decoded is limited in the same way as for -hexdump.`},
 		{aliases: []string{"whatis"}, group: dataCmds, cmdFn: whatisCommand, helpMsg: `Prints type of an expression.
 
 	whatis <expression>`},
@@ -363,7 +533,9 @@ The optional format argument is a format specifier, like the ones used by the fm
 
 	[goroutine <n>] [frame <m>] set <variable> = <value>
 
-See Documentation/cli/expr.md for a description of supported expressions. Only numerical variables and pointers can be changed.`},
+See Documentation/cli/expr.md for a description of supported expressions. Only numerical variables and pointers can be changed.
+
+The variable can also be an element of a slice or array, or the value associated with an existing key of a map, for example "set s[2] = x" or "set m["key"] = 5". Adding new keys to a map is not supported.`},
 		{aliases: []string{"sources"}, cmdFn: sources, helpMsg: `Print list of source files.
 
 	sources [<regex>]
@@ -388,24 +560,28 @@ If regex is specified only the packages matching it will be returned.`},
 
 	[goroutine <n>] [frame <m>] args [-v] [<regex>]
 
-If regex is specified only function arguments with a name matching it will be returned. If -v is specified more information about each function argument will be shown.`},
+If regex is specified only function arguments with a name matching it will be returned. If -v is specified more information about each function argument, including its type, will be shown.`},
 		{aliases: []string{"locals"}, allowedPrefixes: onPrefix | deferredPrefix, group: dataCmds, cmdFn: locals, helpMsg: `Print local variables.
 
-	[goroutine <n>] [frame <m>] locals [-v] [<regex>]
+	[goroutine <n>] [frame <m>] locals [-captures] [-v] [<regex>]
 
 The name of variables that are shadowed in the current scope will be shown in parenthesis.
 
-If regex is specified only local variables with a name matching it will be returned. If -v is specified more information about each local variable will be shown.`},
+If -captures is specified only the variables that the current function, a closure, captured from its enclosing function will be shown. Captured variables can only be identified in programs compiled with Go 1.23 or later.
+
+If regex is specified only local variables with a name matching it will be returned. If -v is specified more information about each local variable, including its type, will be shown.`},
 		{aliases: []string{"vars"}, cmdFn: vars, group: dataCmds, helpMsg: `Print package variables.
 
 	vars [-v] [<regex>]
 
-If regex is specified only package variables with a name matching it will be returned. If -v is specified more information about each package variable will be shown.`},
+If regex is specified only package variables with a name matching it will be returned. If -v is specified more information about each package variable, including its type, will be shown.`},
 		{aliases: []string{"regs"}, cmdFn: regs, group: dataCmds, helpMsg: `Print contents of CPU registers.
 
 	regs [-a]
 
-Argument -a shows more registers. Individual registers can also be displayed by 'print' and 'display'. See Documentation/cli/expr.md.`},
+Prints the registers of the current thread, see 'thread' to select a different thread. Argument -a shows more registers. Individual registers can also be displayed by 'print' and 'display'.
+
+Registers whose value changed since the last time 'regs' was used on the same thread are marked with a '*'. See Documentation/cli/expr.md.`},
 		{aliases: []string{"exit", "quit", "q"}, cmdFn: exitCommand, helpMsg: `Exit the debugger.
 		
 	exit [-c]
@@ -490,10 +666,14 @@ If path is a single '-' character an interactive starlark interpreter will start
 If no argument is specified the function being executed in the selected stack frame will be executed.
 
 	-a <start> <end>	disassembles the specified address range
-	-l <locspec>		disassembles the specified function`},
+	-l <locspec>		disassembles the specified function
+
+The address range does not need to belong to a known function, instructions at the start of a function or symbol are labeled with its name.`},
 		{aliases: []string{"on"}, group: breakCmds, cmdFn: c.onCmd, helpMsg: `Executes a command when a breakpoint is hit.
 
 	on <breakpoint name or id> <command>
+	on <breakpoint name or id> { <command>; <command>; ... }
+	on <breakpoint name or id> eval <expression>
 	on <breakpoint name or id> -edit
 	
 
@@ -504,7 +684,32 @@ To convert a breakpoint into a tracepoint use:
 
 The command 'on <bp> cond <cond-arguments>' is equivalent to 'cond <bp> <cond-arguments>'.
 
+Any sequence of commands can be attached to a breakpoint by enclosing it in braces, the commands are separated by ';' or newlines. If the opening brace is the last character of the line the commands are read from the following lines, up to a line ending with the closing brace. Every time the breakpoint is hit, during continue, next, step, stepout or any other command that resumes the program, the commands are executed in order, if one of them is 'continue' the execution of the program is resumed:
+
+	on 1 {
+		print x
+		stack 2
+		continue
+	}
+
+The command list replaces the one previously attached to the breakpoint, 'on <bp> {}' removes it.
+
+The command 'on <bp> eval <expression>' makes the breakpoint collect the value of the expression every time it is hit, without stopping the program or printing it. The collected values can be printed later with 'evallog <bp>'.
+
 The command 'on x -edit' can be used to edit the list of commands executed when the breakpoint is hit.`},
+		{aliases: []string{"evallog"}, group: breakCmds, cmdFn: evallogCmd, helpMsg: `Prints the values collected by a breakpoint.
+
+	evallog <breakpoint name or id>
+	evallog -clear <breakpoint name or id>
+
+Prints the values of the expressions collected by a breakpoint set up with 'on <bp> eval <expression>', one line for every time the breakpoint was hit. With -clear the collected values are discarded.`},
+		{aliases: []string{"hotspots"}, group: breakCmds, cmdFn: hotspotsCmd, helpMsg: `Lists the hottest functions of a pprof profile.
+
+	hotspots <profile> [count]
+	hotspots -break <n>...
+
+The first form reads a profile written by runtime/pprof, for example a CPU profile, and lists the count functions (10 by default) with the highest self value, that is the value of the samples whose innermost function they are. Functions that are not in the current binary are marked as such.
+The second form sets a breakpoint on the nth function listed by the first one.`},
 		{aliases: []string{"condition", "cond"}, group: breakCmds, cmdFn: conditionCmd, allowedPrefixes: onPrefix, helpMsg: `Set breakpoint condition.
 
 	condition <breakpoint name or id> <boolean expression>.
@@ -566,6 +771,15 @@ See also Documentation/cli/substitutepath.md for how the rules are applied.
 
 Defines <alias> as an alias to <command> or removes an alias.
 
+	config alias "<template>" <alias>
+
+Defines <alias> as an alias that expands to the command template <template>. When the alias is invoked %1 through %9 in the template are replaced with the corresponding argument of the alias, %* is replaced with all its arguments and %% with a single %. For example:
+
+	config alias "break main.%1" bpm
+	bpm foo
+
+is equivalent to "break main.foo".
+
 	config debug-info-directories -add <path>
 	config debug-info-directories -rm <path>
 	config debug-info-directories -clear
@@ -600,12 +814,32 @@ For example:
 
 		{aliases: []string{"display"}, group: dataCmds, cmdFn: display, helpMsg: `Print value of an expression every time the program stops.
 
-	display -a [%format] <expression>
-	display -d <number>
+	display -a|-add [%format] <expression>
+	display -d|-del <number>
 
 The '-a' option adds an expression to the list of expression printed every time the program stops. The '-d' option removes the specified expression from the list.
 
-If display is called without arguments it will print the value of all expression in the list.`},
+If display is called without arguments it will print the value of all expression in the list.
+
+Expressions are printed in the order in which they were added, expressions that can not be evaluated in the current scope are printed with the error that occurred.`},
+
+		{aliases: []string{"snapshot"}, group: dataCmds, cmdFn: snapshot, helpMsg: `Saves the value of variables and compares it with their current value.
+
+	snapshot save <name> [<expression>]
+
+Saves the value of the specified expression, or of all function arguments and local variables if no expression is specified, in the snapshot <name>.
+
+	snapshot diff <name>
+
+Evaluates again the variables saved in snapshot <name> in the current scope and prints every value that changed since the snapshot was saved.
+
+	snapshot list
+
+Lists saved snapshots.
+
+	snapshot clear [<name>]
+
+Deletes the specified snapshot or, if no name is specified, all snapshots.`},
 
 		{aliases: []string{"dump"}, cmdFn: dump, helpMsg: `Creates a core dump from the current process state
 
@@ -653,7 +887,15 @@ Switches to the specified process.`},
 				aliases: []string{"rewind", "rw"},
 				group:   runCmds,
 				cmdFn:   c.rewind,
-				helpMsg: "Run backwards until breakpoint or start of recorded history.",
+				helpMsg: `Run backwards until breakpoint or start of recorded history.
+
+	rewind [<locspec> [if <condition>]]
+
+Optional locspec argument allows you to run backwards until a specific location is reached, optionally only stopping there when condition is true. The program will halt if a breakpoint is hit before reaching the specified location. This is equivalent to 'rev continue <locspec>'.
+
+For example:
+
+	rewind main.go:10 if i == 3`,
 			},
 			command{
 				aliases: []string{"check", "checkpoint"},
@@ -681,7 +923,7 @@ The "note" is arbitrary text that can be used to identify the checkpoint, if it
 				group:   runCmds,
 				cmdFn:   c.revCmd,
 				helpMsg: `Reverses the execution of the target program for the command specified.
-Currently, rev next, step, step-instruction and stepout commands are supported.`,
+Currently, rev continue, next, step, step-instruction, stepout and watch commands are supported.`,
 			})
 	}
 
@@ -725,13 +967,26 @@ func (c *Commands) Find(cmdstr string, prefix cmdPrefix) command {
 
 // CallWithContext takes a command and a context that command should be executed in.
 func (c *Commands) CallWithContext(cmdstr string, t *Term, ctx callContext) error {
+	cmdname, args := splitCommand(cmdstr)
+	if tmpl, ok := c.aliasTemplates[cmdname]; ok {
+		expanded, err := expandAliasTemplate(tmpl, args)
+		if err != nil {
+			return fmt.Errorf("%s: %v", cmdname, err)
+		}
+		// The expansion of an alias template is not expanded again, to avoid
+		// infinite recursion.
+		cmdname, args = splitCommand(expanded)
+	}
+	return c.Find(cmdname, ctx.Prefix).cmdFn(t, ctx, args)
+}
+
+func splitCommand(cmdstr string) (cmdname, args string) {
 	vals := strings.SplitN(strings.TrimSpace(cmdstr), " ", 2)
-	cmdname := vals[0]
-	var args string
+	cmdname = vals[0]
 	if len(vals) > 1 {
 		args = strings.TrimSpace(vals[1])
 	}
-	return c.Find(cmdname, ctx.Prefix).cmdFn(t, ctx, args)
+	return cmdname, args
 }
 
 // Call takes a command to execute.
@@ -741,12 +996,26 @@ func (c *Commands) Call(cmdstr string, t *Term) error {
 }
 
 // Merge takes aliases defined in the config struct and merges them with the default aliases.
+// Aliases defined for a command template (see isAliasTemplate) are
+// expanded when they are called.
 func (c *Commands) Merge(allAliases map[string][]string) {
 	for i := range c.cmds {
 		if c.cmds[i].builtinAliases != nil {
 			c.cmds[i].aliases = append(c.cmds[i].aliases[:0], c.cmds[i].builtinAliases...)
 		}
 	}
+	c.aliasTemplates = nil
+	for tmpl, aliases := range allAliases {
+		if !isAliasTemplate(tmpl) {
+			continue
+		}
+		if c.aliasTemplates == nil {
+			c.aliasTemplates = make(map[string]string)
+		}
+		for _, alias := range aliases {
+			c.aliasTemplates[alias] = tmpl
+		}
+	}
 	for i := range c.cmds {
 		if aliases, ok := allAliases[c.cmds[i].aliases[0]]; ok {
 			if c.cmds[i].builtinAliases == nil {
@@ -758,6 +1027,43 @@ func (c *Commands) Merge(allAliases map[string][]string) {
 	}
 }
 
+// isAliasTemplate returns true if s is a command template, rather than the
+// name of a command.
+func isAliasTemplate(s string) bool {
+	return strings.ContainsAny(s, " \t%")
+}
+
+// expandAliasTemplate substitutes the arguments of an alias in tmpl.
+// Occurrences of %1 through %9 are replaced with the corresponding
+// positional argument, %* with all the arguments and %% with a single %.
+func expandAliasTemplate(tmpl, args string) (string, error) {
+	argv := config.SplitQuotedFields(args, '"')
+	var buf strings.Builder
+	for i := 0; i < len(tmpl); i++ {
+		if tmpl[i] != '%' || i+1 >= len(tmpl) {
+			buf.WriteByte(tmpl[i])
+			continue
+		}
+		i++
+		switch ch := tmpl[i]; {
+		case ch == '*':
+			buf.WriteString(args)
+		case ch == '%':
+			buf.WriteByte('%')
+		case ch >= '1' && ch <= '9':
+			n := int(ch - '1')
+			if n >= len(argv) {
+				return "", fmt.Errorf("not enough arguments, %%%c is not set", ch)
+			}
+			buf.WriteString(argv[n])
+		default:
+			buf.WriteByte('%')
+			buf.WriteByte(ch)
+		}
+	}
+	return buf.String(), nil
+}
+
 var errNoCmd = errors.New("command not available")
 
 func noCmdAvailable(t *Term, ctx callContext, args string) error {
@@ -837,13 +1143,7 @@ func threads(t *Term, ctx callContext, args string) error {
 		if state.CurrentThread != nil && state.CurrentThread.ID == th.ID {
 			prefix = "* "
 		}
-		if th.Function != nil {
-			fmt.Fprintf(t.stdout, "%sThread %d at %#v %s:%d %s\n",
-				prefix, th.ID, th.PC, t.formatPath(th.File),
-				th.Line, th.Function.Name())
-		} else {
-			fmt.Fprintf(t.stdout, "%sThread %s\n", prefix, t.formatThread(th))
-		}
+		fmt.Fprintf(t.stdout, "%sThread %s\n", prefix, t.formatThreadLong(th))
 	}
 	return nil
 }
@@ -874,6 +1174,9 @@ func thread(t *Term, ctx callContext, args string) error {
 		newThread = strconv.Itoa(newState.CurrentThread.ID)
 	}
 	fmt.Fprintf(t.stdout, "Switched from %s to %s\n", oldThread, newThread)
+	if newState.CurrentThread != nil {
+		fmt.Fprintf(t.stdout, "Thread %s\n", t.formatThreadLong(newState.CurrentThread))
+	}
 	return nil
 }
 
@@ -897,9 +1200,13 @@ func (c *Commands) printGoroutines(t *Term, ctx callContext, indent string, gs [
 			writeGoroutineLabels(t.stdout, g, indent+"\t")
 		}
 		if flags&api.PrintGoroutinesStack != 0 {
-			stack, err := t.client.Stacktrace(g.ID, depth, 0, nil)
-			if err != nil {
-				return err
+			stack := g.Stacktrace
+			if stack == nil {
+				var err error
+				stack, err = t.client.Stacktrace(g.ID, depth, 0, nil)
+				if err != nil {
+					return err
+				}
 			}
 			printStack(t, t.stdout, stack, indent+"\t", false)
 		}
@@ -913,6 +1220,13 @@ func (c *Commands) printGoroutines(t *Term, ctx callContext, indent string, gs [
 	return nil
 }
 
+// goroutinesFirstBatchSize is the number of goroutines requested by the
+// first call to ListGoroutines made by the goroutines command, so that the
+// first page is printed right away even on programs with lots of
+// goroutines. Each following batch is twice as big, up to the batch size
+// returned by api.ParseGoroutineArgs.
+var goroutinesFirstBatchSize = 100
+
 func (c *Commands) goroutines(t *Term, ctx callContext, argstr string) error {
 	filters, group, fgl, flags, depth, batchSize, cmd, err := api.ParseGoroutineArgs(argstr)
 	if err != nil {
@@ -930,6 +1244,10 @@ func (c *Commands) goroutines(t *Term, ctx callContext, argstr string) error {
 		groups        []api.GoroutineGroup
 		tooManyGroups bool
 	)
+	count := batchSize
+	if batchSize > goroutinesFirstBatchSize {
+		count = goroutinesFirstBatchSize
+	}
 	done := false
 	t.stdout.pw.PageMaybe(func() { done = true })
 	t.longCommandStart()
@@ -938,10 +1256,17 @@ func (c *Commands) goroutines(t *Term, ctx callContext, argstr string) error {
 			fmt.Fprintf(t.stdout, "interrupted\n")
 			return nil
 		}
-		gs, groups, start, tooManyGroups, err = t.client.ListGoroutinesWithFilter(start, batchSize, filters, &group, &api.EvalScope{GoroutineID: -1, Frame: c.frame})
+		if flags&api.PrintGoroutinesStack != 0 {
+			gs, groups, start, tooManyGroups, err = t.client.ListGoroutinesWithStacktrace(start, count, filters, &group, &api.EvalScope{GoroutineID: -1, Frame: c.frame}, depth)
+		} else {
+			gs, groups, start, tooManyGroups, err = t.client.ListGoroutinesWithFilter(start, count, filters, &group, &api.EvalScope{GoroutineID: -1, Frame: c.frame})
+		}
 		if err != nil {
 			return err
 		}
+		if count < batchSize {
+			count = min(2*count, batchSize)
+		}
 		if len(groups) > 0 {
 			for i := range groups {
 				fmt.Fprintf(t.stdout, "%s\n", groups[i].Name)
@@ -1017,6 +1342,12 @@ func (c *Commands) goroutine(t *Term, ctx callContext, argstr string) error {
 	if err != nil {
 		return err
 	}
+	// The current frame belongs to the selected goroutine, commands executed
+	// on a different goroutine start from its topmost frame unless a frame
+	// was specified explicitly.
+	if !ctx.FrameSpecified {
+		ctx.Scope.Frame = 0
+	}
 	return c.CallWithContext(args[1], t, ctx)
 }
 
@@ -1040,12 +1371,13 @@ func (c *Commands) frameCommand(t *Term, ctx callContext, argstr string, directi
 	}
 	switch direction {
 	case frameUp:
-		frame = c.frame + frame
+		frame = ctx.Scope.Frame + frame
 	case frameDown:
-		frame = c.frame - frame
+		frame = ctx.Scope.Frame - frame
 	}
 	if len(arg) > 0 {
 		ctx.Scope.Frame = frame
+		ctx.FrameSpecified = true
 		return c.CallWithContext(arg, t, ctx)
 	}
 	if frame < 0 {
@@ -1058,12 +1390,14 @@ func (c *Commands) frameCommand(t *Term, ctx callContext, argstr string, directi
 	if frame >= len(stack) {
 		return fmt.Errorf("Invalid frame %d", frame)
 	}
-	c.frame = frame
-	state, err := t.client.GetState()
-	if err != nil {
-		return err
+	if ctx.Scope.GoroutineID < 0 {
+		c.frame = frame
+		state, err := t.client.GetState()
+		if err != nil {
+			return err
+		}
+		printcontext(t, state)
 	}
-	printcontext(t, state)
 	th := stack[frame]
 	fmt.Fprintf(t.stdout, "Frame %d: %s:%d (PC: %x)\n", frame, t.formatPath(th.File), th.Line, th.PC)
 	printfile(t, th.File, th.Line, true)
@@ -1109,6 +1443,26 @@ func (t *Term) formatThread(th *api.Thread) string {
 	return fmt.Sprintf("%d at %s:%d", th.ID, t.formatPath(th.File), th.Line)
 }
 
+// formatThreadLong returns a description of th that includes its current
+// instruction address, function and goroutine.
+func (t *Term) formatThreadLong(th *api.Thread) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%d", th.ID)
+	if th.Name != "" {
+		fmt.Fprintf(&buf, " %q", th.Name)
+	}
+	fmt.Fprintf(&buf, " at %#v %s:%d", th.PC, t.formatPath(th.File), th.Line)
+	if th.Function != nil {
+		fmt.Fprintf(&buf, " %s", th.Function.Name())
+	}
+	if th.GoroutineID != 0 {
+		fmt.Fprintf(&buf, " (goroutine %d)", th.GoroutineID)
+	} else {
+		buf.WriteString(" (no goroutine)")
+	}
+	return buf.String()
+}
+
 func (t *Term) formatLocation(loc api.Location) string {
 	return fmt.Sprintf("%s:%d %s (%#v)", t.formatPath(loc.File), loc.Line, loc.Function.Name(), loc.PC)
 }
@@ -1144,13 +1498,11 @@ func (t *Term) formatGoroutine(g *api.Goroutine, fgl api.FormatGoroutineLoc) str
 	}
 
 	if (g.Status == api.GoroutineWaiting || g.Status == api.GoroutineSyscall) && g.WaitReason != 0 {
-		var wr string
-		if g.WaitReason > 0 && g.WaitReason < int64(len(waitReasonStrings)) {
-			wr = waitReasonStrings[g.WaitReason]
+		if g.WaitReasonString != "" {
+			fmt.Fprintf(buf, " [%s", g.WaitReasonString)
 		} else {
-			wr = fmt.Sprintf("unknown wait reason %d", g.WaitReason)
+			fmt.Fprintf(buf, " [unknown wait reason %d", g.WaitReason)
 		}
-		fmt.Fprintf(buf, " [%s", wr)
 		if g.WaitSince > 0 {
 			fmt.Fprintf(buf, " %d", g.WaitSince)
 		}
@@ -1160,43 +1512,6 @@ func (t *Term) formatGoroutine(g *api.Goroutine, fgl api.FormatGoroutineLoc) str
 	return buf.String()
 }
 
-var waitReasonStrings = [...]string{
-	"",
-	"GC assist marking",
-	"IO wait",
-	"chan receive (nil chan)",
-	"chan send (nil chan)",
-	"dumping heap",
-	"garbage collection",
-	"garbage collection scan",
-	"panicwait",
-	"select",
-	"select (no cases)",
-	"GC assist wait",
-	"GC sweep wait",
-	"GC scavenge wait",
-	"chan receive",
-	"chan send",
-	"finalizer wait",
-	"force gc (idle)",
-	"semacquire",
-	"sleep",
-	"sync.Cond.Wait",
-	"timer goroutine (idle)",
-	"trace reader (blocked)",
-	"wait for GC cycle",
-	"GC worker (idle)",
-	"preempted",
-	"debug call",
-	"GC mark termination",
-	"stopping the world",
-	"flushing proc caches",
-	"trace goroutine status",
-	"trace proc status",
-	"page trace flush",
-	"coroutine",
-}
-
 func writeGoroutineLong(t *Term, w io.Writer, g *api.Goroutine, prefix string) {
 	fmt.Fprintf(w, "%sGoroutine %d:\n%s\tRuntime: %s\n%s\tUser: %s\n%s\tGo: %s\n%s\tStart: %s\n",
 		prefix, g.ID,
@@ -1314,6 +1629,7 @@ func restartIntl(t *Term, rerecord bool, restartPos string, resetArgs bool, newA
 	if err != nil {
 		return err
 	}
+	t.traceCalls = nil
 	for i := range discarded {
 		fmt.Fprintf(t.stdout, "Discarded %s at %s: %v\n", formatBreakpointName(discarded[i].Breakpoint, false), t.formatBreakpointLocation(discarded[i].Breakpoint), discarded[i].Reason)
 	}
@@ -1405,6 +1721,22 @@ func (c *Commands) rebuild(t *Term, ctx callContext, args string) error {
 }
 
 func (c *Commands) cont(t *Term, ctx callContext, args string) error {
+	count := 1
+	if rest, ok := strings.CutPrefix(args, "-count "); ok {
+		v := config.Split2PartsBySpace(strings.TrimSpace(rest))
+		n, err := strconv.Atoi(v[0])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid count %q", v[0])
+		}
+		if ctx.Prefix == revPrefix {
+			return errors.New("-count can not be used with rev")
+		}
+		count = n
+		args = ""
+		if len(v) > 1 {
+			args = v[1]
+		}
+	}
 	if args != "" {
 		tmp, err := setBreakpoint(t, ctx, false, args)
 		if err != nil {
@@ -1425,31 +1757,107 @@ func (c *Commands) cont(t *Term, ctx callContext, args string) error {
 	}
 	defer t.onStop()
 	c.frame = 0
-	stateChan := t.client.Continue()
-	var state *api.DebuggerState
-	for state = range stateChan {
-		if state.Err != nil {
-			printcontextNoState(t)
-			return state.Err
+	for hits := 1; ; hits++ {
+		stateChan := t.client.Continue()
+		var state *api.DebuggerState
+		for state = range stateChan {
+			if state.Err != nil {
+				printcontextNoState(t)
+				if count > 1 {
+					fmt.Fprintf(t.stdout, "%d of %d breakpoint hits occurred\n", hits-1, count)
+				}
+				return state.Err
+			}
+			if hits == count || !stoppedAtBreakpoint(state) {
+				printcontext(t, state)
+			}
 		}
-		printcontext(t, state)
+		if hits == count || !stoppedAtBreakpoint(state) {
+			if count > 1 && hits != count {
+				fmt.Fprintf(t.stdout, "%d of %d breakpoint hits occurred\n", hits-1, count)
+			}
+			if !c.selectUserFrame(t, state) {
+				printGoroutineExit(t, state)
+				printPos(t, state.CurrentThread, printPosShowArrow)
+			}
+			resume, err := c.runBreakpointCommands(t, state)
+			if !resume || err != nil {
+				return err
+			}
+			// the commands attached to the breakpoint continue the
+			// execution, start counting hits again
+			hits, count = 0, 1
+			continue
+		}
+		if _, err := c.runBreakpointCommands(t, state); err != nil {
+			return err
+		}
+		fmt.Fprintf(t.stdout, "breakpoint hit %d of %d, continuing...\n", hits, count)
 	}
-	printPos(t, state.CurrentThread, printPosShowArrow)
-	return nil
 }
 
-func continueUntilCompleteNext(t *Term, state *api.DebuggerState, op string, shouldPrintFile bool) error {
-	defer t.onStop()
+// stoppedAtBreakpoint returns true if the current thread of state is
+// stopped at a breakpoint.
+func stoppedAtBreakpoint(state *api.DebuggerState) bool {
+	return state.CurrentThread != nil && state.CurrentThread.Breakpoint != nil
+}
+
+// selectedGoroutineID returns the ID of the selected goroutine, or 0 if it
+// can not be determined.
+func selectedGoroutineID(t *Term) int64 {
+	state, err := t.client.GetStateNonBlocking()
+	if err != nil || state.SelectedGoroutine == nil {
+		return 0
+	}
+	return state.SelectedGoroutine.ID
+}
+
+// warnGoroutineSwitch prints a warning if the goroutine selected in state
+// is not gid, the goroutine that was being stepped.
+func warnGoroutineSwitch(t *Term, state *api.DebuggerState, gid int64) {
+	if gid == 0 || state.SelectedGoroutine == nil || state.SelectedGoroutine.ID == gid {
+		return
+	}
+	fmt.Fprintf(t.stdout, "Warning: stepped into goroutine %d (was %d)\n", state.SelectedGoroutine.ID, gid)
+}
+
+// continueUntilCompleteNext asks the user how to proceed every time the
+// step operation op, started on goroutine gid, is interrupted by a
+// breakpoint and continues it until it completes.
+// If the operation ends on a breakpoint the commands attached to it are
+// executed.
+func continueUntilCompleteNext(t *Term, state *api.DebuggerState, op string, gid int64, shouldPrintFile bool) error {
+	state, err := waitCompleteNext(t, state, op, gid, shouldPrintFile)
+	t.onStop()
+	if err != nil || state == nil {
+		return err
+	}
+	resume, err := t.cmds.runBreakpointCommands(t, state)
+	if !resume || err != nil {
+		return err
+	}
+	return t.cmds.cont(t, callContext{}, "")
+}
+
+// waitCompleteNext implements continueUntilCompleteNext, it returns the
+// state where the step operation completed or nil if it was canceled.
+func waitCompleteNext(t *Term, state *api.DebuggerState, op string, gid int64, shouldPrintFile bool) (*api.DebuggerState, error) {
 	if !state.NextInProgress {
+		warnGoroutineSwitch(t, state, gid)
 		if shouldPrintFile {
 			printPos(t, state.CurrentThread, printPosShowArrow)
 		}
-		return nil
+		return state, nil
 	}
 	skipBreakpoints := false
 	for {
+		resume, err := t.cmds.runBreakpointCommands(t, state)
+		if err != nil {
+			return nil, err
+		}
+		warnGoroutineSwitch(t, state, gid)
 		fmt.Fprintf(t.stdout, "\tbreakpoint hit during %s", op)
-		if !skipBreakpoints {
+		if !skipBreakpoints && !resume {
 			fmt.Fprintf(t.stdout, "\n")
 			answer, err := promptAutoContinue(t, op)
 			switch answer {
@@ -1463,23 +1871,23 @@ func continueUntilCompleteNext(t *Term, state *api.DebuggerState, op string, sho
 			default:
 				t.client.CancelNext()
 				printPos(t, state.CurrentThread, printPosShowArrow)
-				return err
+				return nil, err
 			}
 		} else {
 			fmt.Fprintf(t.stdout, ", continuing...\n")
 		}
 		stateChan := t.client.DirectionCongruentContinue()
-		var state *api.DebuggerState
 		for state = range stateChan {
 			if state.Err != nil {
 				printcontextNoState(t)
-				return state.Err
+				return nil, state.Err
 			}
 			printcontext(t, state)
 		}
 		if !state.NextInProgress {
+			warnGoroutineSwitch(t, state, gid)
 			printPos(t, state.CurrentThread, printPosShowArrow)
-			return nil
+			return state, nil
 		}
 	}
 }
@@ -1524,13 +1932,56 @@ func (c *Commands) step(t *Term, ctx callContext, args string) error {
 	if ctx.Prefix == revPrefix {
 		stepfn = t.client.ReverseStep
 	}
-	state, err := exitedToError(stepfn())
-	if err != nil {
-		printcontextNoState(t)
-		return err
+	count := int64(1)
+	if strings.HasPrefix(args, "-") {
+		opt, fnName, _ := strings.Cut(args, " ")
+		fnName = strings.TrimSpace(fnName)
+		switch {
+		case opt != "-into":
+			return fmt.Errorf("unknown option %q", opt)
+		case fnName == "":
+			return errors.New("not enough arguments")
+		case ctx.Prefix == revPrefix:
+			return errors.New("-into can not be used with the rev prefix")
+		}
+		stepfn = func() (*api.DebuggerState, error) {
+			return t.client.StepIntoFunction(fnName)
+		}
+	} else {
+		var err error
+		if count, err = parseOptionalCount(args); err != nil {
+			return err
+		} else if count <= 0 {
+			return errors.New("Invalid step count")
+		}
 	}
-	printcontext(t, state)
-	return continueUntilCompleteNext(t, state, "step", true)
+	return repeatStep(t, stepfn, "step", count)
+}
+
+// repeatStep executes the step operation stepfn, called op, count times.
+// Only the location reached by the last step is printed, if a breakpoint is
+// hit the remaining steps are not executed.
+func repeatStep(t *Term, stepfn func() (*api.DebuggerState, error), op string, count int64) error {
+	gid := selectedGoroutineID(t)
+	for ; count > 0; count-- {
+		state, err := exitedToError(stepfn())
+		if err != nil {
+			printcontextNoState(t)
+			return err
+		}
+		// If we're about the exit the loop, print the context.
+		finished := count == 1 || state.NextInProgress || stoppedAtBreakpoint(state)
+		if finished {
+			printcontext(t, state)
+		}
+		if err := continueUntilCompleteNext(t, state, op, gid, finished); err != nil {
+			return err
+		}
+		if finished {
+			break
+		}
+	}
+	return nil
 }
 
 var errNotOnFrameZero = errors.New("not on topmost frame")
@@ -1545,31 +1996,116 @@ func (c *Commands) nextInstruction(t *Term, ctx callContext, args string) error
 	return stepInstruction(t, ctx, c.frame, true)
 }
 
-func stepInstruction(t *Term, ctx callContext, frame int, skipCalls bool) error {
+// stepiUntilMaxSteps is the default maximum number of instructions executed
+// by stepi-until.
+const stepiUntilMaxSteps = 10000
+
+// stepInstructionUntil implements the stepi-until command.
+func (c *Commands) stepInstructionUntil(t *Term, ctx callContext, args string) error {
 	if err := scopePrefixSwitch(t, ctx); err != nil {
 		return err
 	}
-	if frame != 0 {
+	if c.frame != 0 {
 		return errNotOnFrameZero
 	}
 
+	maxSteps := stepiUntilMaxSteps
+	if rest, ok := strings.CutPrefix(args, "-max "); ok {
+		n, cond, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		var err error
+		maxSteps, err = strconv.Atoi(n)
+		if err != nil || maxSteps <= 0 {
+			return fmt.Errorf("invalid maximum number of instructions %q", n)
+		}
+		args = cond
+	}
+	cond := strings.TrimSpace(args)
+	if cond == "" {
+		return errors.New("not enough arguments")
+	}
+
 	defer t.onStop()
 
-	var fn func(bool) (*api.DebuggerState, error)
+	fn := t.client.StepInstruction
 	if ctx.Prefix == revPrefix {
 		fn = t.client.ReverseStepInstruction
-	} else {
-		fn = t.client.StepInstruction
 	}
 
-	state, err := exitedToError(fn(skipCalls))
-	if err != nil {
-		printcontextNoState(t)
-		return err
+	var state *api.DebuggerState
+	var err error
+	stop := func() {
+		printcontext(t, state)
+		printPos(t, state.CurrentThread, printPosShowArrow|printPosStepInstruction)
 	}
-	printcontext(t, state)
-	printPos(t, state.CurrentThread, printPosShowArrow|printPosStepInstruction)
-	return nil
+	for i := 0; i < maxSteps; i++ {
+		state, err = exitedToError(fn(false))
+		if err != nil {
+			printcontextNoState(t)
+			return err
+		}
+		if stoppedAtBreakpoint(state) {
+			stop()
+			return nil
+		}
+		v, err := t.client.EvalVariable(api.EvalScope{GoroutineID: -1}, cond, ShortLoadConfig)
+		if err != nil {
+			stop()
+			return fmt.Errorf("could not evaluate condition: %v", err)
+		}
+		if v.Kind != reflect.Bool {
+			stop()
+			return errors.New("condition is not a boolean expression")
+		}
+		if v.Value == "true" {
+			stop()
+			return nil
+		}
+	}
+	stop()
+	return fmt.Errorf("condition not satisfied after %d instructions", maxSteps)
+}
+
+func stepInstruction(t *Term, ctx callContext, frame int, skipCalls bool) error {
+	if err := scopePrefixSwitch(t, ctx); err != nil {
+		return err
+	}
+	if frame != 0 {
+		return errNotOnFrameZero
+	}
+
+	defer t.onStop()
+
+	var fn func(bool) (*api.DebuggerState, error)
+	if ctx.Prefix == revPrefix {
+		fn = t.client.ReverseStepInstruction
+	} else {
+		fn = t.client.StepInstruction
+	}
+
+	state, err := exitedToError(fn(skipCalls))
+	if err != nil {
+		printcontextNoState(t)
+		return err
+	}
+	printcontext(t, state)
+	printPos(t, state.CurrentThread, printPosShowArrow|printPosStepInstruction)
+	return nil
+}
+
+func (c *Commands) stepBack(t *Term, ctx callContext, args string) error {
+	if err := scopePrefixSwitch(t, ctx); err != nil {
+		return err
+	}
+	defer t.onStop()
+	c.frame = 0
+	state, err := exitedToError(t.client.StepBack())
+	if err != nil {
+		printcontextNoState(t)
+		return err
+	}
+	printcontext(t, state)
+	printPos(t, state.CurrentThread, printPosShowArrow)
+	return nil
 }
 
 func (c *Commands) revCmd(t *Term, ctx callContext, args string) error {
@@ -1601,22 +2137,7 @@ func (c *Commands) next(t *Term, ctx callContext, args string) error {
 	} else if count <= 0 {
 		return errors.New("Invalid next count")
 	}
-	for ; count > 0; count-- {
-		state, err := exitedToError(nextfn())
-		if err != nil {
-			printcontextNoState(t)
-			return err
-		}
-		// If we're about the exit the loop, print the context.
-		finishedNext := count == 1
-		if finishedNext {
-			printcontext(t, state)
-		}
-		if err := continueUntilCompleteNext(t, state, "next", finishedNext); err != nil {
-			return err
-		}
-	}
-	return nil
+	return repeatStep(t, nextfn, "next", count)
 }
 
 func (c *Commands) stepout(t *Term, ctx callContext, args string) error {
@@ -1632,13 +2153,14 @@ func (c *Commands) stepout(t *Term, ctx callContext, args string) error {
 		stepoutfn = t.client.ReverseStepOut
 	}
 
+	gid := selectedGoroutineID(t)
 	state, err := exitedToError(stepoutfn())
 	if err != nil {
 		printcontextNoState(t)
 		return err
 	}
 	printcontext(t, state)
-	return continueUntilCompleteNext(t, state, "stepout", true)
+	return continueUntilCompleteNext(t, state, "stepout", gid, true)
 }
 
 func (c *Commands) call(t *Term, ctx callContext, args string) error {
@@ -1658,13 +2180,26 @@ func (c *Commands) call(t *Term, ctx callContext, args string) error {
 		return err
 	}
 	printcontext(t, state)
-	return continueUntilCompleteNext(t, state, "call", true)
+	return continueUntilCompleteNext(t, state, "call", 0, true)
 }
 
 func clear(t *Term, ctx callContext, args string) error {
 	if len(args) == 0 {
 		return errors.New("not enough arguments")
 	}
+	if tag, ok := strings.CutPrefix(args, tagFlag+" "); ok {
+		bps, err := breakpointsWithTag(t, strings.TrimSpace(tag))
+		if err != nil {
+			return err
+		}
+		for _, bp := range bps {
+			if _, err := t.client.ClearBreakpoint(bp.ID); err != nil {
+				return err
+			}
+			fmt.Fprintf(t.stdout, "%s cleared at %s\n", formatBreakpointName(bp, true), t.formatBreakpointLocation(bp))
+		}
+		return nil
+	}
 	id, err := strconv.Atoi(args)
 	var bp *api.Breakpoint
 	if err == nil {
@@ -1686,7 +2221,15 @@ func clearAll(t *Term, ctx callContext, args string) error {
 	}
 
 	var locPCs map[uint64]struct{}
-	if args != "" {
+	var locRe *regexp.Regexp
+	if rest, ok := strings.CutPrefix(args, "-r "); ok {
+		locRe, err = regexp.Compile(strings.TrimSpace(rest))
+		if err != nil {
+			return err
+		}
+	} else if args == "-r" {
+		return errors.New("not enough arguments")
+	} else if args != "" {
 		locs, _, err := t.client.FindLocation(api.EvalScope{GoroutineID: -1, Frame: 0}, args, true, t.substitutePathRules())
 		if err != nil {
 			return err
@@ -1706,6 +2249,9 @@ func clearAll(t *Term, ctx callContext, args string) error {
 				continue
 			}
 		}
+		if locRe != nil && !locRe.MatchString(bp.FunctionName) && !locRe.MatchString(bp.File) {
+			continue
+		}
 
 		if bp.ID < 0 {
 			continue
@@ -1724,6 +2270,30 @@ func toggle(t *Term, ctx callContext, args string) error {
 	if args == "" {
 		return errors.New("not enough arguments")
 	}
+	if tag, ok := strings.CutPrefix(args, tagFlag+" "); ok {
+		bps, err := breakpointsWithTag(t, strings.TrimSpace(tag))
+		if err != nil {
+			return err
+		}
+		anyEnabled := false
+		for _, bp := range bps {
+			if !bp.Disabled {
+				anyEnabled = true
+				break
+			}
+		}
+		for _, bp := range bps {
+			if bp.Disabled == anyEnabled {
+				continue
+			}
+			bp, err := t.client.ToggleBreakpoint(bp.ID)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(t.stdout, "%s toggled at %s\n", formatBreakpointName(bp, true), t.formatBreakpointLocation(bp))
+		}
+		return nil
+	}
 	id, err := strconv.Atoi(args)
 	var bp *api.Breakpoint
 	if err == nil {
@@ -1738,6 +2308,229 @@ func toggle(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
+// exportedBreakpoint is the definition of a breakpoint saved by
+// 'breakpoints -export'.
+type exportedBreakpoint struct {
+	Location    string          `json:"location"`
+	Name        string          `json:"name,omitempty"`
+	Tags        []string        `json:"tags,omitempty"`
+	Commands    []string        `json:"commands,omitempty"`
+	Disabled    bool            `json:"disabled,omitempty"`
+	Cond        string          `json:"cond,omitempty"`
+	HitCond     string          `json:"hitCond,omitempty"`
+	HitCondPerG bool            `json:"hitCondPerG,omitempty"`
+	Tracepoint  bool            `json:"tracepoint,omitempty"`
+	Goroutine   bool            `json:"goroutine,omitempty"`
+	Stacktrace  int             `json:"stacktrace,omitempty"`
+	Variables   []string        `json:"variables,omitempty"`
+	LoadArgs    *api.LoadConfig `json:"loadArgs,omitempty"`
+	LoadLocals  *api.LoadConfig `json:"loadLocals,omitempty"`
+}
+
+// exportBreakpoints implements 'breakpoints -export'.
+func exportBreakpoints(t *Term, file string) error {
+	if file == "" {
+		return errors.New("not enough arguments")
+	}
+	breakPoints, err := t.client.ListBreakpoints(false)
+	if err != nil {
+		return err
+	}
+	sort.Sort(byID(breakPoints))
+	// functions traced by a tracepoint that also traces their returns
+	traceReturnFns := make(map[string]bool)
+	for _, bp := range breakPoints {
+		if bp.TraceReturn {
+			traceReturnFns[bp.FunctionName] = true
+		}
+	}
+	out := []exportedBreakpoint{}
+	for _, bp := range breakPoints {
+		if bp.ID < 0 || bp.TraceReturn {
+			continue
+		}
+		var loc string
+		switch {
+		case bp.WatchExpr != "":
+		case bp.Tracepoint && traceReturnFns[bp.FunctionName]:
+			loc = bp.FunctionName
+		case bp.File != "" && bp.Line > 0:
+			loc = fmt.Sprintf("%s:%d", bp.File, bp.Line)
+		default:
+			// suspended breakpoint
+			loc = bp.ExprString
+		}
+		if loc == "" {
+			fmt.Fprintf(t.stdout, "%s at %s not exported\n", formatBreakpointName(bp, true), t.formatBreakpointLocation(bp))
+			continue
+		}
+		out = append(out, exportedBreakpoint{
+			Location:    loc,
+			Name:        bp.Name,
+			Tags:        bp.Tags,
+			Commands:    bp.Commands,
+			Disabled:    bp.Disabled,
+			Cond:        bp.Cond,
+			HitCond:     bp.HitCond,
+			HitCondPerG: bp.HitCondPerG,
+			Tracepoint:  bp.Tracepoint,
+			Goroutine:   bp.Goroutine,
+			Stacktrace:  bp.Stacktrace,
+			Variables:   bp.Variables,
+			LoadArgs:    bp.LoadArgs,
+			LoadLocals:  bp.LoadLocals,
+		})
+	}
+	buf, err := json.MarshalIndent(out, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, append(buf, '\n'), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(t.stdout, "%d breakpoints exported to %s\n", len(out), file)
+	return nil
+}
+
+// importBreakpoints implements 'break -import'.
+func importBreakpoints(t *Term, ctx callContext, file string) error {
+	if file == "" {
+		return errors.New("not enough arguments")
+	}
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	var in []exportedBreakpoint
+	if err := json.Unmarshal(buf, &in); err != nil {
+		return fmt.Errorf("could not read %s: %v", file, err)
+	}
+	failed := 0
+	for _, ebp := range in {
+		if err := importBreakpoint(t, ctx, &ebp); err != nil {
+			fmt.Fprintf(t.stdout, "Could not import breakpoint at %s: %v\n", ebp.Location, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d breakpoints could not be imported", failed, len(in))
+	}
+	return nil
+}
+
+// importBreakpoint creates the breakpoint described by ebp. Tracepoints
+// are created like the trace command does, so that the returns of traced
+// functions are traced too.
+func importBreakpoint(t *Term, ctx callContext, ebp *exportedBreakpoint) error {
+	requestedBp := &api.Breakpoint{
+		Name:        ebp.Name,
+		Tags:        ebp.Tags,
+		Commands:    ebp.Commands,
+		Cond:        ebp.Cond,
+		HitCond:     ebp.HitCond,
+		HitCondPerG: ebp.HitCondPerG,
+		Tracepoint:  ebp.Tracepoint,
+		Goroutine:   ebp.Goroutine,
+		Stacktrace:  ebp.Stacktrace,
+		Variables:   ebp.Variables,
+		LoadArgs:    ebp.LoadArgs,
+		LoadLocals:  ebp.LoadLocals,
+	}
+	var bp *api.Breakpoint
+	if ebp.Tracepoint {
+		bps, err := setBreakpoint(t, ctx, true, ebp.Location)
+		if err != nil {
+			return err
+		}
+		if len(bps) != 1 {
+			return fmt.Errorf("location resolves to %d locations", len(bps))
+		}
+		requestedBp.ID = bps[0].ID
+		if err := t.client.AmendBreakpoint(requestedBp); err != nil {
+			return err
+		}
+		bp = bps[0]
+	} else {
+		locs, substSpec, err := t.client.FindLocation(ctx.Scope, ebp.Location, true, t.substitutePathRules())
+		if err != nil {
+			return err
+		}
+		if len(locs) != 1 {
+			return fmt.Errorf("location resolves to %d locations", len(locs))
+		}
+		spec := ebp.Location
+		if substSpec != "" {
+			spec = substSpec
+		}
+		requestedBp.Addr = locs[0].PC
+		requestedBp.Addrs = locs[0].PCs
+		requestedBp.AddrPid = locs[0].PCPids
+		bp, err = t.client.CreateBreakpointWithExpr(requestedBp, spec, t.substitutePathRules(), false)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(t.stdout, "%s set at %s\n", formatBreakpointName(bp, true), t.formatBreakpointLocation(bp))
+	}
+	if ebp.Disabled {
+		_, err := t.client.ToggleBreakpoint(bp.ID)
+		return err
+	}
+	return nil
+}
+
+// breakpointsWithTag returns the breakpoints that have the specified tag.
+func breakpointsWithTag(t *Term, tag string) ([]*api.Breakpoint, error) {
+	if tag == "" {
+		return nil, errors.New("not enough arguments")
+	}
+	breakPoints, err := t.client.ListBreakpoints(false)
+	if err != nil {
+		return nil, err
+	}
+	var r []*api.Breakpoint
+	for _, bp := range breakPoints {
+		if slices.Contains(bp.Tags, tag) {
+			r = append(r, bp)
+		}
+	}
+	if len(r) == 0 {
+		return nil, fmt.Errorf("no breakpoint with tag %q", tag)
+	}
+	sort.Sort(byID(r))
+	return r, nil
+}
+
+// parseBreakpointTags removes the -tag=<tag>[,<tag>...] options from the
+// arguments of the break command, before its condition, and returns them.
+func parseBreakpointTags(args string) (string, []string, error) {
+	cond := ""
+	if match := regexp.MustCompile(`^if | if `).FindStringIndex(args); match != nil {
+		args, cond = args[:match[0]], args[match[0]:]
+	}
+	var tags []string
+	fields := strings.Fields(args)
+	rest := fields[:0]
+	for _, field := range fields {
+		v, ok := strings.CutPrefix(field, tagFlag+"=")
+		if !ok {
+			rest = append(rest, field)
+			continue
+		}
+		for _, tag := range strings.Split(v, ",") {
+			if tag == "" {
+				return "", nil, fmt.Errorf("empty tag in %q", field)
+			}
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	if tags == nil {
+		return args + cond, nil, nil
+	}
+	return strings.Join(rest, " ") + cond, tags, nil
+}
+
 // byID sorts breakpoints by ID.
 type byID []*api.Breakpoint
 
@@ -1746,6 +2539,9 @@ func (a byID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a byID) Less(i, j int) bool { return a[i].ID < a[j].ID }
 
 func breakpoints(t *Term, ctx callContext, args string) error {
+	if file, ok := strings.CutPrefix(args, exportFlag+" "); ok {
+		return exportBreakpoints(t, strings.TrimSpace(file))
+	}
 	breakPoints, err := t.client.ListBreakpoints(args == "-a")
 	if err != nil {
 		return err
@@ -1770,6 +2566,9 @@ func breakpoints(t *Term, ctx callContext, args string) error {
 		if len(attrs) > 0 {
 			fmt.Fprintf(t.stdout, "%s\n", strings.Join(attrs, "\n"))
 		}
+		if len(bp.Tags) > 0 {
+			fmt.Fprintf(t.stdout, "\ttags %s\n", strings.Join(bp.Tags, ","))
+		}
 	}
 	return nil
 }
@@ -1815,6 +2614,9 @@ func formatBreakpointAttrs(prefix string, bp *api.Breakpoint, includeTrace bool)
 	for i := range bp.VerboseDescr {
 		attrs = append(attrs, fmt.Sprintf("%s%s", prefix, bp.VerboseDescr[i]))
 	}
+	if len(bp.Commands) > 0 {
+		attrs = append(attrs, fmt.Sprintf("%s{ %s }", prefix, strings.Join(bp.Commands, "; ")))
+	}
 	return attrs
 }
 
@@ -1939,6 +2741,12 @@ func setBreakpoint(t *Term, ctx callContext, tracepoint bool, argstr string) ([]
 		shouldSetReturnBreakpoints = true
 	}
 	if tracepoint && shouldSetReturnBreakpoints && locs[0].Function != nil {
+		if t.traceEntries == nil {
+			t.traceEntries = make(map[int]bool)
+		}
+		for _, bp := range created {
+			t.traceEntries[bp.ID] = true
+		}
 		for i := range locs {
 			if locs[i].Function == nil {
 				continue
@@ -1964,10 +2772,344 @@ func setBreakpoint(t *Term, ctx callContext, tracepoint bool, argstr string) ([]
 }
 
 func breakpoint(t *Term, ctx callContext, args string) error {
-	_, err := setBreakpoint(t, ctx, false, args)
+	hasFlag := func(flag string) bool {
+		return args == flag || strings.HasPrefix(args, flag+" ")
+	}
+	switch {
+	case hasFlag(goroutineCreateFlag):
+		return goroutineCreateBreakpoint(t, ctx, strings.TrimSpace(args[len(goroutineCreateFlag):]))
+	case hasFlag(goroutineExitFlag):
+		return goroutineExitBreakpoint(t, ctx, strings.TrimSpace(args[len(goroutineExitFlag):]))
+	case hasFlag(chanFlag):
+		return chanBreakpoint(t, ctx, strings.TrimSpace(args[len(chanFlag):]))
+	case hasFlag(mutexContentionFlag):
+		return mutexContentionBreakpoint(t, ctx, strings.TrimSpace(args[len(mutexContentionFlag):]))
+	case hasFlag(atReturnFlag):
+		return atReturnBreakpoint(t, ctx, strings.TrimSpace(args[len(atReturnFlag):]))
+	case hasFlag(importFlag):
+		return importBreakpoints(t, ctx, strings.TrimSpace(args[len(importFlag):]))
+	}
+	args, tags, err := parseBreakpointTags(args)
+	if err != nil {
+		return err
+	}
+	bps, err := setBreakpoint(t, ctx, false, args)
+	if err != nil || tags == nil {
+		return err
+	}
+	for _, bp := range bps {
+		bp.Tags = tags
+		if err := t.client.AmendBreakpoint(bp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const (
+	goroutineCreateFlag = "-goroutine-create"
+	goroutineExitFlag   = "-goroutine-exit"
+	chanFlag            = "-chan"
+	mutexContentionFlag = "-mutex-contention"
+	atReturnFlag        = "-at-return"
+	importFlag          = "-import"
+	exportFlag          = "-export"
+	tagFlag             = "-tag"
+	// goroutineCreateFunc is the function called by go statements to create
+	// a new goroutine.
+	goroutineCreateFunc = "runtime.newproc"
+	// goroutineExitFunc is the function called on the stack of a goroutine
+	// when it finishes, both when its function returns and from
+	// runtime.Goexit.
+	goroutineExitFunc = "runtime.goexit1"
+	// chanSendFunc and chanRecvFunc implement send and receive operations on
+	// channels, their first argument c is the channel.
+	chanSendFunc = "runtime.chansend"
+	chanRecvFunc = "runtime.chanrecv"
+)
+
+// mutexContentionFuncs are the functions called by sync.Mutex when it has
+// to wait for the mutex to be unlocked, their first argument addr is the
+// address of the semaphore of the mutex. The first one is used starting
+// with Go 1.24.
+var mutexContentionFuncs = []string{"internal/sync.runtime_SemacquireMutex", "sync.runtime_SemacquireMutex"}
+
+// userFrameBreakpointFuncs maps the functions used by the -goroutine-create
+// and -chan forms of break to a description of what the stopped goroutine
+// is doing.
+var userFrameBreakpointFuncs = map[string]string{
+	goroutineCreateFunc:     "creating a new goroutine",
+	chanSendFunc:            "sending to a channel",
+	chanRecvFunc:            "receiving from a channel",
+	mutexContentionFuncs[0]: "waiting for a locked mutex",
+	mutexContentionFuncs[1]: "waiting for a locked mutex",
+}
+
+// parseGoroutineBreakpointArgs splits the arguments of the -goroutine-create
+// and -goroutine-exit forms of break into the first argument and a
+// condition.
+func parseGoroutineBreakpointArgs(args string) (arg, cond string, err error) {
+	switch {
+	case args == "":
+		// nothing to do
+	case strings.HasPrefix(args, "if "):
+		cond = args[len("if "):]
+	default:
+		v := config.Split2PartsBySpace(args)
+		arg = v[0]
+		if len(v) > 1 {
+			if !strings.HasPrefix(v[1], "if ") {
+				return "", "", fmt.Errorf("wrong argument %q, expected 'if <condition>'", v[1])
+			}
+			cond = v[1][len("if "):]
+		}
+	}
+	return arg, cond, nil
+}
+
+// setGoroutineBreakpoint sets a breakpoint on fn with condition
+// fncond && (cond).
+func setGoroutineBreakpoint(t *Term, ctx callContext, fn, fncond, cond string) error {
+	if fncond != "" {
+		if cond != "" {
+			cond = fncond + " && (" + cond + ")"
+		} else {
+			cond = fncond
+		}
+	}
+	spec := fn
+	if cond != "" {
+		spec += " if " + cond
+	}
+	_, err := setBreakpoint(t, ctx, false, spec)
 	return err
 }
 
+// goroutineCreateBreakpoint implements 'break -goroutine-create'.
+func goroutineCreateBreakpoint(t *Term, ctx callContext, args string) error {
+	fnname, cond, err := parseGoroutineBreakpointArgs(args)
+	if err != nil {
+		return err
+	}
+	var fncond string
+	if fnname != "" {
+		locs, _, err := t.client.FindLocation(ctx.Scope, fnname, false, t.substitutePathRules())
+		if err != nil {
+			return err
+		}
+		if len(locs) != 1 || locs[0].Function == nil {
+			return fmt.Errorf("%q does not specify a single function", fnname)
+		}
+		fncond = fmt.Sprintf("fn.fn == %#x", locs[0].Function.Value)
+	}
+	return setGoroutineBreakpoint(t, ctx, goroutineCreateFunc, fncond, cond)
+}
+
+// goroutineExitBreakpoint implements 'break -goroutine-exit'.
+func goroutineExitBreakpoint(t *Term, ctx callContext, args string) error {
+	gidstr, cond, err := parseGoroutineBreakpointArgs(args)
+	if err != nil {
+		return err
+	}
+	gid := ctx.Scope.GoroutineID
+	if gidstr != "" {
+		gid, err = strconv.ParseInt(gidstr, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid goroutine id %q", gidstr)
+		}
+	} else if gid < 0 {
+		state, err := t.client.GetState()
+		if err != nil {
+			return err
+		}
+		if state.SelectedGoroutine == nil {
+			return errors.New("no selected goroutine")
+		}
+		gid = state.SelectedGoroutine.ID
+	}
+	if _, err := t.client.Stacktrace(gid, 0, 0, nil); err != nil {
+		return err
+	}
+	return setGoroutineBreakpoint(t, ctx, goroutineExitFunc, fmt.Sprintf("runtime.curg.goid == %d", gid), cond)
+}
+
+// chanBreakpoint implements 'break -chan'.
+func chanBreakpoint(t *Term, ctx callContext, args string) error {
+	if args == "" {
+		return errors.New("not enough arguments")
+	}
+	expr := args
+	fns := []string{chanSendFunc, chanRecvFunc}
+	if i := strings.LastIndex(args, " "); i >= 0 {
+		switch args[i+1:] {
+		case "send":
+			expr, fns = args[:i], fns[:1]
+		case "recv":
+			expr, fns = args[:i], fns[1:]
+		}
+	}
+	v, err := t.client.EvalVariable(ctx.Scope, expr, ShortLoadConfig)
+	if err != nil {
+		return err
+	}
+	if v.Kind != reflect.Chan {
+		return fmt.Errorf("%s is not a channel", expr)
+	}
+	if v.Base == 0 {
+		return fmt.Errorf("%s is a nil channel", expr)
+	}
+	for _, fn := range fns {
+		if err := setGoroutineBreakpoint(t, ctx, fn, fmt.Sprintf("uintptr(c) == %#x", v.Base), ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mutexContentionBreakpoint implements 'break -mutex-contention'.
+func mutexContentionBreakpoint(t *Term, ctx callContext, args string) error {
+	var cond string
+	if args != "" {
+		var sema *api.Variable
+		var err error
+		// Starting with Go 1.24 sync.Mutex wraps internal/sync.Mutex.
+		for _, field := range []string{"sema", "mu.sema"} {
+			sema, err = t.client.EvalVariable(ctx.Scope, fmt.Sprintf("&(%s).%s", args, field), ShortLoadConfig)
+			if err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%s is not a sync.Mutex: %v", args, err)
+		}
+		if len(sema.Children) != 1 {
+			return fmt.Errorf("could not find the address of %s", args)
+		}
+		cond = fmt.Sprintf("uintptr(addr) == %#x", sema.Children[0].Addr)
+	}
+	for _, fn := range mutexContentionFuncs {
+		if _, _, err := t.client.FindLocation(ctx.Scope, fn, false, nil); err == nil {
+			return setGoroutineBreakpoint(t, ctx, fn, cond, "")
+		}
+	}
+	return errors.New("could not find the function used by sync.Mutex to wait")
+}
+
+// atReturnBreakpoint implements 'break -at-return', it sets a breakpoint on
+// all the return instructions of a function, including the calls to
+// runtime.deferreturn.
+func atReturnBreakpoint(t *Term, ctx callContext, args string) error {
+	var name, cond string
+	if match := regexp.MustCompile(`^if | if `).FindStringIndex(args); match != nil {
+		cond = args[match[1]:]
+		args = strings.TrimSpace(args[:match[0]])
+	}
+	spec := args
+	if v := config.Split2PartsBySpace(args); len(v) == 2 && api.ValidBreakpointName(v[0]) == nil {
+		name, spec = v[0], v[1]
+	}
+	if spec == "" {
+		return errors.New("not enough arguments")
+	}
+	if loc, err := locspec.Parse(spec); err != nil {
+		return err
+	} else if loc, ok := loc.(*locspec.NormalLocationSpec); !ok || loc.FuncBase == nil || loc.LineOffset != -1 {
+		return fmt.Errorf("%q does not specify a single function", spec)
+	}
+	locs, _, err := t.client.FindLocation(ctx.Scope, spec, false, t.substitutePathRules())
+	if err != nil {
+		return err
+	}
+	if len(locs) != 1 || locs[0].Function == nil {
+		return fmt.Errorf("%q does not specify a single function", spec)
+	}
+	fnName := locs[0].Function.Name()
+	addrs, err := t.client.(*rpc2.RPCClient).FunctionReturnLocations(fnName)
+	if err != nil {
+		return err
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("could not find any return instruction in %s", fnName)
+	}
+	bp, err := t.client.CreateBreakpoint(&api.Breakpoint{Name: name, Addrs: addrs, Cond: cond})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(t.stdout, "%s set at %s\n", formatBreakpointName(bp, true), t.formatBreakpointLocation(bp))
+	return nil
+}
+
+// mutexAddress returns the address of the mutex the current goroutine is
+// waiting for, stack is the stack of the current goroutine.
+func mutexAddress(t *Term, stack []api.Stackframe) (uint64, bool) {
+	for i := range stack {
+		if stack[i].Function == nil || !strings.HasSuffix(stack[i].Function.Name(), ".(*Mutex).lockSlow") {
+			continue
+		}
+		v, err := t.client.EvalVariable(api.EvalScope{GoroutineID: -1, Frame: i}, "uintptr(m)", ShortLoadConfig)
+		if err != nil {
+			return 0, false
+		}
+		addr, err := strconv.ParseUint(v.Value, 0, 64)
+		return addr, err == nil
+	}
+	return 0, false
+}
+
+func isRuntimeOrSyncFunction(name string) bool {
+	for _, pkg := range []string{"runtime.", "sync.", "internal/sync."} {
+		if strings.HasPrefix(name, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// printGoroutineExit prints a message if the current goroutine is stopped
+// at a breakpoint set on goroutineExitFunc.
+func printGoroutineExit(t *Term, state *api.DebuggerState) {
+	th := state.CurrentThread
+	if th == nil || th.Breakpoint == nil || th.Function == nil || th.Function.Name() != goroutineExitFunc {
+		return
+	}
+	fmt.Fprintf(t.stdout, "Goroutine %d is exiting\n", th.GoroutineID)
+}
+
+// selectUserFrame selects the topmost frame outside of the runtime if the
+// current goroutine is stopped at a breakpoint set on one of
+// userFrameBreakpointFuncs and prints its position. Returns false if the
+// target is stopped somewhere else.
+func (c *Commands) selectUserFrame(t *Term, state *api.DebuggerState) bool {
+	th := state.CurrentThread
+	if th == nil || th.Breakpoint == nil || th.Function == nil {
+		return false
+	}
+	what, ok := userFrameBreakpointFuncs[th.Function.Name()]
+	if !ok {
+		return false
+	}
+	const maxDepth = 20
+	stack, err := t.client.Stacktrace(-1, maxDepth, 0, nil)
+	if err != nil {
+		return false
+	}
+	if fn := th.Function.Name(); fn == mutexContentionFuncs[0] || fn == mutexContentionFuncs[1] {
+		if addr, ok := mutexAddress(t, stack); ok {
+			what = fmt.Sprintf("waiting for a locked mutex at %#x", addr)
+		}
+	}
+	for i := 1; i < len(stack); i++ {
+		if stack[i].Function == nil || isRuntimeOrSyncFunction(stack[i].Function.Name()) {
+			continue
+		}
+		c.frame = i
+		fmt.Fprintf(t.stdout, "Goroutine %d %s, frame %d: %s:%d (PC: %x)\n", th.GoroutineID, what, i, t.formatPath(stack[i].File), stack[i].Line, stack[i].PC)
+		printfile(t, stack[i].File, stack[i].Line, true)
+		return true
+	}
+	return false
+}
+
 func tracepoint(t *Term, ctx callContext, args string) error {
 	if ctx.Prefix == onPrefix {
 		if args != "" {
@@ -2044,7 +3186,15 @@ func watchpoint(t *Term, ctx callContext, args string) error {
 		return err
 	}
 	fmt.Fprintf(t.stdout, "%s set at %s\n", formatBreakpointName(bp, true), t.formatBreakpointLocation(bp))
-	return nil
+	if ctx.Prefix != revPrefix {
+		return nil
+	}
+	defer func() {
+		if _, err := t.client.ClearBreakpoint(bp.ID); err != nil {
+			fmt.Fprintf(t.stdout, "failed to clear temporary watchpoint: %d", bp.ID)
+		}
+	}()
+	return t.cmds.rewind(t, ctx, "")
 }
 
 func examineMemoryCmd(t *Term, ctx callContext, argstr string) error {
@@ -2195,20 +3345,81 @@ func (c *Commands) printVar(t *Term, ctx callContext, args string) error {
 	if len(args) == 0 {
 		return errors.New("not enough arguments")
 	}
+	// at most one of -call, -chanbuf, -hexdump and -proto can be specified
+	var flag string
+	for {
+		var f string
+		switch {
+		case strings.HasPrefix(args, "-call "):
+			f = "-call"
+		case strings.HasPrefix(args, "-chanbuf "):
+			f = "-chanbuf"
+		case strings.HasPrefix(args, "-hexdump "):
+			f = "-hexdump"
+		case strings.HasPrefix(args, "-proto "):
+			f = "-proto"
+		}
+		if f == "" {
+			break
+		}
+		if flag != "" {
+			return fmt.Errorf("%s and %s can not be used together", flag, f)
+		}
+		flag = f
+		args = strings.TrimSpace(args[len(f):])
+	}
+	chanbuf, hexdump, proto := flag == "-chanbuf", flag == "-hexdump", flag == "-proto"
 	if ctx.Prefix == onPrefix {
+		if flag != "" {
+			return fmt.Errorf("%s can not be used with the on prefix", flag)
+		}
 		ctx.Breakpoint.Variables = append(ctx.Breakpoint.Variables, args)
 		return nil
 	}
 	fmtstr, args := parseFormatArg(args)
+	if flag == "-call" {
+		return printCall(t, ctx, fmtstr, args)
+	}
 	val, err := t.client.EvalVariable(ctx.Scope, args, t.loadConfig())
 	if err != nil {
 		return err
 	}
+	if chanbuf && val.Kind != reflect.Chan {
+		return fmt.Errorf("%s is not a channel", args)
+	}
+
+	if hexdump || proto {
+		buf, err := variableBytes(val)
+		if err != nil {
+			return fmt.Errorf("%s: %v", args, err)
+		}
+		t.stdout.pw.PageMaybe(nil)
+		if hexdump {
+			fmt.Fprint(t.stdout, hex.Dump(buf))
+		} else {
+			printProtoWire(t.stdout, buf)
+		}
+		if int64(len(buf)) < val.Len {
+			fmt.Fprintf(t.stdout, "...+%d more bytes\n", val.Len-int64(len(buf)))
+		}
+		return nil
+	}
 
 	t.stdout.pw.PageMaybe(nil)
 
 	fmt.Fprintln(t.stdout, val.MultilineString("", fmtstr))
 
+	if val.Kind == reflect.Chan && chanbuf {
+		buf, err := t.client.ChanBuffer(ctx.Scope, args, t.loadConfig())
+		if err != nil {
+			fmt.Fprintf(t.stdout, "Error reading channel buffer: %v\n", err)
+		} else {
+			fmt.Fprintln(t.stdout)
+			fmt.Fprintln(t.stdout, "Buffered values:")
+			fmt.Fprintln(t.stdout, buf.MultilineString("", fmtstr))
+		}
+	}
+
 	if val.Kind == reflect.Chan {
 		fmt.Fprintln(t.stdout)
 		gs, _, _, _, err := t.client.ListGoroutinesWithFilter(0, maxPrintVarChanGoroutines, []api.ListGoroutinesFilter{{Kind: api.GoroutineWaitingOnChannel, Arg: fmt.Sprintf("*(*%q)(%#x)", val.Type, val.Addr)}}, nil, &ctx.Scope)
@@ -2227,6 +3438,73 @@ func (c *Commands) printVar(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
+// variableBytes returns the loaded contents of val, which must be a string
+// or a slice or array of bytes.
+func variableBytes(val *api.Variable) ([]byte, error) {
+	if val.Unreadable != "" {
+		return nil, errors.New(val.Unreadable)
+	}
+	switch val.Kind {
+	case reflect.String:
+		return []byte(val.Value), nil
+	case reflect.Slice, reflect.Array:
+		buf := make([]byte, 0, len(val.Children))
+		for i := range val.Children {
+			child := &val.Children[i]
+			if child.Kind != reflect.Uint8 && child.RealType != "uint8" && child.RealType != "byte" {
+				return nil, fmt.Errorf("can not hexdump values of type %s", val.Type)
+			}
+			n, err := strconv.ParseUint(child.Value, 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("element %d is not readable", i)
+			}
+			buf = append(buf, byte(n))
+		}
+		return buf, nil
+	default:
+		return nil, fmt.Errorf("can not hexdump values of type %s", val.Type)
+	}
+}
+
+// printCallTimeout is the maximum amount of time the function calls
+// injected by 'print -call' are allowed to run before the target is stopped.
+const printCallTimeout = 5 * time.Second
+
+// printCall evaluates an expression containing function calls, using the
+// same mechanism as the call command, and prints its value.
+func printCall(t *Term, ctx callContext, fmtstr, expr string) error {
+	if ctx.Scope.Frame != 0 || ctx.Scope.DeferredCall != 0 {
+		return errors.New("expressions containing function calls can only be evaluated in the topmost frame")
+	}
+	timer := time.AfterFunc(printCallTimeout, func() {
+		t.client.Halt()
+	})
+	state, err := exitedToError(t.client.Call(ctx.Scope.GoroutineID, expr, false))
+	if !timer.Stop() && err == nil {
+		printcontext(t, state)
+		return fmt.Errorf("function call did not complete within %v, the target was stopped", printCallTimeout)
+	}
+	if err != nil {
+		return err
+	}
+	th := state.CurrentThread
+	if th == nil || th.ReturnValues == nil {
+		// the call did not complete, for example because a breakpoint was
+		// hit during its execution.
+		printcontext(t, state)
+		return continueUntilCompleteNext(t, state, "call", 0, true)
+	}
+	t.stdout.pw.PageMaybe(nil)
+	if len(th.ReturnValues) == 1 {
+		fmt.Fprintln(t.stdout, th.ReturnValues[0].MultilineString("", fmtstr))
+		return nil
+	}
+	for _, v := range th.ReturnValues {
+		fmt.Fprintf(t.stdout, "%s: %s\n", v.Name, v.MultilineString("", fmtstr))
+	}
+	return nil
+}
+
 func whatisCommand(t *Term, ctx callContext, args string) error {
 	if len(args) == 0 {
 		return errors.New("not enough arguments")
@@ -2287,6 +3565,8 @@ func (t *Term) printFilteredVariables(varType string, vars []api.Variable, filte
 			}
 			if cfg == ShortLoadConfig {
 				fmt.Fprintf(t.stdout, "%s = %s\n", name, v.SinglelineString())
+			} else if v.Type != "" {
+				fmt.Fprintf(t.stdout, "%s %s = %s\n", name, v.Type, v.MultilineString("", ""))
 			} else {
 				fmt.Fprintf(t.stdout, "%s = %s\n", name, v.MultilineString("", ""))
 			}
@@ -2366,9 +3646,15 @@ func args(t *Term, ctx callContext, args string) error {
 }
 
 func locals(t *Term, ctx callContext, args string) error {
+	const capturesFlag = "-captures"
+	captures := false
+	if args == capturesFlag || strings.HasPrefix(args, capturesFlag+" ") {
+		captures = true
+		args = strings.TrimSpace(args[len(capturesFlag):])
+	}
 	filter, cfg := parseVarArguments(args, t)
 	if ctx.Prefix == onPrefix {
-		if filter != "" {
+		if filter != "" || captures {
 			return errors.New("filter not supported on breakpoint")
 		}
 		ctx.Breakpoint.LoadLocals = &cfg
@@ -2378,6 +3664,15 @@ func locals(t *Term, ctx callContext, args string) error {
 	if err != nil {
 		return err
 	}
+	if captures {
+		captured := locals[:0]
+		for _, v := range locals {
+			if v.Flags&api.VariableCaptured != 0 {
+				captured = append(captured, v)
+			}
+		}
+		return t.printFilteredVariables("captured variables", captured, filter, cfg)
+	}
 	return t.printFilteredVariables("locals", locals, filter, cfg)
 }
 
@@ -2405,10 +3700,52 @@ func regs(t *Term, ctx callContext, args string) error {
 	if err != nil {
 		return err
 	}
-	fmt.Fprintln(t.stdout, regs)
+	if ctx.Scope.GoroutineID >= 0 || ctx.Scope.Frame != 0 {
+		fmt.Fprintln(t.stdout, regs)
+		return nil
+	}
+	state, err := t.client.GetStateNonBlocking()
+	if err != nil || state.CurrentThread == nil {
+		fmt.Fprintln(t.stdout, regs)
+		return nil
+	}
+	if t.lastRegs == nil {
+		t.lastRegs = make(map[int]api.Registers)
+	}
+	prev := t.lastRegs[state.CurrentThread.ID]
+	t.lastRegs[state.CurrentThread.ID] = regs
+	t.printRegisters(regs, prev)
 	return nil
 }
 
+// printRegisters prints regs, registers whose value changed since prev was
+// displayed are marked with a '*' and highlighted.
+func (t *Term) printRegisters(regs, prev api.Registers) {
+	prevValues := make(map[string]string, len(prev))
+	for _, reg := range prev {
+		prevValues[reg.Name] = reg.Value
+	}
+	maxlen := 0
+	for _, reg := range regs {
+		if n := len(reg.Name); n > maxlen {
+			maxlen = n
+		}
+	}
+	for _, reg := range regs {
+		old, ok := prevValues[reg.Name]
+		if !ok || old == reg.Value {
+			fmt.Fprintf(t.stdout, "  %*s = %s\n", maxlen, reg.Name, reg.Value)
+			continue
+		}
+		if t.stdout.colorEscapes != nil {
+			fmt.Fprintf(t.stdout, "* %*s = "+terminalHighlightEscapeCode+"%s"+terminalResetEscapeCode+"\n", maxlen, reg.Name, ansiRed, reg.Value)
+		} else {
+			fmt.Fprintf(t.stdout, "* %*s = %s\n", maxlen, reg.Name, reg.Value)
+		}
+	}
+	fmt.Fprintln(t.stdout)
+}
+
 func stackCommand(t *Term, ctx callContext, args string) error {
 	sa, err := parseStackArgs(args)
 	if err != nil {
@@ -2682,6 +4019,354 @@ func disassCommand(t *Term, ctx callContext, args string) error {
 	return nil
 }
 
+func scheduler(t *Term, ctx callContext, args string) error {
+	sched, err := t.client.GetScheduler()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(t.stdout, "GOMAXPROCS: %d, global run queue: %d, idle Ps: %d, idle Ms: %d, spinning Ms: %d\n", sched.Gomaxprocs, sched.GlobalRunqSize, sched.NPIdle, sched.NMIdle, sched.NMSpinning)
+
+	idOrNone := func(id int64) string {
+		if id < 0 {
+			return "-"
+		}
+		return strconv.FormatInt(id, 10)
+	}
+
+	w := new(tabwriter.Writer)
+	w.Init(t.stdout, 4, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\nP\tStatus\tRun queue\tM")
+	for _, p := range sched.Ps {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\n", p.ID, p.Status, p.RunqSize, idOrNone(p.MID))
+	}
+	fmt.Fprintln(w, "\nM\tThread\tP\tGoroutine")
+	for _, m := range sched.Ms {
+		g := "-"
+		if m.CurG != 0 {
+			g = strconv.FormatInt(m.CurG, 10)
+		}
+		spinning := ""
+		if m.Spinning {
+			spinning = " (spinning)"
+		}
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s%s\n", m.ID, m.ThreadID, idOrNone(int64(m.PID)), g, spinning)
+	}
+	return w.Flush()
+}
+
+func selectInfo(t *Term, ctx callContext, args string) error {
+	if args != "" {
+		return errors.New("too many arguments to selectinfo")
+	}
+	cases, err := t.client.ListSelectCases(ctx.Scope.GoroutineID)
+	if err != nil {
+		return err
+	}
+	w := new(tabwriter.Writer)
+	w.Init(t.stdout, 4, 4, 2, ' ', 0)
+	for _, sc := range cases {
+		dir := sc.Dir
+		if dir == "" {
+			dir = "?"
+		}
+		typ := "chan ?"
+		if sc.ElemType != "" {
+			typ = "chan " + sc.ElemType
+		}
+		fmt.Fprintf(w, "%s\t%s\t%#x\n", dir, typ, sc.Chan)
+	}
+	return w.Flush()
+}
+
+func gcInfo(t *Term, ctx callContext, args string) error {
+	gci, err := t.client.GetGCInfo()
+	if err != nil {
+		return err
+	}
+	lastGC := "never"
+	if gci.LastGC != 0 {
+		lastGC = time.Unix(0, gci.LastGC).Format(time.RFC3339Nano)
+	}
+	fmtBytes := func(n uint64) string {
+		const unit = 1024
+		if n < unit {
+			return fmt.Sprintf("%d B", n)
+		}
+		div, exp := uint64(unit), 0
+		for m := n / unit; m >= unit; m /= unit {
+			div *= unit
+			exp++
+		}
+		return fmt.Sprintf("%.1f %ciB (%d)", float64(n)/float64(div), "KMGTPE"[exp], n)
+	}
+	w := new(tabwriter.Writer)
+	w.Init(t.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "GC phase:\t%s\n", gci.Phase)
+	fmt.Fprintf(w, "NumGC:\t%d (forced: %d)\n", gci.NumGC, gci.NumForcedGC)
+	fmt.Fprintf(w, "LastGC:\t%s\n", lastGC)
+	fmt.Fprintf(w, "PauseTotal:\t%v\n", time.Duration(gci.PauseTotalNs))
+	fmt.Fprintf(w, "GCCPUFraction:\t%g\n", gci.GCCPUFraction)
+	fmt.Fprintf(w, "HeapInuse:\t%s\n", fmtBytes(gci.HeapInuse))
+	fmt.Fprintf(w, "HeapIdle:\t%s\n", fmtBytes(gci.HeapIdle))
+	fmt.Fprintf(w, "HeapReleased:\t%s\n", fmtBytes(gci.HeapReleased))
+	fmt.Fprintf(w, "Heap live:\t%s\n", fmtBytes(gci.HeapLive))
+	fmt.Fprintf(w, "Heap marked:\t%s\n", fmtBytes(gci.HeapMarked))
+	fmt.Fprintf(w, "NextGC:\t%s\n", fmtBytes(gci.NextGC))
+	return w.Flush()
+}
+
+func vmmap(t *Term, ctx callContext, args string) error {
+	regions, err := t.client.ListMemoryRegions()
+	if err != nil {
+		return err
+	}
+	perm := func(b bool, c byte) byte {
+		if b {
+			return c
+		}
+		return '-'
+	}
+	const maxGoroutinesListed = 5
+	w := new(tabwriter.Writer)
+	w.Init(t.stdout, 0, 4, 2, ' ', 0)
+	for _, reg := range regions {
+		var descr []string
+		if reg.Filename != "" {
+			descr = append(descr, fmt.Sprintf("%s+%#x", reg.Filename, reg.Offset))
+		}
+		if reg.Heap {
+			descr = append(descr, "[heap]")
+		}
+		switch {
+		case len(reg.Goroutines) > maxGoroutinesListed:
+			descr = append(descr, fmt.Sprintf("[stacks of %d goroutines]", len(reg.Goroutines)))
+		case len(reg.Goroutines) > 0:
+			ids := make([]string, len(reg.Goroutines))
+			for i, id := range reg.Goroutines {
+				ids[i] = strconv.FormatInt(id, 10)
+			}
+			descr = append(descr, fmt.Sprintf("[stacks of goroutines %s]", strings.Join(ids, " ")))
+		}
+		fmt.Fprintf(w, "%#x-%#x\t%c%c%c", reg.Addr, reg.Addr+reg.Size, perm(reg.Read, 'r'), perm(reg.Write, 'w'), perm(reg.Exec, 'x'))
+		if len(descr) > 0 {
+			fmt.Fprintf(w, "\t%s", strings.Join(descr, " "))
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
+
+// addressArg returns the address specified by args, which is either a
+// number or an expression evaluating to a pointer or an integer.
+func (t *Term) addressArg(ctx callContext, args string) (uint64, error) {
+	if args == "" {
+		return 0, errors.New("no address specified")
+	}
+	addr, err := strconv.ParseUint(args, 0, 64)
+	if err == nil {
+		return addr, nil
+	}
+	val, err := t.client.EvalVariable(ctx.Scope, args, t.loadConfig())
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case val.Kind == reflect.Ptr && len(val.Children) > 0:
+		return val.Children[0].Addr, nil
+	case (val.Kind == reflect.Int || val.Kind == reflect.Uint || val.Kind == reflect.Uintptr) && val.Value != "":
+		addr, err = strconv.ParseUint(val.Value, 0, 64)
+		if err != nil {
+			return 0, fmt.Errorf("bad expression result: %q: %s", val.Value, err)
+		}
+		return addr, nil
+	default:
+		return 0, fmt.Errorf("unsupported expression type: %s", val.Kind)
+	}
+}
+
+func whatobject(t *Term, ctx callContext, args string) error {
+	addr, err := t.addressArg(ctx, args)
+	if err != nil {
+		return err
+	}
+	obj, err := t.client.FindHeapObject(addr)
+	if err != nil {
+		return err
+	}
+	w := new(tabwriter.Writer)
+	w.Init(t.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Object:\t%#x", obj.Addr)
+	switch {
+	case addr > obj.Addr:
+		fmt.Fprintf(w, " (%#x is %d bytes into the object)", addr, addr-obj.Addr)
+	case addr < obj.Addr:
+		fmt.Fprintf(w, " (%#x is in the malloc header of the object)", addr)
+	}
+	fmt.Fprintln(w)
+	if obj.SizeClass != 0 {
+		fmt.Fprintf(w, "Size:\t%d bytes (size class %d)\n", obj.Size, obj.SizeClass)
+	} else {
+		fmt.Fprintf(w, "Size:\t%d bytes (large object)\n", obj.Size)
+	}
+	typ := obj.Type
+	switch {
+	case typ != "":
+	case obj.Noscan:
+		typ = "unknown (no pointers)"
+	default:
+		typ = "unknown"
+	}
+	fmt.Fprintf(w, "Type:\t%s\n", typ)
+	if obj.Free {
+		fmt.Fprintf(w, "State:\tfree\n")
+	} else {
+		fmt.Fprintf(w, "State:\tallocated\n")
+	}
+	return w.Flush()
+}
+
+func refsto(t *Term, ctx callContext, args string) error {
+	const defaultMaxRefs = 100
+	stacksOnly, max := false, defaultMaxRefs
+	for strings.HasPrefix(args, "-") {
+		v := config.Split2PartsBySpace(args)
+		args = ""
+		if len(v) > 1 {
+			args = v[1]
+		}
+		switch v[0] {
+		case "-stacks":
+			stacksOnly = true
+		case "-max":
+			v = config.Split2PartsBySpace(args)
+			n, err := strconv.Atoi(v[0])
+			if err != nil || n < 0 {
+				return fmt.Errorf("wrong argument to -max: %q", v[0])
+			}
+			max = n
+			args = ""
+			if len(v) > 1 {
+				args = v[1]
+			}
+		default:
+			return fmt.Errorf("unknown flag %q", v[0])
+		}
+	}
+	addr, err := t.addressArg(ctx, args)
+	if err != nil {
+		return err
+	}
+	refs, truncated, err := t.client.FindReferences(addr, stacksOnly, max)
+	if err != nil {
+		return err
+	}
+	if len(refs) == 0 {
+		fmt.Fprintf(t.stdout, "No references to %#x found\n", addr)
+		return nil
+	}
+	w := new(tabwriter.Writer)
+	w.Init(t.stdout, 0, 4, 2, ' ', 0)
+	for _, ref := range refs {
+		fmt.Fprintf(w, "%#x\t", ref.Addr)
+		switch {
+		case ref.Object != nil:
+			fmt.Fprintf(w, "heap object %#x+%#x", ref.Object.Addr, ref.Addr-ref.Object.Addr)
+			if ref.Object.Type != "" {
+				fmt.Fprintf(w, " (%s)", ref.Object.Type)
+			}
+		case ref.Frame < 0:
+			fmt.Fprintf(w, "goroutine %d", ref.GoroutineID)
+		default:
+			fmt.Fprintf(w, "goroutine %d frame %d", ref.GoroutineID, ref.Frame)
+			if ref.Function != nil {
+				fmt.Fprintf(w, " in %s", ref.Function.Name())
+			}
+			if ref.Variable != "" {
+				fmt.Fprintf(w, ", variable %s", ref.Variable)
+				if ref.VarOffset != 0 {
+					fmt.Fprintf(w, "+%#x", ref.VarOffset)
+				}
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if truncated {
+		fmt.Fprintf(t.stdout, "(search stopped after %d references, use -max to find more)\n", max)
+	}
+	return nil
+}
+
+func finalizer(t *Term, ctx callContext, args string) error {
+	addr, err := t.addressArg(ctx, args)
+	if err != nil {
+		return err
+	}
+	fin, err := t.client.FindFinalizer(addr)
+	if err != nil {
+		return err
+	}
+	if fin == nil {
+		fmt.Fprintf(t.stdout, "No finalizer for %#x\n", addr)
+		return nil
+	}
+	fnname := "?"
+	if fin.Function != nil {
+		fnname = fin.Function.Name()
+	}
+	fmt.Fprintf(t.stdout, "Finalizer for %#x: %s at %#x", fin.Addr, fnname, fin.PC)
+	if fin.Queued {
+		fmt.Fprintf(t.stdout, " (queued for execution)")
+	}
+	fmt.Fprintln(t.stdout)
+	return nil
+}
+
+func leaks(t *Term, ctx callContext, args string) error {
+	const defaultMaxLeaks = 100
+	max := defaultMaxLeaks
+	if args != "" {
+		v := config.Split2PartsBySpace(args)
+		if v[0] != "-max" || len(v) < 2 {
+			return fmt.Errorf("wrong arguments: %q", args)
+		}
+		n, err := strconv.Atoi(v[1])
+		if err != nil || n < 0 {
+			return fmt.Errorf("wrong argument to -max: %q", v[1])
+		}
+		max = n
+	}
+	objs, truncated, err := t.client.ListUnreachableObjects(max)
+	if err != nil {
+		return err
+	}
+	if len(objs) == 0 {
+		fmt.Fprintln(t.stdout, "No unreachable objects found")
+		return nil
+	}
+	w := new(tabwriter.Writer)
+	w.Init(t.stdout, 0, 4, 2, ' ', 0)
+	var total uint64
+	for _, obj := range objs {
+		typ := obj.Type
+		if typ == "" {
+			typ = "unknown"
+		}
+		fmt.Fprintf(w, "%#x\t%d bytes\t%s\n", obj.Addr, obj.Size, typ)
+		total += obj.Size
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(t.stdout, "%d unreachable objects, %d bytes\n", len(objs), total)
+	if truncated {
+		fmt.Fprintf(t.stdout, "(stopped after %d objects, use -max to see more)\n", max)
+	}
+	return nil
+}
+
 func libraries(t *Term, ctx callContext, args string) error {
 	libs, err := t.client.ListDynamicLibraries()
 	if err != nil {
@@ -2780,15 +4465,22 @@ func printcontextLocation(t *Term, loc api.Location) {
 	}
 }
 
+// printReturnValues prints the values returned by the function that th
+// just stepped out of on a single line. Unnamed return values, which the
+// compiler calls ~r0, ~r1, etc, are printed without their name.
 func printReturnValues(t *Term, th *api.Thread) {
 	if th.ReturnValues == nil {
 		return
 	}
-	fmt.Fprintln(t.stdout, "Values returned:")
+	retVals := make([]string, 0, len(th.ReturnValues))
 	for _, v := range th.ReturnValues {
-		fmt.Fprintf(t.stdout, "\t%s: %s\n", v.Name, v.MultilineString("\t", ""))
+		if v.Name == "" || strings.HasPrefix(v.Name, "~") {
+			retVals = append(retVals, v.SinglelineString())
+		} else {
+			retVals = append(retVals, v.Name+": "+v.SinglelineString())
+		}
 	}
-	fmt.Fprintln(t.stdout)
+	fmt.Fprintf(t.stdout, ">> returned: (%s)\n", strings.Join(retVals, ", "))
 }
 
 func printcontextThread(t *Term, th *api.Thread) {
@@ -2800,6 +4492,10 @@ func printcontextThread(t *Term, th *api.Thread) {
 		return
 	}
 
+	if t.appendEvalLog(th) {
+		return
+	}
+
 	args := ""
 	var hasReturnValue bool
 	if th.BreakpointInfo != nil && th.Breakpoint.LoadArgs != nil && *th.Breakpoint.LoadArgs == ShortLoadConfig {
@@ -2952,6 +4648,10 @@ func printTracepoint(t *Term, th *api.Thread, bpname string, fn *api.Function, a
 	}
 
 	if th.Breakpoint.Tracepoint {
+		if th.Breakpoint.TraceFollowCalls <= 0 && t.isTraceEntry(th.Breakpoint) {
+			depth := t.traceCallStart(th.GoroutineID, fn.Name())
+			depthPrefix = strings.Repeat(" ", depth)
+		}
 		// Print trace only if there was a match on the function while TraceFollowCalls is on or if it's a regular trace
 		if rootindex != -1 || th.Breakpoint.TraceFollowCalls <= 0 {
 			fmt.Fprintf(t.stdout, "%s> %s %s%s(%s)\n", depthPrefix, tracePrefix, bpname, fn.Name(), args)
@@ -2963,9 +4663,18 @@ func printTracepoint(t *Term, th *api.Thread, bpname string, fn *api.Function, a
 		for _, v := range th.ReturnValues {
 			retVals = append(retVals, v.SinglelineString())
 		}
+		duration := ""
+		if th.Breakpoint.TraceFollowCalls <= 0 {
+			if depth, d, ok := t.traceCallEnd(th.GoroutineID, fn.Name()); ok {
+				depthPrefix = strings.Repeat(" ", depth)
+				if t.conf.TraceShowDuration {
+					duration = fmt.Sprintf(" in %v", d)
+				}
+			}
+		}
 		// Print trace only if there was a match on the function while TraceFollowCalls is on or if it's a regular trace
 		if rootindex != -1 || th.Breakpoint.TraceFollowCalls <= 0 {
-			fmt.Fprintf(t.stdout, "%s>> %s %s => (%s)\n", depthPrefix, tracePrefix, fn.Name(), strings.Join(retVals, ","))
+			fmt.Fprintf(t.stdout, "%s>> %s %s => (%s)%s\n", depthPrefix, tracePrefix, fn.Name(), strings.Join(retVals, ","), duration)
 		}
 	}
 	if th.Breakpoint.TraceFollowCalls > 0 {
@@ -2980,6 +4689,59 @@ func printTracepoint(t *Term, th *api.Thread, bpname string, fn *api.Function, a
 	}
 }
 
+// maxTraceCalls is the maximum number of calls without a return kept for
+// each goroutine by traceCallStart.
+const maxTraceCalls = 1000
+
+// traceCall is a call of a traced function that hasn't returned yet.
+type traceCall struct {
+	fn    string
+	start time.Time
+}
+
+// isTraceEntry returns true if bp is a tracepoint on the entry of a
+// function whose returns are also traced.
+// All tracepoints set by 'dlv trace' are, tracepoints set by the trace
+// command are if they were set on a function.
+func (t *Term) isTraceEntry(bp *api.Breakpoint) bool {
+	return t.traceNonInteractive || t.traceEntries[bp.ID]
+}
+
+// traceCallStart records that goroutine goid entered function fn and
+// returns the call depth of the new call, i.e. the number of traced calls
+// of goid that haven't returned yet.
+func (t *Term) traceCallStart(goid int64, fn string) int {
+	if t.traceCalls == nil {
+		t.traceCalls = make(map[int64][]traceCall)
+	}
+	calls := t.traceCalls[goid]
+	if len(calls) >= maxTraceCalls {
+		calls = calls[1:]
+	}
+	t.traceCalls[goid] = append(calls, traceCall{fn: fn, start: time.Now()})
+	return len(calls)
+}
+
+// traceCallEnd records that goroutine goid returned from function fn and
+// returns the call depth and the duration of the call. The innermost call
+// of fn is used, calls made after it are discarded since they must have
+// ended without their return being traced, for example because of a panic.
+func (t *Term) traceCallEnd(goid int64, fn string) (int, time.Duration, bool) {
+	calls := t.traceCalls[goid]
+	for i := len(calls) - 1; i >= 0; i-- {
+		if calls[i].fn == fn {
+			d := time.Since(calls[i].start)
+			if i == 0 {
+				delete(t.traceCalls, goid)
+			} else {
+				t.traceCalls[goid] = calls[:i]
+			}
+			return i, d, true
+		}
+	}
+	return 0, 0, false
+}
+
 type printPosFlags uint8
 
 const (
@@ -3117,7 +4879,14 @@ func (c *Commands) onCmd(t *Term, ctx callContext, argstr string) error {
 	ctx.Prefix = onPrefix
 	ctx.Breakpoint = bp
 
-	if args[1] == "-edit" {
+	if strings.HasPrefix(args[1], "{") {
+		ctx.Breakpoint.Commands, err = readCommandList(args[1], func() (string, error) {
+			return t.line.Prompt("> ")
+		})
+		if err != nil {
+			return err
+		}
+	} else if args[1] == "-edit" {
 		f, err := os.CreateTemp("", "dlv-on-cmd-")
 		if err != nil {
 			return err
@@ -3150,6 +4919,11 @@ func (c *Commands) onCmd(t *Term, ctx callContext, argstr string) error {
 		if err != nil {
 			return err
 		}
+	} else if cmdname, expr := splitCommand(args[1]); cmdname == "eval" {
+		err = onEval(t, ctx.Breakpoint, expr)
+		if err != nil {
+			return err
+		}
 	} else {
 		err = c.CallWithContext(args[1], t, ctx)
 		if err != nil {
@@ -3166,12 +4940,24 @@ func (c *Commands) parseBreakpointAttrs(t *Term, ctx callContext, r io.Reader) e
 	ctx.Breakpoint.Variables = ctx.Breakpoint.Variables[:0]
 	ctx.Breakpoint.Cond = ""
 	ctx.Breakpoint.HitCond = ""
+	ctx.Breakpoint.Commands = nil
 
 	scan := bufio.NewScanner(r)
 	lineno := 0
 	for scan.Scan() {
 		lineno++
-		err := c.CallWithContext(scan.Text(), t, ctx)
+		var err error
+		if line := strings.TrimSpace(scan.Text()); strings.HasPrefix(line, "{") {
+			ctx.Breakpoint.Commands, err = readCommandList(line, func() (string, error) {
+				if !scan.Scan() {
+					return "", errors.New("unterminated command list")
+				}
+				lineno++
+				return scan.Text(), nil
+			})
+		} else {
+			err = c.CallWithContext(scan.Text(), t, ctx)
+		}
 		if err != nil {
 			fmt.Fprintf(t.stdout, "%d: %s\n", lineno, err.Error())
 		}
@@ -3179,6 +4965,48 @@ func (c *Commands) parseBreakpointAttrs(t *Term, ctx callContext, r io.Reader) e
 	return scan.Err()
 }
 
+// readCommandList parses a command list enclosed in braces, starting with
+// s. If s does not contain the closing brace the following lines are read
+// by calling nextLine until one of them ends with it.
+func readCommandList(s string, nextLine func() (string, error)) ([]string, error) {
+	s = strings.TrimSpace(strings.TrimPrefix(s, "{"))
+	for !strings.HasSuffix(s, "}") {
+		line, err := nextLine()
+		if err != nil {
+			return nil, err
+		}
+		s += "\n" + strings.TrimSpace(line)
+	}
+	var cmds []string
+	for _, cmdstr := range strings.FieldsFunc(strings.TrimSuffix(s, "}"), func(r rune) bool { return r == ';' || r == '\n' }) {
+		if cmdstr = strings.TrimSpace(cmdstr); cmdstr != "" {
+			cmds = append(cmds, cmdstr)
+		}
+	}
+	return cmds, nil
+}
+
+// runBreakpointCommands executes the commands attached to the breakpoint
+// the current thread of state is stopped at, it returns true if one of
+// them asked to continue the execution of the program.
+func (c *Commands) runBreakpointCommands(t *Term, state *api.DebuggerState) (bool, error) {
+	if !stoppedAtBreakpoint(state) {
+		return false, nil
+	}
+	for _, cmdstr := range state.CurrentThread.Breakpoint.Commands {
+		if cmdname, args := splitCommand(cmdstr); args == "" && c.Find(cmdname, noPrefix).aliases[0] == "continue" {
+			return true, nil
+		}
+		if err := c.Call(cmdstr, t); err != nil {
+			if _, isExitRequest := err.(ExitRequestError); isExitRequest {
+				return false, err
+			}
+			fmt.Fprintf(t.stdout, "%s: %v\n", cmdstr, err)
+		}
+	}
+	return false, nil
+}
+
 func conditionCmd(t *Term, ctx callContext, argstr string) error {
 	args := config.Split2PartsBySpace(argstr)
 
@@ -3252,6 +5080,14 @@ func (c *Commands) executeFile(t *Term, name string) error {
 			continue
 		}
 
+		// a command list spanning multiple lines, see 'help on'
+		if strings.HasSuffix(line, "{") {
+			for !strings.HasSuffix(line, "}") && scanner.Scan() {
+				line += "\n" + strings.TrimSpace(scanner.Text())
+				lineno++
+			}
+		}
+
 		if err := c.Call(line, t); err != nil {
 			if _, isExitRequest := err.(ExitRequestError); isExitRequest {
 				return err
@@ -3264,6 +5100,11 @@ func (c *Commands) executeFile(t *Term, name string) error {
 }
 
 func (c *Commands) rewind(t *Term, ctx callContext, args string) error {
+	if args != "" && ctx.Prefix != revPrefix {
+		// rewind to a location, let cont set the temporary breakpoint.
+		ctx.Prefix = revPrefix
+		return c.cont(t, ctx, args)
+	}
 	c.frame = 0
 	stateChan := t.client.Rewind()
 	var state *api.DebuggerState
@@ -3329,25 +5170,21 @@ func clearCheckpoint(t *Term, ctx callContext, args string) error {
 }
 
 func display(t *Term, ctx callContext, args string) error {
-	const (
-		addOption = "-a "
-		delOption = "-d "
-	)
-	switch {
-	case args == "":
+	option, rest, _ := strings.Cut(args, " ")
+	switch option {
+	case "":
 		t.printDisplays()
 
-	case strings.HasPrefix(args, addOption):
-		args = strings.TrimSpace(args[len(addOption):])
-		fmtstr, args := parseFormatArg(args)
+	case "-a", "-add":
+		fmtstr, args := parseFormatArg(strings.TrimSpace(rest))
 		if args == "" {
 			return errors.New("not enough arguments")
 		}
 		t.addDisplay(args, fmtstr)
 		t.printDisplay(len(t.displays) - 1)
 
-	case strings.HasPrefix(args, delOption):
-		args = strings.TrimSpace(args[len(delOption):])
+	case "-d", "-del":
+		args = strings.TrimSpace(rest)
 		n, err := strconv.Atoi(args)
 		if err != nil {
 			return fmt.Errorf("%q is not a number", args)
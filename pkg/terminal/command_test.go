@@ -11,6 +11,8 @@ import (
 	"reflect"
 	"regexp"
 	"runtime"
+	"runtime/pprof"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
@@ -276,6 +278,85 @@ func TestTraceWithName(t *testing.T) {
 	})
 }
 
+func TestTraceDuration(t *testing.T) {
+	test.AllowRecording(t)
+	withTestTerminal("issue573", t, func(term *FakeTerminal) {
+		term.MustExec("config trace-show-duration true")
+		term.MustExec("trace foo")
+		out, _ := term.Exec("continue")
+		if !regexp.MustCompile(`=> \(9900\) in [0-9.]+[a-zµ]+s\n`).MatchString(out) {
+			t.Fatalf("Wrong output for tracepoint return value: %s", out)
+		}
+	})
+}
+
+func TestTraceDepth(t *testing.T) {
+	test.AllowRecording(t)
+	withTestTerminal("testtracefns", t, func(term *FakeTerminal) {
+		term.MustExec("trace main.A")
+		term.MustExec("trace main.B")
+		term.MustExec("trace main.C")
+		term.MustExec("trace main.D")
+		term.MustExec("trace online testtracefns.go:17")
+		term.MustExec("break main.first")
+		out, _ := term.Exec("continue")
+		var got []string
+		for _, line := range strings.Split(out, "\n") {
+			m := regexp.MustCompile(`^( *>>? goroutine\(1\): )(?:\[[^\]]*\] )?(main\.\w+)`).FindStringSubmatch(line)
+			if m != nil {
+				got = append(got, m[1]+m[2])
+			}
+		}
+		expected := []string{
+			"> goroutine(1): main.A",
+			" > goroutine(1): main.B",
+			"  > goroutine(1): main.D",
+			"  >> goroutine(1): main.D",
+			" >> goroutine(1): main.B",
+			"> goroutine(1): main.A", // online
+			" > goroutine(1): main.C",
+			"  > goroutine(1): main.D",
+			"  >> goroutine(1): main.D",
+			" >> goroutine(1): main.C",
+			">> goroutine(1): main.A",
+		}
+		if !slices.Equal(got, expected) {
+			t.Fatalf("wrong trace output, got:\n%s\nexpected:\n%s\n(full output: %s)", strings.Join(got, "\n"), strings.Join(expected, "\n"), out)
+		}
+	})
+}
+
+func TestNextGoroutineSwitch(t *testing.T) {
+	withTestTerminal("nextgoroutineswitch", t, func(term *FakeTerminal) {
+		term.MustExec("break nextgoroutineswitch.go:17")
+		term.MustExec("continue")
+		term.MustExec("break nextgoroutineswitch.go:5")
+		// The breakpoint is hit by the worker goroutine while main is being
+		// stepped, answering the prompt fails and the next is cancelled.
+		out, _ := term.Exec("next")
+		if !regexp.MustCompile(`Warning: stepped into goroutine \d+ \(was 1\)\n\tbreakpoint hit during next`).MatchString(out) {
+			t.Fatalf("goroutine switch warning not found in output:\n%s", out)
+		}
+	})
+}
+
+func TestStepIntoFunctionCommand(t *testing.T) {
+	withTestTerminal("stepintofunc", t, func(term *FakeTerminal) {
+		term.MustExec("break stepintofunc.go:15")
+		term.MustExec("continue")
+		out := term.MustExec("step -into h")
+		if !strings.Contains(out, "main.h()") {
+			t.Fatalf("did not step into main.h:\n%s", out)
+		}
+		// unnamed return values are printed without their name
+		if out := term.MustExec("stepout"); !strings.Contains(out, ">> returned: (2)\n") {
+			t.Fatalf("return value of main.h not found:\n%s", out)
+		}
+		term.AssertExecError("step -into", "not enough arguments")
+		term.AssertExecError("step -foo h", `unknown option "-foo"`)
+	})
+}
+
 func TestTraceOnNonFunctionEntry(t *testing.T) {
 	test.AllowRecording(t)
 	withTestTerminal("issue573", t, func(term *FakeTerminal) {
@@ -303,6 +384,69 @@ func TestExitStatus(t *testing.T) {
 	})
 }
 
+func TestGoroutinesStacks(t *testing.T) {
+	// goroutines -t must print the same stack as the stack command for every
+	// goroutine.
+	test.AllowRecording(t)
+	withTestTerminal("goroutinestackprog", t, func(term *FakeTerminal) {
+		term.MustExec("b stacktraceme")
+		term.MustExec("continue")
+
+		out := term.MustExec("goroutines -t")
+		n := 0
+		for _, g := range strings.Split(out, "Goroutine ")[1:] {
+			gid, err := strconv.Atoi(g[:strings.Index(g, " ")])
+			if err != nil {
+				t.Fatalf("could not parse goroutine line %q: %v", g, err)
+			}
+			stack := term.MustExec(fmt.Sprintf("goroutine %d stack 10", gid))
+			frames := func(out string) []string {
+				var r []string
+				for _, line := range strings.Split(out, "\n") {
+					if strings.Contains(line, " in ") {
+						r = append(r, strings.TrimSpace(line))
+					}
+				}
+				return r
+			}
+			if !reflect.DeepEqual(frames(g), frames(stack)) {
+				t.Errorf("mismatched stack for goroutine %d:\n%s\nexpected:\n%s", gid, g, stack)
+			}
+			if strings.Contains(g, " main.agoroutine") {
+				n++
+			}
+		}
+		if n < 9 {
+			t.Errorf("stacks of main.agoroutine not found (%d)", n)
+		}
+	})
+}
+
+func TestGoroutinesBatches(t *testing.T) {
+	// Listing goroutines in small batches must not lose or repeat any of
+	// them.
+	test.AllowRecording(t)
+	withTestTerminal("goroutinestackprog", t, func(term *FakeTerminal) {
+		term.MustExec("b stacktraceme")
+		term.MustExec("continue")
+
+		lines := func() []string {
+			r := strings.Split(term.MustExec("goroutines"), "\n")
+			slices.Sort(r)
+			return r
+		}
+		out := lines()
+
+		defaultFirstBatchSize := goroutinesFirstBatchSize
+		defer func() { goroutinesFirstBatchSize = defaultFirstBatchSize }()
+		goroutinesFirstBatchSize = 1
+
+		if out2 := lines(); !reflect.DeepEqual(out, out2) {
+			t.Errorf("mismatched output:\n%s\n%s", strings.Join(out, "\n"), strings.Join(out2, "\n"))
+		}
+	})
+}
+
 func TestScopePrefix(t *testing.T) {
 	if runtime.GOARCH == "ppc64le" && buildMode == "pie" {
 		t.Skip("pie mode broken on ppc64le")
@@ -451,6 +595,14 @@ func TestScopePrefix(t *testing.T) {
 		term.AssertExecError("up 100", "Invalid frame 103")
 		term.AssertExec("print n", "1\n")
 
+		// Commands executed on an explicit goroutine do not change the current frame.
+		term.AssertExecError(fmt.Sprintf("goroutine %d print n", curgid), "could not find symbol value for n")
+		term.AssertExec(fmt.Sprintf("goroutine %d frame 2 print n", curgid), "2\n")
+		term.AssertExec(fmt.Sprintf("frame 2 goroutine %d print n", curgid), "2\n")
+		term.AssertExec(fmt.Sprintf("goroutine %d up 1 print n", curgid), "3\n")
+		term.MustExec(fmt.Sprintf("goroutine %d frame 4", curgid))
+		term.AssertExec("print n", "1\n")
+
 		term.MustExec("step")
 		term.AssertExecError("print n", "could not find symbol value for n")
 		term.MustExec("frame 2")
@@ -508,6 +660,81 @@ func TestOnPrefix(t *testing.T) {
 	})
 }
 
+func TestOnEval(t *testing.T) {
+	withTestTerminal("issue1264", t, func(term *FakeTerminal) {
+		term.MustExec("break issue1264.go:8")
+		term.MustExec("on 1 eval i")
+		term.MustExec("on 1 eval equalsTwo")
+		term.AssertExec("evallog 1", "(no values collected)\n")
+		term.MustExec("break issue1264.go:7")
+		term.AssertExecError("evallog 2", "breakpoint 2 is not collecting values, use 'on 2 eval <expr>'")
+		term.MustExec("clear 2")
+		out, _ := term.Exec("continue")
+		if strings.Contains(out, "issue1264.go:8") {
+			t.Fatalf("collected values printed: %q", out)
+		}
+		term.AssertExec("evallog 1", `1: goroutine(1) i = 0, equalsTwo = false
+2: goroutine(1) i = 1, equalsTwo = false
+3: goroutine(1) i = 2, equalsTwo = true
+4: goroutine(1) i = 3, equalsTwo = false
+`)
+		term.MustExec("evallog -clear 1")
+		term.AssertExec("evallog 1", "(no values collected)\n")
+	})
+}
+
+func TestOnCommandList(t *testing.T) {
+	withTestTerminal("stepintofunc", t, func(term *FakeTerminal) {
+		term.MustExec("break main.g")
+		term.MustExec("break main.h")
+		term.MustExec("on 1 { print 1+1; continue }")
+		term.MustExec("on 2 {print 2+2}")
+		out := term.MustExec("breakpoints")
+		if !strings.Contains(out, "\t{ print 1+1; continue }\n") || !strings.Contains(out, "\t{ print 2+2 }\n") {
+			t.Fatalf("command lists not listed: %q", out)
+		}
+		out, _ = term.Exec("continue")
+		if !strings.Contains(out, "\n2\n> [Breakpoint 2] main.h() ") || !strings.HasSuffix(out, "\n4\n") {
+			t.Fatalf("wrong output: %q", out)
+		}
+
+		term.MustExec("on 2 {}")
+		out = term.MustExec("breakpoints")
+		if strings.Contains(out, "print 2+2") {
+			t.Fatalf("command list not removed: %q", out)
+		}
+
+		// multi-line command lists in a script
+		file := filepath.Join(t.TempDir(), "script")
+		err := os.WriteFile(file, []byte("on 2 {\n\tprint 3+3\n\tstack 1\n}\n"), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		term.MustExec("source " + file)
+		out = term.MustExec("breakpoints")
+		if !strings.Contains(out, "\t{ print 3+3; stack 1 }\n") {
+			t.Fatalf("command list not set by script: %q", out)
+		}
+	})
+}
+
+func TestOnCommandListNext(t *testing.T) {
+	withTestTerminal("stepintofunc", t, func(term *FakeTerminal) {
+		term.MustExec("break stepintofunc.go:13")
+		term.MustExec("break main.h")
+		term.MustExec("on 2 { print 2+2 }")
+		term.MustExec("continue")
+		out := term.MustExec("next")
+		if !strings.Contains(out, "> [Breakpoint 2] main.h() ") || !strings.HasSuffix(out, "\n4\n") {
+			t.Fatalf("command list not executed by next: %q", out)
+		}
+		out = term.MustExec("stepout")
+		if strings.Contains(out, "\n4\n") {
+			t.Fatalf("command list executed without hitting the breakpoint: %q", out)
+		}
+	})
+}
+
 func TestNoVars(t *testing.T) {
 	test.AllowRecording(t)
 	withTestTerminal("locationsUpperCase", t, func(term *FakeTerminal) {
@@ -620,6 +847,136 @@ func TestListCmd(t *testing.T) {
 	})
 }
 
+func TestLocalsVerbose(t *testing.T) {
+	withTestTerminal("loopprog", t, func(term *FakeTerminal) {
+		term.MustExec("break loopprog.go:10")
+		term.MustExec("continue")
+		term.AssertExec("locals i", "i = 1000000\n")
+		term.AssertExec("locals -v i", "i int = 1000000\n")
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+	withTestTerminal("loopprog", t, func(term *FakeTerminal) {
+		term.MustExec("break loopprog.go:10")
+		term.MustExec("continue")
+		term.MustExec("snapshot save s1")
+		term.MustExec("snapshot save s2 i + 1")
+		term.AssertExec("snapshot diff s1", "(no changes)\n")
+		term.AssertExec("snapshot list", "s1\t(args and locals)\ns2\ti + 1\n")
+		term.MustExec("continue")
+		term.AssertExec("snapshot diff s1", "i: 1000000 -> 2000000\n")
+		term.AssertExec("snapshot diff s2", "i + 1: 1000001 -> 2000001\n")
+		term.MustExec("snapshot clear s1")
+		term.AssertExecError("snapshot diff s1", `unknown snapshot "s1"`)
+	})
+}
+
+func TestDiffVariables(t *testing.T) {
+	mkstruct := func(a, b string, items ...string) api.Variable {
+		v := api.Variable{Name: "s", Type: "main.T", Kind: reflect.Struct, Children: []api.Variable{
+			{Name: "A", Type: "int", Kind: reflect.Int, Value: a},
+			{Name: "B", Type: "string", Kind: reflect.String, Value: b, Len: int64(len(b))},
+			{Name: "C", Type: "[]int", Kind: reflect.Slice, Len: int64(len(items))},
+		}}
+		for _, item := range items {
+			v.Children[2].Children = append(v.Children[2].Children, api.Variable{Type: "int", Kind: reflect.Int, Value: item})
+		}
+		return v
+	}
+	old := []api.Variable{mkstruct("1", "a", "1", "2"), {Name: "x", Type: "int", Kind: reflect.Int, Value: "3"}}
+	cur := []api.Variable{mkstruct("1", "b", "1", "3", "4"), {Name: "y", Type: "int", Kind: reflect.Int, Value: "4"}}
+	tgt := []string{
+		`s.B: "a" -> "b"`,
+		"len(s.C): 2 -> 3",
+		"s.C[1]: 2 -> 3",
+		"x: removed",
+		"y: added 4",
+	}
+	if changes := diffVariableLists(old, cur); !reflect.DeepEqual(changes, tgt) {
+		t.Errorf("wrong changes:\n%s\nexpected:\n%s", strings.Join(changes, "\n"), strings.Join(tgt, "\n"))
+	}
+}
+
+func TestVmmap(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("memory map only supported on linux")
+	}
+	withTestTerminal("continuetestprog", t, func(term *FakeTerminal) {
+		term.MustExec("break main.sayhi")
+		term.MustExec("continue")
+		out := term.MustExec("vmmap")
+		if !strings.Contains(out, "[heap]") || !strings.Contains(out, "[stacks of goroutines 1") {
+			t.Errorf("heap or goroutine stacks missing from vmmap output:\n%s", out)
+		}
+	})
+}
+
+func TestWhatobject(t *testing.T) {
+	if !goversion.VersionAfterOrEqual(runtime.Version(), 1, 22) {
+		t.Skip("malloc headers were introduced in Go 1.22")
+	}
+	withTestTerminal("heapobjects", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		for _, tc := range []struct {
+			expr string
+			tgt  []string
+		}{
+			{"s", []string{"size class 2", "State:   allocated"}},
+			{"&bs[10]", []string{"is 10 bytes into the object", "no pointers"}},
+			{"b", []string{"size class", "main.big"}},
+			{"h", []string{"large object", "main.huge"}},
+		} {
+			out := term.MustExec("whatobject " + tc.expr)
+			for _, tgt := range tc.tgt {
+				if !strings.Contains(out, tgt) {
+					t.Errorf("output of whatobject %s does not contain %q:\n%s", tc.expr, tgt, out)
+				}
+			}
+		}
+		if _, err := term.Exec("whatobject 0x10"); err == nil || !strings.Contains(err.Error(), "is not in the Go heap") {
+			t.Errorf("unexpected error for address outside of the heap: %v", err)
+		}
+	})
+}
+
+func TestRefsto(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("memory map only supported on linux")
+	}
+	withTestTerminal("heapobjects", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		out := term.MustExec("refsto &x")
+		if n := strings.Count(out, "heap object"); n != 3 {
+			t.Errorf("expected 3 references from the heap, got %d:\n%s", n, out)
+		}
+		out = term.MustExec("refsto -stacks b")
+		if strings.Contains(out, "heap object") || !strings.Contains(out, "goroutine 1 frame 0 in main.main") {
+			t.Errorf("unexpected output for refsto -stacks:\n%s", out)
+		}
+		out = term.MustExec("refsto -max 1 &x")
+		if !strings.Contains(out, "search stopped after 1 references") {
+			t.Errorf("search not truncated:\n%s", out)
+		}
+	})
+}
+
+func TestLeaks(t *testing.T) {
+	if !goversion.VersionAfterOrEqual(runtime.Version(), 1, 22) {
+		t.Skip("malloc headers were introduced in Go 1.22")
+	}
+	withTestTerminal("leakcandidates", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		out := term.MustExec("leaks -max 0")
+		if n := strings.Count(out, "main.leakedObj"); n != 9 {
+			t.Errorf("expected 9 unreachable main.leakedObj objects, got %d:\n%s", n, out)
+		}
+		if strings.Contains(out, "main.keptObj") {
+			t.Errorf("reachable object reported:\n%s", out)
+		}
+	})
+}
+
 func TestReverseContinue(t *testing.T) {
 	test.AllowRecording(t)
 	if testBackend != "rr" {
@@ -634,6 +991,38 @@ func TestReverseContinue(t *testing.T) {
 	})
 }
 
+func TestReverseContinueToLocation(t *testing.T) {
+	test.AllowRecording(t)
+	if testBackend != "rr" {
+		return
+	}
+	withTestTerminal("loopprog", t, func(term *FakeTerminal) {
+		term.MustExec("break loopprog.go:8")
+		term.MustExec("continue -count 5")
+		term.MustExec("clear 1")
+		listIsAt(t, term, "rewind loopprog.go:8 if i == 2", 8, -1, -1)
+		if out := term.MustExec("print i"); out != "2\n" {
+			t.Fatalf("wrong value of i after rewind: %q", out)
+		}
+		listIsAt(t, term, "rev continue main.main", 16, -1, -1)
+	})
+}
+
+func TestReverseWatchpoint(t *testing.T) {
+	test.AllowRecording(t)
+	if testBackend != "rr" {
+		return
+	}
+	withTestTerminal("databpeasy", t, func(term *FakeTerminal) {
+		term.MustExec("break databpeasy.go:23")
+		term.MustExec("continue")
+		listIsAt(t, term, "rev watch -w globalvar1", 18, -1, -1)
+		if out := term.MustExec("breakpoints"); strings.Contains(out, "globalvar1") {
+			t.Fatalf("temporary watchpoint not cleared: %q", out)
+		}
+	})
+}
+
 func TestCheckpoints(t *testing.T) {
 	test.AllowRecording(t)
 	if testBackend != "rr" {
@@ -661,6 +1050,38 @@ func TestNextWithCount(t *testing.T) {
 	})
 }
 
+func TestStepWithCount(t *testing.T) {
+	withTestTerminal("stepintofunc", t, func(term *FakeTerminal) {
+		term.MustExec("break stepintofunc.go:12")
+		listIsAt(t, term, "continue", 12, -1, -1)
+		listIsAt(t, term, "step 2", 5, -1, -1)
+		term.MustExec("stepout")
+		// the remaining steps are skipped when a breakpoint is hit
+		term.MustExec("break stepintofunc.go:14")
+		listIsAt(t, term, "next 5", 14, -1, -1)
+		term.AssertExecError("step 0", "Invalid step count")
+	})
+}
+
+func TestStepInstructionUntil(t *testing.T) {
+	if runtime.GOARCH != "amd64" {
+		t.Skip("test uses amd64 registers")
+	}
+	withTestTerminal("stepintofunc", t, func(term *FakeTerminal) {
+		term.MustExec("break stepintofunc.go:13")
+		term.MustExec("continue")
+		// main.f returns a + b = 3 in RAX
+		out := term.MustExec("stepi-until RAX == 3")
+		if !strings.Contains(out, "main.f()") {
+			t.Fatalf("wrong stop location:\n%s", out)
+		}
+		term.AssertExec("print RAX", "3\n")
+		term.AssertExecError("stepi-until -max 5 RAX == 12345", "condition not satisfied after 5 instructions")
+		term.AssertExecError("stepi-until RAX", "condition is not a boolean expression")
+		term.AssertExecError("stepi-until", "not enough arguments")
+	})
+}
+
 func TestRestart(t *testing.T) {
 	withTestTerminal("restartargs", t, func(term *FakeTerminal) {
 		term.MustExec("break main.printArgs")
@@ -809,6 +1230,31 @@ func TestConfig(t *testing.T) {
 		t.Fatalf("new alias found after delete")
 	}
 
+	if err := configureCmd(&term, callContext{}, "alias bpm \"break main.%1\""); err == nil {
+		t.Fatalf("no error for alias with the arguments in the wrong order")
+	}
+
+	assertNoErrorConfigureCmd(t, &term, "alias \"break main.%1\" bpm")
+	if len(term.conf.Aliases["break main.%1"]) != 1 {
+		t.Fatalf("alias template not added after configure command %v", term.conf.Aliases)
+	}
+	if term.cmds.aliasTemplates["bpm"] != "break main.%1" {
+		t.Fatalf("alias template not found %v", term.cmds.aliasTemplates)
+	}
+
+	assertNoErrorConfigureCmd(t, &term, "alias bpm")
+	if _, ok := term.cmds.aliasTemplates["bpm"]; ok {
+		t.Fatalf("alias template found after delete")
+	}
+
+	assertNoErrorConfigureCmd(t, &term, "pager off")
+	if term.conf.Pager != "off" {
+		t.Fatalf("expected Pager off, got %q", term.conf.Pager)
+	}
+	if err := configureCmd(&term, callContext{}, "pager sometimes"); err == nil {
+		t.Fatalf("expected error setting pager to an invalid value")
+	}
+
 	err = configureCmd(&term, callContext{}, "show-location-expr")
 	if err == nil {
 		t.Fatalf("no error form configureCmd(show-location-expr)")
@@ -925,6 +1371,9 @@ func TestStepOutReturn(t *testing.T) {
 		if !strings.Contains(out, "num: ") || !strings.Contains(out, "str: ") {
 			t.Fatal("could not find parameter")
 		}
+		if !strings.Contains(out, `>> returned: (str: "return 47", num: 48)`+"\n") {
+			t.Fatal("return values not printed on a single line")
+		}
 	})
 }
 
@@ -990,6 +1439,191 @@ func TestIssue1493(t *testing.T) {
 	})
 }
 
+func TestRegsChanged(t *testing.T) {
+	withTestTerminal("continuetestprog", t, func(term *FakeTerminal) {
+		term.MustExec("break main.main")
+		term.MustExec("continue")
+		out := term.MustExec("regs")
+		if strings.Contains(out, "\n*") || strings.HasPrefix(out, "*") {
+			t.Fatalf("registers marked as changed on first use: %q", out)
+		}
+		out = term.MustExec("regs")
+		if strings.Contains(out, "\n*") || strings.HasPrefix(out, "*") {
+			t.Fatalf("registers marked as changed without resuming the target: %q", out)
+		}
+		term.MustExec("step-instruction")
+		out = term.MustExec("regs")
+		t.Logf("regs: %s", out)
+		if !strings.Contains(out, "\n*") && !strings.HasPrefix(out, "*") {
+			t.Fatalf("no registers marked as changed after step-instruction: %q", out)
+		}
+	})
+}
+
+func TestThreadsAndThreadRegs(t *testing.T) {
+	withTestTerminal("continuetestprog", t, func(term *FakeTerminal) {
+		term.MustExec("break main.main")
+		term.MustExec("continue")
+		out := term.MustExec("threads")
+		t.Logf("threads: %s", out)
+		var tids []string
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			fields := strings.Fields(strings.TrimPrefix(line, "*"))
+			if len(fields) < 4 || fields[0] != "Thread" || fields[2] != "at" || !strings.HasPrefix(fields[3], "0x") {
+				t.Fatalf("unexpected threads line %q", line)
+			}
+			if strings.HasPrefix(line, "*") && !strings.Contains(line, "(goroutine ") {
+				t.Fatalf("current thread is not running a goroutine %q", line)
+			}
+			tids = append(tids, fields[1])
+		}
+		for _, tid := range tids {
+			out := term.MustExec("thread " + tid)
+			if !strings.Contains(out, "Thread "+tid+" at 0x") {
+				t.Fatalf("thread %s: unexpected output %q", tid, out)
+			}
+			term.MustExec("regs")
+		}
+	})
+}
+
+func TestSchedulerCommand(t *testing.T) {
+	withTestTerminal("goroutinestackprog", t, func(term *FakeTerminal) {
+		term.MustExec("break main.stacktraceme")
+		term.MustExec("continue")
+		out := term.MustExec("scheduler")
+		t.Logf("scheduler: %s", out)
+		for _, tgt := range []string{"GOMAXPROCS: ", "\nP ", "running", "\nM "} {
+			if !strings.Contains(out, tgt) {
+				t.Errorf("output of scheduler does not contain %q", tgt)
+			}
+		}
+	})
+}
+
+func TestPrintChanBuf(t *testing.T) {
+	withTestTerminal("chanbuf", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		out := term.MustExec("print -chanbuf ch")
+		t.Logf("print -chanbuf ch: %s", out)
+		if !strings.Contains(out, "Buffered values:\n[3]int [3,4,5]\n") {
+			t.Errorf("buffer contents not printed")
+		}
+		out = term.MustExec("print -chanbuf %#x ch")
+		if !strings.Contains(out, "Buffered values:\n[3]int [0x3,0x4,0x5]\n") {
+			t.Errorf("buffer contents not formatted")
+		}
+		out = term.MustExec("print ch")
+		if strings.Contains(out, "Buffered values:") {
+			t.Errorf("buffer contents printed without -chanbuf")
+		}
+		if _, err := term.Exec("print -chanbuf len(ch)"); err == nil {
+			t.Errorf("print -chanbuf of a non-channel expression did not return an error")
+		}
+	})
+}
+
+func TestPrintHexdump(t *testing.T) {
+	withTestTerminal("testvariables2", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		const tgt = "00000000  74 c3 a8 73 74                                    |t..st|\n"
+		for _, expr := range []string{"byteslice", "bytearray", "string(byteslice)"} {
+			out := term.MustExec("print -hexdump " + expr)
+			if out != tgt {
+				t.Errorf("print -hexdump %s: got %q expected %q", expr, out, tgt)
+			}
+		}
+		out := term.MustExec("print -hexdump longbyteslice")
+		t.Logf("print -hexdump longbyteslice: %s", out)
+		if !strings.HasPrefix(out, "00000000  76 65 72 79 20 6c 6f 6e  67 20 73 74 72 69 6e 67  |very long string|\n") || !strings.HasSuffix(out, " more bytes\n") {
+			t.Errorf("unexpected output for truncated slice")
+		}
+		if _, err := term.Exec("print -hexdump runeslice"); err == nil {
+			t.Errorf("print -hexdump of a []rune did not return an error")
+		}
+	})
+}
+
+func TestPrintProtoWire(t *testing.T) {
+	buf := []byte{
+		0x08, 0x96, 0x01, // 1: varint 150
+		0x12, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g', // 2: string
+		0x1a, 0x03, 0x08, 0x96, 0x01, // 3: message
+		0x25, 0x00, 0x00, 0x80, 0x3f, // 4: fixed32
+		0x2a, 0x02, 0xff, 0x00, // 5: bytes
+		0x33, 0x08, 0x01, 0x34, // 6: group
+		0x39, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf0, 0x3f, // 7: fixed64
+		0x40, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01, // 8: varint -1
+		0x52, 0x05, 'a', // 10: truncated
+	}
+	const tgt = `1: varint 150
+2: string "testing"
+3: message {
+	1: varint 150
+}
+4: fixed32 0x3f800000 (float 1)
+5: bytes [ff 00]
+6: group {
+	1: varint 1
+}
+7: fixed64 0x3ff0000000000000 (double 1)
+8: varint 18446744073709551615 (int64 -1)
+error at offset 50: truncated length-delimited field
+`
+	var out strings.Builder
+	printProtoWire(&out, buf)
+	if out.String() != tgt {
+		t.Errorf("got:\n%s\nexpected:\n%s", out.String(), tgt)
+	}
+}
+
+var hotspotsTestSink [][]byte
+
+//go:noinline
+func hotspotsTestAlloc() {
+	for i := 0; i < 1000; i++ {
+		hotspotsTestSink = append(hotspotsTestSink, make([]byte, 1024))
+	}
+}
+
+func TestReadPprofHotspots(t *testing.T) {
+	defer func(rate int) { runtime.MemProfileRate = rate }(runtime.MemProfileRate)
+	runtime.MemProfileRate = 1
+	hotspotsTestAlloc()
+	runtime.GC()
+	defer func() { hotspotsTestSink = nil }()
+
+	var buf bytes.Buffer
+	if err := pprof.Lookup("allocs").WriteTo(&buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	p, err := readPprofHotspots(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%s %s %d", p.sampleType, p.unit, p.total)
+	const fn = "github.com/go-delve/delve/pkg/terminal.hotspotsTestAlloc"
+	found := false
+	for _, h := range p.fns {
+		if h.fn == fn {
+			found = true
+			if h.flat < 1000*1024 || h.flat > p.total {
+				t.Errorf("wrong value for %s: %d (total %d)", fn, h.flat, p.total)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("%s not found in %v", fn, p.fns)
+	}
+	if p.unit != "bytes" {
+		t.Errorf("wrong unit %q", p.unit)
+	}
+
+	if _, err := readPprofHotspots([]byte("not a profile")); err == nil {
+		t.Errorf("no error reading an invalid profile")
+	}
+}
+
 func findStarFile(name string) string {
 	return filepath.Join(test.FindFixturesDir(), name+".star")
 }
@@ -1011,6 +1645,71 @@ func TestIssue1598(t *testing.T) {
 	})
 }
 
+func TestExpandAliasTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl, args, out string
+		err             bool
+	}{
+		{"break main.%1", "foo", "break main.foo", false},
+		{"print %2 + %1", "a b", "print b + a", false},
+		{"print %*", "a + b", "print a + b", false},
+		{"print \"%1\"", "\"a b\"", "print \"a b\"", false},
+		{"print %x %%1", "", "print %x %1", false},
+		{"break main.%1", "", "", true},
+	}
+	for _, tc := range tests {
+		out, err := expandAliasTemplate(tc.tmpl, tc.args)
+		if tc.err {
+			if err == nil {
+				t.Errorf("expandAliasTemplate(%q, %q): expected error, got %q", tc.tmpl, tc.args, out)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expandAliasTemplate(%q, %q): %v", tc.tmpl, tc.args, err)
+			continue
+		}
+		if out != tc.out {
+			t.Errorf("expandAliasTemplate(%q, %q): got %q expected %q", tc.tmpl, tc.args, out, tc.out)
+		}
+	}
+}
+
+func TestAliasTemplate(t *testing.T) {
+	withTestTerminal("continuetestprog", t, func(term *FakeTerminal) {
+		term.MustExec("config alias \"break main.%1\" bpm")
+		out := term.MustExec("bpm sayhi")
+		if !strings.Contains(out, "main.sayhi()") {
+			t.Fatalf("wrong output for bpm sayhi: %q", out)
+		}
+		if _, err := term.Exec("bpm"); err == nil {
+			t.Fatalf("alias without arguments did not fail")
+		}
+	})
+}
+
+func TestPrintFunctionCall(t *testing.T) {
+	if buildMode == "pie" && runtime.GOARCH == "ppc64le" {
+		t.Skip("Debug function call Test broken in PIE mode")
+	}
+	test.MustSupportFunctionCalls(t, testBackend)
+	withTestTerminal("issue1598", t, func(term *FakeTerminal) {
+		term.MustExec("break issue1598.go:5")
+		term.MustExec("continue")
+		if _, err := term.Exec("print x()"); err == nil {
+			t.Fatalf("function call evaluated without -call")
+		}
+		out := term.MustExec("print -call x()")
+		t.Logf("%q", out)
+		if !strings.HasPrefix(out, "\"Lorem ipsum dolor sit amet") {
+			t.Fatalf("wrong output for print -call x(): %q", out)
+		}
+		if _, err := term.Exec("frame 1 print -call x()"); err == nil {
+			t.Fatalf("function call in a frame other than the topmost one did not fail")
+		}
+	})
+}
+
 func TestExamineMemoryCmd(t *testing.T) {
 	withTestTerminal("examinememory", t, func(term *FakeTerminal) {
 		term.MustExec("break examinememory.go:19")
@@ -1199,6 +1898,287 @@ func TestContinueUntilExistingBreakpoint(t *testing.T) {
 	})
 }
 
+func TestContinueCount(t *testing.T) {
+	withTestTerminal("loopprog", t, func(term *FakeTerminal) {
+		term.MustExec("break loopprog.go:8")
+		out := term.MustExec("continue -count 5")
+		t.Logf("%q", out)
+		if n := strings.Count(out, "continuing..."); n != 4 {
+			t.Fatalf("expected 4 intermediate breakpoint hits, got %d: %q", n, out)
+		}
+		out = term.MustExec("print i")
+		if out != "4\n" {
+			t.Fatalf("wrong value of i after continue -count 5: %q", out)
+		}
+		if _, err := term.Exec("continue -count 0"); err == nil {
+			t.Fatalf("continue -count 0 did not fail")
+		}
+	})
+
+	withTestTerminal("continuetestprog", t, func(term *FakeTerminal) {
+		term.MustExec("break main.sayhi")
+		out, err := term.Exec("continue -count 3")
+		t.Logf("%q %v", out, err)
+		if err == nil || !strings.Contains(err.Error(), "has exited with status") {
+			t.Fatalf("expected process exited error, got %v", err)
+		}
+		if !strings.Contains(out, "1 of 3 breakpoint hits occurred") {
+			t.Fatalf("number of breakpoint hits not reported: %q", out)
+		}
+	})
+}
+
+func TestBreakGoroutineCreate(t *testing.T) {
+	withTestTerminal("changoroutines", t, func(term *FakeTerminal) {
+		term.MustExec("break main.main")
+		term.MustExec("continue")
+		term.MustExec("break -goroutine-create")
+		for _, line := range []int{12, 13, 14} {
+			out := term.MustExec("continue")
+			t.Logf("%q", out)
+			if !strings.Contains(out, "creating a new goroutine, frame 1:") || !strings.Contains(out, fmt.Sprintf("changoroutines.go:%d", line)) {
+				t.Fatalf("expected stop at the go statement on line %d: %q", line, out)
+			}
+			// commands operate on the frame of the go statement
+			out = term.MustExec("print blockingchan1")
+			if !strings.HasPrefix(out, "chan int") {
+				t.Fatalf("wrong frame selected, print blockingchan1 returned %q", out)
+			}
+		}
+		term.MustExec("clear 2")
+
+		locs, _, err := term.client.FindLocation(api.EvalScope{GoroutineID: -1}, "main.sendToChan", false, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fnloc := locs[0]
+		term.MustExec("break -goroutine-create main.sendToChan if name == \"x\"")
+		bp, err := term.client.GetBreakpoint(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("fn.fn == %#x && (name == \"x\")", fnloc.Function.Value); bp.Cond != want || bp.FunctionName != "runtime.newproc" {
+			t.Fatalf("wrong breakpoint %s %q, expected condition %q", bp.FunctionName, bp.Cond, want)
+		}
+		if _, err := term.Exec("break -goroutine-create main.sendToChan name"); err == nil {
+			t.Fatal("expected error for a malformed condition")
+		}
+	})
+}
+
+func TestBreakGoroutineExit(t *testing.T) {
+	withTestTerminal("goroutineexit", t, func(term *FakeTerminal) {
+		term.MustExec("break main.worker")
+		term.MustExec("continue")
+		state, err := term.client.GetState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		gid := state.SelectedGoroutine.ID
+		term.MustExec("break -goroutine-exit")
+		term.MustExec("clear 1")
+		out := term.MustExec("continue")
+		t.Logf("%q", out)
+		if !strings.Contains(out, fmt.Sprintf("Goroutine %d is exiting", gid)) {
+			t.Fatalf("expected goroutine %d to exit: %q", gid, out)
+		}
+		state, err = term.client.GetState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.SelectedGoroutine.ID != gid {
+			t.Fatalf("stopped on goroutine %d, expected %d", state.SelectedGoroutine.ID, gid)
+		}
+		if _, err := term.Exec("break -goroutine-exit 100000"); err == nil {
+			t.Fatal("expected error for a goroutine that does not exist")
+		}
+		if _, err := term.Exec("break -goroutine-exit x"); err == nil {
+			t.Fatal("expected error for an invalid goroutine id")
+		}
+	})
+}
+
+func TestBreakChan(t *testing.T) {
+	withTestTerminal("goroutineexit", t, func(term *FakeTerminal) {
+		term.MustExec("break main.main")
+		term.MustExec("continue")
+		if _, err := term.Exec("break -chan done"); err == nil {
+			t.Fatal("expected error for a nil channel")
+		}
+		term.MustExec("break goroutineexit.go:11")
+		term.MustExec("continue")
+		if _, err := term.Exec("break -chan 1 send"); err == nil {
+			t.Fatal("expected error for an expression that is not a channel")
+		}
+		term.MustExec("break -chan done")
+		seen := map[string]bool{}
+		for i := 0; i < 2; i++ {
+			out := term.MustExec("continue")
+			t.Logf("%q", out)
+			switch {
+			case strings.Contains(out, "sending to a channel, frame") && strings.Contains(out, "goroutineexit.go:6"):
+				seen["send"] = true
+			case strings.Contains(out, "receiving from a channel, frame") && strings.Contains(out, "goroutineexit.go:12"):
+				seen["recv"] = true
+			default:
+				t.Fatalf("unexpected stop: %q", out)
+			}
+		}
+		if !seen["send"] || !seen["recv"] {
+			t.Fatalf("expected to stop on both send and receive, got %v", seen)
+		}
+	})
+}
+
+func TestBreakMutexContention(t *testing.T) {
+	withTestTerminal("mutexcontention", t, func(term *FakeTerminal) {
+		term.MustExec("break -mutex-contention")
+		out := term.MustExec("continue")
+		t.Logf("%q", out)
+		if !strings.Contains(out, "waiting for a locked mutex") || !strings.Contains(out, "mutexcontention.go:24") {
+			t.Fatalf("expected stop on the contention on other: %q", out)
+		}
+	})
+
+	withTestTerminal("mutexcontention", t, func(term *FakeTerminal) {
+		term.MustExec("break main.main")
+		term.MustExec("continue")
+		if _, err := term.Exec("break -mutex-contention locked"); err == nil {
+			t.Fatal("expected error for an expression that is not a mutex")
+		}
+		term.MustExec("break -mutex-contention mu")
+		out := term.MustExec("continue")
+		t.Logf("%q", out)
+		// main blocks on other first, which does not stop the program
+		if !strings.Contains(out, "waiting for a locked mutex") || !strings.Contains(out, "mutexcontention.go:25") {
+			t.Fatalf("expected stop on the contention on mu: %q", out)
+		}
+	})
+}
+
+func TestClearAllRegex(t *testing.T) {
+	withTestTerminal("stepintofunc", t, func(term *FakeTerminal) {
+		term.MustExec("break main.g")
+		term.MustExec("break main.h")
+		term.MustExec("break fmt.Println")
+		out := term.MustExec(`clearall -r ^main\.[gh]$`)
+		if strings.Count(out, " cleared at ") != 2 || strings.Contains(out, "fmt.Println") {
+			t.Fatalf("wrong breakpoints cleared: %q", out)
+		}
+		out = term.MustExec("breakpoints")
+		if strings.Contains(out, "main.g()") || strings.Contains(out, "main.h()") || !strings.Contains(out, "fmt.Println()") {
+			t.Fatalf("wrong breakpoints left: %q", out)
+		}
+		// file names are matched too
+		term.MustExec("break stepintofunc.go:14")
+		out = term.MustExec("clearall -r stepintofunc\\.go$")
+		if strings.Count(out, " cleared at ") != 1 {
+			t.Fatalf("wrong breakpoints cleared: %q", out)
+		}
+		term.AssertExecError("clearall -r", "not enough arguments")
+	})
+}
+
+func TestBreakpointTags(t *testing.T) {
+	withTestTerminal("stepintofunc", t, func(term *FakeTerminal) {
+		term.MustExec("break main.g -tag=net")
+		term.MustExec("break h main.h -tag=net,io if true")
+		term.MustExec("break main.f -tag=io")
+		term.MustExec("break stepintofunc.go:14")
+		out := term.MustExec("breakpoints")
+		if !strings.Contains(out, "\tcond true\n\ttags net,io\n") {
+			t.Fatalf("tags not listed: %q", out)
+		}
+		disabled := func() []string {
+			var r []string
+			for _, line := range strings.Split(term.MustExec("breakpoints"), "\n") {
+				if strings.Contains(line, "(disabled)") {
+					r = append(r, strings.Fields(line)[1])
+				}
+			}
+			return r
+		}
+		term.MustExec("toggle -tag net")
+		if d := disabled(); !slices.Equal(d, []string{"1", "h"}) {
+			t.Fatalf("wrong breakpoints disabled after toggling net: %v", d)
+		}
+		// h is disabled but f is enabled, toggling io disables both
+		term.MustExec("toggle -tag io")
+		if d := disabled(); !slices.Equal(d, []string{"1", "h", "3"}) {
+			t.Fatalf("wrong breakpoints disabled after toggling io: %v", d)
+		}
+		term.MustExec("toggle -tag io")
+		if d := disabled(); !slices.Equal(d, []string{"1"}) {
+			t.Fatalf("wrong breakpoints disabled after toggling io again: %v", d)
+		}
+		out = term.MustExec("clear -tag net")
+		if strings.Count(out, " cleared at ") != 2 {
+			t.Fatalf("wrong breakpoints cleared: %q", out)
+		}
+		term.AssertExecError("toggle -tag net", `no breakpoint with tag "net"`)
+		term.AssertExecError("break main.g -tag=", `empty tag in "-tag="`)
+	})
+}
+
+func TestBreakpointsExportImport(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "bps.json")
+	withTestTerminal("stepintofunc", t, func(term *FakeTerminal) {
+		term.MustExec("break main.g -tag=net")
+		term.MustExec("break h main.h if true")
+		term.MustExec("break main.f")
+		term.MustExec("cond -hitcount 3 == 2")
+		term.MustExec("toggle 3")
+		term.MustExec("trace main.main")
+		term.AssertExec("breakpoints -export "+file, "4 breakpoints exported to "+file+"\n")
+		before := term.MustExec("breakpoints")
+		term.MustExec("clearall")
+		term.MustExec("break -import " + file)
+		after := term.MustExec("breakpoints")
+		// the imported breakpoints have different IDs
+		idRe := regexp.MustCompile(`(?m)^(Breakpoint|Tracepoint) \d+ `)
+		before, after = idRe.ReplaceAllString(before, "$1 N "), idRe.ReplaceAllString(after, "$1 N ")
+		// the addresses of the runtime breakpoints are not listed in a stable order
+		before = regexp.MustCompile(`(?m)^Breakpoint runtime-fatal-throw .*\n`).ReplaceAllString(before, "")
+		after = regexp.MustCompile(`(?m)^Breakpoint runtime-fatal-throw .*\n`).ReplaceAllString(after, "")
+		// disabled breakpoints are listed with their location expression once imported
+		before = regexp.MustCompile(`(?m)^(Breakpoint N \(disabled\) at ).*\n`).ReplaceAllString(before, "$1\n")
+		after = regexp.MustCompile(`(?m)^(Breakpoint N \(disabled\) at ).*\n`).ReplaceAllString(after, "$1\n")
+		if before != after {
+			t.Fatalf("breakpoints changed after import:\n%s\n%s", before, after)
+		}
+	})
+
+	err := os.WriteFile(file, []byte(`[{"location": "main.g"}, {"location": "main.nonexistent"}]`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withTestTerminal("stepintofunc", t, func(term *FakeTerminal) {
+		out, err := term.Exec("break -import " + file)
+		if err == nil || err.Error() != "1 of 2 breakpoints could not be imported" {
+			t.Fatalf("wrong error: %v", err)
+		}
+		if !strings.Contains(out, "Could not import breakpoint at main.nonexistent: ") || !strings.Contains(out, "Breakpoint 1 set at ") {
+			t.Fatalf("wrong output: %q", out)
+		}
+	})
+}
+
+func TestBreakpointAtReturn(t *testing.T) {
+	withTestTerminal("stepoutret", t, func(term *FakeTerminal) {
+		out := term.MustExec("break -at-return myret main.stepout if num == 48")
+		if !strings.HasPrefix(out, "Breakpoint myret set at ") {
+			t.Fatalf("wrong output: %q", out)
+		}
+		out = term.MustExec("continue")
+		if !strings.Contains(out, "[myret] main.stepout()") {
+			t.Fatalf("breakpoint not hit: %q", out)
+		}
+		term.AssertExec("print str", "\"return 47\"\n")
+		term.AssertExecError("break -at-return", "not enough arguments")
+		term.AssertExecError("break -at-return stepoutret.go:6", `"stepoutret.go:6" does not specify a single function`)
+	})
+}
+
 func TestPrintFormat(t *testing.T) {
 	withTestTerminal("testvariables2", t, func(term *FakeTerminal) {
 		term.MustExec("continue")
@@ -1574,5 +2554,106 @@ func TestDisplay(t *testing.T) {
 			}
 			term.MustExec("display -d 0")
 		}
+
+		term.MustExec("display -add i1")
+		term.MustExec("display -add nosuchvar")
+		term.MustExec("display -add %03d i2")
+		out := term.MustExec("display")
+		t.Logf("display: %q", out)
+		if out != "0: i1 = 1\n1: nosuchvar = error could not find symbol value for nosuchvar\n2: i2 = 002\n" {
+			t.Errorf("wrong output for display: %q", out)
+		}
+		term.MustExec("display -del 1")
+		out = term.MustExec("display")
+		if out != "0: i1 = 1\n2: i2 = 002\n" {
+			t.Errorf("wrong output for display after -del: %q", out)
+		}
+	})
+}
+
+func TestDisassRangeCmd(t *testing.T) {
+	withTestTerminal("testvariables2", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		locs, _, err := term.client.FindLocation(api.EvalScope{GoroutineID: -1}, "main.main", false, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn, err := term.client.DisassemblePC(api.EvalScope{GoroutineID: -1}, locs[0].PC, api.IntelFlavour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		last := fn[len(fn)-1]
+		end := last.Loc.PC + uint64(len(last.Bytes))
+
+		// The range crosses the end of main.main, the first instruction of the
+		// following function must be labeled. Functions are aligned to 32
+		// bytes so the range must extend that far past the end of main.main.
+		out := term.MustExec(fmt.Sprintf("disassemble -a %#x %#x", end-16, end+32))
+		t.Logf("%s", out)
+		var labels []string
+		for _, line := range strings.Split(out, "\n") {
+			if strings.HasSuffix(line, ":") && !strings.Contains(line, "\t") {
+				labels = append(labels, line)
+			}
+		}
+		if len(labels) != 1 {
+			t.Errorf("expected one symbol label, got %q", labels)
+		}
+	})
+}
+
+func TestLocalsCaptures(t *testing.T) {
+	if !goversion.VersionAfterOrEqual(runtime.Version(), 1, 23) {
+		t.Skip("captured variables are not marked before Go 1.23")
+	}
+	withTestTerminal("closurecontents", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		term.AssertExec("locals -captures", "(no captured variables)\n")
+		term.MustExec("break main.makeAcc.func1")
+		term.MustExec("continue")
+		out := term.MustExec("locals -captures")
+		t.Logf("locals -captures: %s", out)
+		if !strings.Contains(out, "scale = ") {
+			t.Errorf("captured variable scale not listed: %q", out)
+		}
+		if strings.Contains(term.MustExec("locals -captures ^a$"), "scale") {
+			t.Errorf("filter not applied to captured variables")
+		}
+	})
+}
+
+func TestSelectInfo(t *testing.T) {
+	withTestTerminal("selectblock", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		term.AssertExecError("selectinfo", fmt.Sprintf("goroutine %d is not blocked in a select statement", 1))
+		var gid string
+		for _, line := range strings.Split(term.MustExec("goroutines"), "\n") {
+			if strings.Contains(line, " main.selector ") {
+				gid = strings.Fields(strings.TrimPrefix(line, "*"))[1]
+				break
+			}
+		}
+		if gid == "" {
+			t.Fatal("could not find goroutine running main.selector")
+		}
+		out := term.MustExec("goroutine " + gid + " selectinfo")
+		t.Logf("selectinfo: %s", out)
+		if !regexp.MustCompile(`(?m)^recv +chan int +0x[0-9a-f]+$`).MatchString(out) || !regexp.MustCompile(`(?m)^send +chan string +0x[0-9a-f]+$`).MatchString(out) {
+			t.Errorf("unexpected selectinfo output %q", out)
+		}
+	})
+}
+
+func TestFinalizer(t *testing.T) {
+	withTestTerminal("finalizers", t, func(term *FakeTerminal) {
+		term.MustExec("continue")
+		out := term.MustExec("finalizer withfin")
+		if !regexp.MustCompile(`^Finalizer for 0x[0-9a-f]+: main\.closeResource at 0x[0-9a-f]+\n$`).MatchString(out) {
+			t.Errorf("unexpected output of finalizer withfin: %q", out)
+		}
+		out = term.MustExec("finalizer nofin")
+		if !strings.HasPrefix(out, "No finalizer for 0x") {
+			t.Errorf("unexpected output of finalizer nofin: %q", out)
+		}
 	})
 }
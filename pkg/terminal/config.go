@@ -54,6 +54,12 @@ func configureSet(t *Term, args string) error {
 		return configureSetAlias(t, rest)
 	case "debug-info-directories":
 		return configureSetDebugInfoDirectories(t, rest)
+	case "pager":
+		switch rest {
+		case config.PagerAuto, config.PagerOff, config.PagerAlways:
+		default:
+			return fmt.Errorf("invalid value for \"pager\": %q (must be one of %q, %q or %q)", rest, config.PagerAuto, config.PagerOff, config.PagerAlways)
+		}
 	}
 
 	field := config.ConfigureFindFieldByName(t.conf, cfgname, "yaml")
@@ -123,6 +129,9 @@ func configureSetAlias(t *Term, rest string) error {
 		}
 	case 2: // add alias rule
 		alias, cmd := argv[1], argv[0]
+		if isAliasTemplate(alias) {
+			return fmt.Errorf("invalid alias name %q, the command or command template must come before the alias", alias)
+		}
 		if t.conf.Aliases == nil {
 			t.conf.Aliases = make(map[string][]string)
 		}
@@ -13,12 +13,16 @@ import (
 func disasmPrint(dv api.AsmInstructions, out io.Writer, showHeader bool) {
 	bw := bufio.NewWriter(out)
 	defer bw.Flush()
-	if len(dv) > 0 && dv[0].Loc.Function != nil && showHeader {
+	header := len(dv) > 0 && dv[0].Loc.Function != nil && showHeader
+	if header {
 		fmt.Fprintf(bw, "TEXT %s(SB) %s\n", dv[0].Loc.Function.Name(), dv[0].Loc.File)
 	}
 	tw := tabwriter.NewWriter(bw, 1, 8, 1, '\t', 0)
 	defer tw.Flush()
-	for _, inst := range dv {
+	for i, inst := range dv {
+		if inst.Symbol != "" && (i > 0 || !header) {
+			fmt.Fprintf(tw, "%s:\n", inst.Symbol)
+		}
 		atbp := ""
 		if inst.Breakpoint {
 			atbp = "*"
@@ -27,6 +31,10 @@ func disasmPrint(dv api.AsmInstructions, out io.Writer, showHeader bool) {
 		if inst.AtPC {
 			atpc = "=>"
 		}
-		fmt.Fprintf(tw, "%s\t%s:%d\t%#x%s\t%x\t%s\n", atpc, filepath.Base(inst.Loc.File), inst.Loc.Line, inst.Loc.PC, atbp, inst.Bytes, inst.Text)
+		loc := "?"
+		if inst.Loc.File != "" {
+			loc = fmt.Sprintf("%s:%d", filepath.Base(inst.Loc.File), inst.Loc.Line)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%#x%s\t%x\t%s\n", atpc, loc, inst.Loc.PC, atbp, inst.Bytes, inst.Text)
 	}
 }
@@ -43,7 +43,8 @@ func (c *Commands) WriteMarkdown(w io.Writer) {
 	fmt.Fprint(w, "If `$XDG_CONFIG_HOME` is set, then configuration and command history files are located in `$XDG_CONFIG_HOME/dlv`. ")
 	fmt.Fprint(w, "Otherwise, they are located in `$HOME/.config/dlv` on Linux and `$HOME/.dlv` on other systems.\n\n")
 	fmt.Fprint(w, "The configuration file `config.yml` contains all the configurable options and their default values. ")
-	fmt.Fprint(w, "The command history is stored in `.dbg_history`.\n\n")
+	fmt.Fprint(w, "The command history is stored in `.dbg_history`, if the current directory contains a `.dlv` directory the command history is stored in `.dlv/.dbg_history` instead. ")
+	fmt.Fprint(w, "Consecutive duplicate commands are only stored once and the history can be searched with Ctrl-R.\n\n")
 
 	fmt.Fprint(w, "# Commands\n")
 
@@ -0,0 +1,89 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-delve/delve/pkg/config"
+	"github.com/go-delve/delve/service/api"
+)
+
+// evalLog is the list of values collected by a breakpoint set up with
+// 'on <bp> eval <expr>', one entry for every time the breakpoint was hit.
+type evalLog struct {
+	entries []evalLogEntry
+}
+
+type evalLogEntry struct {
+	goroutineID int64
+	vars        []api.Variable
+}
+
+// onEval implements 'on <bp> eval <expr>'. The breakpoint becomes a
+// tracepoint evaluating expr, the terminal appends the value to the eval
+// log of the breakpoint instead of printing it.
+func onEval(t *Term, bp *api.Breakpoint, expr string) error {
+	if expr == "" {
+		return errors.New("not enough arguments")
+	}
+	bp.Tracepoint = true
+	bp.Variables = append(bp.Variables, expr)
+	if t.evalLogs == nil {
+		t.evalLogs = make(map[int]*evalLog)
+	}
+	if t.evalLogs[bp.ID] == nil {
+		t.evalLogs[bp.ID] = &evalLog{}
+	}
+	return nil
+}
+
+// appendEvalLog records the values evaluated when th hit its breakpoint,
+// if the breakpoint is collecting them. Returns false if it isn't.
+func (t *Term) appendEvalLog(th *api.Thread) bool {
+	log := t.evalLogs[th.Breakpoint.ID]
+	if log == nil {
+		return false
+	}
+	var vars []api.Variable
+	if th.BreakpointInfo != nil {
+		vars = th.BreakpointInfo.Variables
+	}
+	log.entries = append(log.entries, evalLogEntry{goroutineID: th.GoroutineID, vars: vars})
+	return true
+}
+
+func evallogCmd(t *Term, ctx callContext, args string) error {
+	argv := config.Split2PartsBySpace(args)
+	clear := false
+	if argv[0] == "-clear" {
+		clear = true
+		argv = argv[1:]
+	}
+	if len(argv) == 0 || argv[0] == "" {
+		return errors.New("not enough arguments")
+	}
+	bp, err := getBreakpointByIDOrName(t, argv[0])
+	if err != nil {
+		return err
+	}
+	log := t.evalLogs[bp.ID]
+	if log == nil {
+		return fmt.Errorf("%s is not collecting values, use 'on %s eval <expr>'", formatBreakpointName(bp, false), argv[0])
+	}
+	if clear {
+		log.entries = nil
+		return nil
+	}
+	if len(log.entries) == 0 {
+		fmt.Fprintln(t.stdout, "(no values collected)")
+	}
+	for i, entry := range log.entries {
+		vals := make([]string, len(entry.vars))
+		for j := range entry.vars {
+			vals[j] = entry.vars[j].Name + " = " + entry.vars[j].SinglelineString()
+		}
+		fmt.Fprintf(t.stdout, "%d: goroutine(%d) %s\n", i+1, entry.goroutineID, strings.Join(vals, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package terminal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pprofHotspots are the functions where the samples of a pprof profile
+// were taken, sorted by decreasing self value.
+type pprofHotspots struct {
+	sampleType string
+	unit       string
+	total      int64
+	fns        []hotspot
+}
+
+type hotspot struct {
+	fn   string
+	flat int64
+}
+
+// Field numbers of the messages of profile.proto, see
+// https://github.com/google/pprof/blob/main/proto/profile.proto
+const (
+	pprofProfileSampleType        = 1
+	pprofProfileSample            = 2
+	pprofProfileLocation          = 4
+	pprofProfileFunction          = 5
+	pprofProfileStringTable       = 6
+	pprofProfileDefaultSampleType = 14
+
+	pprofValueTypeType = 1
+	pprofValueTypeUnit = 2
+
+	pprofSampleLocationID = 1
+	pprofSampleValue      = 2
+
+	pprofLocationID      = 1
+	pprofLocationAddress = 3
+	pprofLocationLine    = 4
+
+	pprofLineFunctionID = 1
+
+	pprofFunctionID   = 1
+	pprofFunctionName = 2
+)
+
+// readPprofHotspots decodes buf, a profile in the format written by
+// runtime/pprof, compressed with gzip or not, and adds up the values of
+// its samples by the innermost function of their stack. The default sample
+// type of the profile is used or, if it doesn't specify one, the last one.
+func readPprofHotspots(buf []byte) (*pprofHotspots, error) {
+	if len(buf) >= 2 && buf[0] == 0x1f && buf[1] == 0x8b {
+		r, err := gzip.NewReader(bytes.NewReader(buf))
+		if err != nil {
+			return nil, err
+		}
+		buf, err = io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+	}
+	fields, _, err := decodeProtoWire(buf, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("not a pprof profile: %v", err)
+	}
+
+	var (
+		strtab      []string
+		sampleTypes [][2]uint64
+		samples     [][]protoField
+		defaultType uint64
+		funcNames   = map[uint64]uint64{} // function ID -> name index
+		locFuncs    = map[uint64]uint64{} // location ID -> innermost function ID
+		locAddrs    = map[uint64]uint64{} // location ID -> address
+	)
+	for _, f := range fields {
+		switch f.num {
+		case pprofProfileStringTable:
+			strtab = append(strtab, string(f.data))
+		case pprofProfileDefaultSampleType:
+			defaultType = f.varint
+		case pprofProfileSample:
+			sample, err := protoMessage(f)
+			if err != nil {
+				return nil, err
+			}
+			samples = append(samples, sample)
+		case pprofProfileSampleType, pprofProfileLocation, pprofProfileFunction:
+			msg, err := protoMessage(f)
+			if err != nil {
+				return nil, err
+			}
+			var id, addr, funcID, name, unit uint64
+			for _, f2 := range msg {
+				switch {
+				case f.num == pprofProfileSampleType && f2.num == pprofValueTypeType:
+					name = f2.varint
+				case f.num == pprofProfileSampleType && f2.num == pprofValueTypeUnit:
+					unit = f2.varint
+				case f.num == pprofProfileLocation && f2.num == pprofLocationID, f.num == pprofProfileFunction && f2.num == pprofFunctionID:
+					id = f2.varint
+				case f.num == pprofProfileLocation && f2.num == pprofLocationAddress:
+					addr = f2.varint
+				case f.num == pprofProfileLocation && f2.num == pprofLocationLine && funcID == 0:
+					// The first line is the innermost function, the following
+					// ones are the functions it was inlined into.
+					line, err := protoMessage(f2)
+					if err != nil {
+						return nil, err
+					}
+					for _, f3 := range line {
+						if f3.num == pprofLineFunctionID {
+							funcID = f3.varint
+						}
+					}
+				case f.num == pprofProfileFunction && f2.num == pprofFunctionName:
+					name = f2.varint
+				}
+			}
+			switch f.num {
+			case pprofProfileSampleType:
+				sampleTypes = append(sampleTypes, [2]uint64{name, unit})
+			case pprofProfileLocation:
+				locFuncs[id], locAddrs[id] = funcID, addr
+			case pprofProfileFunction:
+				funcNames[id] = name
+			}
+		}
+	}
+
+	str := func(i uint64) string {
+		if i < uint64(len(strtab)) {
+			return strtab[i]
+		}
+		return ""
+	}
+
+	if len(sampleTypes) == 0 {
+		return nil, errors.New("not a pprof profile: no sample types")
+	}
+	valueIdx := len(sampleTypes) - 1
+	for i := range sampleTypes {
+		if defaultType != 0 && sampleTypes[i][0] == defaultType {
+			valueIdx = i
+		}
+	}
+	r := &pprofHotspots{sampleType: str(sampleTypes[valueIdx][0]), unit: str(sampleTypes[valueIdx][1])}
+
+	flat := map[string]int64{}
+	for _, sample := range samples {
+		var locs, values []uint64
+		for _, f := range sample {
+			switch f.num {
+			case pprofSampleLocationID:
+				locs, err = protoAppendVarints(locs, f)
+			case pprofSampleValue:
+				values, err = protoAppendVarints(values, f)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(locs) == 0 || valueIdx >= len(values) {
+			continue
+		}
+		fn := str(funcNames[locFuncs[locs[0]]])
+		if fn == "" {
+			fn = fmt.Sprintf("%#x", locAddrs[locs[0]])
+		}
+		flat[fn] += int64(values[valueIdx])
+		r.total += int64(values[valueIdx])
+	}
+
+	for fn, v := range flat {
+		r.fns = append(r.fns, hotspot{fn, v})
+	}
+	sort.Slice(r.fns, func(i, j int) bool {
+		if r.fns[i].flat != r.fns[j].flat {
+			return r.fns[i].flat > r.fns[j].flat
+		}
+		return r.fns[i].fn < r.fns[j].fn
+	})
+	return r, nil
+}
+
+func (p *pprofHotspots) formatValue(v int64) string {
+	if p.unit == "nanoseconds" {
+		return time.Duration(v).String()
+	}
+	return fmt.Sprintf("%d %s", v, p.unit)
+}
+
+func hotspotsCmd(t *Term, ctx callContext, args string) error {
+	argv := strings.Fields(args)
+	if len(argv) > 0 && argv[0] == "-break" {
+		if len(t.hotspots) == 0 {
+			return errors.New("no hotspots listed, use 'hotspots <profile>' first")
+		}
+		if len(argv) == 1 {
+			return errors.New("not enough arguments")
+		}
+		for _, arg := range argv[1:] {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 || n > len(t.hotspots) {
+				return fmt.Errorf("invalid hotspot number %q", arg)
+			}
+			if _, err := setBreakpoint(t, ctx, false, t.hotspots[n-1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(argv) == 0 || len(argv) > 2 {
+		return errors.New("wrong number of arguments")
+	}
+	count := 10
+	if len(argv) == 2 {
+		var err error
+		count, err = strconv.Atoi(argv[1])
+		if err != nil || count <= 0 {
+			return fmt.Errorf("invalid count %q", argv[1])
+		}
+	}
+	buf, err := os.ReadFile(argv[0])
+	if err != nil {
+		return err
+	}
+	p, err := readPprofHotspots(buf)
+	if err != nil {
+		return err
+	}
+	fns, err := t.client.ListFunctions("", 0)
+	if err != nil {
+		return err
+	}
+	inBinary := make(map[string]bool, len(fns))
+	for _, fn := range fns {
+		inBinary[fn] = true
+	}
+
+	t.hotspots = t.hotspots[:0]
+	fmt.Fprintf(t.stdout, "Total %s: %s\n", p.sampleType, p.formatValue(p.total))
+	for i, h := range p.fns {
+		if i >= count {
+			break
+		}
+		pct := 0.0
+		if p.total != 0 {
+			pct = 100 * float64(h.flat) / float64(p.total)
+		}
+		note := ""
+		if !inBinary[h.fn] {
+			note = " (not in the current binary)"
+		}
+		t.hotspots = append(t.hotspots, h.fn)
+		fmt.Fprintf(t.stdout, "%3d %12s %6.2f%% %s%s\n", i+1, p.formatValue(h.flat), pct, h.fn, note)
+	}
+	if len(t.hotspots) > 0 {
+		fmt.Fprintln(t.stdout, "Use 'hotspots -break <n>' to set a breakpoint on the nth function.")
+	}
+	return nil
+}
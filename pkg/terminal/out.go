@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"strings"
 
+	"github.com/go-delve/delve/pkg/config"
 	"github.com/go-delve/delve/pkg/terminal/colorize"
 	"github.com/mattn/go-isatty"
 )
@@ -100,6 +101,7 @@ type pagingWriter struct {
 	pager    string
 	lastnl   bool
 	cancel   func()
+	setting  string // value of the pager configuration option
 
 	lines, columns int
 }
@@ -177,7 +179,7 @@ func (w *pagingWriter) Reset() {
 // piping output to a pager.
 // The cancel function is called the first time a write to the pager errors.
 func (w *pagingWriter) PageMaybe(cancel func()) {
-	if w.mode != pagingWriterNormal {
+	if w.mode != pagingWriterNormal || w.setting == config.PagerOff {
 		return
 	}
 	dlvpager := os.Getenv("DELVE_PAGER")
@@ -205,6 +207,9 @@ func (w *pagingWriter) PageMaybe(cancel func()) {
 }
 
 func (w *pagingWriter) largeOutput() bool {
+	if w.setting == config.PagerAlways {
+		return len(w.buf) > 0
+	}
 	lines := 0
 	lineStart := 0
 	for i := range w.buf {
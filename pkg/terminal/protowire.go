@@ -0,0 +1,210 @@
+package terminal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Wire types of the protocol buffers encoding, see
+// https://protobuf.dev/programming-guides/encoding/
+const (
+	protoVarint     = 0
+	protoFixed64    = 1
+	protoBytes      = 2
+	protoStartGroup = 3
+	protoEndGroup   = 4
+	protoFixed32    = 5
+)
+
+// protoMaxDepth is the maximum nesting depth of messages decoded by
+// printProtoWire, deeper length-delimited fields are printed as bytes.
+const protoMaxDepth = 32
+
+// protoField is a field of a protocol buffers message decoded without its
+// schema.
+type protoField struct {
+	num      uint64
+	wireType int
+	varint   uint64       // value of varint, fixed32 and fixed64 fields
+	data     []byte       // contents of length-delimited fields
+	fields   []protoField // fields of groups and of length-delimited fields that are valid messages
+	isMsg    bool         // data is a valid message, decoded in fields
+}
+
+// printProtoWire decodes buf as a protocol buffers message in wire format
+// and prints its fields to w. If buf is not a valid message the fields
+// decoded up to the first error are printed, followed by the error.
+func printProtoWire(w io.Writer, buf []byte) {
+	fields, n, err := decodeProtoWire(buf, 0, 0)
+	printProtoFields(w, fields, "")
+	if err != nil {
+		fmt.Fprintf(w, "error at offset %d: %v\n", n, err)
+	}
+}
+
+func printProtoFields(w io.Writer, fields []protoField, indent string) {
+	for _, f := range fields {
+		fmt.Fprintf(w, "%s%d: ", indent, f.num)
+		switch f.wireType {
+		case protoVarint:
+			fmt.Fprintf(w, "varint %d", f.varint)
+			if int64(f.varint) < 0 {
+				fmt.Fprintf(w, " (int64 %d)", int64(f.varint))
+			}
+			fmt.Fprintln(w)
+		case protoFixed64:
+			fmt.Fprintf(w, "fixed64 0x%016x (double %g)\n", f.varint, math.Float64frombits(f.varint))
+		case protoFixed32:
+			fmt.Fprintf(w, "fixed32 0x%08x (float %g)\n", f.varint, math.Float32frombits(uint32(f.varint)))
+		case protoStartGroup:
+			fmt.Fprintln(w, "group {")
+			printProtoFields(w, f.fields, indent+"\t")
+			fmt.Fprintf(w, "%s}\n", indent)
+		case protoBytes:
+			switch {
+			case len(f.data) > 0 && protoIsString(f.data):
+				fmt.Fprintf(w, "string %s\n", strconv.Quote(string(f.data)))
+			case f.isMsg:
+				fmt.Fprintln(w, "message {")
+				printProtoFields(w, f.fields, indent+"\t")
+				fmt.Fprintf(w, "%s}\n", indent)
+			default:
+				fmt.Fprintf(w, "bytes [% x]\n", f.data)
+			}
+		}
+	}
+}
+
+// decodeProtoWire decodes the fields of a message contained in buf. If
+// group is not zero buf is the contents of the group with that field
+// number and decoding stops at its end group tag.
+// Returns the decoded fields and the number of bytes consumed or, if an
+// error is returned, the offset of the error.
+func decodeProtoWire(buf []byte, group uint64, depth int) ([]protoField, int, error) {
+	var fields []protoField
+	off := 0
+	for off < len(buf) {
+		start := off
+		tag, n := binary.Uvarint(buf[off:])
+		if n <= 0 {
+			return fields, start, errors.New("invalid tag")
+		}
+		off += n
+		f := protoField{num: tag >> 3, wireType: int(tag & 7)}
+		if f.num == 0 {
+			return fields, start, errors.New("invalid field number 0")
+		}
+		switch f.wireType {
+		case protoVarint:
+			f.varint, n = binary.Uvarint(buf[off:])
+			if n <= 0 {
+				return fields, start, errors.New("invalid varint")
+			}
+			off += n
+		case protoFixed64:
+			if len(buf)-off < 8 {
+				return fields, start, errors.New("truncated fixed64")
+			}
+			f.varint = binary.LittleEndian.Uint64(buf[off:])
+			off += 8
+		case protoFixed32:
+			if len(buf)-off < 4 {
+				return fields, start, errors.New("truncated fixed32")
+			}
+			f.varint = uint64(binary.LittleEndian.Uint32(buf[off:]))
+			off += 4
+		case protoBytes:
+			sz, n := binary.Uvarint(buf[off:])
+			if n <= 0 {
+				return fields, start, errors.New("invalid length")
+			}
+			off += n
+			if sz > uint64(len(buf)-off) {
+				return fields, start, errors.New("truncated length-delimited field")
+			}
+			f.data = buf[off : off+int(sz)]
+			off += int(sz)
+			if len(f.data) > 0 && depth < protoMaxDepth {
+				if subfields, _, err := decodeProtoWire(f.data, 0, depth+1); err == nil {
+					f.fields, f.isMsg = subfields, true
+				}
+			}
+		case protoStartGroup:
+			if depth >= protoMaxDepth {
+				return fields, start, errors.New("groups nested too deeply")
+			}
+			subfields, n, err := decodeProtoWire(buf[off:], f.num, depth+1)
+			if err != nil {
+				return fields, off + n, err
+			}
+			f.fields = subfields
+			off += n
+		case protoEndGroup:
+			if f.num != group {
+				return fields, start, fmt.Errorf("unexpected end group tag for field %d", f.num)
+			}
+			return fields, off, nil
+		default:
+			return fields, start, fmt.Errorf("invalid wire type %d", f.wireType)
+		}
+		fields = append(fields, f)
+	}
+	if group != 0 {
+		return fields, off, fmt.Errorf("missing end group tag for field %d", group)
+	}
+	return fields, off, nil
+}
+
+// protoIsString returns true if buf is valid UTF-8 made only of printable
+// characters. Length-delimited fields that satisfy this are printed as
+// strings even if they are also valid messages.
+func protoIsString(buf []byte) bool {
+	if !utf8.Valid(buf) {
+		return false
+	}
+	for _, r := range string(buf) {
+		if !strconv.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// protoMessage returns the fields of f, a length-delimited field
+// containing a message.
+func protoMessage(f protoField) ([]protoField, error) {
+	if f.wireType != protoBytes {
+		return nil, fmt.Errorf("field %d is not a message", f.num)
+	}
+	if f.isMsg || len(f.data) == 0 {
+		return f.fields, nil
+	}
+	fields, _, err := decodeProtoWire(f.data, 0, 0)
+	return fields, err
+}
+
+// protoAppendVarints appends the values of f, an element of a repeated
+// varint field, packed or not, to r.
+func protoAppendVarints(r []uint64, f protoField) ([]uint64, error) {
+	switch f.wireType {
+	case protoVarint:
+		return append(r, f.varint), nil
+	case protoBytes:
+		for data := f.data; len(data) > 0; {
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return r, errors.New("invalid packed varint")
+			}
+			r = append(r, v)
+			data = data[n:]
+		}
+		return r, nil
+	default:
+		return r, fmt.Errorf("field %d is not a varint", f.num)
+	}
+}
@@ -0,0 +1,203 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/go-delve/delve/pkg/config"
+	"github.com/go-delve/delve/service/api"
+)
+
+// varSnapshot is a copy of the values of some variables, saved by the
+// snapshot command so that they can be compared with their values at a
+// later time.
+type varSnapshot struct {
+	expr string // expression evaluated by the snapshot, empty for all arguments and local variables
+	vars []api.Variable
+}
+
+func snapshot(t *Term, ctx callContext, args string) error {
+	argv := config.Split2PartsBySpace(args)
+	switch argv[0] {
+	case "save":
+		if len(argv) < 2 {
+			return errors.New("not enough arguments")
+		}
+		argv = config.Split2PartsBySpace(argv[1])
+		snap := &varSnapshot{}
+		if len(argv) == 2 {
+			snap.expr = argv[1]
+		}
+		var err error
+		snap.vars, err = t.snapshotVars(ctx, snap.expr)
+		if err != nil {
+			return err
+		}
+		if t.snapshots == nil {
+			t.snapshots = make(map[string]*varSnapshot)
+		}
+		t.snapshots[argv[0]] = snap
+		return nil
+	case "diff":
+		if len(argv) < 2 {
+			return errors.New("not enough arguments")
+		}
+		snap := t.snapshots[argv[1]]
+		if snap == nil {
+			return fmt.Errorf("unknown snapshot %q", argv[1])
+		}
+		vars, err := t.snapshotVars(ctx, snap.expr)
+		if err != nil {
+			return err
+		}
+		changes := diffVariableLists(snap.vars, vars)
+		if len(changes) == 0 {
+			fmt.Fprintln(t.stdout, "(no changes)")
+		}
+		for _, change := range changes {
+			fmt.Fprintln(t.stdout, change)
+		}
+		return nil
+	case "list":
+		names := make([]string, 0, len(t.snapshots))
+		for name := range t.snapshots {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if expr := t.snapshots[name].expr; expr != "" {
+				fmt.Fprintf(t.stdout, "%s\t%s\n", name, expr)
+			} else {
+				fmt.Fprintf(t.stdout, "%s\t(args and locals)\n", name)
+			}
+		}
+		return nil
+	case "clear":
+		if len(argv) < 2 {
+			t.snapshots = nil
+			return nil
+		}
+		if t.snapshots[argv[1]] == nil {
+			return fmt.Errorf("unknown snapshot %q", argv[1])
+		}
+		delete(t.snapshots, argv[1])
+		return nil
+	default:
+		return fmt.Errorf("unknown argument %q to 'snapshot'", argv[0])
+	}
+}
+
+// snapshotVars returns the value of expr or, if expr is empty, the
+// arguments and local variables of the selected scope.
+func (t *Term) snapshotVars(ctx callContext, expr string) ([]api.Variable, error) {
+	cfg := t.loadConfig()
+	if expr != "" {
+		v, err := t.client.EvalVariable(ctx.Scope, expr, cfg)
+		if err != nil {
+			return nil, err
+		}
+		v.Name = expr
+		return []api.Variable{*v}, nil
+	}
+	args, err := t.client.ListFunctionArgs(ctx.Scope, cfg)
+	if err != nil {
+		return nil, err
+	}
+	locals, err := t.client.ListLocalVariables(ctx.Scope, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return append(args, locals...), nil
+}
+
+// diffVariableLists compares two lists of variables matching them by name
+// and returns a description of every value that changed.
+func diffVariableLists(old, cur []api.Variable) []string {
+	var changes []string
+	curByName := make(map[string]*api.Variable)
+	for i := range cur {
+		curByName[cur[i].Name] = &cur[i]
+	}
+	oldByName := make(map[string]bool)
+	for i := range old {
+		oldByName[old[i].Name] = true
+		if v := curByName[old[i].Name]; v != nil {
+			changes = diffVariables(changes, old[i].Name, &old[i], v)
+		} else {
+			changes = append(changes, fmt.Sprintf("%s: removed", old[i].Name))
+		}
+	}
+	for i := range cur {
+		if !oldByName[cur[i].Name] {
+			changes = append(changes, fmt.Sprintf("%s: added %s", cur[i].Name, cur[i].SinglelineString()))
+		}
+	}
+	return changes
+}
+
+// diffVariables appends to changes a description of the scalar values that
+// are different between old and cur, path is the expression used to refer
+// to them.
+func diffVariables(changes []string, path string, old, cur *api.Variable) []string {
+	if old.Type != cur.Type {
+		return append(changes, fmt.Sprintf("%s: %s -> %s", path, old.SinglelineString(), cur.SinglelineString()))
+	}
+	if old.Unreadable != "" || cur.Unreadable != "" {
+		if old.Unreadable != cur.Unreadable {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", path, old.SinglelineString(), cur.SinglelineString()))
+		}
+		return changes
+	}
+
+	switch old.Kind {
+	case reflect.Ptr, reflect.Interface:
+		if len(old.Children) == 0 || len(cur.Children) == 0 || old.Children[0].Addr != cur.Children[0].Addr {
+			if old.SinglelineString() != cur.SinglelineString() {
+				changes = append(changes, fmt.Sprintf("%s: %s -> %s", path, old.SinglelineString(), cur.SinglelineString()))
+			}
+			return changes
+		}
+		return diffVariables(changes, path, &old.Children[0], &cur.Children[0])
+	case reflect.Struct:
+		for i := range old.Children {
+			if i < len(cur.Children) {
+				changes = diffVariables(changes, path+"."+old.Children[i].Name, &old.Children[i], &cur.Children[i])
+			}
+		}
+		return changes
+	case reflect.Array, reflect.Slice:
+		if old.Len != cur.Len {
+			changes = append(changes, fmt.Sprintf("len(%s): %d -> %d", path, old.Len, cur.Len))
+		}
+		for i := range old.Children {
+			if i < len(cur.Children) {
+				changes = diffVariables(changes, path+"["+strconv.Itoa(i)+"]", &old.Children[i], &cur.Children[i])
+			}
+		}
+		return changes
+	case reflect.Map:
+		if old.Len != cur.Len {
+			changes = append(changes, fmt.Sprintf("len(%s): %d -> %d", path, old.Len, cur.Len))
+		}
+		// Children of maps alternate keys and values.
+		curByKey := make(map[string]*api.Variable)
+		for i := 0; i+1 < len(cur.Children); i += 2 {
+			curByKey[cur.Children[i].SinglelineString()] = &cur.Children[i+1]
+		}
+		for i := 0; i+1 < len(old.Children); i += 2 {
+			key := old.Children[i].SinglelineString()
+			if v := curByKey[key]; v != nil {
+				changes = diffVariables(changes, path+"["+key+"]", &old.Children[i+1], v)
+			}
+		}
+		return changes
+	default:
+		if old.Value != cur.Value || old.Len != cur.Len {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", path, old.SinglelineString(), cur.SinglelineString()))
+		}
+		return changes
+	}
+}
@@ -3,12 +3,14 @@ package terminal
 //lint:file-ignore ST1005 errors here can be capitalized
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
 	"net/rpc"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"sync"
 	"syscall"
@@ -26,6 +28,7 @@ import (
 
 const (
 	historyFile                 string = ".dbg_history"
+	workspaceConfigDir          string = ".dlv"
 	terminalHighlightEscapeCode string = "\033[%2dm"
 	terminalResetEscapeCode     string = "\033[0m"
 )
@@ -61,6 +64,14 @@ type Term struct {
 	displays []displayEntry
 	oldPid   int
 
+	// snapshots are the variable snapshots saved by the snapshot command,
+	// indexed by name.
+	snapshots map[string]*varSnapshot
+
+	// lastRegs is the register set last displayed by the regs command for
+	// each thread.
+	lastRegs map[int]api.Registers
+
 	stackTraceColors api.StackTraceColors
 
 	historyFile *os.File
@@ -73,6 +84,10 @@ type Term struct {
 	// should be resumed before quitting.
 	quitContinue bool
 
+	// readOnly is true if the connection to the headless instance is
+	// read-only.
+	readOnly bool
+
 	longCommandMu         sync.Mutex
 	longCommandCancelFlag bool
 
@@ -80,6 +95,21 @@ type Term struct {
 	quitting      bool
 
 	traceNonInteractive bool
+
+	// traceEntries is the set of IDs of the tracepoints set by the trace
+	// command on the entry of functions whose returns are also traced.
+	traceEntries map[int]bool
+	// traceCalls holds, for each goroutine, the traced function calls that
+	// haven't returned yet, used to indent the trace output by call depth
+	// and to show the duration of calls.
+	traceCalls map[int64][]traceCall
+
+	// evalLogs are the values collected by the breakpoints set up with
+	// 'on <bp> eval <expr>', indexed by breakpoint ID.
+	evalLogs map[int]*evalLog
+
+	// hotspots are the functions listed by the last hotspots command.
+	hotspots []string
 }
 
 type displayEntry struct {
@@ -132,6 +162,7 @@ func (t *Term) updateConfig() {
 	// These are always called together.
 	t.updateColorScheme()
 	t.updateTab()
+	t.stdout.pw.setting = t.conf.Pager
 }
 
 func (t *Term) updateColorScheme() {
@@ -258,6 +289,10 @@ func (t *Term) Run() (int, error) {
 	defer t.Close()
 
 	multiClient := t.client.IsMulticlient()
+	t.readOnly = t.client.IsReadOnly()
+	if t.readOnly {
+		fmt.Fprintln(t.stdout, "Connected in read-only mode, commands that change the state of the target are not available.")
+	}
 
 	// Send the debugger a halt command on SIGINT
 	ch := make(chan os.Signal, 1)
@@ -320,7 +355,7 @@ func (t *Term) Run() (int, error) {
 		return
 	})
 
-	fullHistoryFile, err := config.GetConfigFilePath(historyFile)
+	fullHistoryFile, err := historyFilePath()
 	if err != nil {
 		fmt.Printf("Unable to load history file: %v.", err)
 	}
@@ -329,7 +364,7 @@ func (t *Term) Run() (int, error) {
 	if err != nil {
 		fmt.Printf("Unable to open history file: %v. History will not be saved for this session.", err)
 	}
-	if _, err := t.line.ReadHistory(t.historyFile); err != nil {
+	if err := t.readHistory(t.historyFile); err != nil {
 		fmt.Printf("Unable to read history file %s: %v\n", fullHistoryFile, err)
 	}
 
@@ -374,6 +409,13 @@ func (t *Term) Run() (int, error) {
 			if _, ok := err.(ExitRequestError); ok {
 				return t.handleExit()
 			}
+			if isErrConnectionLost(err) {
+				fmt.Fprintf(os.Stderr, "Connection to the debugger lost: %v\n", err)
+				if multiClient {
+					fmt.Fprintln(os.Stderr, "The debugging session is still active on the headless instance, use 'dlv connect' to reconnect.")
+				}
+				return 1, nil
+			}
 			// The type information gets lost in serialization / de-serialization,
 			// so we do a string compare on the error message to see if the process
 			// has exited, or if the command actually failed.
@@ -453,6 +495,42 @@ func (t *Term) promptForInput() (string, error) {
 	return l, nil
 }
 
+// historyFilePath returns the path of the command history file. If the
+// current directory contains a workspace configuration directory (.dlv)
+// the history is stored there, so that each project has its own history,
+// otherwise it is stored in the user's configuration directory.
+func historyFilePath() (string, error) {
+	if fi, err := os.Stat(workspaceConfigDir); err == nil && fi.IsDir() {
+		return filepath.Abs(filepath.Join(workspaceConfigDir, historyFile))
+	}
+	return config.GetConfigFilePath(historyFile)
+}
+
+// readHistory loads the command history from r. Consecutive duplicate
+// entries are collapsed into one.
+func (t *Term) readHistory(r io.Reader) error {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		if l := s.Text(); l != "" {
+			t.line.AppendHistory(l)
+		}
+	}
+	return s.Err()
+}
+
+// writeHistory replaces the contents of the history file with the
+// current command history.
+func (t *Term) writeHistory() error {
+	if _, err := t.historyFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := t.historyFile.Truncate(0); err != nil {
+		return err
+	}
+	_, err := t.line.WriteHistory(t.historyFile)
+	return err
+}
+
 func yesno(line *liner.State, question, defaultAnswer string) (bool, error) {
 	for {
 		answer, err := line.Prompt(question)
@@ -474,7 +552,7 @@ func yesno(line *liner.State, question, defaultAnswer string) (bool, error) {
 
 func (t *Term) handleExit() (int, error) {
 	if t.historyFile != nil {
-		if _, err := t.line.WriteHistory(t.historyFile); err != nil {
+		if err := t.writeHistory(); err != nil {
 			fmt.Println("readline history error:", err)
 		}
 		if err := t.historyFile.Close(); err != nil {
@@ -489,6 +567,11 @@ func (t *Term) handleExit() (int, error) {
 		return 0, nil
 	}
 
+	if t.readOnly {
+		// The target belongs to the controlling client, just disconnect.
+		return 0, t.client.Disconnect(false)
+	}
+
 	s, err := t.client.GetState()
 	if err != nil {
 		if isErrProcessExited(err) {
@@ -633,3 +716,9 @@ func isErrProcessExited(err error) bool {
 	rpcError, ok := err.(rpc.ServerError)
 	return ok && strings.Contains(rpcError.Error(), "has exited with status")
 }
+
+// isErrConnectionLost returns true if err means that the connection to the
+// headless instance was closed.
+func isErrConnectionLost(err error) bool {
+	return errors.Is(err, rpc.ErrShutdown) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -2,8 +2,16 @@ package terminal
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"net/rpc"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/go-delve/delve/pkg/config"
+	"github.com/go-delve/liner"
 )
 
 func TestIsErrProcessExited(t *testing.T) {
@@ -23,3 +31,86 @@ func TestIsErrProcessExited(t *testing.T) {
 		}
 	}
 }
+
+func TestHistoryFilePath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+
+	p, err := historyFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp, _ := config.GetConfigFilePath(historyFile); p != cp || !strings.HasPrefix(p, filepath.ToSlash(dir)) {
+		t.Errorf("wrong history file path without workspace configuration directory: %s", p)
+	}
+
+	if err := os.Mkdir(workspaceConfigDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	p, err = historyFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wsdir, err := filepath.Abs(workspaceConfigDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != filepath.Join(wsdir, historyFile) {
+		t.Errorf("wrong history file path with workspace configuration directory: %s", p)
+	}
+}
+
+func TestHistoryRoundTrip(t *testing.T) {
+	f, err := os.OpenFile(filepath.Join(t.TempDir(), historyFile), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// More entries than liner keeps, with consecutive duplicates and empty
+	// lines that must not be loaded.
+	var want []string
+	for i := 0; i < liner.HistoryLimit+10; i++ {
+		cmd := fmt.Sprintf("print %d", i)
+		fmt.Fprintf(f, "%s\n%s\n\n", cmd, cmd)
+		want = append(want, cmd)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	term := &Term{line: liner.NewLiner(), historyFile: f}
+	defer term.line.Close()
+	if err := term.readHistory(f); err != nil {
+		t.Fatal(err)
+	}
+	term.line.AppendHistory("continue")
+	term.line.AppendHistory("continue")
+	want = append(want, "continue")
+	want = want[len(want)-liner.HistoryLimit:]
+	if err := term.writeHistory(); err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("wrong number of history entries: got %d, expected %d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("wrong history entry %d: got %q, expected %q", i, got[i], want[i])
+		}
+	}
+}
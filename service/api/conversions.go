@@ -24,6 +24,8 @@ func ConvertLogicalBreakpoint(lbp *proc.LogicalBreakpoint) *Breakpoint {
 		File:             lbp.File,
 		Line:             lbp.Line,
 		Name:             lbp.Name,
+		Tags:             lbp.Tags,
+		Commands:         lbp.Commands,
 		Tracepoint:       lbp.Tracepoint,
 		TraceReturn:      lbp.TraceReturn,
 		Stacktrace:       lbp.Stacktrace,
@@ -118,6 +120,7 @@ func ConvertThread(th proc.Thread, bp *Breakpoint) *Thread {
 
 	return &Thread{
 		ID:          th.ThreadID(),
+		Name:        proc.ThreadName(th),
 		PC:          pc,
 		File:        file,
 		Line:        line,
@@ -304,7 +307,7 @@ func ConvertGoroutine(tgt *proc.Target, g *proc.G) *Goroutine {
 	if g.Unreadable != nil {
 		return &Goroutine{Unreadable: g.Unreadable.Error()}
 	}
-	return &Goroutine{
+	r := &Goroutine{
 		ID:             g.ID,
 		CurrentLoc:     ConvertLocation(g.CurrentLoc),
 		UserCurrentLoc: ConvertLocation(g.UserCurrent()),
@@ -316,6 +319,10 @@ func ConvertGoroutine(tgt *proc.Target, g *proc.G) *Goroutine {
 		Labels:         g.Labels(),
 		Status:         g.Status,
 	}
+	if g.WaitReason != 0 {
+		r.WaitReasonString = proc.WaitReasonString(tgt, g.WaitReason)
+	}
+	return r
 }
 
 // ConvertGoroutines converts from []*proc.G to []*api.Goroutine.
@@ -351,6 +358,7 @@ func ConvertAsmInstruction(inst proc.AsmInstruction, text string) AsmInstruction
 		Bytes:      inst.Bytes,
 		Breakpoint: inst.Breakpoint,
 		AtPC:       inst.AtPC,
+		Symbol:     inst.Symbol,
 	}
 }
 
@@ -443,6 +451,143 @@ func ConvertImage(image *proc.Image) Image {
 	return Image{Path: image.Path, Address: image.StaticBase, LoadError: lerr}
 }
 
+// ConvertScheduler converts proc.Scheduler to api.Scheduler.
+func ConvertScheduler(sched *proc.Scheduler) *Scheduler {
+	r := &Scheduler{
+		Gomaxprocs:     sched.Gomaxprocs,
+		GlobalRunqSize: sched.GlobalRunqSize,
+		NMIdle:         sched.NMIdle,
+		NMSpinning:     sched.NMSpinning,
+		NPIdle:         sched.NPIdle,
+		Ms:             make([]SchedM, 0, len(sched.Ms)),
+		Ps:             make([]SchedP, 0, len(sched.Ps)),
+	}
+	for _, m := range sched.Ms {
+		r.Ms = append(r.Ms, SchedM{ID: m.ID, ThreadID: m.ThreadID, PID: m.PID, CurG: m.CurG, Spinning: m.Spinning})
+	}
+	for i := range sched.Ps {
+		p := &sched.Ps[i]
+		r.Ps = append(r.Ps, SchedP{ID: p.ID, Status: p.StatusString(), RunqSize: p.RunqSize, MID: p.MID})
+	}
+	return r
+}
+
+// ConvertGCInfo converts proc.GCInfo to api.GCInfo.
+func ConvertGCInfo(gci *proc.GCInfo) *GCInfo {
+	var lastGC int64
+	if !gci.LastGC.IsZero() {
+		lastGC = gci.LastGC.UnixNano()
+	}
+	return &GCInfo{
+		Phase:         gci.PhaseString(),
+		NumGC:         gci.NumGC,
+		NumForcedGC:   gci.NumForcedGC,
+		LastGC:        lastGC,
+		PauseTotalNs:  uint64(gci.PauseTotal),
+		GCCPUFraction: gci.GCCPUFraction,
+		HeapInuse:     gci.HeapInuse,
+		HeapIdle:      gci.HeapIdle,
+		HeapReleased:  gci.HeapReleased,
+		HeapLive:      gci.HeapLive,
+		HeapMarked:    gci.HeapMarked,
+		NextGC:        gci.NextGC,
+	}
+}
+
+// ConvertMemoryRegions converts a slice of proc.MemoryRegion to a slice
+// of api.MemoryRegion.
+func ConvertMemoryRegions(regions []proc.MemoryRegion) []MemoryRegion {
+	r := make([]MemoryRegion, len(regions))
+	for i, reg := range regions {
+		r[i] = MemoryRegion{
+			Addr:       reg.Addr,
+			Size:       reg.Size,
+			Read:       reg.Read,
+			Write:      reg.Write,
+			Exec:       reg.Exec,
+			Filename:   reg.Filename,
+			Offset:     reg.Offset,
+			Heap:       reg.Heap,
+			Goroutines: reg.Goroutines,
+		}
+	}
+	return r
+}
+
+// ConvertHeapObject converts a proc.HeapObject to an api.HeapObject.
+func ConvertHeapObject(obj *proc.HeapObject) *HeapObject {
+	return &HeapObject{
+		Addr:      obj.Addr,
+		Size:      obj.Size,
+		SizeClass: obj.SizeClass,
+		Noscan:    obj.Noscan,
+		Free:      obj.Free,
+		Type:      PrettyTypeName(obj.Type),
+	}
+}
+
+// ConvertReferences converts a slice of proc.Reference to a slice of
+// api.Reference.
+func ConvertReferences(refs []proc.Reference) []Reference {
+	r := make([]Reference, len(refs))
+	for i, ref := range refs {
+		r[i] = Reference{
+			Addr:        ref.Addr,
+			GoroutineID: ref.GoroutineID,
+			Frame:       ref.Frame,
+			Function:    ConvertFunction(ref.Function),
+			Variable:    ref.Variable,
+			VarOffset:   ref.VarOffset,
+		}
+		if ref.Object != nil {
+			r[i].Object = ConvertHeapObject(ref.Object)
+		}
+	}
+	return r
+}
+
+// ConvertHeapObjects converts a slice of proc.HeapObject to a slice of
+// api.HeapObject.
+func ConvertHeapObjects(objs []proc.HeapObject) []HeapObject {
+	r := make([]HeapObject, len(objs))
+	for i := range objs {
+		r[i] = *ConvertHeapObject(&objs[i])
+	}
+	return r
+}
+
+// ConvertSelectCases converts a slice of proc.SelectCase to a slice of
+// api.SelectCase.
+func ConvertSelectCases(cases []proc.SelectCase) []SelectCase {
+	r := make([]SelectCase, len(cases))
+	for i, sc := range cases {
+		r[i].Chan = sc.Chan
+		if sc.ElemType != nil {
+			r[i].ElemType = PrettyTypeName(sc.ElemType)
+		}
+		switch sc.Dir {
+		case reflect.SendDir:
+			r[i].Dir = "send"
+		case reflect.RecvDir:
+			r[i].Dir = "recv"
+		}
+	}
+	return r
+}
+
+// ConvertFinalizer converts a proc.Finalizer to an api.Finalizer.
+func ConvertFinalizer(fin *proc.Finalizer) *Finalizer {
+	if fin == nil {
+		return nil
+	}
+	return &Finalizer{
+		Addr:     fin.Addr,
+		PC:       fin.PC,
+		Function: ConvertFunction(fin.Fn),
+		Queued:   fin.Queued,
+	}
+}
+
 // ConvertDumpState converts proc.DumpState to api.DumpState.
 func ConvertDumpState(dumpState *proc.DumpState) *DumpState {
 	dumpState.Mutex.Lock()
@@ -126,6 +126,9 @@ func (v *Variable) writeTo(buf io.Writer, flags prettyFlags, indent, fmtstr stri
 			}
 		}
 	case reflect.Struct:
+		if strings.HasPrefix(v.Type, "sync/atomic.") && v.writeAtomicTo(buf, flags, indent, fmtstr) {
+			return
+		}
 		if v.Value != "" {
 			fmt.Fprintf(buf, "%s(%s)", v.typeStr(flags), v.Value)
 			flags = flags.set(prettyIncludeType, false)
@@ -240,7 +243,11 @@ func (v *Variable) writeBasicType(buf io.Writer, fmtstr string) {
 
 	case reflect.Complex64, reflect.Complex128:
 		if fmtstr == "" {
-			fmt.Fprintf(buf, "(%s + %si)", v.Children[0].Value, v.Children[1].Value)
+			imag, sign := v.Children[1].Value, "+"
+			if len(imag) > 0 && (imag[0] == '-' || imag[0] == '+') {
+				imag, sign = imag[1:], imag[:1]
+			}
+			fmt.Fprintf(buf, "(%s %s %si)", v.Children[0].Value, sign, imag)
 			return
 		}
 		real, _ := strconv.ParseFloat(v.Children[0].Value, 64)
@@ -290,6 +297,34 @@ func (v *Variable) writeArrayTo(buf io.Writer, flags prettyFlags, indent, fmtstr
 	v.writeSliceOrArrayTo(buf, flags, indent, fmtstr)
 }
 
+// writeAtomicTo writes the value held by one of the types of package
+// sync/atomic, returns false if the value could not be determined.
+func (v *Variable) writeAtomicTo(buf io.Writer, flags prettyFlags, indent, fmtstr string) bool {
+	var valv *Variable
+	if v.Value == "" {
+		for i := range v.Children {
+			if v.Children[i].Name == "v" {
+				valv = &v.Children[i]
+			}
+		}
+		if valv == nil {
+			return false
+		}
+	}
+	if flags.includeType() {
+		fmt.Fprintf(buf, "%s(", v.typeStr(flags))
+	}
+	if valv != nil {
+		valv.writeTo(buf, flags.set(prettyTop, false), indent, fmtstr)
+	} else {
+		fmt.Fprint(buf, v.Value)
+	}
+	if flags.includeType() {
+		fmt.Fprint(buf, ")")
+	}
+	return true
+}
+
 func (v *Variable) writeStructTo(buf io.Writer, flags prettyFlags, indent, fmtstr string) {
 	if int(v.Len) != len(v.Children) && len(v.Children) == 0 {
 		if strings.Contains(v.Type, "/") {
@@ -78,6 +78,12 @@ type Breakpoint struct {
 	ID int `json:"id"`
 	// User defined name of the breakpoint.
 	Name string `json:"name"`
+	// User defined tags of the breakpoint, used to operate on groups of
+	// breakpoints.
+	Tags []string `json:"tags,omitempty"`
+	// Commands is a list of terminal commands executed by the client when
+	// the breakpoint is hit, the server only stores them.
+	Commands []string `json:"commands,omitempty"`
 	// Addr is deprecated, use Addrs.
 	Addr uint64 `json:"addr"`
 	// Addrs is the list of addresses for this breakpoint.
@@ -170,6 +176,9 @@ const (
 type Thread struct {
 	// ID is a unique identifier for the thread.
 	ID int `json:"id"`
+	// Name is the name the operating system associates with the thread,
+	// if any.
+	Name string `json:"name,omitempty"`
 	// PC is the current program counter for the thread.
 	PC uint64 `json:"pc"`
 	// File is the file for the program counter.
@@ -303,6 +312,10 @@ const (
 
 	// VariableCPURegister means this variable is a CPU register.
 	VariableCPURegister
+
+	// VariableCaptured means this variable was captured by a closure and it
+	// is stored in the closure context instead of the stack frame.
+	VariableCaptured
 )
 
 // Variable describes a variable.
@@ -385,9 +398,15 @@ type Goroutine struct {
 	Status     uint64 `json:"status"`
 	WaitSince  int64  `json:"waitSince"`
 	WaitReason int64  `json:"waitReason"`
-	Unreadable string `json:"unreadable"`
+	// Description of WaitReason, as printed by the runtime in goroutine
+	// tracebacks.
+	WaitReasonString string `json:"waitReasonString,omitempty"`
+	Unreadable       string `json:"unreadable"`
 	// Goroutine's pprof labels
 	Labels map[string]string `json:"labels,omitempty"`
+	// Stacktrace of the goroutine, only returned by ListGoroutines when a
+	// stacktrace depth is requested
+	Stacktrace []Stackframe `json:"stacktrace,omitempty"`
 }
 
 const (
@@ -395,6 +414,16 @@ const (
 	GoroutineSyscall = proc.Gsyscall
 )
 
+// WaitReasonString returns the description of the wait reason of a
+// goroutine, as printed by the runtime in goroutine tracebacks.
+//
+// Deprecated: the descriptions used by this function can be out of date
+// with the version of Go used by the target, use the WaitReasonString
+// field of Goroutine instead.
+func WaitReasonString(waitReason int64) string {
+	return proc.FallbackWaitReasonString(waitReason)
+}
+
 // DebuggerCommand is a command which changes the debugger's execution state.
 type DebuggerCommand struct {
 	// Name is the command to run.
@@ -408,7 +437,8 @@ type DebuggerCommand struct {
 	// When ReturnInfoLoadConfig is not nil it will be used to load the value
 	// of any return variables.
 	ReturnInfoLoadConfig *LoadConfig
-	// Expr is the expression argument for a Call command
+	// Expr is the expression argument for a Call command and the name of
+	// the function for a StepIntoFunction command.
 	Expr string `json:"expr,omitempty"`
 
 	// UnsafeCall disables parameter escape checking for function calls.
@@ -455,6 +485,8 @@ const (
 	Step = "step"
 	// ReverseStep continues backward to the previous line of source code, entering function calls.
 	ReverseStep = "reverseStep"
+	// StepIntoFunction continues until the function named by Expr, called from the current line, is entered.
+	StepIntoFunction = "stepIntoFunction"
 	// StepOut continues to the return address of the current function
 	StepOut = "stepOut"
 	// ReverseStepOut continues backward to the caller of the current function.
@@ -482,6 +514,9 @@ const (
 	Halt = "halt"
 	// Call resumes process execution injecting a function call.
 	Call = "call"
+	// StepBack restores the state the target process had the last time it
+	// was resumed (target must have been launched with state recording).
+	StepBack = "stepBack"
 )
 
 // AssemblyFlavour describes the output
@@ -511,6 +546,9 @@ type AsmInstruction struct {
 	Breakpoint bool
 	// In AtPC is true this is the instruction the current thread is stopped at
 	AtPC bool
+	// Symbol is the name of the function or symbol starting at this
+	// instruction, if any
+	Symbol string `json:",omitempty"`
 }
 
 // AsmInstructions is a slice of single instructions.
@@ -540,6 +578,15 @@ type SetAPIVersionIn struct {
 type SetAPIVersionOut struct {
 }
 
+// IsReadOnlyIn is the argument for IsReadOnly.
+type IsReadOnlyIn struct {
+}
+
+// IsReadOnlyOut is the result of IsReadOnly.
+type IsReadOnlyOut struct {
+	ReadOnly bool
+}
+
 // Register holds information on a CPU register.
 type Register struct {
 	Name        string
@@ -677,3 +724,108 @@ type Target struct {
 	CmdLine       string
 	CurrentThread *Thread
 }
+
+// Scheduler describes the state of the Go runtime scheduler.
+type Scheduler struct {
+	Gomaxprocs     int64
+	GlobalRunqSize int64 // number of goroutines in the global run queue
+	NMIdle         int64 // number of idle Ms waiting for work
+	NMSpinning     int64 // number of spinning Ms
+	NPIdle         int64 // number of idle Ps
+	Ms             []SchedM
+	Ps             []SchedP
+}
+
+// SchedM describes a M (OS thread) of the Go runtime scheduler.
+type SchedM struct {
+	ID       int64
+	ThreadID int   // OS thread ID
+	PID      int32 // ID of the P bound to this M, or -1
+	CurG     int64 // ID of the goroutine running on this M, or 0
+	Spinning bool
+}
+
+// SchedP describes a P (processor) of the Go runtime scheduler.
+type SchedP struct {
+	ID       int32
+	Status   string
+	RunqSize int   // number of goroutines in the local run queue
+	MID      int64 // ID of the M this P is bound to, or -1
+}
+
+// GCInfo describes the state of the garbage collector and heap statistics
+// of the target process, see runtime.MemStats for the meaning of fields
+// with the same name.
+type GCInfo struct {
+	Phase         string
+	NumGC         uint64
+	NumForcedGC   uint64
+	LastGC        int64 // time of the last GC in nanoseconds since the epoch, 0 if no GC cycle completed
+	PauseTotalNs  uint64
+	GCCPUFraction float64
+
+	HeapInuse    uint64
+	HeapIdle     uint64
+	HeapReleased uint64
+	HeapLive     uint64 // bytes marked live by the last GC plus bytes allocated since
+	HeapMarked   uint64 // bytes marked live by the last GC
+	NextGC       uint64
+}
+
+// MemoryRegion is a mapped region of the address space of the target
+// process.
+type MemoryRegion struct {
+	Addr     uint64
+	Size     uint64
+	Read     bool
+	Write    bool
+	Exec     bool
+	Filename string // file mapped to the region, if any
+	Offset   uint64 // offset of the region in Filename
+
+	Heap       bool    // the region contains at least one Go heap arena
+	Goroutines []int64 // goroutines with a stack inside the region
+}
+
+// HeapObject describes the object of the Go heap containing an address.
+type HeapObject struct {
+	Addr      uint64 // address of the object, after its malloc header if it has one
+	Size      uint64 // size of the object, excluding its malloc header
+	SizeClass int64  // size class of the span containing the object, 0 for large objects
+	Noscan    bool   // the object does not contain pointers
+	Free      bool   // the object is not allocated
+	Type      string // type of the object, empty if it could not be determined
+}
+
+// Reference is a word of memory of the target process containing a
+// searched address.
+type Reference struct {
+	Addr uint64 // address of the word containing the reference
+
+	// Fields set for references found on the stack of a goroutine.
+	GoroutineID int64
+	Frame       int       // index of the frame containing the reference, -1 if it could not be determined
+	Function    *Function // function of the frame containing the reference
+	Variable    string    // local variable containing the reference, empty if it could not be determined
+	VarOffset   uint64    // offset of the reference from the start of Variable
+
+	// Object is the heap object containing the reference, nil for
+	// references found on the stack of a goroutine.
+	Object *HeapObject
+}
+
+// SelectCase describes one of the cases of the select statement a
+// goroutine is blocked on.
+type SelectCase struct {
+	Chan     uint64 // address of the runtime.hchan struct of the channel
+	ElemType string // element type of the channel, empty if it could not be determined
+	Dir      string // "send", "recv" or empty if the direction could not be determined
+}
+
+// Finalizer describes the finalizer associated with a heap object.
+type Finalizer struct {
+	Addr     uint64    // address the finalizer was set on
+	PC       uint64    // entry point of the finalizer function
+	Function *Function // finalizer function, nil if it could not be determined
+	Queued   bool      // the object is unreachable and the finalizer is queued to be executed
+}
@@ -0,0 +1,82 @@
+// Package authtoken implements the handshake used by headless instances of
+// Delve to authenticate new client connections.
+//
+// When a headless server is started with an authentication token, clients
+// must send the token, followed by a newline character, as the first thing
+// after connecting. The connection is closed by the server if the token is
+// missing or wrong, otherwise the connection proceeds normally using either
+// the JSON-RPC or the DAP protocol.
+package authtoken
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// MaxLen is the maximum length of an authentication token.
+const MaxLen = 1024
+
+// Timeout is the time a client has to complete the handshake after
+// connecting.
+var Timeout = 10 * time.Second
+
+// ErrBadToken is returned by Check when the client sent the wrong token.
+var ErrBadToken = errors.New("wrong authentication token")
+
+// ReadFile reads an authentication token from the file at path. Leading
+// and trailing whitespace is removed.
+func ReadFile(path string) (string, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(buf))
+	if token == "" {
+		return "", fmt.Errorf("authentication token file %s is empty", path)
+	}
+	if len(token) > MaxLen || strings.ContainsAny(token, "\r\n") {
+		return "", fmt.Errorf("authentication token in %s must be a single line shorter than %d characters", path, MaxLen)
+	}
+	return token, nil
+}
+
+// Send sends token to the server on conn.
+func Send(conn io.Writer, token string) error {
+	_, err := io.WriteString(conn, token+"\n")
+	return err
+}
+
+// Check reads the handshake from conn and verifies that the client sent
+// token. Reading stops at the first newline character so that no data
+// belonging to the protocol spoken after the handshake is consumed.
+func Check(conn net.Conn, token string) error {
+	if err := conn.SetReadDeadline(time.Now().Add(Timeout)); err != nil {
+		return err
+	}
+	defer conn.SetReadDeadline(time.Time{})
+
+	buf := make([]byte, 0, len(token)+1)
+	b := make([]byte, 1)
+	for {
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return fmt.Errorf("reading authentication token: %w", err)
+		}
+		if b[0] == '\n' {
+			break
+		}
+		if len(buf) >= MaxLen {
+			return ErrBadToken
+		}
+		buf = append(buf, b[0])
+	}
+	if subtle.ConstantTimeCompare(buf, []byte(token)) != 1 {
+		return ErrBadToken
+	}
+	return nil
+}
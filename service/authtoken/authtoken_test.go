@@ -0,0 +1,68 @@
+package authtoken
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		sent string
+		err  error
+	}{
+		{"secret\n", nil},
+		{"wrong\n", ErrBadToken},
+		{"secretsecret\n", ErrBadToken},
+		{"\n", ErrBadToken},
+	}
+	for _, tc := range tests {
+		server, client := net.Pipe()
+		go func() {
+			io.WriteString(client, tc.sent+"rest")
+		}()
+		err := Check(server, "secret")
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%q: got error %v, expected %v", tc.sent, err, tc.err)
+		}
+		if err == nil {
+			// the data following the handshake must not be consumed
+			rest := make([]byte, 4)
+			io.ReadFull(server, rest)
+			if string(rest) != "rest" {
+				t.Errorf("%q: data after the handshake was consumed, got %q", tc.sent, rest)
+			}
+		}
+		server.Close()
+		client.Close()
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	defer func(old time.Duration) { Timeout = old }(Timeout)
+	Timeout = 100 * time.Millisecond
+	server, client := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	if err := Check(server, "secret"); err == nil {
+		t.Fatal("expected error for a client that does not send a token")
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+	os.WriteFile(path, []byte("  secret\n"), 0600)
+	token, err := ReadFile(path)
+	if err != nil || token != "secret" {
+		t.Fatalf("got %q %v", token, err)
+	}
+	os.WriteFile(path, []byte("\n"), 0600)
+	if _, err := ReadFile(path); err == nil {
+		t.Fatal("expected error for empty token file")
+	}
+}
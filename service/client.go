@@ -45,6 +45,8 @@ type Client interface {
 	Step() (*api.DebuggerState, error)
 	// ReverseStep continues backward to the previous line of source code, entering function calls.
 	ReverseStep() (*api.DebuggerState, error)
+	// StepIntoFunction continues until fnName, called from the current source line, is entered.
+	StepIntoFunction(fnName string) (*api.DebuggerState, error)
 	// StepOut continues to the return address of the current function.
 	StepOut() (*api.DebuggerState, error)
 	// ReverseStepOut continues backward to the caller of the current function.
@@ -56,6 +58,9 @@ type Client interface {
 	StepInstruction(skipCalls bool) (*api.DebuggerState, error)
 	// ReverseStepInstruction will reverse step a single cpu instruction.
 	ReverseStepInstruction(skipCalls bool) (*api.DebuggerState, error)
+	// StepBack restores the state the target process had the last time it
+	// was resumed.
+	StepBack() (*api.DebuggerState, error)
 	// SwitchThread switches the current thread context.
 	SwitchThread(threadID int) (*api.DebuggerState, error)
 	// SwitchGoroutine switches the current goroutine (and the current thread as well)
@@ -98,6 +103,8 @@ type Client interface {
 	ListPackageVariables(filter string, cfg api.LoadConfig) ([]api.Variable, error)
 	// EvalVariable returns a variable in the context of the current thread.
 	EvalVariable(scope api.EvalScope, symbol string, cfg api.LoadConfig) (*api.Variable, error)
+	// ChanBuffer returns the values queued in the buffer of a channel.
+	ChanBuffer(scope api.EvalScope, expr string, cfg api.LoadConfig) (*api.Variable, error)
 
 	// SetVariable sets the value of a variable
 	SetVariable(scope api.EvalScope, symbol, value string) error
@@ -123,6 +130,9 @@ type Client interface {
 	ListGoroutines(start, count int) ([]*api.Goroutine, int, error)
 	// ListGoroutinesWithFilter lists goroutines matching the filters
 	ListGoroutinesWithFilter(start, count int, filters []api.ListGoroutinesFilter, group *api.GoroutineGroupingOptions, scope *api.EvalScope) ([]*api.Goroutine, []api.GoroutineGroup, int, bool, error)
+	// ListGoroutinesWithStacktrace is like ListGoroutinesWithFilter but also
+	// returns the stacktrace of each goroutine, up to depth frames.
+	ListGoroutinesWithStacktrace(start, count int, filters []api.ListGoroutinesFilter, group *api.GoroutineGroupingOptions, scope *api.EvalScope, depth int) ([]*api.Goroutine, []api.GoroutineGroup, int, bool, error)
 
 	// Stacktrace returns stacktrace
 	Stacktrace(goroutineID int64, depth int, opts api.StacktraceOptions, cfg *api.LoadConfig) ([]api.Stackframe, error)
@@ -168,10 +178,47 @@ type Client interface {
 
 	// IsMulticlient returns true if the headless instance is multiclient.
 	IsMulticlient() bool
+	// IsReadOnly returns true if the connection to the headless instance is
+	// read-only.
+	IsReadOnly() bool
+	// WaitForStateChange waits until the state of the target changes. Count
+	// should be the value returned by the previous call, or zero to return
+	// the current state immediately.
+	WaitForStateChange(count uint64) (state *api.DebuggerState, newCount uint64, err error)
 
 	// ListDynamicLibraries returns a list of loaded dynamic libraries.
 	ListDynamicLibraries() ([]api.Image, error)
 
+	// GetScheduler returns the state of the Go runtime scheduler.
+	GetScheduler() (*api.Scheduler, error)
+
+	// GetGCInfo returns the state of the garbage collector and heap statistics.
+	GetGCInfo() (*api.GCInfo, error)
+
+	// ListMemoryRegions returns the memory map of the target process.
+	ListMemoryRegions() ([]api.MemoryRegion, error)
+
+	// FindHeapObject returns the object of the Go heap containing addr.
+	FindHeapObject(addr uint64) (*api.HeapObject, error)
+
+	// FindReferences searches goroutine stacks and, unless stacksOnly is
+	// set, the heap for words equal to addr. At most max references are
+	// returned, the second return value is true if there were more.
+	FindReferences(addr uint64, stacksOnly bool, max int) ([]api.Reference, bool, error)
+
+	// ListUnreachableObjects returns the allocated objects of the Go heap
+	// that can not be reached from any root. At most max objects are
+	// returned, the second return value is true if there were more.
+	ListUnreachableObjects(max int) ([]api.HeapObject, bool, error)
+
+	// ListSelectCases returns the cases of the select statement the
+	// specified goroutine is blocked on.
+	ListSelectCases(goroutineID int64) ([]api.SelectCase, error)
+
+	// FindFinalizer returns the finalizer associated with the heap object
+	// containing addr, or nil if the object does not have one.
+	FindFinalizer(addr uint64) (*api.Finalizer, error)
+
 	// ExamineMemory returns the raw memory stored at the given address.
 	// The amount of data to be read is specified by length which must be less than or equal to 1000.
 	// This function will return an error if it reads less than `length` bytes.
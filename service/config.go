@@ -2,6 +2,7 @@ package service
 
 import (
 	"net"
+	"time"
 
 	"github.com/go-delve/delve/service/debugger"
 )
@@ -27,6 +28,17 @@ type Config struct {
 	// Note that the server API is not reentrant and clients will have to coordinate.
 	AcceptMulti bool
 
+	// AcceptReadOnly configures the server to accept read-only connections
+	// while another client is connected, requires AcceptMulti. Read-only
+	// clients can only call methods that do not change the state of the
+	// debugger or of the target process.
+	AcceptReadOnly bool
+
+	// IdleTimeout, if AcceptMulti is set, is the time after which the server
+	// will close DisconnectChan if no client is connected. Zero means that
+	// the server will wait for new clients indefinitely.
+	IdleTimeout time.Duration
+
 	// APIVersion selects which version of the API to serve (default: 1).
 	APIVersion int
 
@@ -34,6 +46,10 @@ type Config struct {
 	// connections come from the same user that started the headless server
 	CheckLocalConnUser bool
 
+	// AuthToken, if not empty, is the token that clients must send when
+	// connecting to the server, see package authtoken.
+	AuthToken string
+
 	// DisconnectChan will be closed by the server when the client disconnects
 	DisconnectChan chan<- struct{}
 }
@@ -39,7 +39,7 @@ func configureSet(sargs *launchAttachArgs, args string) (bool, string, error) {
 	}
 
 	if cfgname == "showPprofLabels" {
-		err := configureSetShowPprofLabels(sargs, rest)
+		err := configureSetStringList(&sargs.ShowPprofLabels, cfgname, "label", rest)
 		if err != nil {
 			return false, "", err
 		}
@@ -47,6 +47,15 @@ func configureSet(sargs *launchAttachArgs, args string) (bool, string, error) {
 		return true, config.ConfigureListByName(sargs, cfgname, "cfgName"), nil
 	}
 
+	if cfgname == "globalVariablesPackages" {
+		err := configureSetStringList(&sargs.GlobalVariablesPackages, cfgname, "package", rest)
+		if err != nil {
+			return false, "", err
+		}
+		// Print the updated packages
+		return true, config.ConfigureListByName(sargs, cfgname, "cfgName"), nil
+	}
+
 	err := config.ConfigureSetSimple(rest, cfgname, field)
 	if err != nil {
 		return false, "", err
@@ -96,9 +105,12 @@ func configureSetSubstitutePath(args *launchAttachArgs, rest string) error {
 	return nil
 }
 
-func configureSetShowPprofLabels(args *launchAttachArgs, rest string) error {
+// configureSetStringList adds an element to or removes an element from
+// the list of strings of the configuration parameter cfgname.
+// The elements of the list are described as what in error messages.
+func configureSetStringList(list *[]string, cfgname, what, rest string) error {
 	if strings.TrimSpace(rest) == "-clear" {
-		args.ShowPprofLabels = args.ShowPprofLabels[:0]
+		*list = (*list)[:0]
 		return nil
 	}
 	delete := false
@@ -113,19 +125,18 @@ func configureSetShowPprofLabels(args *launchAttachArgs, rest string) error {
 		return nil
 	case 1:
 		if delete {
-			for i := range args.ShowPprofLabels {
-				if args.ShowPprofLabels[i] == argv[0] {
-					copy(args.ShowPprofLabels[i:], args.ShowPprofLabels[i+1:])
-					args.ShowPprofLabels = args.ShowPprofLabels[:len(args.ShowPprofLabels)-1]
+			for i := range *list {
+				if (*list)[i] == argv[0] {
+					*list = append((*list)[:i], (*list)[i+1:]...)
 					return nil
 				}
 			}
-			return fmt.Errorf("could not find label %q", argv[0])
+			return fmt.Errorf("could not find %s %q", what, argv[0])
 		} else {
-			args.ShowPprofLabels = append(args.ShowPprofLabels, argv[0])
+			*list = append(*list, argv[0])
 		}
 	default:
-		return errors.New("too many arguments to \"config showPprofLabels\"")
+		return fmt.Errorf("too many arguments to \"config %s\"", cfgname)
 	}
 	return nil
 }
@@ -118,6 +118,10 @@ func (c *Client) ExpectInitializeResponseAndCapabilities(t *testing.T) *dap.Init
 		SupportsSteppingGranularity:      true,
 		SupportsLogPoints:                true,
 		SupportsDisassembleRequest:       true,
+		SupportsRestartRequest:           true,
+		ExceptionBreakpointFilters: []dap.ExceptionBreakpointsFilter{
+			{Filter: "panic", Label: "Panics", Description: "Stop when a panic is raised, even if it is recovered later"},
+		},
 	}
 	if !reflect.DeepEqual(initResp.Body, wantCapabilities) {
 		t.Errorf("capabilities in initializeResponse: got %+v, want %v", pretty(initResp.Body), pretty(wantCapabilities))
@@ -337,6 +341,14 @@ func (c *Client) SetExceptionBreakpointsRequest() {
 	c.send(request)
 }
 
+// SetExceptionBreakpointsRequestWithFilters sends a 'setExceptionBreakpoints'
+// request enabling the given filters.
+func (c *Client) SetExceptionBreakpointsRequestWithFilters(filters []string) {
+	request := &dap.SetExceptionBreakpointsRequest{Request: *c.newRequest("setExceptionBreakpoints")}
+	request.Arguments.Filters = filters
+	c.send(request)
+}
+
 // ConfigurationDoneRequest sends a 'configurationDone' request.
 func (c *Client) ConfigurationDoneRequest() {
 	request := &dap.ConfigurationDoneRequest{Request: *c.newRequest("configurationDone")}
@@ -441,6 +453,16 @@ func (c *Client) IndexedVariablesRequest(variablesReference, start, count int) {
 	c.send(request)
 }
 
+// PagedVariablesRequest sends a 'variables' request for a range of
+// children, without a filter.
+func (c *Client) PagedVariablesRequest(variablesReference, start, count int) {
+	request := &dap.VariablesRequest{Request: *c.newRequest("variables")}
+	request.Arguments.VariablesReference = variablesReference
+	request.Arguments.Start = start
+	request.Arguments.Count = count
+	c.send(request)
+}
+
 // NamedVariablesRequest sends a 'variables' request.
 func (c *Client) NamedVariablesRequest(variablesReference int) {
 	request := &dap.VariablesRequest{Request: *c.newRequest("variables")}
@@ -14,6 +14,7 @@ const (
 	FailedToLaunch             = 3000
 	FailedToAttach             = 3001
 	FailedToInitialize         = 3002
+	FailedToRestart            = 3003
 	UnableToSetBreakpoints     = 2002
 	UnableToDisplayThreads     = 2003
 	UnableToProduceStackTrace  = 2004
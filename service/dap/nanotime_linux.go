@@ -0,0 +1,14 @@
+package dap
+
+import "golang.org/x/sys/unix"
+
+// targetNanotime returns the current value of the clock read by the
+// runtime.nanotime function of a Go program running on this machine, which
+// on linux is CLOCK_MONOTONIC.
+func targetNanotime() (int64, bool) {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+		return 0, false
+	}
+	return ts.Nano(), true
+}
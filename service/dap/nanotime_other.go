@@ -0,0 +1,10 @@
+//go:build !linux
+
+package dap
+
+// targetNanotime returns the current value of the clock read by the
+// runtime.nanotime function of a Go program running on this machine, it is
+// only implemented on linux.
+func targetNanotime() (int64, bool) {
+	return 0, false
+}
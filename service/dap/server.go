@@ -40,6 +40,7 @@ import (
 
 	"github.com/go-delve/delve/service"
 	"github.com/go-delve/delve/service/api"
+	"github.com/go-delve/delve/service/authtoken"
 	"github.com/go-delve/delve/service/debugger"
 	"github.com/go-delve/delve/service/internal/sameuser"
 	"github.com/google/go-dap"
@@ -123,6 +124,14 @@ type Session struct {
 	args launchAttachArgs
 	// exceptionErr tracks the runtime error that last occurred.
 	exceptionErr error
+	// stopCount is incremented at every stop.
+	stopCount int
+	// lastStoppedGoroutineID is the goroutine reported by the last stopped event.
+	lastStoppedGoroutineID int64
+	// watchHistory maps the expressions evaluated for the Watch panel to
+	// the values they had at previous stops, oldest first.
+	// Only used if args.WatchHistory is greater than zero.
+	watchHistory map[string][]watchHistoryEntry
 	// clientCapabilities tracks special settings for handling debug session requests.
 	clientCapabilities dapClientCapabilities
 
@@ -136,6 +145,9 @@ type Session struct {
 	debugger *debugger.Debugger
 	// binaryToRemove is the temp compiled binary to be removed on disconnect (if any).
 	binaryToRemove string
+	// launchArgs is the configuration of the launch request that started
+	// the debug session, used to restart it. Nil for attach requests.
+	launchArgs *LaunchConfig
 	// noDebugProcess is set for the noDebug launch process.
 	noDebugProcess *process
 
@@ -152,7 +164,10 @@ type Session struct {
 	// haltRequested tracks whether a halt of the program has been requested, which may
 	// not correspond to whether a Halt Request has been sent to the target.
 	haltRequested bool
-	haltMu        sync.Mutex
+	// pauseGoroutineID is the goroutine specified by the pause request
+	// that caused the halt, if any.
+	pauseGoroutineID int64
+	haltMu           sync.Mutex
 
 	// changeStateMu must be held for a request to protect itself from another goroutine
 	// changing the state of the running process at the same time.
@@ -223,6 +238,10 @@ type launchAttachArgs struct {
 	StackTraceDepth int `cfgName:"stackTraceDepth"`
 	// ShowGlobalVariables indicates if global package variables should be loaded.
 	ShowGlobalVariables bool `cfgName:"showGlobalVariables"`
+	// GlobalVariablesPackages are the prefixes of the paths of the packages
+	// whose global variables are loaded, if empty only the current package
+	// is used.
+	GlobalVariablesPackages []string `cfgName:"globalVariablesPackages"`
 	// ShowRegisters indicates if register values should be loaded.
 	ShowRegisters bool `cfgName:"showRegisters"`
 	// GoroutineFilters are the filters used when loading goroutines.
@@ -235,6 +254,15 @@ type launchAttachArgs struct {
 	// HideSystemGoroutines indicates if system goroutines should be removed from threads
 	// responses.
 	HideSystemGoroutines bool `cfgName:"hideSystemGoroutines"`
+	// WatchHistory is the number of values of each watched expression to
+	// remember, zero disables the history.
+	WatchHistory int `cfgName:"watchHistory"`
+	// maxStringLen, maxArrayValues, maxVariableRecurse and maxStructFields
+	// override the corresponding limits of DefaultLoadConfig if positive.
+	maxStringLen       int
+	maxArrayValues     int
+	maxVariableRecurse int
+	maxStructFields    int
 	// substitutePathClientToServer indicates rules for converting file paths between client and debugger.
 	substitutePathClientToServer [][2]string `cfgName:"substitutePath"`
 	// substitutePathServerToClient indicates rules for converting file paths between debugger and client.
@@ -248,8 +276,10 @@ var defaultArgs = launchAttachArgs{
 	stopOnEntry:                  false,
 	StackTraceDepth:              50,
 	ShowGlobalVariables:          false,
+	GlobalVariablesPackages:      []string{},
 	HideSystemGoroutines:         false,
 	ShowRegisters:                false,
+	WatchHistory:                 0,
 	GoroutineFilters:             "",
 	ShowPprofLabels:              []string{},
 	substitutePathClientToServer: [][2]string{},
@@ -268,8 +298,9 @@ type dapClientCapabilities struct {
 
 // DefaultLoadConfig controls how variables are loaded from the target's memory.
 // These limits are conservative to minimize performance overhead for bulk loading.
-// With dlv-dap, users do not have a way to adjust these.
-// Instead, we are focusing in interactive loading with nested reloads, array/map
+// With dlv-dap, users can raise them through the maxStringLen, maxArrayValues,
+// maxVariableRecurse and maxStructFields launch and attach attributes, but
+// we are focusing in interactive loading with nested reloads, array/map
 // paging and context-specific string limits.
 var DefaultLoadConfig = proc.LoadConfig{
 	FollowPointers:     true,
@@ -282,6 +313,25 @@ var DefaultLoadConfig = proc.LoadConfig{
 	MaxStructFields: -1,
 }
 
+// loadConfig returns the configuration used to load variables,
+// DefaultLoadConfig with the limits from the launch or attach request.
+func (s *Session) loadConfig() proc.LoadConfig {
+	cfg := DefaultLoadConfig
+	if s.args.maxStringLen > 0 {
+		cfg.MaxStringLen = s.args.maxStringLen
+	}
+	if s.args.maxArrayValues > 0 {
+		cfg.MaxArrayValues = s.args.maxArrayValues
+	}
+	if s.args.maxVariableRecurse > 0 {
+		cfg.MaxVariableRecurse = s.args.maxVariableRecurse
+	}
+	if s.args.maxStructFields > 0 {
+		cfg.MaxStructFields = s.args.maxStructFields
+	}
+	return cfg
+}
+
 const (
 	// When a user examines a single string, we can relax the loading limit.
 	maxSingleStringLen = 4 << 10 // 4096
@@ -291,6 +341,11 @@ const (
 	maxStringLenInCallRetVars = 1 << 10 // 1024
 )
 
+// Max number of frames between the top of the stack of a goroutine and the
+// runtime function handling an unrecovered panic or a fatal error, see
+// exceptionFrame.
+const exceptionFrameDepth = 16
+
 // Max number of goroutines that we will return.
 // This is a var for testing
 var maxGoroutines = 1 << 10
@@ -361,10 +416,16 @@ func (s *Session) setLaunchAttachArgs(args LaunchAttachCommonConfig) {
 		s.args.StackTraceDepth = depth
 	}
 	s.args.ShowGlobalVariables = args.ShowGlobalVariables
+	s.args.GlobalVariablesPackages = args.GlobalVariablesPackages
 	s.args.ShowRegisters = args.ShowRegisters
 	s.args.HideSystemGoroutines = args.HideSystemGoroutines
 	s.args.GoroutineFilters = args.GoroutineFilters
 	s.args.ShowPprofLabels = args.ShowPprofLabels
+	s.args.WatchHistory = args.WatchHistory
+	s.args.maxStringLen = args.MaxStringLen
+	s.args.maxArrayValues = args.MaxArrayValues
+	s.args.maxVariableRecurse = args.MaxVariableRecurse
+	s.args.maxStructFields = args.MaxStructFields
 	if paths := args.SubstitutePath; len(paths) > 0 {
 		clientToServer := make([][2]string, 0, len(paths))
 		serverToClient := make([][2]string, 0, len(paths))
@@ -486,6 +547,14 @@ func (s *Server) Run() {
 				return
 			}
 		}
+		if s.config.AuthToken != "" {
+			if err := authtoken.Check(conn, s.config.AuthToken); err != nil {
+				s.config.log.Errorf("Error accepting client connection: %v", err)
+				conn.Close()
+				s.config.triggerServerStop()
+				return
+			}
+		}
 		s.runSession(conn)
 	}()
 }
@@ -625,7 +694,7 @@ func (s *Session) handleRequest(request dap.Message) {
 		/*TODO*/ s.onTerminateRequest(request) // not yet implemented
 		return
 	case *dap.RestartRequest: // Optional (capability 'supportsRestartRequest')
-		/*TODO*/ s.onRestartRequest(request) // not yet implemented
+		s.onRestartRequest(request)
 		return
 	}
 
@@ -864,12 +933,13 @@ func (s *Session) onInitializeRequest(request *dap.InitializeRequest) {
 	response.Body.SupportsSteppingGranularity = true
 	response.Body.SupportsLogPoints = true
 	response.Body.SupportsDisassembleRequest = true
+	response.Body.ExceptionBreakpointFilters = exceptionBreakpointsFilters
 	// To be enabled by CapabilitiesEvent based on launch configuration
 	response.Body.SupportsStepBack = false
 	response.Body.SupportTerminateDebuggee = false
 	// TODO(polina): support these requests in addition to vscode-go feature parity
 	response.Body.SupportsTerminateRequest = false
-	response.Body.SupportsRestartRequest = false
+	response.Body.SupportsRestartRequest = true
 	response.Body.SupportsSetExpression = false
 	response.Body.SupportsLoadedSourcesRequest = false
 	response.Body.SupportsReadMemoryRequest = false
@@ -993,15 +1063,7 @@ func (s *Session) onLaunchRequest(request *dap.LaunchRequest) {
 		}
 		debugbinary = args.Output
 
-		var cmd string
-		var out []byte
-
-		switch args.Mode {
-		case "debug":
-			cmd, out, err = gobuild.GoBuildCombinedOutput(args.Output, []string{args.Program}, args.BuildFlags.value)
-		case "test":
-			cmd, out, err = gobuild.GoTestBuildCombinedOutput(args.Output, []string{args.Program}, args.BuildFlags.value)
-		}
+		cmd, out, err := buildProgram(&args, args.Output)
 		args.DlvCwd, _ = filepath.Abs(args.DlvCwd)
 		s.config.log.Debugf("building from %q: [%s]", args.DlvCwd, cmd)
 		if err != nil {
@@ -1059,39 +1121,6 @@ func (s *Session) onLaunchRequest(request *dap.LaunchRequest) {
 		return
 	}
 
-	redirectedFunc := func(stdoutReader io.ReadCloser, stderrReader io.ReadCloser) {
-		runReadFunc := func(reader io.ReadCloser, category string) {
-			defer s.preTerminatedWG.Done()
-			defer reader.Close()
-			// Read output from `reader` and send to client
-			var out [1024]byte
-			for {
-				n, err := reader.Read(out[:])
-				if n > 0 {
-					outs := string(out[:n])
-					s.send(&dap.OutputEvent{
-						Event: *newEvent("output"),
-						Body: dap.OutputEventBody{
-							Output:   outs,
-							Category: category,
-						},
-					})
-				}
-				if err != nil {
-					if err == io.EOF {
-						return
-					}
-					s.config.log.Errorf("failed read by %s - %v ", category, err)
-					return
-				}
-			}
-		}
-
-		s.preTerminatedWG.Add(2)
-		go runReadFunc(stdoutReader, "stdout")
-		go runReadFunc(stderrReader, "stderr")
-	}
-
 	if args.NoDebug {
 		s.mu.Lock()
 		cmd, err := s.newNoDebugProcess(debugbinary, args.Args, s.config.Debugger.WorkingDir, redirected)
@@ -1107,7 +1136,7 @@ func (s *Session) onLaunchRequest(request *dap.LaunchRequest) {
 		// Start the program on a different goroutine, so we can listen for disconnect request.
 		go func() {
 			if redirected {
-				redirectedFunc(s.stdoutReader, s.stderrReader)
+				s.forwardOutput(s.stdoutReader, s.stderrReader)
 			}
 
 			if err := cmd.Wait(); err != nil {
@@ -1123,24 +1152,17 @@ func (s *Session) onLaunchRequest(request *dap.LaunchRequest) {
 
 	var closeAll func()
 	if redirected {
-		var (
-			readers         [2]io.ReadCloser
-			outputRedirects [2]proc.OutputRedirect
-		)
-
-		for i := 0; i < 2; i++ {
-			readers[i], outputRedirects[i], err = proc.Redirector()
-			if err != nil {
-				s.sendShowUserErrorResponse(request.Request, InternalError, "Internal Error",
-					fmt.Sprintf("failed to generate stdio pipes - %v", err))
-				return
-			}
+		readers, outputRedirects, err := outputRedirectors()
+		if err != nil {
+			s.sendShowUserErrorResponse(request.Request, InternalError, "Internal Error",
+				fmt.Sprintf("failed to generate stdio pipes - %v", err))
+			return
 		}
 
 		s.config.Debugger.Stdout = outputRedirects[0]
 		s.config.Debugger.Stderr = outputRedirects[1]
 
-		redirectedFunc(readers[0], readers[1])
+		s.forwardOutput(readers[0], readers[1])
 		closeAll = func() {
 			for index := range readers {
 				if closeErr := readers[index].Close(); closeErr != nil {
@@ -1165,6 +1187,7 @@ func (s *Session) onLaunchRequest(request *dap.LaunchRequest) {
 		}
 		return
 	}
+	s.launchArgs = &args
 	// Enable StepBack controls on supported backends
 	if s.config.Debugger.Backend == "rr" {
 		s.send(&dap.CapabilitiesEvent{Event: *newEvent("capabilities"), Body: dap.CapabilitiesEventBody{Capabilities: dap.Capabilities{SupportsStepBack: true}}})
@@ -1177,6 +1200,66 @@ func (s *Session) onLaunchRequest(request *dap.LaunchRequest) {
 	s.send(&dap.LaunchResponse{Response: *newResponse(request.Request)})
 }
 
+// buildProgram builds the program of a launch configuration in debug or
+// test mode, writing the executable to output.
+func buildProgram(args *LaunchConfig, output string) (cmd string, out []byte, err error) {
+	switch args.Mode {
+	case "debug":
+		return gobuild.GoBuildCombinedOutput(output, []string{args.Program}, args.BuildFlags.value)
+	case "test":
+		return gobuild.GoTestBuildCombinedOutput(output, []string{args.Program}, args.BuildFlags.value)
+	}
+	return "", nil, nil
+}
+
+// outputRedirectors creates the redirects for the standard output and
+// standard error of the target, used by launch configurations with
+// outputMode set to "remote", and the readers for them.
+func outputRedirectors() (readers [2]io.ReadCloser, redirects [2]proc.OutputRedirect, err error) {
+	for i := range readers {
+		readers[i], redirects[i], err = proc.Redirector()
+		if err != nil {
+			return readers, redirects, err
+		}
+	}
+	return readers, redirects, nil
+}
+
+// forwardOutput sends everything read from stdoutReader and stderrReader
+// to the client as output events.
+func (s *Session) forwardOutput(stdoutReader, stderrReader io.ReadCloser) {
+	runReadFunc := func(reader io.ReadCloser, category string) {
+		defer s.preTerminatedWG.Done()
+		defer reader.Close()
+		// Read output from `reader` and send to client
+		var out [1024]byte
+		for {
+			n, err := reader.Read(out[:])
+			if n > 0 {
+				outs := string(out[:n])
+				s.send(&dap.OutputEvent{
+					Event: *newEvent("output"),
+					Body: dap.OutputEventBody{
+						Output:   outs,
+						Category: category,
+					},
+				})
+			}
+			if err != nil {
+				if err == io.EOF {
+					return
+				}
+				s.config.log.Errorf("failed read by %s - %v ", category, err)
+				return
+			}
+		}
+	}
+
+	s.preTerminatedWG.Add(2)
+	go runReadFunc(stdoutReader, "stdout")
+	go runReadFunc(stderrReader, "stderr")
+}
+
 func (s *Session) getPackageDir(pkg string) string {
 	cmd := exec.Command("go", "list", "-f", "{{.Dir}}", pkg)
 	out, err := cmd.Output()
@@ -1655,10 +1738,52 @@ func (s *Session) getMatchingBreakpoints(prefix string) map[string]*api.Breakpoi
 	return matchingBps
 }
 
+// exceptionBpPrefix is the prefix of bp.Name for every breakpoint bp set
+// in setExceptionBreakpoints requests.
+const exceptionBpPrefix = "exceptionBreakpoint"
+
+// panicExceptionFilter is the exception filter that stops the program
+// when a panic is raised, before any deferred function runs and whether
+// or not the panic is recovered. Unrecovered panics always stop the
+// program.
+const panicExceptionFilter = "panic"
+
+// panicExceptionBpName is the name of the breakpoint set for panicExceptionFilter.
+var panicExceptionBpName = fmt.Sprintf("%s Filter=%s", exceptionBpPrefix, panicExceptionFilter)
+
+var exceptionBreakpointsFilters = []dap.ExceptionBreakpointsFilter{
+	{
+		Filter:      panicExceptionFilter,
+		Label:       "Panics",
+		Description: "Stop when a panic is raised, even if it is recovered later",
+	},
+}
+
 func (s *Session) onSetExceptionBreakpointsRequest(request *dap.SetExceptionBreakpointsRequest) {
-	// Unlike what DAP documentation claims, this request is always sent
-	// even though we specified no filters at initialization. Handle as no-op.
-	s.send(&dap.SetExceptionBreakpointsResponse{Response: *newResponse(request.Request)})
+	filters := request.Arguments.Filters
+	breakpoints := s.setBreakpoints(exceptionBpPrefix, len(filters), func(i int) *bpMetadata {
+		return &bpMetadata{name: fmt.Sprintf("%s Filter=%s", exceptionBpPrefix, filters[i])}
+	}, func(i int) (*bpLocation, error) {
+		if filters[i] != panicExceptionFilter {
+			return nil, fmt.Errorf("unknown exception filter %q", filters[i])
+		}
+		spec, err := locspec.Parse("runtime.gopanic")
+		if err != nil {
+			return nil, err
+		}
+		locs, err := s.debugger.FindLocationSpec(-1, 0, 0, "runtime.gopanic", spec, true, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(locs) == 0 {
+			return nil, errors.New("could not find runtime.gopanic")
+		}
+		return &bpLocation{addr: locs[0].PC, addrs: locs[0].PCs}, nil
+	})
+
+	response := &dap.SetExceptionBreakpointsResponse{Response: *newResponse(request.Request)}
+	response.Body.Breakpoints = breakpoints
+	s.send(response)
 }
 
 func closeIfOpen(ch chan struct{}) {
@@ -1745,20 +1870,27 @@ func (s *Session) onThreadsRequest(request *dap.ThreadsRequest) {
 	var gs []*proc.G
 	var next int
 	if s.debugger != nil {
-		gs, next, err = s.debugger.Goroutines(0, maxGoroutines)
-		if err == nil {
-			// Parse the goroutine arguments.
-			filters, _, _, _, _, _, _, parseErr := api.ParseGoroutineArgs(s.args.GoroutineFilters)
-			if parseErr != nil {
-				s.logToConsole(parseErr.Error())
-			}
-			if s.args.HideSystemGoroutines {
-				filters = append(filters, api.ListGoroutinesFilter{
-					Kind:    api.GoroutineUser,
-					Negated: false,
-				})
+		// Parse the goroutine arguments.
+		filters, _, _, _, _, _, _, parseErr := api.ParseGoroutineArgs(s.args.GoroutineFilters)
+		if parseErr != nil {
+			s.logToConsole(parseErr.Error())
+		}
+		if s.args.HideSystemGoroutines {
+			filters = append(filters, api.ListGoroutinesFilter{
+				Kind:    api.GoroutineUser,
+				Negated: false,
+			})
+		}
+		// Goroutines are loaded one page at a time, until maxGoroutines of them
+		// pass the filters, so that we never load more goroutines than needed
+		// but filtering doesn't leave us with a partial list.
+		for next = 0; next >= 0 && len(gs) < maxGoroutines; {
+			var page []*proc.G
+			page, next, err = s.debugger.Goroutines(next, maxGoroutines-len(gs))
+			if err != nil {
+				break
 			}
-			gs = s.debugger.FilterGoroutines(gs, filters)
+			gs = append(gs, s.debugger.FilterGoroutines(page, filters)...)
 		}
 	}
 
@@ -1814,6 +1946,13 @@ func (s *Session) onThreadsRequest(request *dap.ThreadsRequest) {
 			}
 		}
 
+		// The time goroutines have been waiting for can only be computed for
+		// running processes, not for core files and recordings.
+		now, nowOk := int64(0), false
+		if recorded, _ := s.debugger.Recorded(); !recorded {
+			now, nowOk = targetNanotime()
+		}
+
 		threads = make([]dap.Thread, len(gs))
 		s.debugger.LockTarget()
 		defer s.debugger.UnlockTarget()
@@ -1825,7 +1964,11 @@ func (s *Session) onThreadsRequest(request *dap.ThreadsRequest) {
 			}
 			thread := ""
 			if g.Thread != nil && g.Thread.ThreadID() != 0 {
-				thread = fmt.Sprintf(" (Thread %d)", g.Thread.ThreadID())
+				if name := proc.ThreadName(g.Thread); name != "" {
+					thread = fmt.Sprintf(" (Thread %d %q)", g.Thread.ThreadID(), name)
+				} else {
+					thread = fmt.Sprintf(" (Thread %d)", g.Thread.ThreadID())
+				}
 			}
 			var labels strings.Builder
 			writeLabelsForKeys := func(keys []string) {
@@ -1860,8 +2003,18 @@ func (s *Session) onThreadsRequest(request *dap.ThreadsRequest) {
 			}
 			// File name and line number are communicated via `stackTrace`
 			// so no need to include them here.
+			wait := ""
+			if (g.Status == proc.Gwaiting || g.Status == proc.Gsyscall) && g.WaitReason != 0 {
+				wait = " (" + proc.WaitReasonString(s.debugger.Target(), g.WaitReason)
+				// waitsince is set by the garbage collector, it is zero for
+				// goroutines that started waiting after the last collection.
+				if nowOk && g.WaitSince > 0 && now > g.WaitSince {
+					wait += ", " + time.Duration(now-g.WaitSince).Round(time.Second).String()
+				}
+				wait += ")"
+			}
 			loc := g.UserCurrent()
-			threads[i].Name = fmt.Sprintf("%s[Go %d%s] %s%s", selected, g.ID, labels.String(), fnName(&loc), thread)
+			threads[i].Name = fmt.Sprintf("%s[Go %d%s] %s%s%s", selected, g.ID, labels.String(), fnName(&loc), wait, thread)
 			threads[i].Id = int(g.ID)
 		}
 	}
@@ -2050,6 +2203,34 @@ func (s *Session) stoppedOnBreakpointGoroutineID(state *api.DebuggerState) (int6
 	return goid, abp
 }
 
+// pausedGoroutineID returns the goroutine to report in the stopped event
+// that follows a pause request, so that the client focuses on a sensible
+// frame instead of whatever thread the halt happened to select. In order
+// of preference this is the goroutine specified by the pause request, the
+// selected goroutine if it is not a system goroutine, and the goroutine of
+// the previous stop if it still exists. The returned goroutine is also
+// selected in the debugger, so that threads requests include it.
+func (s *Session) pausedGoroutineID(state *api.DebuggerState) int64 {
+	goid := stoppedGoroutineID(state)
+	if requested := s.requestedPauseGoroutineID(); requested > 0 {
+		if g, _ := s.debugger.FindGoroutine(requested); g != nil {
+			goid = requested
+		}
+	} else if g, _ := s.debugger.FindGoroutine(goid); g == nil || g.System(s.debugger.Target()) {
+		if g, _ := s.debugger.FindGoroutine(s.lastStoppedGoroutineID); g != nil && s.lastStoppedGoroutineID > 0 {
+			goid = s.lastStoppedGoroutineID
+		}
+	}
+	if goid == 0 || (state.SelectedGoroutine != nil && state.SelectedGoroutine.ID == goid) {
+		return goid
+	}
+	if _, err := s.debugger.Command(&api.DebuggerCommand{Name: api.SwitchGoroutine, GoroutineID: goid}, nil, s.conn.closedChan); err != nil {
+		s.config.log.Errorf("Error switching to goroutine %d after pause: %v", goid, err)
+		return stoppedGoroutineID(state)
+	}
+	return goid
+}
+
 // stepUntilStopAndNotify is a wrapper around runUntilStopAndNotify that
 // first switches selected goroutine. allowNextStateChange is
 // a channel that will be closed to signal that an
@@ -2093,7 +2274,7 @@ func (s *Session) stepUntilStopAndNotify(command string, threadId int, granulari
 func (s *Session) onPauseRequest(request *dap.PauseRequest) {
 	s.changeStateMu.Lock()
 	defer s.changeStateMu.Unlock()
-	s.setHaltRequested(true)
+	s.setPauseRequested(int64(request.Arguments.ThreadId))
 	_, err := s.halt()
 	if err != nil {
 		s.sendErrorResponse(request.Request, UnableToHalt, "Unable to halt execution", err.Error())
@@ -2212,14 +2393,14 @@ func (s *Session) onScopesRequest(request *dap.ScopesRequest) {
 		suffix = " (warning: optimized function)"
 	}
 	// Retrieve arguments
-	args, err := s.debugger.FunctionArguments(int64(goid), frame, 0, DefaultLoadConfig)
+	args, err := s.debugger.FunctionArguments(int64(goid), frame, 0, s.loadConfig())
 	if err != nil {
 		s.sendErrorResponse(request.Request, UnableToListArgs, "Unable to list args", err.Error())
 		return
 	}
 
 	// Retrieve local variables
-	locals, err := s.debugger.LocalVariables(int64(goid), frame, 0, DefaultLoadConfig)
+	locals, err := s.debugger.LocalVariables(int64(goid), frame, 0, s.loadConfig())
 	if err != nil {
 		s.sendErrorResponse(request.Request, UnableToListLocals, "Unable to list locals", err.Error())
 		return
@@ -2228,7 +2409,21 @@ func (s *Session) onScopesRequest(request *dap.ScopesRequest) {
 	scopeLocals := dap.Scope{Name: locScope.Name, VariablesReference: s.variableHandles.create(locScope)}
 	scopes := []dap.Scope{scopeLocals}
 
-	if s.args.ShowGlobalVariables {
+	if s.args.ShowGlobalVariables && len(s.args.GlobalVariablesPackages) > 0 {
+		globals, err := s.debugger.PackageVariables(globalVariablesFilter(s.args.GlobalVariablesPackages), s.loadConfig())
+		if err != nil {
+			s.sendErrorResponse(request.Request, UnableToListGlobals, "Unable to list globals", err.Error())
+			return
+		}
+		// The variables of different packages are distinguished by their
+		// fully-qualified names.
+		globScope := &fullyQualifiedVariable{&proc.Variable{
+			Name:     "Globals",
+			Children: slicePtrVarToSliceVar(globals),
+		}, "", true, 0}
+		scopeGlobals := dap.Scope{Name: globScope.Name, VariablesReference: s.variableHandles.create(globScope)}
+		scopes = append(scopes, scopeGlobals)
+	} else if s.args.ShowGlobalVariables {
 		// Limit what global variables we will return to the current package only.
 		// TODO(polina): This is how vscode-go currently does it to make
 		// the amount of the returned data manageable. In fact, this is
@@ -2238,13 +2433,14 @@ func (s *Session) onScopesRequest(request *dap.ScopesRequest) {
 		// scope is expanded, generating an explicit variable request,
 		// should we consider making all globals accessible with a scope per package?
 		// Or users can just rely on watch variables.
+		// The globalVariablesPackages setting can be used to select other packages.
 		currPkg, err := s.debugger.CurrentPackage()
 		if err != nil {
 			s.sendErrorResponse(request.Request, UnableToListGlobals, "Unable to list globals", err.Error())
 			return
 		}
 		currPkgFilter := fmt.Sprintf("^%s\\.", currPkg)
-		globals, err := s.debugger.PackageVariables(currPkgFilter, DefaultLoadConfig)
+		globals, err := s.debugger.PackageVariables(currPkgFilter, s.loadConfig())
 		if err != nil {
 			s.sendErrorResponse(request.Request, UnableToListGlobals, "Unable to list globals", err.Error())
 			return
@@ -2298,6 +2494,20 @@ func slicePtrVarToSliceVar(vars []*proc.Variable) []proc.Variable {
 	return r
 }
 
+// globalVariablesFilter returns the filter for Debugger.PackageVariables
+// selecting the global variables of the packages whose path starts with one
+// of prefixes, or of all packages if prefixes is the single element "*".
+func globalVariablesFilter(prefixes []string) string {
+	if len(prefixes) == 1 && prefixes[0] == "*" {
+		return ""
+	}
+	quoted := make([]string, len(prefixes))
+	for i := range prefixes {
+		quoted[i] = regexp.QuoteMeta(prefixes[i])
+	}
+	return "^(?:" + strings.Join(quoted, "|") + ")"
+}
+
 // onVariablesRequest handles 'variables' requests.
 // This is a mandatory request to support.
 func (s *Session) onVariablesRequest(request *dap.VariablesRequest) {
@@ -2308,10 +2518,14 @@ func (s *Session) onVariablesRequest(request *dap.VariablesRequest) {
 		return
 	}
 
-	// If there is a filter applied, we will need to create a new variable that includes
-	// the values actually needed to load. This cannot be done when loading the parent
-	// node, since it is unknown at that point which children will need to be loaded.
-	if request.Arguments.Filter == "indexed" {
+	// If a page of indexed children is requested, we will need to create a new variable
+	// that includes the values actually needed to load. This cannot be done when loading
+	// the parent node, since it is unknown at that point which children will need to be loaded.
+	// Without a filter start and count only apply to indexed children, named children
+	// are returned with the first page.
+	paged := request.Arguments.Filter == "indexed" ||
+		request.Arguments.Filter == "" && (request.Arguments.Start > 0 || request.Arguments.Count > 0) && getIndexedVariableCount(v.Variable) > 0
+	if paged {
 		var err error
 		v, err = s.maybeLoadResliced(v, request.Arguments.Start, request.Arguments.Count)
 		if err != nil {
@@ -2321,7 +2535,7 @@ func (s *Session) onVariablesRequest(request *dap.VariablesRequest) {
 	}
 
 	children := []dap.Variable{} // must return empty array, not null, if no children
-	if request.Arguments.Filter == "named" || request.Arguments.Filter == "" {
+	if request.Arguments.Filter == "named" || request.Arguments.Filter == "" && (!paged || request.Arguments.Start == 0) {
 		named, err := s.metadataToDAPVariables(v)
 		if err != nil {
 			s.sendErrorResponse(request.Request, UnableToLookupVariable, "Unable to lookup variable", err.Error())
@@ -2341,6 +2555,16 @@ func (s *Session) onVariablesRequest(request *dap.VariablesRequest) {
 }
 
 func (s *Session) maybeLoadResliced(v *fullyQualifiedVariable, start, count int) (*fullyQualifiedVariable, error) {
+	if count <= 0 || int64(start+count) > v.Len {
+		// A count of 0 requests all the children after start.
+		count = int(v.Len) - start
+	}
+	if count <= 0 {
+		// Past the last child.
+		newV := *v.Variable
+		newV.Children = nil
+		return &fullyQualifiedVariable{&newV, v.fullyQualifiedNameOrExpr, false, start}, nil
+	}
 	if start == 0 {
 		want := count
 		if v.Kind == reflect.Map {
@@ -2353,7 +2577,7 @@ func (s *Session) maybeLoadResliced(v *fullyQualifiedVariable, start, count int)
 			return v, nil
 		}
 	}
-	indexedLoadConfig := DefaultLoadConfig
+	indexedLoadConfig := s.loadConfig()
 	indexedLoadConfig.MaxArrayValues = count
 	newV, err := s.debugger.LoadResliced(v.Variable, start, indexedLoadConfig)
 	if err != nil {
@@ -2563,7 +2787,7 @@ func (s *Session) metadataToDAPVariables(v *fullyQualifiedVariable) ([]dap.Varia
 
 		s.config.log.Debugf("loading %s (type %s) with %s", v.fullyQualifiedNameOrExpr, typeName, loadExpr)
 		// We know that this is an array/slice of Uint8 or Int32, so we will load up to MaxStringLen.
-		config := DefaultLoadConfig
+		config := s.loadConfig()
 		config.MaxArrayValues = config.MaxStringLen
 		vLoaded, err := s.debugger.EvalVariableInScope(-1, 0, 0, loadExpr, config)
 		if err == nil {
@@ -2672,7 +2896,7 @@ func (s *Session) convertVariableWithOpts(v *proc.Variable, qualifiedNameOrExpr
 		s.config.log.Debugf("loading %s (type %s) with %s", qualifiedNameOrExpr, typeName, loadExpr)
 		// Make sure we can load the pointers directly, not by updating just the child
 		// This is not really necessary now because users have no way of setting FollowPointers to false.
-		config := DefaultLoadConfig
+		config := s.loadConfig()
 		config.FollowPointers = true
 		vLoaded, err := s.debugger.EvalVariableInScope(-1, 0, 0, loadExpr, config)
 		if err != nil {
@@ -2706,7 +2930,7 @@ func (s *Session) convertVariableWithOpts(v *proc.Variable, qualifiedNameOrExpr
 					cTypeName := api.PrettyTypeName(v.Children[0].DwarfType)
 					cLoadExpr := fmt.Sprintf("*(*%q)(%#x)", cTypeName, v.Children[0].Addr)
 					s.config.log.Debugf("loading *(%s) (type %s) with %s", qualifiedNameOrExpr, cTypeName, cLoadExpr)
-					cLoaded, err := s.debugger.EvalVariableInScope(-1, 0, 0, cLoadExpr, DefaultLoadConfig)
+					cLoaded, err := s.debugger.EvalVariableInScope(-1, 0, 0, cLoadExpr, s.loadConfig())
 					if err != nil {
 						value += fmt.Sprintf(" - FAILED TO LOAD: %s", err)
 					} else {
@@ -2858,7 +3082,7 @@ func (s *Session) onEvaluateRequest(request *dap.EvaluateRequest) {
 			}
 		}
 	} else { // {expression}
-		exprVar, err := s.debugger.EvalVariableInScope(int64(goid), frame, 0, expr, DefaultLoadConfig)
+		exprVar, err := s.debugger.EvalVariableInScope(int64(goid), frame, 0, expr, s.loadConfig())
 		if err != nil {
 			s.sendErrorResponseWithOpts(request.Request, UnableToEvaluateExpression, "Unable to evaluate expression", err.Error(), showErrorToUser)
 			return
@@ -2870,8 +3094,8 @@ func (s *Session) onEvaluateRequest(request *dap.EvaluateRequest) {
 			if exprVar.Kind == reflect.String {
 				if strVal := constant.StringVal(exprVar.Value); exprVar.Len > int64(len(strVal)) {
 					// Reload the string value with a bigger limit.
-					loadCfg := DefaultLoadConfig
-					loadCfg.MaxStringLen = maxSingleStringLen
+					loadCfg := s.loadConfig()
+					loadCfg.MaxStringLen = max(loadCfg.MaxStringLen, maxSingleStringLen)
 					if v, err := s.debugger.EvalVariableInScope(int64(goid), frame, 0, request.Arguments.Expression, loadCfg); err != nil {
 						s.config.log.Debugf("Failed to load more for %v: %v", request.Arguments.Expression, err)
 					} else {
@@ -2888,10 +3112,53 @@ func (s *Session) onEvaluateRequest(request *dap.EvaluateRequest) {
 		}
 		exprVal, exprRef := s.convertVariableWithOpts(exprVar, fmt.Sprintf("(%s)", request.Arguments.Expression), opts)
 		response.Body = dap.EvaluateResponseBody{Result: exprVal, Type: s.getTypeIfSupported(exprVar), VariablesReference: exprRef, IndexedVariables: getIndexedVariableCount(exprVar), NamedVariables: getNamedVariableCount(exprVar)}
+		if ctxt == "watch" && s.args.WatchHistory > 0 {
+			// The children are the previous values of the expression
+			// instead of the children of its current value.
+			response.Body.VariablesReference = s.recordWatchHistory(request.Arguments.Expression, exprVar)
+			response.Body.IndexedVariables, response.Body.NamedVariables = 0, 0
+		}
 	}
 	s.send(response)
 }
 
+// watchHistoryEntry is the value of a watched expression at a stop.
+type watchHistoryEntry struct {
+	stop int
+	v    *proc.Variable
+}
+
+// recordWatchHistory records v as the value of the watched expression expr
+// at the current stop and returns the reference of a variable that has
+// the last values of expr as children, most recent first.
+func (s *Session) recordWatchHistory(expr string, v *proc.Variable) int {
+	if s.watchHistory == nil {
+		s.watchHistory = make(map[string][]watchHistoryEntry)
+	}
+	history := s.watchHistory[expr]
+	if n := len(history); n > 0 && history[n-1].stop == s.stopCount {
+		// Evaluated again during the same stop (e.g. for a different frame),
+		// only the last value is kept.
+		history = history[:n-1]
+	}
+	history = append(history, watchHistoryEntry{stop: s.stopCount, v: v})
+	if len(history) > s.args.WatchHistory {
+		history = history[len(history)-s.args.WatchHistory:]
+	}
+	s.watchHistory[expr] = history
+
+	children := make([]proc.Variable, len(history))
+	for i := range history {
+		entry := history[len(history)-1-i]
+		children[i] = *entry.v
+		children[i].Name = fmt.Sprintf("[stop %d]", entry.stop)
+	}
+	// Like the return values of calls, the values are wrapped in a nameless
+	// variable. Its children are not reloadable, old values can not be
+	// evaluated again.
+	return s.variableHandles.create(&fullyQualifiedVariable{&proc.Variable{Children: children}, "", false /*not a scope*/, 0})
+}
+
 func (s *Session) doCall(goid, frame int, expr string) (*api.DebuggerState, []*proc.Variable, error) {
 	// This call might be evaluated in the context of the frame that is not topmost
 	// if the editor is set to view the variables for one of the parent frames.
@@ -2911,8 +3178,8 @@ func (s *Session) doCall(goid, frame int, expr string) (*api.DebuggerState, []*p
 	// TODO: investigate whether we need to increase other limits. For example,
 	// the return value is a pointer to a temporary object, which can become
 	// invalid by other injected function calls. Do we care about such use cases?
-	loadCfg := DefaultLoadConfig
-	loadCfg.MaxStringLen = maxStringLenInCallRetVars
+	loadCfg := s.loadConfig()
+	loadCfg.MaxStringLen = max(loadCfg.MaxStringLen, maxStringLenInCallRetVars)
 
 	// TODO(polina): since call will resume execution of all goroutines,
 	// we should do this asynchronously and send a continued event to the
@@ -3003,10 +3270,99 @@ func (s *Session) onTerminateRequest(request *dap.TerminateRequest) {
 	s.sendNotYetImplementedErrorResponse(request.Request)
 }
 
-// onRestartRequest sends a not-yet-implemented error response
-// Capability 'supportsRestartRequest' is not set in 'initialize' response.
+// onRestartRequest handles 'restart' request.
+// Only debug sessions started with a launch request can be restarted.
+// The program is restarted with the arguments and the environment of the
+// original launch request, rebuilding it first in debug and test modes.
+// Breakpoints are kept, breakpoints that can not be set in the new process
+// are reported with a 'breakpoint' event.
+// A 'terminated' event is not sent because clients would end the debug
+// session, instead the 'initialized' event is sent after the response to
+// start a new configuration sequence. The 'configurationDone' request that
+// ends it resumes the program or stops it on entry as after launch.
 func (s *Session) onRestartRequest(request *dap.RestartRequest) {
-	s.sendNotYetImplementedErrorResponse(request.Request)
+	if s.debugger == nil || s.launchArgs == nil {
+		s.sendErrorResponse(request.Request, FailedToRestart, "Unable to restart", "restart is only supported for launch requests")
+		return
+	}
+	args := s.launchArgs
+
+	s.changeStateMu.Lock()
+	defer s.changeStateMu.Unlock()
+	s.setHaltRequested(true)
+	if _, err := s.halt(); err != nil {
+		s.sendErrorResponse(request.Request, UnableToHalt, "Unable to halt execution", err.Error())
+		return
+	}
+
+	rebuild := args.Mode == "debug" || args.Mode == "test"
+	if rebuild {
+		// Build to a different file first so that the current process is left
+		// alone if the build fails.
+		tmp := args.Output + ".restart"
+		cmd, out, err := buildProgram(args, tmp)
+		if err == nil {
+			err = os.Rename(tmp, args.Output)
+		}
+		if err != nil {
+			gobuild.Remove(tmp)
+			s.send(&dap.OutputEvent{
+				Event: *newEvent("output"),
+				Body: dap.OutputEventBody{
+					Output:   fmt.Sprintf("Build Error: %s\n%s (%s)\n", cmd, strings.TrimSpace(string(out)), err.Error()),
+					Category: "stderr",
+				},
+			})
+			s.sendErrorResponse(request.Request, FailedToRestart, "Unable to restart",
+				"Build error: Check the debug console for details.")
+			return
+		}
+	}
+
+	var redirects [3]string
+	resetArgs := false
+	if args.OutputMode == "remote" {
+		// The old redirects are closed when the process is killed, the output
+		// of the new process must be forwarded to the client through new ones.
+		readers, outputRedirects, err := outputRedirectors()
+		if err == nil && (outputRedirects[0].Path == "" || outputRedirects[1].Path == "") {
+			err = errors.New("output redirection is not supported on this platform")
+		}
+		if err != nil {
+			for _, reader := range readers {
+				if reader != nil {
+					reader.Close()
+				}
+			}
+			s.sendErrorResponse(request.Request, FailedToRestart, "Unable to restart", err.Error())
+			return
+		}
+		redirects = [3]string{"", outputRedirects[0].Path, outputRedirects[1].Path}
+		resetArgs = true
+		s.forwardOutput(readers[0], readers[1])
+	}
+
+	discarded, err := s.debugger.Restart(false, "", resetArgs, args.Args, redirects, false)
+	if err != nil {
+		s.sendErrorResponse(request.Request, FailedToRestart, "Unable to restart", err.Error())
+		return
+	}
+	for _, dbp := range discarded {
+		s.send(&dap.BreakpointEvent{
+			Event: *newEvent("breakpoint"),
+			Body: dap.BreakpointEventBody{
+				Reason: "changed",
+				Breakpoint: dap.Breakpoint{
+					Id:       dbp.Breakpoint.ID,
+					Verified: false,
+					Message:  dbp.Reason,
+				},
+			},
+		})
+	}
+	s.resetHandlesForStoppedEvent()
+	s.send(&dap.RestartResponse{Response: *newResponse(request.Request)})
+	s.send(&dap.InitializedEvent{Event: *newEvent("initialized")})
 }
 
 // onStepBackRequest handles 'stepBack' request.
@@ -3063,7 +3419,7 @@ func (s *Session) onSetVariableRequest(request *dap.SetVariableRequest) {
 	// trying to update is valid and accessible from the top most frame & the
 	// current goroutine.
 	goid, frame := -1, 0
-	evaluated, err := s.debugger.EvalVariableInScope(int64(goid), frame, 0, evaluateName, DefaultLoadConfig)
+	evaluated, err := s.debugger.EvalVariableInScope(int64(goid), frame, 0, evaluateName, s.loadConfig())
 	if err != nil {
 		s.sendErrorResponse(request.Request, UnableToSetVariable, "Unable to lookup variable", err.Error())
 		return
@@ -3399,13 +3755,22 @@ func (s *Session) onExceptionInfoRequest(request *dap.ExceptionInfoRequest) {
 	if g.Thread != nil {
 		bpState = g.Thread.Breakpoint()
 	}
-	// Check if this goroutine ID is stopped at a breakpoint.
+	// Check if this goroutine ID is stopped at a breakpoint or, failing
+	// that, if it is inside the runtime functions handling an unrecovered
+	// panic or a fatal error.
 	includeStackTrace := true
-	if bpState != nil && bpState.Breakpoint != nil && bpState.Breakpoint.Logical != nil && (bpState.Breakpoint.Logical.Name == proc.FatalThrow || bpState.Breakpoint.Logical.Name == proc.UnrecoveredPanic) {
-		switch bpState.Breakpoint.Logical.Name {
+	exception, frame := "", 0
+	if bpState != nil && bpState.Breakpoint != nil && bpState.Breakpoint.Logical != nil && (bpState.Breakpoint.Logical.Name == proc.FatalThrow || bpState.Breakpoint.Logical.Name == proc.UnrecoveredPanic || bpState.Breakpoint.Logical.Name == panicExceptionBpName) {
+		exception = bpState.Breakpoint.Logical.Name
+	} else if s.exceptionErr == nil {
+		exception, frame = s.exceptionFrame(goroutineID)
+	}
+	var panicType string
+	if exception != "" {
+		switch exception {
 		case proc.FatalThrow:
 			body.ExceptionId = "fatal error"
-			body.Description, err = s.throwReason(goroutineID)
+			body.Description, err = s.throwReason(goroutineID, frame)
 			if err != nil {
 				body.Description = fmt.Sprintf("Error getting throw reason: %s", err.Error())
 				// This is not currently working for Go 1.16.
@@ -3417,10 +3782,20 @@ func (s *Session) onExceptionInfoRequest(request *dap.ExceptionInfoRequest) {
 		case proc.UnrecoveredPanic:
 			body.ExceptionId = "panic"
 			// Attempt to get the value of the panic message.
-			body.Description, err = s.panicReason(goroutineID)
+			body.Description, err = s.panicReason(goroutineID, frame)
+			if err != nil {
+				body.Description = fmt.Sprintf("Error getting panic message: %s", err.Error())
+			} else {
+				panicType = s.panicType(goroutineID, frame)
+			}
+			body.BreakMode = "unhandled"
+		case panicExceptionBpName:
+			body.ExceptionId = "panic"
+			body.Description, err = s.raisedPanicReason(goroutineID, frame)
 			if err != nil {
 				body.Description = fmt.Sprintf("Error getting panic message: %s", err.Error())
 			}
+			body.BreakMode = "always"
 		}
 	} else {
 		// If this thread is not stopped on a breakpoint, then a runtime error must have occurred.
@@ -3454,6 +3829,10 @@ func (s *Session) onExceptionInfoRequest(request *dap.ExceptionInfoRequest) {
 
 	if includeStackTrace {
 		body.Details = &dap.ExceptionDetails{}
+		if exception == proc.UnrecoveredPanic && panicType != "" {
+			body.Details.Message = body.Description
+			body.Details.TypeName = panicType
+		}
 		frames, err := s.stacktrace(goroutineID, g)
 		if err != nil {
 			body.Details.StackTrace = fmt.Sprintf("Error getting stack trace: %s", err.Error())
@@ -3479,7 +3858,7 @@ func (s *Session) stacktrace(goroutineID int64, g *proc.G) (string, error) {
 	}
 
 	var buf bytes.Buffer
-	fmt.Fprintln(&buf, "Stack:")
+	fmt.Fprintf(&buf, "Goroutine %d stack:\n", goroutineID)
 	userLoc := g.UserCurrent()
 	userFuncPkg := fnPackageName(&userLoc)
 	api.PrintStack(s.toClientPath, &buf, apiFrames, "\t", false, api.StackTraceColors{}, func(s api.Stackframe) bool {
@@ -3493,16 +3872,88 @@ func (s *Session) stacktrace(goroutineID int64, g *proc.G) (string, error) {
 	return buf.String(), nil
 }
 
-func (s *Session) throwReason(goroutineID int64) (string, error) {
-	return s.getExprString("s", goroutineID, 0)
+func (s *Session) throwReason(goroutineID int64, frame int) (string, error) {
+	return s.getExprString("s", goroutineID, frame)
+}
+
+func (s *Session) panicReason(goroutineID int64, frame int) (string, error) {
+	r, err := s.getExprString("(*msgs).arg.(data)", goroutineID, frame)
+	if err != nil && frame > 0 {
+		// msgs is usually only available while runtime.fatalpanic is the
+		// topmost frame, read the current panic of the goroutine instead.
+		r, err = s.getExprString("runtime.curg._panic.arg.(data)", goroutineID, frame)
+	}
+	return r, err
+}
+
+// raisedPanicReason returns the value passed to panic, frame must be the
+// frame of runtime.gopanic.
+func (s *Session) raisedPanicReason(goroutineID int64, frame int) (string, error) {
+	return s.getExprString("e.(data)", goroutineID, frame)
 }
 
-func (s *Session) panicReason(goroutineID int64) (string, error) {
-	return s.getExprString("(*msgs).arg.(data)", goroutineID, 0)
+// setStoppedOnException sets the reason of a stopped event for a goroutine
+// that stopped because of an unrecovered panic or a fatal error, frame is
+// the index of the frame of the runtime function handling it.
+func (s *Session) setStoppedOnException(stopped *dap.StoppedEvent, exception string, frame int) {
+	stopped.Body.Reason = "exception"
+	switch exception {
+	case proc.FatalThrow:
+		stopped.Body.Description = "fatal error"
+		stopped.Body.Text, _ = s.throwReason(int64(stopped.Body.ThreadId), frame)
+	case proc.UnrecoveredPanic:
+		stopped.Body.Description = "panic"
+		stopped.Body.Text, _ = s.panicReason(int64(stopped.Body.ThreadId), frame)
+	case panicExceptionBpName:
+		stopped.Body.Description = "panic"
+		stopped.Body.Text, _ = s.raisedPanicReason(int64(stopped.Body.ThreadId), frame)
+	}
+}
+
+// panicType returns the dynamic type of the value of an unrecovered panic,
+// or an empty string if it can not be read.
+func (s *Session) panicType(goroutineID int64, frame int) string {
+	v, err := s.debugger.EvalVariableInScope(goroutineID, frame, 0, "(*msgs).arg", s.loadConfig())
+	if (err != nil || v.Unreadable != nil) && frame > 0 {
+		v, err = s.debugger.EvalVariableInScope(goroutineID, frame, 0, "runtime.curg._panic.arg", s.loadConfig())
+	}
+	if err != nil || v.Unreadable != nil || len(v.Children) == 0 || v.Children[0].RealType == nil {
+		return ""
+	}
+	return v.Children[0].TypeString()
+}
+
+// exceptionFrame looks for the runtime function handling an unrecovered
+// panic or a fatal error in the stack of the goroutine. It returns
+// proc.UnrecoveredPanic or proc.FatalThrow and the index of the frame,
+// or an empty string if the goroutine is not panicking.
+// This catches the cases where the target stopped somewhere other than the
+// breakpoints on unrecovered panics and fatal errors, for example after
+// stepping into them or pausing the target while they are running.
+// Since this is called on every stop only the topmost exceptionFrameDepth
+// frames are examined, and only if the goroutine is stopped inside the
+// runtime.
+func (s *Session) exceptionFrame(goroutineID int64) (string, int) {
+	frames, err := s.debugger.Stacktrace(goroutineID, exceptionFrameDepth, 0)
+	if err != nil || len(frames) == 0 || frames[0].Current.Fn == nil || frames[0].Current.Fn.PackageName() != "runtime" {
+		return "", 0
+	}
+	for i := range frames {
+		if frames[i].Current.Fn == nil {
+			continue
+		}
+		switch frames[i].Current.Fn.Name {
+		case "runtime.fatalpanic":
+			return proc.UnrecoveredPanic, i
+		case "runtime.throw", "runtime.fatal":
+			return proc.FatalThrow, i
+		}
+	}
+	return "", 0
 }
 
 func (s *Session) getExprString(expr string, goroutineID int64, frame int) (string, error) {
-	exprVar, err := s.debugger.EvalVariableInScope(goroutineID, frame, 0, expr, DefaultLoadConfig)
+	exprVar, err := s.debugger.EvalVariableInScope(goroutineID, frame, 0, expr, s.loadConfig())
 	if err != nil {
 		return "", err
 	}
@@ -3597,6 +4048,7 @@ const BetterNextWhileNextingError = `Unable to step while the previous step is i
 Use 'Continue' to resume the original step command.`
 
 func (s *Session) resetHandlesForStoppedEvent() {
+	s.stopCount++
 	s.stackFrameHandles.reset()
 	s.variableHandles.reset()
 	s.exceptionErr = nil
@@ -3624,6 +4076,22 @@ func (s *Session) setHaltRequested(requested bool) {
 	s.haltMu.Lock()
 	defer s.haltMu.Unlock()
 	s.haltRequested = requested
+	if !requested {
+		s.pauseGoroutineID = 0
+	}
+}
+
+func (s *Session) setPauseRequested(goid int64) {
+	s.haltMu.Lock()
+	defer s.haltMu.Unlock()
+	s.haltRequested = true
+	s.pauseGoroutineID = goid
+}
+
+func (s *Session) requestedPauseGoroutineID() int64 {
+	s.haltMu.Lock()
+	defer s.haltMu.Unlock()
+	return s.pauseGoroutineID
 }
 
 func (s *Session) checkHaltRequested() bool {
@@ -3704,6 +4172,7 @@ func (s *Session) runUntilStopAndNotify(command string, allowNextStateChange *sy
 			stopped.Body.Reason = "step"
 		case proc.StopManual: // triggered by halt
 			stopped.Body.Reason = "pause"
+			stopped.Body.ThreadId = int(s.pausedGoroutineID(state))
 		case proc.StopUnknown: // can happen while terminating
 			stopped.Body.Reason = "unknown"
 		case proc.StopWatchpoint:
@@ -3714,14 +4183,8 @@ func (s *Session) runUntilStopAndNotify(command string, allowNextStateChange *sy
 			stopped.Body.ThreadId = int(goid)
 			if bp != nil {
 				switch bp.Name {
-				case proc.FatalThrow:
-					stopped.Body.Reason = "exception"
-					stopped.Body.Description = "fatal error"
-					stopped.Body.Text, _ = s.throwReason(int64(stopped.Body.ThreadId))
-				case proc.UnrecoveredPanic:
-					stopped.Body.Reason = "exception"
-					stopped.Body.Description = "panic"
-					stopped.Body.Text, _ = s.panicReason(int64(stopped.Body.ThreadId))
+				case proc.FatalThrow, proc.UnrecoveredPanic, panicExceptionBpName:
+					s.setStoppedOnException(stopped, bp.Name, 0)
 				}
 				if strings.HasPrefix(bp.Name, functionBpPrefix) {
 					stopped.Body.Reason = "function breakpoint"
@@ -3733,6 +4196,13 @@ func (s *Session) runUntilStopAndNotify(command string, allowNextStateChange *sy
 			}
 		}
 
+		if stopped.Body.Reason != "exception" {
+			if exception, frame := s.exceptionFrame(int64(stopped.Body.ThreadId)); exception != "" {
+				s.setStoppedOnException(stopped, exception, frame)
+				stopped.Body.HitBreakpointIds = nil
+			}
+		}
+
 		// Override the stop reason if there was a manual stop request.
 		// TODO(suzmue): move this logic into the runUntilStop command
 		// so that the stop reason is determined by that function which
@@ -3764,6 +4234,8 @@ func (s *Session) runUntilStopAndNotify(command string, allowNextStateChange *sy
 		}
 	}
 
+	s.lastStoppedGoroutineID = int64(stopped.Body.ThreadId)
+
 	// NOTE: If we happen to be responding to another request with an is-running
 	// error while this one completes, it is possible that the error response
 	// will arrive after this stopped event.
@@ -3888,7 +4360,7 @@ func (s *Session) logBreakpointMessage(bp *api.Breakpoint, goid int64) bool {
 func (msg *logMessage) evaluate(s *Session, goid int64) string {
 	evaluated := make([]interface{}, len(msg.args))
 	for i := range msg.args {
-		exprVar, err := s.debugger.EvalVariableInScope(goid, 0, 0, msg.args[i], DefaultLoadConfig)
+		exprVar, err := s.debugger.EvalVariableInScope(goid, 0, 0, msg.args[i], s.loadConfig())
 		if err != nil {
 			evaluated[i] = fmt.Sprintf("{eval err: %e}", err)
 			continue
@@ -930,6 +930,39 @@ func TestFilterGoroutines(t *testing.T) {
 	})
 }
 
+// Tests that the names of waiting goroutines in the threads response
+// include their wait reason.
+func TestThreadsRequestWaitReason(t *testing.T) {
+	runTest(t, "goroutinestackprog", func(client *daptest.Client, fixture protest.Fixture) {
+		runDebugSessionWithBPs(t, client, "launch",
+			// Launch
+			func() {
+				client.LaunchRequest("exec", fixture.Path, !stopOnEntry)
+			},
+			// Set breakpoints
+			fixture.Source, []int{30},
+			[]onBreakpoint{{
+				// Stop at line 30, all agoroutine goroutines are blocked sending on done.
+				// The description of the wait reason depends on the version of Go.
+				execute: func() {
+					client.ThreadsRequest()
+					tr := client.ExpectThreadsResponse(t)
+					re := regexp.MustCompile(`^\[Go \d+\] main\.agoroutine \(.+\)$`)
+					n := 0
+					for _, thread := range tr.Body.Threads {
+						if re.MatchString(thread.Name) {
+							n++
+						}
+					}
+					if n != 10 {
+						t.Errorf("got %d waiting agoroutine threads, expected 10: %#v", n, tr.Body.Threads)
+					}
+				},
+				disconnect: true,
+			}})
+	})
+}
+
 func checkStackFramesHasMore(t *testing.T, got *dap.StackTraceResponse,
 	wantStartName string, wantStartLine, wantStartID, wantFrames, wantTotalFrames int,
 ) {
@@ -1476,9 +1509,14 @@ func TestGoroutineLabels(t *testing.T) {
 }
 
 func TestHideSystemGoroutinesRequest(t *testing.T) {
-	tests := []struct{ hideSystemGoroutines bool }{
+	tests := []struct {
+		hideSystemGoroutines bool
+		maxGoroutines        int
+	}{
 		{hideSystemGoroutines: true},
 		{hideSystemGoroutines: false},
+		// Goroutines are loaded until enough of them pass the filter.
+		{hideSystemGoroutines: true, maxGoroutines: 10},
 	}
 	for _, tt := range tests {
 		runTest(t, "goroutinestackprog", func(client *daptest.Client, fixture protest.Fixture) {
@@ -1498,12 +1536,27 @@ func TestHideSystemGoroutinesRequest(t *testing.T) {
 					execute: func() {
 						checkStop(t, client, 1, "main.main", 25)
 
+						if tt.maxGoroutines > 0 {
+							defaultMaxGoroutines := maxGoroutines
+							defer func() { maxGoroutines = defaultMaxGoroutines }()
+							maxGoroutines = tt.maxGoroutines
+						}
+
 						client.ThreadsRequest()
+						if tt.maxGoroutines > 0 {
+							oe := client.ExpectOutputEvent(t)
+							if !strings.HasPrefix(oe.Body.Output, "Too many goroutines") {
+								t.Errorf("got %#v, expected Output=\"Too many goroutines...\"\n", oe)
+							}
+						}
 						tr := client.ExpectThreadsResponse(t)
 
 						// The user process creates 10 goroutines in addition to the
 						// main goroutine, for a total of 11 goroutines.
 						userCount := 11
+						if tt.maxGoroutines > 0 {
+							userCount = tt.maxGoroutines
+						}
 						if tt.hideSystemGoroutines {
 							if len(tr.Body.Threads) != userCount {
 								t.Errorf("got %d goroutines, expected %d\n", len(tr.Body.Threads), userCount)
@@ -2426,6 +2479,86 @@ func TestVariablesLoading(t *testing.T) {
 	})
 }
 
+// TestVariablesPaging tests the start and count arguments of variables
+// requests for ranges of children that are not loaded.
+func TestVariablesPaging(t *testing.T) {
+	runTest(t, "testvariables2", func(client *daptest.Client, fixture protest.Fixture) {
+		runDebugSessionWithBPs(t, client, "launch",
+			// Launch
+			func() {
+				client.LaunchRequest("exec", fixture.Path, !stopOnEntry)
+			},
+			// Breakpoints are set within the program
+			fixture.Source, []int{},
+			[]onBreakpoint{{
+				execute:    func() {},
+				disconnect: false,
+			}, {
+				execute: func() {
+					client.EvaluateRequest("longslice", 1000, "repl")
+					ref := checkEvalIndexed(t, client.ExpectEvaluateResponse(t), "[]int len: 100, cap: 100, [0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,...+36 more]", hasChildren, 100, 0)
+					checkPage := func(got *dap.VariablesResponse, start, n int) {
+						t.Helper()
+						checkChildren(t, got, "longslice", n)
+						for i, child := range got.Body.Variables {
+							if want := fmt.Sprintf("[%d]", start+i); child.Name != want {
+								t.Errorf("got %#v, want Name=%q", child, want)
+							}
+						}
+					}
+
+					// A count of 0 requests all the children.
+					client.IndexedVariablesRequest(ref, 0, 0)
+					checkPage(client.ExpectVariablesResponse(t), 0, 100)
+					client.IndexedVariablesRequest(ref, 90, 0)
+					checkPage(client.ExpectVariablesResponse(t), 90, 10)
+					// The count is truncated to the number of children.
+					client.IndexedVariablesRequest(ref, 95, 10)
+					checkPage(client.ExpectVariablesResponse(t), 95, 5)
+					client.IndexedVariablesRequest(ref, 100, 10)
+					checkPage(client.ExpectVariablesResponse(t), 100, 0)
+					// Without a filter the range applies to the indexed children.
+					client.PagedVariablesRequest(ref, 70, 20)
+					checkPage(client.ExpectVariablesResponse(t), 70, 20)
+				},
+				disconnect: true,
+			}})
+	})
+}
+
+// TestVariablesLoadConfig tests that the limits specified in the launch
+// request are used to load variables.
+func TestVariablesLoadConfig(t *testing.T) {
+	runTest(t, "testvariables2", func(client *daptest.Client, fixture protest.Fixture) {
+		runDebugSessionWithBPs(t, client, "launch",
+			// Launch
+			func() {
+				client.LaunchRequestWithArgs(map[string]interface{}{
+					"mode": "exec", "program": fixture.Path, "maxStringLen": 64, "maxArrayValues": 33,
+				})
+			},
+			// Breakpoints are set within the program
+			fixture.Source, []int{},
+			[]onBreakpoint{{
+				execute:    func() {},
+				disconnect: false,
+			}, {
+				execute: func() {
+					client.EvaluateRequest("longstr", 1000, "watch")
+					checkEval(t, client.ExpectEvaluateResponse(t), longstrLoaded64, noChildren)
+
+					client.EvaluateRequest("longslice", 1000, "watch")
+					ref := checkEvalIndexed(t, client.ExpectEvaluateResponse(t), "[]int len: 100, cap: 100, [0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,...+67 more]", hasChildren, 100, 0)
+					if ref > 0 {
+						client.VariablesRequest(ref)
+						checkChildren(t, client.ExpectVariablesResponse(t), "longslice", 33)
+					}
+				},
+				disconnect: true,
+			}})
+	})
+}
+
 // TestVariablesMetadata exposes test cases where variables contain metadata that
 // can be accessed by requesting named variables.
 func TestVariablesMetadata(t *testing.T) {
@@ -2594,6 +2727,57 @@ func TestGlobalScopeAndVariables(t *testing.T) {
 	})
 }
 
+// TestGlobalVariablesPackages launches the program with showGlobalVariables
+// and globalVariablesPackages set and tests that the Globals scope lists the
+// variables of the selected packages regardless of the current package.
+func TestGlobalVariablesPackages(t *testing.T) {
+	runTest(t, "consts", func(client *daptest.Client, fixture protest.Fixture) {
+		runDebugSessionWithBPs(t, client, "launch",
+			// Launch
+			func() {
+				client.LaunchRequestWithArgs(map[string]interface{}{
+					"mode": "exec", "program": fixture.Path, "showGlobalVariables": true,
+					"globalVariablesPackages": []string{"github.com/go-delve/delve/_fixtures/internal/dir0"},
+				})
+			},
+			// Breakpoints are set within the program
+			fixture.Source, []int{},
+			[]onBreakpoint{{
+				execute: func() {
+					client.StackTraceRequest(1, 0, 20)
+					client.ExpectStackTraceResponse(t)
+
+					client.ScopesRequest(1000)
+					scopes := client.ExpectScopesResponse(t)
+					checkScope(t, scopes, 0, "Locals", localsScope)
+					checkScope(t, scopes, 1, "Globals", globalsScope)
+
+					client.VariablesRequest(globalsScope)
+					globals := client.ExpectVariablesResponse(t)
+					checkChildren(t, globals, "Globals", 1)
+					checkVarExact(t, globals, 0, "github.com/go-delve/delve/_fixtures/internal/dir0/pkg.SomeVar", "github.com/go-delve/delve/_fixtures/internal/dir0/pkg.SomeVar", "pkg.SomeType {X: 0}", "github.com/go-delve/delve/_fixtures/internal/dir0/pkg.SomeType", hasChildren)
+				},
+				disconnect: false,
+			}})
+	})
+}
+
+func TestGlobalVariablesFilter(t *testing.T) {
+	tests := []struct {
+		prefixes []string
+		want     string
+	}{
+		{[]string{"*"}, ""},
+		{[]string{"main"}, `^(?:main)`},
+		{[]string{"main", "example.com/a.b"}, `^(?:main|example\.com/a\.b)`},
+	}
+	for _, tc := range tests {
+		if got := globalVariablesFilter(tc.prefixes); got != tc.want {
+			t.Errorf("globalVariablesFilter(%q) = %q, want %q", tc.prefixes, got, tc.want)
+		}
+	}
+}
+
 // TestRegisterScopeAndVariables launches the program with showRegisters
 // arg set, executes to a breakpoint in the main package and tests that the registers
 // got loaded. It then steps into a function in another package and tests that
@@ -2788,6 +2972,46 @@ func TestLaunchRequestWithStackTraceDepth(t *testing.T) {
 	})
 }
 
+// Tests that with 'watchHistory' the values of watched expressions at the
+// previous stops are returned as their children.
+func TestWatchHistory(t *testing.T) {
+	runTest(t, "issue1264", func(client *daptest.Client, fixture protest.Fixture) {
+		checkHistory := func(want ...string) {
+			t.Helper()
+			// Evaluating again during the same stop does not add entries.
+			client.EvaluateRequest("i", 1000, "watch")
+			client.ExpectEvaluateResponse(t)
+			client.EvaluateRequest("i", 1000, "watch")
+			ref := checkEval(t, client.ExpectEvaluateResponse(t), want[0], hasChildren)
+			client.VariablesRequest(ref)
+			history := client.ExpectVariablesResponse(t)
+			checkChildren(t, history, "i", len(want))
+			for i := range want {
+				checkVarRegex(t, history, i, `^\[stop \d+\]$`, "", want[i], "int", noChildren)
+			}
+		}
+		runDebugSessionWithBPs(t, client, "launch",
+			// Launch
+			func() {
+				client.LaunchRequestWithArgs(map[string]interface{}{
+					"mode": "exec", "program": fixture.Path, "watchHistory": 2,
+				})
+			},
+			// Set breakpoints
+			fixture.Source, []int{8},
+			[]onBreakpoint{{
+				execute:    func() { checkHistory("0") },
+				disconnect: false,
+			}, {
+				execute:    func() { checkHistory("1", "0") },
+				disconnect: false,
+			}, {
+				execute:    func() { checkHistory("2", "1") },
+				disconnect: true,
+			}})
+	})
+}
+
 type Breakpoint struct {
 	line      int
 	path      string
@@ -4125,16 +4349,18 @@ func TestEvaluateRequest(t *testing.T) {
 	})
 }
 
-func formatConfig(depth int, showGlobals, showRegisters bool, goroutineFilters string, showPprofLabels []string, hideSystemGoroutines bool, substitutePath [][2]string) string {
+func formatConfig(depth int, showGlobals bool, globalsPackages []string, showRegisters bool, goroutineFilters string, showPprofLabels []string, hideSystemGoroutines bool, watchHistory int, substitutePath [][2]string) string {
 	formatStr := `stackTraceDepth	%d
 showGlobalVariables	%v
+globalVariablesPackages	%v
 showRegisters	%v
 goroutineFilters	%q
 showPprofLabels	%v
 hideSystemGoroutines	%v
+watchHistory	%d
 substitutePath	%v
 `
-	return fmt.Sprintf(formatStr, depth, showGlobals, showRegisters, goroutineFilters, showPprofLabels, hideSystemGoroutines, substitutePath)
+	return fmt.Sprintf(formatStr, depth, showGlobals, globalsPackages, showRegisters, goroutineFilters, showPprofLabels, hideSystemGoroutines, watchHistory, substitutePath)
 }
 
 func TestEvaluateCommandRequest(t *testing.T) {
@@ -4171,7 +4397,7 @@ Type 'dlv help' followed by a command for full documentation.
 
 					client.EvaluateRequest("dlv config -list", 1000, "repl")
 					got = client.ExpectEvaluateResponse(t)
-					checkEval(t, got, formatConfig(50, false, false, "", []string{}, false, [][2]string{}), noChildren)
+					checkEval(t, got, formatConfig(50, false, []string{}, false, "", []string{}, false, 0, [][2]string{}), noChildren)
 
 					// Read and modify showGlobalVariables.
 					client.EvaluateRequest("dlv config -list showGlobalVariables", 1000, "repl")
@@ -4192,7 +4418,7 @@ Type 'dlv help' followed by a command for full documentation.
 
 					client.EvaluateRequest("dlv config -list", 1000, "repl")
 					got = client.ExpectEvaluateResponse(t)
-					checkEval(t, got, formatConfig(50, true, false, "", []string{}, false, [][2]string{}), noChildren)
+					checkEval(t, got, formatConfig(50, true, []string{}, false, "", []string{}, false, 0, [][2]string{}), noChildren)
 
 					client.ScopesRequest(1000)
 					scopes = client.ExpectScopesResponse(t)
@@ -5098,6 +5324,9 @@ func TestPanicBreakpointOnContinue(t *testing.T) {
 					if eInfo.Body.ExceptionId != "panic" || eInfo.Body.Description != text {
 						t.Errorf("\ngot  %#v\nwant ExceptionId=\"panic\" Description=%q", eInfo, text)
 					}
+					if d := eInfo.Body.Details; d == nil || d.Message != text || d.TypeName != "string" || !strings.HasPrefix(d.StackTrace, "Goroutine 1 stack:\n") {
+						t.Errorf("\ngot  %#v\nwant Message=%q TypeName=\"string\" StackTrace=\"Goroutine 1 stack:...\"", d, text)
+					}
 
 					client.StackTraceRequest(se.Body.ThreadId, 0, 20)
 					st := client.ExpectStackTraceResponse(t)
@@ -5116,6 +5345,97 @@ func TestPanicBreakpointOnContinue(t *testing.T) {
 	})
 }
 
+func TestPanicDetectedFromStack(t *testing.T) {
+	// Stopping inside the runtime functions called by runtime.fatalpanic is
+	// reported as an exception even if the unrecovered-panic breakpoint was
+	// not the reason for the stop.
+	runTest(t, "panic", func(client *daptest.Client, fixture protest.Fixture) {
+		client.InitializeRequest()
+		client.ExpectInitializeResponseAndCapabilities(t)
+		client.LaunchRequest("exec", fixture.Path, !stopOnEntry)
+		client.ExpectInitializedEvent(t)
+		client.ExpectLaunchResponse(t)
+		client.SetFunctionBreakpointsRequest([]dap.FunctionBreakpoint{{Name: "runtime.printpanics"}})
+		client.ExpectSetFunctionBreakpointsResponse(t)
+		client.ConfigurationDoneRequest()
+		client.ExpectConfigurationDoneResponse(t)
+
+		text := "\"BOOM!\""
+		for i, wantFn := range []string{"runtime.fatalpanic", "runtime.printpanics"} {
+			if i > 0 {
+				client.ContinueRequest(1)
+				client.ExpectContinueResponse(t)
+			}
+			se := client.ExpectStoppedEvent(t)
+			if se.Body.ThreadId != 1 || se.Body.Reason != "exception" || se.Body.Description != "panic" || se.Body.Text != text {
+				t.Errorf("\ngot  %#v\nwant ThreadId=1 Reason=\"exception\" Description=\"panic\" Text=%q", se, text)
+			}
+			client.StackTraceRequest(se.Body.ThreadId, 0, 1)
+			st := client.ExpectStackTraceResponse(t)
+			if len(st.Body.StackFrames) != 1 || st.Body.StackFrames[0].Name != wantFn {
+				t.Errorf("\ngot  %#v\nwant stopped in %s", st, wantFn)
+			}
+			client.ExceptionInfoRequest(1)
+			eInfo := client.ExpectExceptionInfoResponse(t)
+			if eInfo.Body.ExceptionId != "panic" || eInfo.Body.Description != text {
+				t.Errorf("\ngot  %#v\nwant ExceptionId=\"panic\" Description=%q", eInfo, text)
+			}
+		}
+
+		client.DisconnectRequestWithKillOption(true)
+		client.ExpectOutputEventDetachingKill(t)
+		client.ExpectDisconnectResponse(t)
+		client.ExpectTerminatedEvent(t)
+	})
+}
+
+func TestPanicExceptionBreakpoint(t *testing.T) {
+	runTest(t, "panicex", func(client *daptest.Client, fixture protest.Fixture) {
+		client.InitializeRequest()
+		client.ExpectInitializeResponseAndCapabilities(t)
+		client.LaunchRequest("exec", fixture.Path, !stopOnEntry)
+		client.ExpectInitializedEvent(t)
+		client.ExpectLaunchResponse(t)
+
+		client.SetExceptionBreakpointsRequestWithFilters([]string{"panic", "unknown"})
+		sebpResp := client.ExpectSetExceptionBreakpointsResponse(t)
+		if bps := sebpResp.Body.Breakpoints; len(bps) != 2 || !bps[0].Verified || bps[1].Verified {
+			t.Fatalf("\ngot  %#v\nwant 2 breakpoints, only the first one verified", sebpResp)
+		}
+		client.ConfigurationDoneRequest()
+		client.ExpectConfigurationDoneResponse(t)
+
+		se := client.ExpectStoppedEvent(t)
+		if se.Body.ThreadId != 1 || se.Body.Reason != "exception" || se.Body.Description != "panic" {
+			t.Errorf("\ngot  %#v\nwant ThreadId=1 Reason=\"exception\" Description=\"panic\"", se)
+		}
+		client.StackTraceRequest(se.Body.ThreadId, 0, 2)
+		st := client.ExpectStackTraceResponse(t)
+		if len(st.Body.StackFrames) != 2 || st.Body.StackFrames[0].Name != "runtime.gopanic" || st.Body.StackFrames[1].Name != "main.F4" || st.Body.StackFrames[1].Line != 23 {
+			t.Errorf("\ngot  %#v\nwant stopped in runtime.gopanic called by main.F4 at line 23", st)
+		}
+		client.ExceptionInfoRequest(1)
+		eInfo := client.ExpectExceptionInfoResponse(t)
+		if eInfo.Body.ExceptionId != "panic" || eInfo.Body.BreakMode != "always" {
+			t.Errorf("\ngot  %#v\nwant ExceptionId=\"panic\" BreakMode=\"always\"", eInfo)
+		}
+
+		// The panic is recovered, the program runs to completion once the
+		// filter is disabled.
+		client.SetExceptionBreakpointsRequestWithFilters(nil)
+		client.ExpectSetExceptionBreakpointsResponse(t)
+		client.ContinueRequest(1)
+		client.ExpectContinueResponse(t)
+		client.ExpectTerminatedEvent(t)
+
+		client.DisconnectRequestWithKillOption(true)
+		client.ExpectOutputEventProcessExitedAnyStatus(t)
+		client.ExpectOutputEventDetaching(t)
+		client.ExpectDisconnectResponse(t)
+		client.ExpectTerminatedEvent(t)
+	})
+}
+
 func TestPanicBreakpointOnNext(t *testing.T) {
 	if !goversion.VersionAfterOrEqual(runtime.Version(), 1, 14) {
 		// In Go 1.13, 'next' will step into the defer in the runtime
@@ -5980,7 +6300,9 @@ func TestPauseAndContinue(t *testing.T) {
 
 					time.Sleep(time.Second)
 
-					// Halt pauses all goroutines, so thread id is ignored
+					// Halt pauses all goroutines. The thread id selects the
+					// goroutine reported in the stopped event, and is ignored
+					// if there is no such goroutine.
 					client.PauseRequest(56789)
 					expectPauseResponseAndStoppedEvent(t, client)
 
@@ -5994,6 +6316,70 @@ func TestPauseAndContinue(t *testing.T) {
 	})
 }
 
+// TestPauseSelectsThread tests that the stopped event following a pause
+// request reports the goroutine specified in the request.
+func TestPauseSelectsThread(t *testing.T) {
+	runTest(t, "loopprog", func(client *daptest.Client, fixture protest.Fixture) {
+		runDebugSessionWithBPs(t, client, "launch",
+			// Launch
+			func() {
+				client.LaunchRequest("exec", fixture.Path, !stopOnEntry)
+			},
+			// Set breakpoints
+			fixture.Source, []int{6},
+			[]onBreakpoint{{
+				execute: func() {
+					client.CheckStopLocation(t, 1, "main.loop", 6)
+
+					client.ThreadsRequest()
+					threads := client.ExpectThreadsResponse(t)
+					other := 0
+					for _, th := range threads.Body.Threads {
+						if th.Id != 1 {
+							other = th.Id
+							break
+						}
+					}
+					if other == 0 {
+						t.Fatalf("got %#v, want more than one thread", threads)
+					}
+
+					client.SetBreakpointsRequest(fixture.Source, []int{})
+					client.ExpectSetBreakpointsResponse(t)
+
+					client.ContinueRequest(1)
+					client.ExpectContinueResponse(t)
+					time.Sleep(time.Second)
+
+					client.PauseRequest(other)
+					for i := 0; i < 2; i++ {
+						switch m := client.ExpectMessage(t).(type) {
+						case *dap.StoppedEvent:
+							if m.Body.Reason != "pause" || m.Body.ThreadId != other {
+								t.Errorf("\ngot %#v\nwant ThreadId=%d Reason='pause'", m, other)
+							}
+						case *dap.PauseResponse:
+						default:
+							t.Fatalf("got %#v, want StoppedEvent or PauseResponse", m)
+						}
+					}
+
+					client.ThreadsRequest()
+					threads = client.ExpectThreadsResponse(t)
+					found := false
+					for _, th := range threads.Body.Threads {
+						found = found || th.Id == other
+					}
+					if !found {
+						t.Errorf("got %#v, want thread %d", threads, other)
+					}
+				},
+				// The program has an infinite loop, so we must kill it by disconnecting.
+				disconnect: true,
+			}})
+	})
+}
+
 func TestUnsupportedCommandResponses(t *testing.T) {
 	var got *dap.ErrorResponse
 	runTest(t, "increment", func(client *daptest.Client, fixture protest.Fixture) {
@@ -6387,6 +6773,46 @@ func TestSetVariableWithCall(t *testing.T) {
 	})
 }
 
+func TestRestart(t *testing.T) {
+	runTest(t, "increment", func(client *daptest.Client, fixture protest.Fixture) {
+		client.InitializeRequest()
+		client.ExpectInitializeResponseAndCapabilities(t)
+		client.LaunchRequestWithArgs(map[string]interface{}{
+			"mode": "debug", "program": fixture.Source, "output": filepath.Join(t.TempDir(), "__debug_bin"),
+		})
+		client.ExpectInitializedEvent(t)
+		client.ExpectLaunchResponse(t)
+
+		client.SetBreakpointsRequest(fixture.Source, []int{7})
+		client.ExpectSetBreakpointsResponse(t)
+		for i := 0; i < 2; i++ {
+			client.ConfigurationDoneRequest()
+			client.ExpectConfigurationDoneResponse(t)
+			se := client.ExpectStoppedEvent(t)
+			if se.Body.Reason != "breakpoint" {
+				t.Fatalf("run %d: got %#v, want Reason=\"breakpoint\"", i, se)
+			}
+			client.StackTraceRequest(se.Body.ThreadId, 0, 1)
+			st := client.ExpectStackTraceResponse(t)
+			if len(st.Body.StackFrames) != 1 || st.Body.StackFrames[0].Line != 7 {
+				t.Fatalf("run %d: got %#v, want stopped at line 7", i, st)
+			}
+			if i == 0 {
+				// The breakpoint is kept across the restart without being set
+				// again by the client.
+				client.RestartRequest()
+				client.ExpectRestartResponse(t)
+				client.ExpectInitializedEvent(t)
+			}
+		}
+
+		client.DisconnectRequestWithKillOption(true)
+		client.ExpectOutputEventDetachingKill(t)
+		client.ExpectDisconnectResponse(t)
+		client.ExpectTerminatedEvent(t)
+	})
+}
+
 func TestOptionalNotYetImplementedResponses(t *testing.T) {
 	var got *dap.ErrorResponse
 	runTest(t, "increment", func(client *daptest.Client, fixture protest.Fixture) {
@@ -6403,9 +6829,6 @@ func TestOptionalNotYetImplementedResponses(t *testing.T) {
 		client.TerminateRequest()
 		expectNotYetImplemented("terminate")
 
-		client.RestartRequest()
-		expectNotYetImplemented("restart")
-
 		client.SetExpressionRequest()
 		expectNotYetImplemented("setExpression")
 
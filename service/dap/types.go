@@ -177,6 +177,13 @@ type LaunchAttachCommonConfig struct {
 	// should be shown in the variables pane or not.
 	ShowGlobalVariables bool `json:"showGlobalVariables,omitempty"`
 
+	// Array of package path prefixes selecting the packages whose global
+	// variables are shown in the variables pane when showGlobalVariables is
+	// set. To show the global variables of all packages, specify the single
+	// element "*". If not specified only the global variables of the package
+	// of the current function are shown.
+	GlobalVariablesPackages []string `json:"globalVariablesPackages,omitempty"`
+
 	// Boolean value to indicate whether registers should be shown
 	// in the variables pane or not.
 	ShowRegisters bool `json:"showRegisters,omitempty"`
@@ -196,6 +203,31 @@ type LaunchAttachCommonConfig struct {
 	// shown as "key:value". To show all labels, specify the single element "*".
 	ShowPprofLabels []string `json:"showPprofLabels,omitempty"`
 
+	// Number of values of each expression in the Watch panel to remember.
+	// When greater than zero the values that a watched expression had at
+	// the last watchHistory stops are returned as its children, most
+	// recent first.
+	// Default is 0, which disables the history.
+	WatchHistory int `json:"watchHistory,omitempty"`
+
+	// Maximum number of bytes read from a string when loading variables.
+	// Default is 512.
+	MaxStringLen int `json:"maxStringLen,omitempty"`
+
+	// Maximum number of elements read from an array, slice or map when
+	// loading variables. Larger ones can still be paged through.
+	// Default is 64.
+	MaxArrayValues int `json:"maxArrayValues,omitempty"`
+
+	// Maximum number of levels of nested structs, arrays, slices and maps
+	// loaded with a variable. Deeper levels are loaded when expanded.
+	// Default is 1.
+	MaxVariableRecurse int `json:"maxVariableRecurse,omitempty"`
+
+	// Maximum number of struct fields read when loading variables.
+	// Default is all fields.
+	MaxStructFields int `json:"maxStructFields,omitempty"`
+
 	// An array of mappings from a local path (client) to the remote path (debugger).
 	// This setting is useful when working in a file system with symbolic links,
 	// running remote debugging, or debugging an executable compiled externally.
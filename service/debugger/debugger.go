@@ -74,6 +74,12 @@ type Debugger struct {
 	running      bool
 	runningMutex sync.Mutex
 
+	// stateChange is closed, and replaced with a new channel, every time the
+	// target is resumed, stopped, restarted or detached from.
+	stateChange      chan struct{}
+	stateChangeCount uint64
+	stateChangeMutex sync.Mutex
+
 	stopRecording func() error
 	recordMutex   sync.Mutex
 
@@ -113,6 +119,9 @@ type Config struct {
 	// AttachWaitForDuration is the time (in milliseconds) that the debugger
 	// waits for WaitFor.
 	AttachWaitForDuration float64
+	// Snapshot, if true, opens AttachPid for read-only inspection without
+	// stopping it, see core.OpenLive.
+	Snapshot bool
 
 	// CoreFile specifies the path to the core dump to open.
 	CoreFile string
@@ -157,6 +166,10 @@ type Config struct {
 	// DisableASLR disables ASLR
 	DisableASLR bool
 
+	// RecordState saves the state of the target every time it is resumed
+	// so that it can be restored with the StepBack command.
+	RecordState bool
+
 	RrOnProcessPid int
 }
 
@@ -168,10 +181,29 @@ func New(config *Config, processArgs []string) (*Debugger, error) {
 		config:      config,
 		processArgs: processArgs,
 		log:         logger,
+
+		stateChangeCount: 1,
 	}
 
 	// Create the process by either attaching or launching.
 	switch {
+	case d.config.AttachPid > 0 && d.config.Snapshot:
+		d.log.Infof("opening pid %d read-only", d.config.AttachPid)
+		path := ""
+		if len(d.processArgs) > 0 {
+			path = d.processArgs[0]
+		}
+		var err error
+		d.target, err = core.OpenLive(d.config.AttachPid, path, d.config.DebugInfoDirectories)
+		if err != nil {
+			err = go11DecodeErrorCheck(err)
+			return nil, fmt.Errorf("could not open pid %d: %v", d.config.AttachPid, err)
+		}
+		if err := d.checkGoVersion(); err != nil {
+			d.target.Detach(false)
+			return nil, err
+		}
+
 	case d.config.AttachPid > 0 || d.config.AttachWaitFor != "":
 		d.log.Infof("attaching to pid %d", d.config.AttachPid)
 		path := ""
@@ -279,6 +311,9 @@ func (d *Debugger) Launch(processArgs []string, wd string) (*proc.TargetGroup, e
 	if d.config.DisableASLR {
 		launchFlags |= proc.LaunchDisableASLR
 	}
+	if d.config.RecordState {
+		launchFlags |= proc.LaunchRecordState
+	}
 
 	switch d.config.Backend {
 	case "native":
@@ -459,6 +494,7 @@ func (d *Debugger) Detach(kill bool) error {
 	d.log.Debug("detaching")
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
+	defer d.notifyStateChange()
 	return d.detach(kill)
 }
 
@@ -477,6 +513,7 @@ func (d *Debugger) detach(kill bool) error {
 func (d *Debugger) Restart(rerecord bool, pos string, resetArgs bool, newArgs []string, newRedirects [3]string, rebuild bool) ([]api.DiscardedBreakpoint, error) {
 	d.targetMutex.Lock()
 	defer d.targetMutex.Unlock()
+	defer d.notifyStateChange()
 
 	recorded, _ := d.target.Recorded()
 	if recorded && !rerecord {
@@ -908,6 +945,8 @@ func (d *Debugger) CancelNext() error {
 
 func copyLogicalBreakpointInfo(lbp *proc.LogicalBreakpoint, requested *api.Breakpoint) error {
 	lbp.Name = requested.Name
+	lbp.Tags = requested.Tags
+	lbp.Commands = requested.Commands
 	lbp.Tracepoint = requested.Tracepoint
 	lbp.TraceReturn = requested.TraceReturn
 	lbp.Goroutine = requested.Goroutine
@@ -1153,6 +1192,29 @@ func (d *Debugger) setRunning(running bool) {
 	d.runningMutex.Lock()
 	d.running = running
 	d.runningMutex.Unlock()
+	d.notifyStateChange()
+}
+
+// StateChange returns a counter, incremented every time the state of the
+// target changes, and a channel that will be closed the next time it
+// changes. The counter is never zero.
+func (d *Debugger) StateChange() (uint64, <-chan struct{}) {
+	d.stateChangeMutex.Lock()
+	defer d.stateChangeMutex.Unlock()
+	if d.stateChange == nil {
+		d.stateChange = make(chan struct{})
+	}
+	return d.stateChangeCount, d.stateChange
+}
+
+func (d *Debugger) notifyStateChange() {
+	d.stateChangeMutex.Lock()
+	defer d.stateChangeMutex.Unlock()
+	if d.stateChange != nil {
+		close(d.stateChange)
+	}
+	d.stateChange = make(chan struct{})
+	d.stateChangeCount++
 }
 
 func (d *Debugger) IsRunning() bool {
@@ -1250,6 +1312,12 @@ func (d *Debugger) Command(command *api.DebuggerCommand, resumeNotify chan struc
 			return nil, err
 		}
 		err = d.target.Step()
+	case api.StepIntoFunction:
+		d.log.Debugf("stepping into %s", command.Expr)
+		if err := d.target.ChangeDirection(proc.Forward); err != nil {
+			return nil, err
+		}
+		err = d.target.StepIntoFunction(command.Expr)
 	case api.StepInstruction:
 		d.log.Debug("single stepping")
 		if err := d.target.ChangeDirection(proc.Forward); err != nil {
@@ -1286,6 +1354,9 @@ func (d *Debugger) Command(command *api.DebuggerCommand, resumeNotify chan struc
 			return nil, err
 		}
 		err = d.target.StepOut()
+	case api.StepBack:
+		d.log.Debug("stepping back")
+		err = d.target.StepBack()
 	case api.SwitchThread:
 		d.log.Debugf("switching to thread %d", command.ThreadID)
 		t := proc.ValidTargets{Group: d.target}
@@ -1601,17 +1672,7 @@ func (d *Debugger) PackageVariables(filter string, cfg proc.LoadConfig) ([]*proc
 	if err != nil {
 		return nil, err
 	}
-	pv, err := scope.PackageVariables(cfg)
-	if err != nil {
-		return nil, err
-	}
-	pvr := pv[:0]
-	for i := range pv {
-		if regex.MatchString(pv[i].Name) {
-			pvr = append(pvr, pv[i])
-		}
-	}
-	return pvr, nil
+	return scope.PackageVariablesMatching(regex.MatchString, cfg)
 }
 
 // ThreadRegisters returns registers of the specified thread.
@@ -1884,6 +1945,32 @@ func (d *Debugger) stacktrace(goroutineID int64, depth int, opts api.StacktraceO
 	}
 }
 
+// GoroutinesStacktraces returns the stacktraces of the goroutines in gs, up
+// to depth frames each. The stacks of the goroutines are read in parallel,
+// if the backend supports it.
+func (d *Debugger) GoroutinesStacktraces(gs []*proc.G, depth int, opts api.StacktraceOptions) ([][]api.Stackframe, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+
+	if _, err := d.target.Valid(); err != nil {
+		return nil, err
+	}
+
+	rawstacks, errs := proc.GoroutinesStacktraces(d.target.Selected, gs, depth, proc.StacktraceOptions(opts))
+	r := make([][]api.Stackframe, len(gs))
+	for i := range gs {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("could not read stacktrace of goroutine %d: %v", gs[i].ID, errs[i])
+		}
+		var err error
+		r[i], err = d.convertStacktrace(rawstacks[i], nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
 // Ancestors returns the stacktraces for the ancestors of a goroutine.
 func (d *Debugger) Ancestors(goroutineID int64, numAncestors, depth int) ([]api.Ancestor, error) {
 	d.targetMutex.Lock()
@@ -2184,6 +2271,74 @@ func (d *Debugger) ListDynamicLibraries() []*proc.Image {
 	return d.target.Selected.BinInfo().Images[1:] // skips the first image because it's the executable file
 }
 
+// Scheduler returns the state of the Go runtime scheduler.
+func (d *Debugger) Scheduler() (*proc.Scheduler, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return proc.GetScheduler(d.target.Selected)
+}
+
+// GCInfo returns the state of the garbage collector and heap statistics.
+func (d *Debugger) GCInfo() (*proc.GCInfo, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return proc.GetGCInfo(d.target.Selected)
+}
+
+// MemoryRegions returns the memory map of the target process.
+func (d *Debugger) MemoryRegions() ([]proc.MemoryRegion, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return proc.GetMemoryRegions(d.target.Selected)
+}
+
+// FindHeapObject returns the object of the Go heap containing addr.
+func (d *Debugger) FindHeapObject(addr uint64) (*proc.HeapObject, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return proc.FindHeapObject(d.target.Selected, addr)
+}
+
+// FindFinalizer returns the finalizer associated with the heap object
+// containing addr, or nil if it does not have one.
+func (d *Debugger) FindFinalizer(addr uint64) (*proc.Finalizer, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return proc.FindFinalizer(d.target.Selected, addr)
+}
+
+// FindReferences searches goroutine stacks and, unless cfg.StacksOnly is
+// set, the heap for words equal to addr.
+func (d *Debugger) FindReferences(addr uint64, cfg proc.FindReferencesConfig) ([]proc.Reference, bool, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return proc.FindReferences(d.target.Selected, addr, cfg)
+}
+
+// UnreachableObjects returns the allocated objects of the Go heap that can
+// not be reached from any root, at most max objects are returned if max is
+// greater than zero.
+func (d *Debugger) UnreachableObjects(max int) ([]proc.HeapObject, bool, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	return proc.FindUnreachableObjects(d.target.Selected, max)
+}
+
+// SelectCases returns the cases of the select statement that goroutine
+// goid is blocked on.
+func (d *Debugger) SelectCases(goid int64) ([]proc.SelectCase, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	g, err := proc.FindGoroutine(d.target.Selected, goid)
+	if err != nil {
+		return nil, err
+	}
+	if g == nil {
+		return nil, errors.New("no selected goroutine")
+	}
+	return proc.GoroutineSelectCases(d.target.Selected, g)
+}
+
 // ExamineMemory returns the raw memory stored at the given address.
 // The amount of data to be read is specified by length.
 // This function will return an error if it reads less than `length` bytes.
@@ -2444,6 +2599,17 @@ func (d *Debugger) ChanGoroutines(goid int64, frame, deferredCall int, expr stri
 	return gs, nil
 }
 
+// ChanBuffer returns the values queued in the buffer of the channel specified by expr.
+func (d *Debugger) ChanBuffer(goid int64, frame, deferredCall int, expr string, cfg proc.LoadConfig) (*proc.Variable, error) {
+	d.targetMutex.Lock()
+	defer d.targetMutex.Unlock()
+	s, err := proc.ConvertEvalScope(d.target.Selected, goid, frame, deferredCall)
+	if err != nil {
+		return nil, err
+	}
+	return s.ChanBuffer(expr, cfg)
+}
+
 func go11DecodeErrorCheck(err error) error {
 	if !errors.Is(err, dwarf.DecodeError{}) {
 		return err
@@ -171,6 +171,12 @@ func (c *RPCClient) StepOut() (*api.DebuggerState, error) {
 	return &out.State, err
 }
 
+func (c *RPCClient) StepIntoFunction(fnName string) (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.StepIntoFunction, Expr: fnName, ReturnInfoLoadConfig: c.retValLoadCfg}, &out)
+	return &out.State, err
+}
+
 func (c *RPCClient) ReverseStepOut() (*api.DebuggerState, error) {
 	var out CommandOut
 	err := c.call("Command", api.DebuggerCommand{Name: api.ReverseStepOut, ReturnInfoLoadConfig: c.retValLoadCfg}, &out)
@@ -193,6 +199,12 @@ func (c *RPCClient) StepInstruction(skipCalls bool) (*api.DebuggerState, error)
 	return &out.State, err
 }
 
+func (c *RPCClient) StepBack() (*api.DebuggerState, error) {
+	var out CommandOut
+	err := c.call("Command", api.DebuggerCommand{Name: api.StepBack}, &out)
+	return &out.State, err
+}
+
 func (c *RPCClient) ReverseStepInstruction(skipCalls bool) (*api.DebuggerState, error) {
 	var out CommandOut
 	name := api.ReverseStepInstruction
@@ -336,6 +348,12 @@ func (c *RPCClient) EvalVariable(scope api.EvalScope, expr string, cfg api.LoadC
 	return out.Variable, err
 }
 
+func (c *RPCClient) ChanBuffer(scope api.EvalScope, expr string, cfg api.LoadConfig) (*api.Variable, error) {
+	var out ChanBufferOut
+	err := c.call("ChanBuffer", ChanBufferIn{scope, expr, &cfg}, &out)
+	return out.Variable, err
+}
+
 func (c *RPCClient) SetVariable(scope api.EvalScope, symbol, value string) error {
 	out := new(SetOut)
 	return c.call("Set", SetIn{scope, symbol, value}, out)
@@ -397,7 +415,7 @@ func (c *RPCClient) ListFunctionArgs(scope api.EvalScope, cfg api.LoadConfig) ([
 
 func (c *RPCClient) ListGoroutines(start, count int) ([]*api.Goroutine, int, error) {
 	var out ListGoroutinesOut
-	err := c.call("ListGoroutines", ListGoroutinesIn{start, count, nil, api.GoroutineGroupingOptions{}, nil}, &out)
+	err := c.call("ListGoroutines", ListGoroutinesIn{start, count, nil, api.GoroutineGroupingOptions{}, nil, 0}, &out)
 	return out.Goroutines, out.Nextg, err
 }
 
@@ -406,7 +424,16 @@ func (c *RPCClient) ListGoroutinesWithFilter(start, count int, filters []api.Lis
 		group = &api.GoroutineGroupingOptions{}
 	}
 	var out ListGoroutinesOut
-	err := c.call("ListGoroutines", ListGoroutinesIn{start, count, filters, *group, scope}, &out)
+	err := c.call("ListGoroutines", ListGoroutinesIn{start, count, filters, *group, scope, 0}, &out)
+	return out.Goroutines, out.Groups, out.Nextg, out.TooManyGroups, err
+}
+
+func (c *RPCClient) ListGoroutinesWithStacktrace(start, count int, filters []api.ListGoroutinesFilter, group *api.GoroutineGroupingOptions, scope *api.EvalScope, depth int) ([]*api.Goroutine, []api.GoroutineGroup, int, bool, error) {
+	if group == nil {
+		group = &api.GoroutineGroupingOptions{}
+	}
+	var out ListGoroutinesOut
+	err := c.call("ListGoroutines", ListGoroutinesIn{start, count, filters, *group, scope, depth}, &out)
 	return out.Goroutines, out.Groups, out.Nextg, out.TooManyGroups, err
 }
 
@@ -499,6 +526,18 @@ func (c *RPCClient) IsMulticlient() bool {
 	return out.IsMulticlient
 }
 
+func (c *RPCClient) IsReadOnly() bool {
+	var out api.IsReadOnlyOut
+	c.call("IsReadOnly", api.IsReadOnlyIn{}, &out)
+	return out.ReadOnly
+}
+
+func (c *RPCClient) WaitForStateChange(count uint64) (*api.DebuggerState, uint64, error) {
+	var out WaitForStateChangeOut
+	err := c.call("WaitForStateChange", WaitForStateChangeIn{Count: count}, &out)
+	return &out.State, out.Count, err
+}
+
 func (c *RPCClient) Disconnect(cont bool) error {
 	if cont {
 		out := new(CommandOut)
@@ -513,6 +552,54 @@ func (c *RPCClient) ListDynamicLibraries() ([]api.Image, error) {
 	return out.List, nil
 }
 
+func (c *RPCClient) GetScheduler() (*api.Scheduler, error) {
+	var out GetSchedulerOut
+	err := c.call("GetScheduler", GetSchedulerIn{}, &out)
+	return out.Scheduler, err
+}
+
+func (c *RPCClient) GetGCInfo() (*api.GCInfo, error) {
+	var out GetGCInfoOut
+	err := c.call("GetGCInfo", GetGCInfoIn{}, &out)
+	return out.GCInfo, err
+}
+
+func (c *RPCClient) ListMemoryRegions() ([]api.MemoryRegion, error) {
+	var out ListMemoryRegionsOut
+	err := c.call("ListMemoryRegions", ListMemoryRegionsIn{}, &out)
+	return out.Regions, err
+}
+
+func (c *RPCClient) FindHeapObject(addr uint64) (*api.HeapObject, error) {
+	var out FindHeapObjectOut
+	err := c.call("FindHeapObject", FindHeapObjectIn{addr}, &out)
+	return &out.Object, err
+}
+
+func (c *RPCClient) FindReferences(addr uint64, stacksOnly bool, max int) ([]api.Reference, bool, error) {
+	var out FindReferencesOut
+	err := c.call("FindReferences", FindReferencesIn{Addr: addr, StacksOnly: stacksOnly, Max: max}, &out)
+	return out.References, out.Truncated, err
+}
+
+func (c *RPCClient) ListUnreachableObjects(max int) ([]api.HeapObject, bool, error) {
+	var out ListUnreachableObjectsOut
+	err := c.call("ListUnreachableObjects", ListUnreachableObjectsIn{Max: max}, &out)
+	return out.Objects, out.Truncated, err
+}
+
+func (c *RPCClient) ListSelectCases(goroutineID int64) ([]api.SelectCase, error) {
+	var out ListSelectCasesOut
+	err := c.call("ListSelectCases", ListSelectCasesIn{GoroutineID: goroutineID}, &out)
+	return out.Cases, err
+}
+
+func (c *RPCClient) FindFinalizer(addr uint64) (*api.Finalizer, error) {
+	var out FindFinalizerOut
+	err := c.call("FindFinalizer", FindFinalizerIn{Addr: addr}, &out)
+	return out.Finalizer, err
+}
+
 func (c *RPCClient) ExamineMemory(address uint64, count int) ([]byte, bool, error) {
 	out := &ExaminedMemoryOut{}
 
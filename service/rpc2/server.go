@@ -137,6 +137,45 @@ func (s *RPCServer) Command(command api.DebuggerCommand, cb service.RPCCallback)
 	cb.Return(out, nil)
 }
 
+type WaitForStateChangeIn struct {
+	// Count is the value of Count returned by the previous call to
+	// WaitForStateChange, or zero to get the current state without
+	// waiting.
+	Count uint64
+}
+
+type WaitForStateChangeOut struct {
+	// Count identifies the state returned, it is never zero.
+	Count uint64
+	State api.DebuggerState
+}
+
+// WaitForStateChange waits until the state of the target changes (i.e. it
+// is resumed, stopped, restarted or detached from) and returns the new
+// state. If the state has already changed since arg.Count was returned, or
+// arg.Count is zero, it returns immediately.
+// This can be used, for example by read-only clients, to follow a
+// debugging session controlled by a different client. If the target is
+// running when the state is read, State.Running will be set.
+func (s *RPCServer) WaitForStateChange(arg WaitForStateChangeIn, cb service.RPCCallback) {
+	close(cb.SetupDoneChan())
+	count, ch := s.debugger.StateChange()
+	if arg.Count != 0 && count == arg.Count {
+		select {
+		case <-ch:
+		case <-cb.DisconnectChan():
+			return
+		}
+		count, _ = s.debugger.StateChange()
+	}
+	st, err := s.debugger.State(true)
+	if err != nil {
+		cb.Return(nil, err)
+		return
+	}
+	cb.Return(WaitForStateChangeOut{Count: count, State: *st}, nil)
+}
+
 type GetBufferedTracepointsIn struct {
 }
 
@@ -544,6 +583,33 @@ func (s *RPCServer) Eval(arg EvalIn, out *EvalOut) error {
 	return nil
 }
 
+type ChanBufferIn struct {
+	Scope api.EvalScope
+	Expr  string
+	Cfg   *api.LoadConfig
+}
+
+type ChanBufferOut struct {
+	Variable *api.Variable
+}
+
+// ChanBuffer returns the values queued in the buffer of the channel
+// specified by arg.Expr, in the order in which they will be received, as
+// an array variable.
+func (s *RPCServer) ChanBuffer(arg ChanBufferIn, out *ChanBufferOut) error {
+	cfg := arg.Cfg
+	if cfg == nil {
+		cfg = &api.LoadConfig{FollowPointers: true, MaxVariableRecurse: 1, MaxStringLen: 64, MaxArrayValues: 64, MaxStructFields: -1}
+	}
+	pcfg := *api.LoadConfigToProc(cfg)
+	v, err := s.debugger.ChanBuffer(arg.Scope.GoroutineID, arg.Scope.Frame, arg.Scope.DeferredCall, arg.Expr, pcfg)
+	if err != nil {
+		return err
+	}
+	out.Variable = api.ConvertVar(v)
+	return nil
+}
+
 type SetIn struct {
 	Scope  api.EvalScope
 	Symbol string
@@ -622,6 +688,10 @@ type ListGoroutinesIn struct {
 	api.GoroutineGroupingOptions
 
 	EvalScope *api.EvalScope
+
+	// StacktraceDepth, if greater than zero, requests the stacktrace of
+	// each returned goroutine, up to StacktraceDepth frames.
+	StacktraceDepth int
 }
 
 type ListGoroutinesOut struct {
@@ -664,6 +734,10 @@ type ListGoroutinesOut struct {
 // be grouped by the value of the label with key GroupByKey.
 // For each group a maximum of MaxGroupMembers example goroutines are
 // returned, as well as the total number of goroutines in the group.
+//
+// If arg.StacktraceDepth is greater than zero the Stacktrace field of
+// each returned goroutine is also filled, stacktraces are computed in
+// parallel when the backend allows it.
 func (s *RPCServer) ListGoroutines(arg ListGoroutinesIn, out *ListGoroutinesOut) error {
 	//TODO(aarzilli): if arg contains a running goroutines filter (not negated)
 	// and start == 0 and count == 0 then we can optimize this by just looking
@@ -704,9 +778,19 @@ func (s *RPCServer) ListGoroutines(arg ListGoroutinesIn, out *ListGoroutinesOut)
 	}
 	gs = s.debugger.FilterGoroutines(gs, arg.Filters)
 	gs, out.Groups, out.TooManyGroups = s.debugger.GroupGoroutines(gs, &arg.GoroutineGroupingOptions)
+	var stacks [][]api.Stackframe
+	if arg.StacktraceDepth > 0 {
+		stacks, err = s.debugger.GoroutinesStacktraces(gs, arg.StacktraceDepth, 0)
+		if err != nil {
+			return err
+		}
+	}
 	s.debugger.LockTarget()
 	defer s.debugger.UnlockTarget()
 	out.Goroutines = api.ConvertGoroutines(s.debugger.Target(), gs)
+	for i := range stacks {
+		out.Goroutines[i].Stacktrace = stacks[i]
+	}
 	out.Nextg = nextg
 	return nil
 }
@@ -914,6 +998,185 @@ func (s *RPCServer) ListDynamicLibraries(in ListDynamicLibrariesIn, out *ListDyn
 	return nil
 }
 
+// GetSchedulerIn holds the arguments of GetScheduler.
+type GetSchedulerIn struct {
+}
+
+// GetSchedulerOut holds the return values of GetScheduler.
+type GetSchedulerOut struct {
+	Scheduler *api.Scheduler
+}
+
+// GetScheduler returns the state of the Go runtime scheduler: the list of
+// Ms (OS threads) and Ps (processors) with their relationships and the
+// goroutine running on each M.
+func (s *RPCServer) GetScheduler(arg GetSchedulerIn, out *GetSchedulerOut) error {
+	sched, err := s.debugger.Scheduler()
+	if err != nil {
+		return err
+	}
+	out.Scheduler = api.ConvertScheduler(sched)
+	return nil
+}
+
+// GetGCInfoIn holds the arguments of GetGCInfo.
+type GetGCInfoIn struct {
+}
+
+// GetGCInfoOut holds the return values of GetGCInfo.
+type GetGCInfoOut struct {
+	GCInfo *api.GCInfo
+}
+
+// GetGCInfo returns the state of the garbage collector and heap statistics
+// of the target process, read from the runtime.
+func (s *RPCServer) GetGCInfo(arg GetGCInfoIn, out *GetGCInfoOut) error {
+	gci, err := s.debugger.GCInfo()
+	if err != nil {
+		return err
+	}
+	out.GCInfo = api.ConvertGCInfo(gci)
+	return nil
+}
+
+// ListMemoryRegionsIn holds the arguments of ListMemoryRegions.
+type ListMemoryRegionsIn struct {
+}
+
+// ListMemoryRegionsOut holds the return values of ListMemoryRegions.
+type ListMemoryRegionsOut struct {
+	Regions []api.MemoryRegion
+}
+
+// ListMemoryRegions returns the memory map of the target process. Regions
+// containing Go heap arenas or goroutine stacks are annotated.
+func (s *RPCServer) ListMemoryRegions(arg ListMemoryRegionsIn, out *ListMemoryRegionsOut) error {
+	regions, err := s.debugger.MemoryRegions()
+	if err != nil {
+		return err
+	}
+	out.Regions = api.ConvertMemoryRegions(regions)
+	return nil
+}
+
+// FindHeapObjectIn holds the arguments of FindHeapObject.
+type FindHeapObjectIn struct {
+	Addr uint64
+}
+
+// FindHeapObjectOut holds the return values of FindHeapObject.
+type FindHeapObjectOut struct {
+	Object api.HeapObject
+}
+
+// FindHeapObject returns the object of the Go heap containing Addr, its
+// size class and, if it can be determined, its type.
+func (s *RPCServer) FindHeapObject(arg FindHeapObjectIn, out *FindHeapObjectOut) error {
+	obj, err := s.debugger.FindHeapObject(arg.Addr)
+	if err != nil {
+		return err
+	}
+	out.Object = *api.ConvertHeapObject(obj)
+	return nil
+}
+
+// FindReferencesIn holds the arguments of FindReferences.
+type FindReferencesIn struct {
+	Addr       uint64
+	StacksOnly bool // only search the stacks of goroutines
+	Max        int  // maximum number of references returned, 0 for no limit
+}
+
+// FindReferencesOut holds the return values of FindReferences.
+type FindReferencesOut struct {
+	References []api.Reference
+	Truncated  bool // the search stopped after finding Max references
+}
+
+// FindReferences searches the stacks of all goroutines and, unless
+// StacksOnly is set, the heap for pointer-sized words equal to Addr.
+// Scanning the heap can be slow for programs with large heaps.
+func (s *RPCServer) FindReferences(arg FindReferencesIn, out *FindReferencesOut) error {
+	refs, truncated, err := s.debugger.FindReferences(arg.Addr, proc.FindReferencesConfig{StacksOnly: arg.StacksOnly, Max: arg.Max})
+	if err != nil {
+		return err
+	}
+	out.References = api.ConvertReferences(refs)
+	out.Truncated = truncated
+	return nil
+}
+
+// ListUnreachableObjectsIn holds the arguments of ListUnreachableObjects.
+type ListUnreachableObjectsIn struct {
+	Max int // maximum number of objects returned, 0 for no limit
+}
+
+// ListUnreachableObjectsOut holds the return values of
+// ListUnreachableObjects.
+type ListUnreachableObjectsOut struct {
+	Objects   []api.HeapObject
+	Truncated bool // there are more than Max unreachable objects
+}
+
+// ListUnreachableObjects returns the allocated objects of the Go heap that
+// can not be reached from goroutine stacks, thread registers, global
+// variables or objects with a finalizer.
+// Reachability is determined by a conservative scan of the heap, objects
+// that became unreachable after the last GC cycle are also returned.
+func (s *RPCServer) ListUnreachableObjects(arg ListUnreachableObjectsIn, out *ListUnreachableObjectsOut) error {
+	objs, truncated, err := s.debugger.UnreachableObjects(arg.Max)
+	if err != nil {
+		return err
+	}
+	out.Objects = api.ConvertHeapObjects(objs)
+	out.Truncated = truncated
+	return nil
+}
+
+// ListSelectCasesIn holds the arguments of ListSelectCases.
+type ListSelectCasesIn struct {
+	GoroutineID int64 // -1 for the selected goroutine
+}
+
+// ListSelectCasesOut holds the return values of ListSelectCases.
+type ListSelectCasesOut struct {
+	Cases []api.SelectCase
+}
+
+// ListSelectCases returns the cases of the select statement the goroutine
+// is blocked on: the address of each channel, its element type and
+// whether the goroutine is waiting to send or to receive.
+// Cases with a nil channel are not reported.
+func (s *RPCServer) ListSelectCases(arg ListSelectCasesIn, out *ListSelectCasesOut) error {
+	cases, err := s.debugger.SelectCases(arg.GoroutineID)
+	if err != nil {
+		return err
+	}
+	out.Cases = api.ConvertSelectCases(cases)
+	return nil
+}
+
+// FindFinalizerIn holds the arguments of FindFinalizer.
+type FindFinalizerIn struct {
+	Addr uint64
+}
+
+// FindFinalizerOut holds the return values of FindFinalizer.
+type FindFinalizerOut struct {
+	Finalizer *api.Finalizer
+}
+
+// FindFinalizer returns the finalizer associated with the heap object
+// containing Addr. Finalizer is nil if the object does not have one.
+func (s *RPCServer) FindFinalizer(arg FindFinalizerIn, out *FindFinalizerOut) error {
+	fin, err := s.debugger.FindFinalizer(arg.Addr)
+	if err != nil {
+		return err
+	}
+	out.Finalizer = api.ConvertFinalizer(fin)
+	return nil
+}
+
 // ListPackagesBuildInfoIn holds the arguments of ListPackagesBuildInfo.
 type ListPackagesBuildInfoIn struct {
 	IncludeFiles bool
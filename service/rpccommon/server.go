@@ -14,6 +14,7 @@ import (
 	"reflect"
 	"runtime"
 	"sync"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
@@ -21,6 +22,7 @@ import (
 	"github.com/go-delve/delve/pkg/version"
 	"github.com/go-delve/delve/service"
 	"github.com/go-delve/delve/service/api"
+	"github.com/go-delve/delve/service/authtoken"
 	"github.com/go-delve/delve/service/dap"
 	"github.com/go-delve/delve/service/debugger"
 	"github.com/go-delve/delve/service/internal/sameuser"
@@ -46,6 +48,19 @@ type ServerImpl struct {
 	// maps of served methods, one for each supported API.
 	methodMaps []map[string]*methodType
 	log        logflags.Logger
+
+	// hasController is true if a client that is not read-only is connected,
+	// only used if config.AcceptReadOnly is set.
+	hasController   bool
+	controllerMutex sync.Mutex
+
+	// connected is the number of connected clients, idleTimer is started
+	// when it drops to zero, see config.IdleTimeout.
+	connected int
+	idleTimer *time.Timer
+	connMutex sync.Mutex
+
+	disconnectMutex sync.Mutex
 }
 
 type RPCCallback struct {
@@ -55,6 +70,7 @@ type RPCCallback struct {
 	req            rpc.Request
 	setupDone      chan struct{}
 	disconnectChan chan struct{}
+	readOnly       bool
 }
 
 var _ service.RPCCallback = &RPCCallback{}
@@ -95,6 +111,12 @@ func NewServer(config *service.Config) *ServerImpl {
 func (s *ServerImpl) Stop() error {
 	s.log.Debug("stopping")
 	close(s.stopChan)
+	s.connMutex.Lock()
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+		s.idleTimer = nil
+	}
+	s.connMutex.Unlock()
 	if s.config.AcceptMulti {
 		s.listener.Close()
 	}
@@ -137,6 +159,10 @@ func (s *ServerImpl) Run() error {
 	suitableMethods(s.s2, s.methodMaps[1], s.log)
 	suitableMethods(rpcServer, s.methodMaps[1], s.log)
 
+	s.connMutex.Lock()
+	s.startIdleTimer()
+	s.connMutex.Unlock()
+
 	go func() {
 		defer s.listener.Close()
 		for {
@@ -158,15 +184,41 @@ func (s *ServerImpl) Run() error {
 				}
 			}
 
-			go s.serveConnectionDemux(c)
-			if !s.config.AcceptMulti {
-				break
+			if s.config.AcceptMulti {
+				// The token is checked by the goroutine serving the connection so
+				// that a client that never sends it doesn't block other clients.
+				go func() {
+					if s.checkAuthToken(c) {
+						s.serveConnectionDemux(c)
+					}
+				}()
+				continue
+			}
+			if !s.checkAuthToken(c) {
+				continue
 			}
+			go s.serveConnectionDemux(c)
+			break
 		}
 	}()
 	return nil
 }
 
+// checkAuthToken checks that the client connected to c sends the
+// authentication token, if one is configured. The connection is closed if
+// the check fails.
+func (s *ServerImpl) checkAuthToken(c net.Conn) bool {
+	if s.config.AuthToken == "" {
+		return true
+	}
+	if err := authtoken.Check(c, s.config.AuthToken); err != nil {
+		s.log.Errorf("rejecting connection from %s: %v", c.RemoteAddr(), err)
+		c.Close()
+		return false
+	}
+	return true
+}
+
 type bufReadWriteCloser struct {
 	*bufio.Reader
 	io.WriteCloser
@@ -179,16 +231,184 @@ func (s *ServerImpl) serveConnectionDemux(c io.ReadWriteCloser) {
 		s.log.Warnf("error determining new connection protocol: %v", err)
 		return
 	}
+	readOnly := s.acquireController()
 	if b[0] == 'C' { // C is for DAP's Content-Length
+		if readOnly {
+			s.log.Errorf("rejecting DAP connection: read-only connections are only supported for JSON-RPC clients")
+			conn.Close()
+			return
+		}
 		s.log.Debugf("serving DAP on new connection")
 		ds := dap.NewSession(conn, &dap.Config{Config: s.config, StopTriggered: s.stopChan}, s.debugger)
-		go ds.ServeDAPCodec()
+		s.clientConnected()
+		go func() {
+			ds.ServeDAPCodec()
+			s.releaseController(readOnly)
+			s.clientDisconnected()
+		}()
 	} else {
-		s.log.Debugf("serving JSON-RPC on new connection")
-		go s.serveJSONCodec(conn)
+		if readOnly {
+			s.log.Debugf("serving JSON-RPC on new read-only connection")
+		} else {
+			s.log.Debugf("serving JSON-RPC on new connection")
+		}
+		s.clientConnected()
+		go func() {
+			s.serveJSONCodec(conn, readOnly)
+			s.releaseController(readOnly)
+			s.clientDisconnected()
+		}()
 	}
 }
 
+func (s *ServerImpl) clientConnected() {
+	s.connMutex.Lock()
+	defer s.connMutex.Unlock()
+	s.connected++
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+		s.idleTimer = nil
+	}
+}
+
+func (s *ServerImpl) clientDisconnected() {
+	s.connMutex.Lock()
+	defer s.connMutex.Unlock()
+	s.connected--
+	if s.connected == 0 {
+		s.startIdleTimer()
+	}
+}
+
+// startIdleTimer starts a timer that will stop the server if no client
+// connects within config.IdleTimeout. Must be called with connMutex held.
+func (s *ServerImpl) startIdleTimer() {
+	if !s.config.AcceptMulti || s.config.IdleTimeout <= 0 {
+		return
+	}
+	s.idleTimer = time.AfterFunc(s.config.IdleTimeout, func() {
+		s.log.Infof("no client connected for %v, stopping", s.config.IdleTimeout)
+		s.closeDisconnectChan()
+	})
+}
+
+// closeDisconnectChan closes config.DisconnectChan, signaling that the
+// server should be stopped.
+func (s *ServerImpl) closeDisconnectChan() {
+	s.disconnectMutex.Lock()
+	defer s.disconnectMutex.Unlock()
+	if s.config.DisconnectChan != nil {
+		close(s.config.DisconnectChan)
+		s.config.DisconnectChan = nil
+	}
+}
+
+// acquireController returns true if a new connection should be read-only,
+// which happens when read-only connections are enabled and there already
+// is a controlling client connected. Otherwise the new connection becomes
+// the controlling client.
+func (s *ServerImpl) acquireController() (readOnly bool) {
+	if !s.config.AcceptReadOnly {
+		return false
+	}
+	s.controllerMutex.Lock()
+	defer s.controllerMutex.Unlock()
+	if s.hasController {
+		return true
+	}
+	s.hasController = true
+	return false
+}
+
+// releaseController is called when a connection is closed, if it was the
+// controlling client the next connection will be able to take its place.
+func (s *ServerImpl) releaseController(readOnly bool) {
+	if readOnly || !s.config.AcceptReadOnly {
+		return
+	}
+	s.controllerMutex.Lock()
+	s.hasController = false
+	s.controllerMutex.Unlock()
+}
+
+// readOnlyMethods is the set of methods, in either version of the API, that
+// do not change the state of the debugger or of the target process and can
+// be called on read-only connections.
+var readOnlyMethods = map[string]bool{
+	"RPCServer.GetVersion":         true,
+	"RPCServer.SetApiVersion":      true, // only allowed if it doesn't change the API version, see readOnlyAllowed
+	"RPCServer.IsReadOnly":         true,
+	"RPCServer.IsMulticlient":      true,
+	"RPCServer.ProcessPid":         true,
+	"RPCServer.LastModified":       true,
+	"RPCServer.State":              true,
+	"RPCServer.WaitForStateChange": true,
+	"RPCServer.Recorded":           true,
+	"RPCServer.ListCheckpoints":    true,
+	"RPCServer.ListTargets":        true,
+	"RPCServer.BuildID":            true,
+
+	"RPCServer.GetBreakpoint":       true,
+	"RPCServer.GetBreakpointByName": true,
+	"RPCServer.ListBreakpoints":     true,
+
+	"RPCServer.ListThreads":         true,
+	"RPCServer.GetThread":           true,
+	"RPCServer.ListGoroutines":      true,
+	"RPCServer.Stacktrace":          true,
+	"RPCServer.StacktraceGoroutine": true,
+	"RPCServer.Ancestors":           true,
+	"RPCServer.GetScheduler":        true,
+	"RPCServer.GetGCInfo":           true,
+	"RPCServer.ListSelectCases":     true,
+
+	"RPCServer.ListPackageVars":        true,
+	"RPCServer.ListThreadPackageVars":  true,
+	"RPCServer.ListRegisters":          true,
+	"RPCServer.ListLocalVars":          true,
+	"RPCServer.ListFunctionArgs":       true,
+	"RPCServer.Eval":                   true,
+	"RPCServer.EvalSymbol":             true,
+	"RPCServer.ChanBuffer":             true,
+	"RPCServer.ExamineMemory":          true,
+	"RPCServer.ListMemoryRegions":      true,
+	"RPCServer.FindHeapObject":         true,
+	"RPCServer.FindReferences":         true,
+	"RPCServer.ListUnreachableObjects": true,
+	"RPCServer.FindFinalizer":          true,
+
+	"RPCServer.ListSources":               true,
+	"RPCServer.ListFunctions":             true,
+	"RPCServer.ListTypes":                 true,
+	"RPCServer.ListPackagesBuildInfo":     true,
+	"RPCServer.ListDynamicLibraries":      true,
+	"RPCServer.FindLocation":              true,
+	"RPCServer.Disassemble":               true,
+	"RPCServer.FunctionReturnLocations":   true,
+	"RPCServer.AttachedToExistingProcess": true,
+}
+
+// readOnlyAllowed returns true if the method called by req, with argument
+// argv, can be called on a read-only connection.
+func (s *ServerImpl) readOnlyAllowed(req *rpc.Request, argv reflect.Value) bool {
+	if !readOnlyMethods[req.ServiceMethod] {
+		return false
+	}
+	if req.ServiceMethod == "RPCServer.SetApiVersion" {
+		// The API version is shared by all connections, read-only clients can
+		// only select the version that is already in use.
+		args, ok := argv.Interface().(api.SetAPIVersionIn)
+		if !ok {
+			return false
+		}
+		if args.APIVersion < 2 {
+			args.APIVersion = 1
+		}
+		return args.APIVersion == s.config.APIVersion
+	}
+	return true
+}
+
 // Precompute the reflect type for error.  Can't use error directly
 // because Typeof takes an empty interface value.  This is annoying.
 var typeOfError = reflect.TypeOf((*error)(nil)).Elem()
@@ -278,12 +498,12 @@ func suitableMethods(rcvr interface{}, methods map[string]*methodType, log logfl
 	}
 }
 
-func (s *ServerImpl) serveJSONCodec(conn io.ReadWriteCloser) {
+func (s *ServerImpl) serveJSONCodec(conn io.ReadWriteCloser, readOnly bool) {
 	clientDisconnectChan := make(chan struct{})
 	defer func() {
 		close(clientDisconnectChan)
-		if !s.config.AcceptMulti && s.config.DisconnectChan != nil {
-			close(s.config.DisconnectChan)
+		if !s.config.AcceptMulti {
+			s.closeDisconnectChan()
 		}
 	}()
 
@@ -326,6 +546,11 @@ func (s *ServerImpl) serveJSONCodec(conn io.ReadWriteCloser) {
 			argv = argv.Elem()
 		}
 
+		if readOnly && !s.readOnlyAllowed(&req, argv) {
+			s.sendResponse(sending, &req, &rpc.Response{}, nil, codec, fmt.Sprintf("%s can not be called on a read-only connection", req.ServiceMethod))
+			continue
+		}
+
 		if mtype.Synchronous {
 			if logflags.RPC() {
 				argvbytes, _ := json.Marshal(argv.Interface())
@@ -355,9 +580,8 @@ func (s *ServerImpl) serveJSONCodec(conn io.ReadWriteCloser) {
 				s.log.Debugf("-> %T%s error: %q", replyv.Interface(), replyvbytes, errmsg)
 			}
 			s.sendResponse(sending, &req, &resp, replyv.Interface(), codec, errmsg)
-			if req.ServiceMethod == "RPCServer.Detach" && s.config.DisconnectChan != nil {
-				close(s.config.DisconnectChan)
-				s.config.DisconnectChan = nil
+			if req.ServiceMethod == "RPCServer.Detach" {
+				s.closeDisconnectChan()
 			}
 		} else {
 			if logflags.RPC() {
@@ -365,7 +589,7 @@ func (s *ServerImpl) serveJSONCodec(conn io.ReadWriteCloser) {
 				s.log.Debugf("(async %d) <- %s(%T%s)", req.Seq, req.ServiceMethod, argv.Interface(), argvbytes)
 			}
 			function := mtype.method.Func
-			ctl := &RPCCallback{s, sending, codec, req, make(chan struct{}), clientDisconnectChan}
+			ctl := &RPCCallback{s, sending, codec, req, make(chan struct{}), clientDisconnectChan, readOnly}
 			go func() {
 				defer func() {
 					if ierr := recover(); ierr != nil {
@@ -462,6 +686,14 @@ func (s *RPCServer) SetApiVersion(args api.SetAPIVersionIn, out *api.SetAPIVersi
 	return nil
 }
 
+// IsReadOnly returns true if the connection is read-only. Read-only
+// connections are accepted when the headless instance was started with
+// --accept-readonly and another client is already connected, only methods
+// that do not change the state of the debugger can be called on them.
+func (s *RPCServer) IsReadOnly(args api.IsReadOnlyIn, cb service.RPCCallback) {
+	cb.Return(api.IsReadOnlyOut{ReadOnly: cb.(*RPCCallback).readOnly}, nil)
+}
+
 type internalError struct {
 	Err   interface{}
 	Stack []internalErrorFrame
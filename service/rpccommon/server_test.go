@@ -0,0 +1,55 @@
+package rpccommon
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-delve/delve/service/rpc1"
+	"github.com/go-delve/delve/service/rpc2"
+)
+
+// mutatingMethods is the set of methods that change the state of the
+// debugger or of the target process and must not be allowed on read-only
+// connections.
+var mutatingMethods = map[string]bool{
+	"RPCServer.AmendBreakpoint":        true,
+	"RPCServer.CancelNext":             true,
+	"RPCServer.Checkpoint":             true,
+	"RPCServer.ClearBreakpoint":        true,
+	"RPCServer.ClearBreakpointByName":  true,
+	"RPCServer.ClearCheckpoint":        true,
+	"RPCServer.Command":                true,
+	"RPCServer.CreateBreakpoint":       true,
+	"RPCServer.CreateEBPFTracepoint":   true,
+	"RPCServer.CreateWatchpoint":       true,
+	"RPCServer.DebugInfoDirectories":   true,
+	"RPCServer.Detach":                 true,
+	"RPCServer.DumpCancel":             true,
+	"RPCServer.DumpStart":              true,
+	"RPCServer.DumpWait":               true,
+	"RPCServer.FollowExec":             true,
+	"RPCServer.FollowExecEnabled":      true,
+	"RPCServer.GetBufferedTracepoints": true,
+	"RPCServer.Restart":                true,
+	"RPCServer.Set":                    true,
+	"RPCServer.SetSymbol":              true,
+	"RPCServer.StopRecording":          true,
+	"RPCServer.ToggleBreakpoint":       true,
+}
+
+// TestReadOnlyMethods checks that every method of the API is classified as
+// either read-only or mutating, so that new methods can't be forgotten.
+func TestReadOnlyMethods(t *testing.T) {
+	for _, rcvr := range []interface{}{&RPCServer{}, &rpc1.RPCServer{}, &rpc2.RPCServer{}} {
+		typ := reflect.TypeOf(rcvr)
+		for i := 0; i < typ.NumMethod(); i++ {
+			name := "RPCServer." + typ.Method(i).Name
+			switch {
+			case readOnlyMethods[name] && mutatingMethods[name]:
+				t.Errorf("%s (%v) is both read-only and mutating", name, typ)
+			case !readOnlyMethods[name] && !mutatingMethods[name]:
+				t.Errorf("%s (%v) must be added to either readOnlyMethods or mutatingMethods", name, typ)
+			}
+		}
+	}
+}
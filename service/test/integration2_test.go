@@ -25,6 +25,7 @@ import (
 	"github.com/go-delve/delve/pkg/proc"
 	"github.com/go-delve/delve/service"
 	"github.com/go-delve/delve/service/api"
+	"github.com/go-delve/delve/service/authtoken"
 	"github.com/go-delve/delve/service/rpc2"
 	"github.com/go-delve/delve/service/rpccommon"
 )
@@ -987,6 +988,8 @@ func TestClientServer_FindLocations(t *testing.T) {
 		someFunctionLine1 := findLocationHelper(t, c, "locationsprog.go:27", false, 1, 0)[0]
 		findLocationHelper(t, c, "anotherFunction:1", false, 1, someFunctionLine1)
 		findLocationHelper(t, c, "main.anotherFunction:1", false, 1, someFunctionLine1)
+		findLocationHelper(t, c, "main.anotherFunction:+1", false, 1, someFunctionLine1)
+		findLocationHelper(t, c, "locationsprog.go:+1", true, 0, 0)
 		findLocationHelper(t, c, "anotherFunction", false, 1, someFunctionCallAddr)
 		findLocationHelper(t, c, "main.anotherFunction", false, 1, someFunctionCallAddr)
 		findLocationHelper(t, c, fmt.Sprintf("*0x%x", someFunctionCallAddr), false, 1, someFunctionCallAddr)
@@ -2609,6 +2612,223 @@ func TestStopServerWithClosedListener(t *testing.T) {
 	time.Sleep(1 * time.Second) // give time to server to panic
 }
 
+func TestAuthToken(t *testing.T) {
+	// Checks that a server started with an authentication token rejects
+	// connections that do not send it.
+	if testBackend == "rr" || buildMode == "pie" {
+		t.Skip("N/A")
+	}
+	listener, err := net.Listen("tcp", "localhost:0")
+	assertNoError(err, t, "listener")
+	fixture := protest.BuildFixture("math", 0)
+	server := rpccommon.NewServer(&service.Config{
+		Listener:       listener,
+		AcceptMulti:    true,
+		APIVersion:     2,
+		AuthToken:      "secret",
+		DisconnectChan: make(chan struct{}),
+		ProcessArgs:    []string{fixture.Path},
+		Debugger: debugger.Config{
+			WorkingDir:  ".",
+			Backend:     testBackend,
+			ExecuteKind: debugger.ExecutingGeneratedFile,
+		},
+	})
+	assertNoError(server.Run(), t, "Run")
+	defer server.Stop()
+
+	dial := func(token string) *rpc2.RPCClient {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		assertNoError(err, t, "Dial")
+		assertNoError(authtoken.Send(conn, token), t, "Send")
+		return rpc2.NewClientFromConn(conn)
+	}
+
+	// A client that connects and never sends the token must not block
+	// other clients.
+	silent, err := net.Dial("tcp", listener.Addr().String())
+	assertNoError(err, t, "Dial")
+	defer silent.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := dial("wrong").GetStateNonBlocking()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("connection with the wrong token was accepted")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for connection with the wrong token to be closed")
+	}
+
+	go func() {
+		_, err := dial("secret").GetStateNonBlocking()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		assertNoError(err, t, "GetStateNonBlocking")
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for connection with the right token")
+	}
+}
+
+func TestReadOnlyClient(t *testing.T) {
+	// Checks that clients connecting while another client is connected are
+	// read-only and can follow the state changes caused by the controlling
+	// client.
+	if testBackend == "rr" || buildMode == "pie" {
+		t.Skip("N/A")
+	}
+	listener, err := net.Listen("tcp", "localhost:0")
+	assertNoError(err, t, "listener")
+	fixture := protest.BuildFixture("continuetestprog", 0)
+	server := rpccommon.NewServer(&service.Config{
+		Listener:       listener,
+		AcceptMulti:    true,
+		AcceptReadOnly: true,
+		APIVersion:     2,
+		DisconnectChan: make(chan struct{}),
+		ProcessArgs:    []string{fixture.Path},
+		Debugger: debugger.Config{
+			WorkingDir:  ".",
+			Backend:     testBackend,
+			ExecuteKind: debugger.ExecutingGeneratedFile,
+		},
+	})
+	assertNoError(server.Run(), t, "Run")
+	defer server.Stop()
+
+	dial := func() *rpc2.RPCClient {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		assertNoError(err, t, "Dial")
+		return rpc2.NewClientFromConn(conn)
+	}
+
+	c := dial()
+	if c.IsReadOnly() {
+		t.Fatal("first client is read-only")
+	}
+	_, err = c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.sayhi"})
+	assertNoError(err, t, "CreateBreakpoint")
+
+	ro := dial()
+	if !ro.IsReadOnly() {
+		t.Fatal("second client is not read-only")
+	}
+	_, err = ro.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.main"})
+	if err == nil {
+		t.Fatal("read-only client could create a breakpoint")
+	}
+	_, err = ro.Next()
+	if err == nil {
+		t.Fatal("read-only client could step")
+	}
+
+	_, count, err := ro.WaitForStateChange(0)
+	assertNoError(err, t, "WaitForStateChange")
+
+	type waitResult struct {
+		state *api.DebuggerState
+		err   error
+	}
+	done := make(chan waitResult, 1)
+	go func() {
+		for {
+			state, newCount, err := ro.WaitForStateChange(count)
+			count = newCount
+			if err != nil || !state.Running {
+				done <- waitResult{state, err}
+				return
+			}
+		}
+	}()
+
+	state := <-c.Continue()
+	assertNoError(state.Err, t, "Continue")
+
+	select {
+	case r := <-done:
+		assertNoError(r.err, t, "WaitForStateChange")
+		if r.state.CurrentThread == nil || r.state.CurrentThread.Function.Name() != "main.sayhi" {
+			t.Fatalf("read-only client received wrong state %#v", r.state.CurrentThread)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for state change")
+	}
+
+	_, _, err = ro.ListGoroutines(0, 0)
+	assertNoError(err, t, "ListGoroutines (read-only)")
+	ro.Disconnect(false)
+	c.Disconnect(false)
+}
+
+func TestIdleTimeout(t *testing.T) {
+	// Checks that a multiclient server keeps its state across client
+	// disconnects and stops after no client has been connected for
+	// IdleTimeout.
+	if testBackend == "rr" || buildMode == "pie" {
+		t.Skip("N/A")
+	}
+	listener, err := net.Listen("tcp", "localhost:0")
+	assertNoError(err, t, "listener")
+	fixture := protest.BuildFixture("continuetestprog", 0)
+	disconnectChan := make(chan struct{})
+	server := rpccommon.NewServer(&service.Config{
+		Listener:       listener,
+		AcceptMulti:    true,
+		IdleTimeout:    2 * time.Second,
+		APIVersion:     2,
+		DisconnectChan: disconnectChan,
+		ProcessArgs:    []string{fixture.Path},
+		Debugger: debugger.Config{
+			WorkingDir:  ".",
+			Backend:     testBackend,
+			ExecuteKind: debugger.ExecutingGeneratedFile,
+		},
+	})
+	assertNoError(server.Run(), t, "Run")
+	defer server.Stop()
+
+	dial := func() *rpc2.RPCClient {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		assertNoError(err, t, "Dial")
+		return rpc2.NewClientFromConn(conn)
+	}
+
+	c := dial()
+	_, err = c.CreateBreakpoint(&api.Breakpoint{FunctionName: "main.sayhi"})
+	assertNoError(err, t, "CreateBreakpoint")
+	c.Disconnect(false)
+
+	// Reconnecting before the timeout expires must find the session intact.
+	time.Sleep(time.Second)
+	c = dial()
+	bps, err := c.ListBreakpoints(false)
+	assertNoError(err, t, "ListBreakpoints")
+	found := false
+	for _, bp := range bps {
+		if bp.FunctionName == "main.sayhi" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("breakpoint lost after reconnecting")
+	}
+	state := <-c.Continue()
+	assertNoError(state.Err, t, "Continue")
+	c.Disconnect(false)
+
+	select {
+	case <-disconnectChan:
+	case <-time.After(10 * time.Second):
+		t.Fatal("server was not stopped after the idle timeout")
+	}
+}
+
 func TestGoroutinesGrouping(t *testing.T) {
 	// Tests the goroutine grouping and filtering feature
 	withTestClient2("goroutinegroup", t, func(c service.Client) {
@@ -3072,36 +3292,6 @@ func TestClientServer_breakpointOnFuncWithABIWrapper(t *testing.T) {
 	})
 }
 
-var waitReasonStrings = [...]string{
-	"",
-	"GC assist marking",
-	"IO wait",
-	"chan receive (nil chan)",
-	"chan send (nil chan)",
-	"dumping heap",
-	"garbage collection",
-	"garbage collection scan",
-	"panicwait",
-	"select",
-	"select (no cases)",
-	"GC assist wait",
-	"GC sweep wait",
-	"GC scavenge wait",
-	"chan receive",
-	"chan send",
-	"finalizer wait",
-	"force gc (idle)",
-	"semacquire",
-	"sleep",
-	"sync.Cond.Wait",
-	"timer goroutine (idle)",
-	"trace reader (blocked)",
-	"wait for GC cycle",
-	"GC worker (idle)",
-	"preempted",
-	"debug call",
-}
-
 func TestClientServer_chanGoroutines(t *testing.T) {
 	withTestClient2("changoroutines", t, func(c service.Client) {
 		state := <-c.Continue()
@@ -3109,8 +3299,8 @@ func TestClientServer_chanGoroutines(t *testing.T) {
 
 		countRecvSend := func(gs []*api.Goroutine) (recvq, sendq int) {
 			for _, g := range gs {
-				t.Logf("\tID: %d WaitReason: %s\n", g.ID, waitReasonStrings[g.WaitReason])
-				switch waitReasonStrings[g.WaitReason] {
+				t.Logf("\tID: %d WaitReason: %s\n", g.ID, g.WaitReasonString)
+				switch g.WaitReasonString {
 				case "chan send":
 					sendq++
 				case "chan receive":